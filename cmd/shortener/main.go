@@ -4,28 +4,49 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"net/http/pprof"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/acme/autocert"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
+	"github.com/Dorrrke/shortener-url/internal/app"
+	"github.com/Dorrrke/shortener-url/internal/audit"
+	"github.com/Dorrrke/shortener-url/internal/cache"
+	"github.com/Dorrrke/shortener-url/internal/compress"
 	"github.com/Dorrrke/shortener-url/internal/config"
 	grpcserver "github.com/Dorrrke/shortener-url/internal/grpc"
+	shortenergrpcv1 "github.com/Dorrrke/shortener-url/internal/grpc/gen/shortenergrpc.v1"
+	grpchandlers "github.com/Dorrrke/shortener-url/internal/grpc/handlers"
+	"github.com/Dorrrke/shortener-url/internal/health"
 	"github.com/Dorrrke/shortener-url/internal/logger"
+	"github.com/Dorrrke/shortener-url/internal/metrics"
+	"github.com/Dorrrke/shortener-url/internal/problem"
+	"github.com/Dorrrke/shortener-url/internal/ratelimit"
+	"github.com/Dorrrke/shortener-url/internal/realip"
 	"github.com/Dorrrke/shortener-url/internal/server"
 	"github.com/Dorrrke/shortener-url/internal/service"
 	"github.com/Dorrrke/shortener-url/internal/storage"
+	"github.com/Dorrrke/shortener-url/internal/tracing"
+	"github.com/Dorrrke/shortener-url/internal/urlcheck"
 )
 
 // FilePath — константа с названием файла для хранения данных при отсутствии подключения к бд.
@@ -42,6 +63,12 @@ var (
 )
 
 func main() {
+	if len(os.Args) > 2 && os.Args[1] == "config" && os.Args[2] == "print" {
+		os.Exit(printConfig(os.Args[3:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate-data" {
+		os.Exit(migrateData(os.Args[2:], os.Stdout, os.Stderr))
+	}
 
 	if buildVersion == "" {
 		buildVersion = "N/A"
@@ -75,23 +102,71 @@ func main() {
 	}()
 
 	var stor storage.Storage
-	appCfg, enableGrpc := config.MustLoad()
+	var dbConn *pgxpool.Pool
+	appCfg := config.MustLoad()
+	enableGrpc := appCfg.EnableGRPC
+	if err := logger.InitializeWithOptions(logger.Options{
+		Level:    appCfg.LogLevel,
+		Format:   appCfg.LogFormat,
+		Sampling: appCfg.LogSampling,
+	}); err != nil {
+		panic(err)
+	}
 	logger.Log.Debug("Server config", zap.Any("cfg", appCfg))
+	shutdownTracing, err := tracing.Init(ctx, appCfg.TracingExporter, appCfg.TracingEndpoint)
+	if err != nil {
+		logger.Log.Error("Error init tracing: ", zap.Error(err))
+		panic(err)
+	}
+	appMetrics := metrics.New()
+	logger.AddRequestObserver(appMetrics.ObserveHTTP)
+	closeCache := func() {}
 	if appCfg.DatabaseDsn != "" {
-		dbConn := initDB(appCfg.DatabaseDsn)
-		stor = &storage.DBStorage{DB: dbConn}
+		dbConn = initDB(appCfg.DatabaseDsn)
+		stor, closeCache = initCache(appCfg, appMetrics.WrapStorage(&storage.DBStorage{DB: dbConn}))
 		logger.Log.Info("DataBase connected")
 	} else {
-		stor = &storage.MemStorage{URLMap: make(map[string]string)}
+		stor = appMetrics.WrapStorage(&storage.MemStorage{URLMap: make(map[string]string)})
 		logger.Log.Info("Mem storage created")
 	}
+	if cached, ok := stor.(*storage.CachedStorage); ok {
+		appMetrics.RegisterCache(cached)
+	}
+	appMetrics.RegisterActiveLinks(stor)
+	auditor, err := initAudit(appCfg, dbConn)
+	if err != nil {
+		logger.Log.Error("Error init audit: ", zap.Error(err))
+		panic(err)
+	}
 	sService := service.NewService(stor, appCfg)
-	if err := sService.RestorStorage(); err != nil {
+	sService.SetAuditor(auditor)
+	appMetrics.RegisterDeleteQueue(sService.DeleteQueueDepth)
+	checkerCtx, cancelChecker := context.WithCancel(ctx)
+	checker, err := initURLChecker(checkerCtx, appCfg)
+	if err != nil {
+		logger.Log.Error("Error init url checker: ", zap.Error(err))
+		panic(err)
+	}
+	sService.SetURLChecker(checker)
+	if err := sService.RestorStorage(ctx); err != nil {
 		logger.Log.Error("Error restor storage: ", zap.Error(err))
 	}
-	serverAPI := server.New(appCfg, sService)
-	grpcServer := grpc.NewServer()
-	grpcserver.RegisterGrpcService(grpcServer, sService, appCfg)
+	readiness := initHealth(appCfg, sService)
+	serverAPI := server.New(sService)
+	ipResolver, err := realip.NewResolver(appCfg.TrustedProxies)
+	if err != nil {
+		logger.Log.Error("Error parse trusted proxies", zap.Error(err))
+	}
+	limiter := ratelimit.New(appCfg.RateLimits)
+	compress.RegisterGRPCCompressors()
+	grpcServer := grpc.NewServer(grpc.StatsHandler(otelgrpc.NewServerHandler()), grpc.ChainUnaryInterceptor(
+		logger.UnaryServerInterceptor(),
+		appMetrics.UnaryServerInterceptor(),
+		limiter.UnaryServerInterceptor(ipResolver, grpchandlers.StatusError),
+	))
+	grpcserver.RegisterGrpcService(grpcServer, sService)
+	healthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
 
 	server := &http.Server{}
 
@@ -100,14 +175,66 @@ func main() {
 		if enableGrpc {
 			return runGrpc(grpcServer, appCfg)
 		}
-		return run(*serverAPI, server)
+		return run(*serverAPI, server, limiter, ipResolver, appMetrics, readiness)
+	})
+	if enableGrpc {
+		g.Go(func() error {
+			readiness.Watch(gCtx, healthServer, health.DefaultWatchInterval, shortenergrpcv1.Shortener_ServiceDesc.ServiceName)
+			return nil
+		})
+	}
+	metricsServer := &http.Server{Addr: appCfg.MetricsAddress, Handler: appMetrics.Handler()}
+	g.Go(func() error {
+		watchReload(gCtx, sService, limiter, cancelChecker)
+		return nil
 	})
+	if appCfg.MetricsAddress != "" {
+		g.Go(func() error {
+			logger.Log.Info("Metrics server started", zap.String("addres", appCfg.MetricsAddress))
+			if err := metricsServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		})
+	}
+
+	// Порядок остановки: перестать принимать запросы и дождаться принятых, затем дописать очередь удаления
+	// и аудит, сбросить файл хранилища на диск и только после этого закрыть кэш и базу данных.
+	shutdownTimeout := time.Duration(appCfg.ShutdownTimeout)
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = config.DefaultShutdownTimeout
+	}
+	shutdown := &app.Shutdown{Timeout: shutdownTimeout}
+	shutdown.Add("grpc health", func(context.Context) error {
+		healthServer.Shutdown()
+		return nil
+	})
+	if enableGrpc {
+		shutdown.Add("grpc server", app.GRPCServer(grpcServer))
+	} else {
+		shutdown.Add("http server", app.HTTPServer(server))
+	}
+	if appCfg.MetricsAddress != "" {
+		shutdown.Add("metrics server", app.HTTPServer(metricsServer))
+	}
+	shutdown.Add("delete queue", sService.Close)
+	shutdown.AddCloser("audit", auditor.Close)
+	shutdown.AddCloser("file storage", sService.SyncFileStorage)
+	shutdown.AddCloser("cache", func() error {
+		closeCache()
+		return nil
+	})
+	if dbConn != nil {
+		shutdown.AddCloser("database", func() error {
+			dbConn.Close()
+			return nil
+		})
+	}
+	shutdown.Add("tracing", shutdownTracing)
 	g.Go(func() error {
 		<-gCtx.Done()
-		if enableGrpc {
-			return stopGrpcService(grpcServer)
-		}
-		return stopService(server)
+		logger.Log.Info("Shutting down", zap.Duration("timeout", shutdownTimeout))
+		return shutdown.Run(context.Background())
 	})
 
 	if err := g.Wait(); err != nil {
@@ -115,55 +242,155 @@ func main() {
 	}
 }
 
-func run(serv server.Server, serverHTTP *http.Server) error {
+// watchReload - функция перечитывания конфигурации по сигналу SIGHUP до завершения ctx.
+// cancelChecker останавливает фоновые задачи текущего URLChecker при его замене.
+func watchReload(ctx context.Context, sService *service.ShortenerService, limiter *ratelimit.Limiter, cancelChecker context.CancelFunc) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	defer func() { cancelChecker() }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			cancelChecker = reloadConfig(ctx, sService, limiter, cancelChecker)
+		}
+	}
+}
+
+// reloadConfig - функция применения новой конфигурации. Перезагружаемые поля заменяются атомарно,
+// изменения остальных полей только логируются: они вступят в силу после перезапуска.
+// При ошибке загрузки или проверки конфигурация остается прежней.
+func reloadConfig(ctx context.Context, sService *service.ShortenerService, limiter *ratelimit.Limiter, cancelChecker context.CancelFunc) context.CancelFunc {
+	next, err := config.Load(os.Args[1:], os.Getenv)
+	if err != nil {
+		logger.Log.Error("Config reload failed, keeping current config", zap.Error(err))
+		return cancelChecker
+	}
+	result := sService.ConfigStore().Reload(next)
+	cfg := sService.Config()
+	if result.Changed("rate_limits") {
+		limiter.SetRules(cfg.RateLimits)
+	}
+	if result.Changed("log_level") {
+		if err := logger.SetLevel(cfg.LogLevel); err != nil {
+			logger.Log.Error("Error set log level", zap.Error(err))
+		}
+	}
+	if result.Changed("blocklist_file") || result.Changed("url_rules_file") ||
+		result.Changed("reputation_url") || result.Changed("reputation_fail_closed") {
+		checkerCtx, cancel := context.WithCancel(ctx)
+		checker, err := initURLChecker(checkerCtx, cfg)
+		if err != nil {
+			cancel()
+			logger.Log.Error("Error reload url checker, keeping current checker", zap.Error(err))
+		} else {
+			sService.SetURLChecker(checker)
+			cancelChecker()
+			cancelChecker = cancel
+		}
+	}
+	if len(result.Ignored) > 0 {
+		logger.Log.Warn("Config fields changed but require restart", zap.Strings("fields", result.Ignored))
+	}
+	logger.Log.Info("Config reloaded", zap.Strings("applied", result.Applied))
+	return cancelChecker
+}
+
+// printConfig - функция вывода итоговой конфигурации (shortener config print [флаги]) с замененными паролями.
+// Конфигурация выводится и при ошибках проверки, ошибки печатаются в stderr, код возврата при этом 1.
+func printConfig(args []string) int {
+	cfg, err := config.Load(args, os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if cfg != nil {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if encErr := enc.Encode(cfg.Redacted()); encErr != nil {
+			fmt.Fprintln(os.Stderr, encErr)
+			return 1
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid config:")
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func run(serv server.Server, serverHTTP *http.Server, limiter *ratelimit.Limiter, ipResolver *realip.Resolver, appMetrics *metrics.Metrics, readiness *health.Checker) error {
 
 	logger.Log.Info("Running server")
 	r := chi.NewRouter()
+	r.Use(tracing.Middleware)
+	limit := func(route string) func(http.HandlerFunc) http.HandlerFunc {
+		return limiter.Middleware(route, ipResolver, problem.Write)
+	}
 
 	r.Route("/", func(r chi.Router) {
-		r.Post("/", logger.WithLogging(server.GzipMiddleware(serv.ShortenerURLHandler)))
-		r.Get("/{id}", logger.WithLogging(server.GzipMiddleware(serv.GetOriginalURLHandler)))
+		r.Post("/", logger.WithLogging(limit("POST /")(compress.Middleware(serv.Validated(serv.Idempotent(serv.ShortenerURLHandler))))))
+		r.Get("/{id}", logger.WithLogging(limit("GET /{id}")(compress.Middleware(serv.Validated(serv.GetOriginalURLHandler)))))
+		r.Post("/{id}", logger.WithLogging(limit("POST /{id}")(compress.Middleware(serv.Validated(serv.UnlockURLHandler)))))
 		r.Route("/api", func(r chi.Router) {
-			r.Get("/user/urls", logger.WithLogging(server.GzipMiddleware(serv.GetAllUrls)))
-			r.Delete("/user/urls", logger.WithLogging(server.GzipMiddleware(serv.DeleteURLHandler)))
-			r.Get("/internal/stats", logger.WithLogging(server.GzipMiddleware(serv.GetServiceStats)))
+			r.Get("/user/urls", logger.WithLogging(limit("GET /api/user/urls")(compress.Middleware(serv.Validated(serv.GetAllUrls)))))
+			r.Get("/user/quota", logger.WithLogging(limit("GET /api/user/quota")(compress.Middleware(serv.Validated(serv.GetUserQuota)))))
+			r.Delete("/user/urls", logger.WithLogging(limit("DELETE /api/user/urls")(compress.Middleware(serv.Validated(serv.DeleteURLHandler)))))
+			r.Post("/user/urls/restore", logger.WithLogging(limit("POST /api/user/urls/restore")(compress.Middleware(serv.Validated(serv.RestoreURLHandler)))))
+			r.Get("/user/urls/export", logger.WithLogging(limit("GET /api/user/urls/export")(compress.Middleware(serv.Validated(serv.ExportUserURLs)))))
+			r.Post("/user/urls/import", logger.WithLogging(limit("POST /api/user/urls/import")(compress.Middleware(serv.Validated(serv.ImportUserURLs)))))
+			r.Get("/internal/stats", logger.WithLogging(compress.Middleware(serv.Validated(serv.GetServiceStats))))
+			r.Get("/internal/audit", logger.WithLogging(compress.Middleware(serv.Validated(serv.GetAuditLog))))
+			r.Get("/internal/urls/export", logger.WithLogging(compress.Middleware(serv.Validated(serv.ExportAllURLs))))
+			r.Post("/internal/urls/import", logger.WithLogging(compress.Middleware(serv.Validated(serv.ImportAllURLs))))
+			r.Get("/openapi.json", logger.WithLogging(compress.Middleware(serv.GetOpenAPISpec)))
 			r.Route("/shorten", func(r chi.Router) {
-				r.Post("/", logger.WithLogging(server.GzipMiddleware(serv.ShortenerJSONURLHandler)))
-				r.Post("/batch", logger.WithLogging(server.GzipMiddleware(serv.InsertBatchHandler)))
+				r.Post("/", logger.WithLogging(limit("POST /api/shorten")(compress.Middleware(serv.Validated(serv.Idempotent(serv.ShortenerJSONURLHandler))))))
+				r.Post("/batch", logger.WithLogging(limit("POST /api/shorten/batch")(compress.Middleware(serv.Validated(serv.Idempotent(serv.InsertBatchHandler))))))
 			})
 		})
-		r.Get("/ping", logger.WithLogging(server.GzipMiddleware(serv.CheckDBConnectionHandler)))
-	})
-	r.HandleFunc("/debug/pprof", func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, r.URL.Path[1:])
+		r.Get("/ping", logger.WithLogging(compress.Middleware(serv.CheckDBConnectionHandler)))
 	})
+	r.Get("/healthz", health.LivenessHandler)
+	r.Get("/readyz", readiness.ReadinessHandler())
+	// Метрики и профилирование доступны только из доверенных подсетей.
+	r.Group(func(r chi.Router) {
+		r.Use(serv.TrustedOnly)
+		if serv.Config().MetricsAddress == "" {
+			r.Handle("/metrics", appMetrics.Handler())
+		}
+		r.HandleFunc("/debug/pprof", pprof.Index)
 
-	// Регистрация обработчиков pprof для различных типов профилирования
-	r.HandleFunc("/debug/pprof/heap", pprof.Index)
-	r.HandleFunc("/debug/pprof/goroutine", pprof.Index)
-	r.HandleFunc("/debug/pprof/block", pprof.Index)
-	r.HandleFunc("/debug/pprof/threadcreate", pprof.Index)
-	r.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
-	r.HandleFunc("/debug/pprof/profile", pprof.Profile)
-	r.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		// Регистрация обработчиков pprof для различных типов профилирования
+		r.HandleFunc("/debug/pprof/heap", pprof.Index)
+		r.HandleFunc("/debug/pprof/goroutine", pprof.Index)
+		r.HandleFunc("/debug/pprof/block", pprof.Index)
+		r.HandleFunc("/debug/pprof/threadcreate", pprof.Index)
+		r.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		r.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		r.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	})
 
 	serverHTTP.Handler = r
-	if serv.Config.ServerAddress != "" {
-		serverHTTP.Addr = serv.Config.ServerAddress
+	if serv.Config().ServerAddress != "" {
+		serverHTTP.Addr = serv.Config().ServerAddress
 	} else {
 		serverHTTP.Addr = ":8080"
 	}
-	if serv.Config.EnableHTTPS {
+	if serv.Config().EnableHTTPS {
 		manager := &autocert.Manager{
 			Cache:      autocert.DirCache("cache-dir"),
 			Prompt:     autocert.AcceptTOS,
 			HostPolicy: autocert.HostWhitelist("short.ru"),
 		}
 		serverHTTP.TLSConfig = manager.TLSConfig()
-		logger.Log.Info("Server with TLS started", zap.String("addres", serv.Config.ServerAddress))
+		logger.Log.Info("Server with TLS started", zap.String("addres", serv.Config().ServerAddress))
 		return serverHTTP.ListenAndServeTLS("", "")
 	}
-	logger.Log.Info("Server without TLS started", zap.String("addres", serv.Config.ServerAddress))
+	logger.Log.Info("Server without TLS started", zap.String("addres", serv.Config().ServerAddress))
 	return serverHTTP.ListenAndServe()
 }
 
@@ -178,7 +405,13 @@ func runGrpc(serverGrpc *grpc.Server, cfg *config.AppConfig) error {
 }
 
 func initDB(DBAddr string) *pgxpool.Pool {
-	pool, err := pgxpool.New(context.Background(), DBAddr)
+	poolConfig, err := pgxpool.ParseConfig(DBAddr)
+	if err != nil {
+		logger.Log.Error("Error wile parse db config: " + err.Error())
+		panic(err)
+	}
+	poolConfig.ConnConfig.Tracer = tracing.QueryTracer{}
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
 		logger.Log.Error("Error wile init db driver: " + err.Error())
 		panic(err)
@@ -187,13 +420,101 @@ func initDB(DBAddr string) *pgxpool.Pool {
 
 }
 
-func stopService(serverHTTP *http.Server) error {
-	serverHTTP.Shutdown(context.Background())
-	logger.Log.Info("Service stop")
-	return nil
+// initHealth - функция создания проверок готовности компонентов сервиса.
+// Проверки таблиц и файла хранилища добавляются, только если эти компоненты используются.
+func initHealth(cfg *config.AppConfig, sService *service.ShortenerService) *health.Checker {
+	checker := health.New()
+	checker.Add("storage", sService.CheckStorage)
+	if cfg.DatabaseDsn != "" {
+		checker.Add("migrations", sService.CheckMigrations)
+	}
+	if cfg.FileStoragePath != "" {
+		checker.Add("file_storage", sService.CheckFileStorage)
+	}
+	checker.Add("delete_queue", sService.CheckDeletePipeline)
+	return checker
+}
+
+// initAudit - функция создания подсистемы аудита с приемниками из конфигурации.
+// Приемник db доступен только при подключении к базе данных.
+func initAudit(cfg *config.AppConfig, pool *pgxpool.Pool) (*audit.Auditor, error) {
+	var sinks []audit.Sink
+	for _, name := range strings.Split(cfg.AuditSinks, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "stdout":
+			sinks = append(sinks, audit.NewStdoutSink())
+		case "file":
+			sink, err := audit.NewFileSink(cfg.AuditFilePath)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		case "db":
+			if pool == nil {
+				return nil, fmt.Errorf("audit sink db requires database connection")
+			}
+			sink := &audit.DBSink{DB: pool}
+			if err := sink.CreateTable(context.Background()); err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		default:
+			return nil, fmt.Errorf("unknown audit sink %q", name)
+		}
+	}
+	logger.Log.Info("Audit initialized", zap.Int("sinks", len(sinks)))
+	return audit.New(sinks...), nil
+}
+
+// initCache - функция подключения кэша переходов перед хранилищем.
+// Кэш в памяти процесса используется всегда, Redis добавляется вторым уровнем, если указан его адрес.
+// Возвращаемая функция закрывает соединения с Redis.
+func initCache(cfg *config.AppConfig, stor storage.Storage) (storage.Storage, func()) {
+	if cfg.CacheSize < 0 {
+		return stor, func() {}
+	}
+	ttl := time.Duration(cfg.CacheTTL) * time.Second
+	negativeTTL := time.Duration(cfg.CacheNegativeTTL) * time.Second
+	if cfg.RedisAddr == "" {
+		logger.Log.Info("Redirect cache enabled", zap.Int("size", cfg.CacheSize))
+		return storage.NewCachedStorage(stor, cache.NewLRU(cfg.CacheSize), ttl, negativeTTL), func() {}
+	}
+	redisCache := cache.NewRedis(cfg.RedisAddr, "shortener:")
+	layered := &cache.Layered{
+		Levels:   []cache.Cache{cache.NewLRU(cfg.CacheSize), redisCache},
+		UpperTTL: storage.DefaultCacheNegativeTTL,
+	}
+	logger.Log.Info("Redirect cache enabled", zap.Int("size", cfg.CacheSize), zap.String("redis", cfg.RedisAddr))
+	return storage.NewCachedStorage(stor, layered, ttl, negativeTTL), func() {
+		if err := redisCache.Close(); err != nil {
+			logger.Log.Error("Error close redis", zap.Error(err))
+		}
+	}
 }
 
-func stopGrpcService(serverGrpc *grpc.Server) error {
-	serverGrpc.GracefulStop()
-	return nil
+// initURLChecker - функция создания цепочки проверок url из конфигурации.
+// Блоклист доменов перечитывается при изменении файла до отмены ctx.
+func initURLChecker(ctx context.Context, cfg *config.AppConfig) (urlcheck.URLChecker, error) {
+	var chain urlcheck.Chain
+	if cfg.BlocklistFile != "" {
+		blocklist, err := urlcheck.NewDomainBlocklist(cfg.BlocklistFile)
+		if err != nil {
+			return nil, err
+		}
+		go blocklist.Watch(ctx, urlcheck.DefaultReloadInterval)
+		chain = append(chain, blocklist)
+	}
+	if cfg.URLRulesFile != "" {
+		rules, err := urlcheck.LoadRegexRules(cfg.URLRulesFile)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, rules)
+	}
+	if cfg.ReputationURL != "" {
+		chain = append(chain, &urlcheck.HTTPChecker{Endpoint: cfg.ReputationURL, FailClosed: cfg.ReputationFailClosed})
+	}
+	logger.Log.Info("URL checker initialized", zap.Int("checkers", len(chain)))
+	return chain, nil
 }
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Dorrrke/shortener-url/internal/migrate"
+	"github.com/Dorrrke/shortener-url/internal/storage"
+)
+
+// migrateData - функция переноса url между хранилищами (shortener migrate-data -from <хранилище> -to <хранилище>).
+// Хранилище задается как file:<путь к файлу хранилища> или строкой подключения postgres://...
+// Код возврата 0 - перенос и проверка прошли успешно, 1 - ошибка, 2 - неверные аргументы.
+func migrateData(args []string, stdout io.Writer, stderr io.Writer) int {
+	fs := flag.NewFlagSet("migrate-data", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	from := fs.String("from", "", "хранилище-источник: file:<путь> или postgres://...")
+	to := fs.String("to", "", "хранилище назначения: file:<путь> или postgres://...")
+	chunk := fs.Int("chunk", migrate.DefaultChunkSize, "количество url в пачке")
+	dryRun := fs.Bool("dry-run", false, "только подсчитать переносимые url, не изменяя хранилище назначения")
+	statePath := fs.String("state", "migrate-data.state", "файл позиции для продолжения прерванного переноса, пустой - без продолжения")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if *from == "" || *to == "" || *from == *to || fs.NArg() != 0 {
+		fmt.Fprintln(stderr, "usage: shortener migrate-data -from <file:path|postgres://...> -to <file:path|postgres://...> [-chunk n] [-dry-run] [-state path]")
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
+	defer stop()
+
+	src, closeSrc, err := openStorage(ctx, *from)
+	if err != nil {
+		fmt.Fprintln(stderr, "open source:", err)
+		return 1
+	}
+	defer closeSrc()
+	dst, closeDst, err := openStorage(ctx, *to)
+	if err != nil {
+		fmt.Fprintln(stderr, "open destination:", err)
+		return 1
+	}
+	defer closeDst()
+
+	key := sha256.Sum256([]byte(*from + "\n" + *to))
+	res, err := migrate.Run(ctx, src, dst, migrate.Options{
+		ChunkSize: *chunk,
+		DryRun:    *dryRun,
+		StatePath: *statePath,
+		Key:       hex.EncodeToString(key[:]),
+		Progress: func(res migrate.Result) {
+			fmt.Fprintf(stderr, "processed %d urls: copied %d, skipped %d\n", res.Source, res.Copied, res.Skipped)
+		},
+	})
+	if *dryRun {
+		fmt.Fprintf(stdout, "dry run: source %d, to copy %d, to skip %d\n", res.Source, res.Copied, res.Skipped)
+	} else {
+		fmt.Fprintf(stdout, "source %d, resumed %d, copied %d, skipped %d, verified %d\n", res.Source, res.Resumed, res.Copied, res.Skipped, res.Verified)
+	}
+	if len(res.Missing) > 0 {
+		fmt.Fprintln(stdout, "missing in destination:", strings.Join(res.Missing, " "))
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// openStorage - функция открытия хранилища по адресу file:<путь> или postgres://...
+// Для базы данных таблицы создаются и обновляются так же, как при запуске сервиса.
+func openStorage(ctx context.Context, uri string) (storage.Storage, func(), error) {
+	switch {
+	case strings.HasPrefix(uri, "file:"):
+		stor, err := storage.OpenFileStorage(ctx, strings.TrimPrefix(uri, "file:"))
+		if err != nil {
+			return nil, nil, err
+		}
+		return stor, func() {}, nil
+	case strings.HasPrefix(uri, "postgres://"), strings.HasPrefix(uri, "postgresql://"):
+		pool, err := pgxpool.New(ctx, uri)
+		if err != nil {
+			return nil, nil, err
+		}
+		stor := &storage.DBStorage{DB: pool}
+		if err := stor.CheckDBConnect(ctx); err != nil {
+			pool.Close()
+			return nil, nil, err
+		}
+		if err := stor.CreateTable(ctx); err != nil {
+			pool.Close()
+			return nil, nil, err
+		}
+		return stor, pool.Close, nil
+	}
+	return nil, nil, fmt.Errorf("unknown storage %q, use file:<path> or postgres://", uri)
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/Dorrrke/shortener-url/pkg/client"
+)
+
+// shortenResult - результат сокращения одного url.
+type shortenResult struct {
+	OriginalURL string `json:"original_url"`
+	ShortURL    string `json:"short_url"`
+	// Status - created для нового сокращения и exists, если url уже был сокращен.
+	Status string `json:"status"`
+}
+
+// shortenCommand - сокращение url из аргументов или, без аргументов, из stdin по одному на строку.
+var shortenCommand = &command{
+	usage:   "[url...]",
+	summary: "сократить url из аргументов или из stdin (по одному на строку)",
+	run: func(ctx context.Context, e *env, args []string) error {
+		urls := trimArgs(args)
+		if len(urls) == 0 {
+			scanner := bufio.NewScanner(e.stdin)
+			for scanner.Scan() {
+				urls = append(urls, trimArgs([]string{scanner.Text()})...)
+			}
+			if err := scanner.Err(); err != nil {
+				return err
+			}
+		}
+		if len(urls) == 0 {
+			return errUsage
+		}
+		var results []shortenResult
+		for _, original := range urls {
+			short, err := e.client.Shorten(ctx, original)
+			status := "created"
+			if errors.Is(err, client.ErrConflict) {
+				status, err = "exists", nil
+			}
+			if err != nil {
+				return fmt.Errorf("shorten %s: %w", original, err)
+			}
+			results = append(results, shortenResult{OriginalURL: original, ShortURL: short, Status: status})
+		}
+		if e.output == "json" {
+			return writeJSON(e.stdout, results)
+		}
+		rows := make([][]string, 0, len(results))
+		for _, res := range results {
+			rows = append(rows, []string{res.ShortURL, res.OriginalURL, res.Status})
+		}
+		return writeTable(e.stdout, []string{"SHORT_URL", "ORIGINAL_URL", "STATUS"}, rows)
+	},
+}
+
+// importResult - результат сокращения строки файла импорта.
+type importResult struct {
+	CorrelationID string `json:"correlation_id"`
+	OriginalURL   string `json:"original_url"`
+	ShortURL      string `json:"short_url"`
+}
+
+// importFormat - флаг формата файла импорта.
+var importFormat string
+
+// importCommand - пакетное сокращение url из файла CSV или JSON через /api/shorten/batch.
+var importCommand = &command{
+	usage:   "<файл|->",
+	summary: "сократить url из файла CSV или JSON пачками",
+	flags: func(fs *flag.FlagSet) {
+		fs.StringVar(&importFormat, "format", "", "формат файла: csv или json, по умолчанию определяется по расширению")
+	},
+	run: func(ctx context.Context, e *env, args []string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+		r, closeFn, err := openInput(args[0], e.stdin)
+		if err != nil {
+			return err
+		}
+		defer closeFn()
+		items, err := readItems(r, detectFormat(importFormat, args[0]))
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return errors.New("no urls to import")
+		}
+		originals := make(map[string]string, len(items))
+		for _, item := range items {
+			originals[item.CorrelationID] = item.OriginalURL
+		}
+		saved, err := e.client.ShortenBatch(ctx, items)
+		results := make([]importResult, 0, len(saved))
+		for _, res := range saved {
+			results = append(results, importResult{CorrelationID: res.CorrelationID, OriginalURL: originals[res.CorrelationID], ShortURL: res.ShortURL})
+		}
+		if len(results) > 0 || err == nil {
+			var writeErr error
+			if e.output == "json" {
+				writeErr = writeJSON(e.stdout, results)
+			} else {
+				rows := make([][]string, 0, len(results))
+				for _, res := range results {
+					rows = append(rows, []string{res.CorrelationID, res.ShortURL, res.OriginalURL})
+				}
+				writeErr = writeTable(e.stdout, []string{"CORRELATION_ID", "SHORT_URL", "ORIGINAL_URL"}, rows)
+			}
+			if err == nil {
+				err = writeErr
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("imported %d of %d urls: %w", len(results), len(items), err)
+		}
+		return nil
+	},
+}
+
+// listCommand - вывод url пользователя.
+var listCommand = &command{
+	summary: "вывести url, сокращенные пользователем",
+	run: func(ctx context.Context, e *env, args []string) error {
+		if len(args) != 0 {
+			return errUsage
+		}
+		urls, err := e.client.UserURLs(ctx)
+		if err != nil {
+			return err
+		}
+		if e.output == "json" {
+			return writeJSON(e.stdout, urls)
+		}
+		rows := make([][]string, 0, len(urls))
+		for _, u := range urls {
+			rows = append(rows, []string{u.ShortURL, u.OriginalURL})
+		}
+		return writeTable(e.stdout, []string{"SHORT_URL", "ORIGINAL_URL"}, rows)
+	},
+}
+
+// deleteCommand - удаление url пользователя.
+var deleteCommand = &command{
+	usage:   "<id|url>...",
+	summary: "удалить url пользователя (удаление выполняется сервисом асинхронно)",
+	run: func(ctx context.Context, e *env, args []string) error {
+		ids := trimArgs(args)
+		if len(ids) == 0 {
+			return errUsage
+		}
+		if err := e.client.Delete(ctx, ids); err != nil {
+			return err
+		}
+		if e.output == "json" {
+			return writeJSON(e.stdout, map[string]int{"accepted": len(ids)})
+		}
+		_, err := fmt.Fprintf(e.stdout, "accepted for deletion: %d\n", len(ids))
+		return err
+	},
+}
+
+// restoreCommand - восстановление удаленных url пользователя.
+var restoreCommand = &command{
+	usage:   "<id|url>...",
+	summary: "восстановить удаленные url пользователя (только rest)",
+	run: func(ctx context.Context, e *env, args []string) error {
+		ids := trimArgs(args)
+		if len(ids) == 0 {
+			return errUsage
+		}
+		restored, err := e.client.Restore(ctx, ids)
+		if err != nil {
+			return err
+		}
+		if e.output == "json" {
+			return writeJSON(e.stdout, restored)
+		}
+		rows := make([][]string, 0, len(restored))
+		for _, short := range restored {
+			rows = append(rows, []string{short})
+		}
+		return writeTable(e.stdout, []string{"RESTORED_URL"}, rows)
+	},
+}
+
+// statsCommand - вывод статистики сервиса, доступна только из доверенной подсети.
+var statsCommand = &command{
+	summary: "вывести статистику сервиса (только из доверенной подсети)",
+	run: func(ctx context.Context, e *env, args []string) error {
+		if len(args) != 0 {
+			return errUsage
+		}
+		stats, err := e.client.Stats(ctx)
+		if err != nil {
+			return err
+		}
+		if e.output == "json" {
+			return writeJSON(e.stdout, stats)
+		}
+		return writeTable(e.stdout, []string{"URLS", "USERS", "CACHE_HITS", "CACHE_MISSES"}, [][]string{{
+			strconv.Itoa(stats.URLs), strconv.Itoa(stats.Users),
+			strconv.FormatInt(stats.CacheHits, 10), strconv.FormatInt(stats.CacheMisses, 10),
+		}})
+	},
+}
+
+// Флаги команды export.
+var (
+	exportFormat string
+	exportOut    string
+)
+
+// exportCommand - выгрузка url пользователя в CSV или JSON в формате, который принимает import.
+var exportCommand = &command{
+	summary: "выгрузить url пользователя в CSV или JSON",
+	flags: func(fs *flag.FlagSet) {
+		fs.StringVar(&exportFormat, "format", formatCSV, "формат выгрузки: csv или json")
+		fs.StringVar(&exportOut, "out", "-", "файл выгрузки, - для stdout")
+	},
+	run: func(ctx context.Context, e *env, args []string) error {
+		if len(args) != 0 || (exportFormat != formatCSV && exportFormat != formatJSON) {
+			return errUsage
+		}
+		urls, err := e.client.UserURLs(ctx)
+		if err != nil {
+			return err
+		}
+		if exportOut == "-" {
+			return writeExport(e.stdout, urls)
+		}
+		file, err := os.Create(exportOut)
+		if err != nil {
+			return err
+		}
+		if err := writeExport(file, urls); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	},
+}
+
+// writeExport - функция записи url в формате exportFormat.
+func writeExport(w io.Writer, urls []client.URL) error {
+	if exportFormat == formatJSON {
+		return writeJSON(w, urls)
+	}
+	return writeURLsCSV(w, urls)
+}
+
+// writeURLsCSV - функция записи url в CSV с заголовком short_url,original_url.
+func writeURLsCSV(w io.Writer, urls []client.URL) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"short_url", "original_url"}); err != nil {
+		return err
+	}
+	for _, u := range urls {
+		if err := cw.Write([]string{u.ShortURL, u.OriginalURL}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
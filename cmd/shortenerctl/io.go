@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Dorrrke/shortener-url/pkg/client"
+)
+
+// Форматы файлов импорта и экспорта.
+const (
+	formatCSV  = "csv"
+	formatJSON = "json"
+)
+
+// openInput - функция открытия файла path, - означает stdin.
+func openInput(path string, stdin io.Reader) (io.Reader, func() error, error) {
+	if path == "-" {
+		return stdin, func() error { return nil }, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, file.Close, nil
+}
+
+// detectFormat - функция определения формата файла: явно заданный формат или расширение файла, по умолчанию csv.
+func detectFormat(format string, path string) string {
+	if format != "" {
+		return strings.ToLower(format)
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return formatJSON
+	}
+	return formatCSV
+}
+
+// readItems - функция чтения url для пакетного сокращения. Строкам без correlation_id назначается номер строки.
+//
+// CSV: одна колонка с url или две колонки correlation_id,original_url. Если первая строка содержит колонку
+// original_url или url, она считается заголовком и колонки ищутся по именам.
+// JSON: массив строк с url или массив объектов {"correlation_id":"...","original_url":"..."},
+// поэтому принимается и выгрузка команды export.
+func readItems(r io.Reader, format string) ([]client.BatchItem, error) {
+	switch format {
+	case formatCSV:
+		return readCSVItems(r)
+	case formatJSON:
+		return readJSONItems(r)
+	}
+	return nil, fmt.Errorf("unknown import format %q, use csv or json", format)
+}
+
+// readCSVItems - функция чтения url из CSV, correlation_id по умолчанию - номер строки файла.
+func readCSVItems(r io.Reader) ([]client.BatchItem, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	corrCol, urlCol := -1, -1
+	var items []client.BatchItem
+	for first := true; ; first = false {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return items, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		if first {
+			for i, name := range record {
+				switch strings.ToLower(strings.TrimSpace(name)) {
+				case "original_url", "url":
+					urlCol = i
+				case "correlation_id":
+					corrCol = i
+				}
+			}
+			if urlCol >= 0 {
+				continue
+			}
+			corrCol = -1
+		}
+		col, corr := urlCol, corrCol
+		if urlCol < 0 {
+			// Без заголовка: url или correlation_id,url.
+			col, corr = len(record)-1, -1
+			if len(record) > 1 {
+				corr = 0
+			}
+		}
+		item := client.BatchItem{CorrelationID: strconv.Itoa(line)}
+		if col < len(record) {
+			item.OriginalURL = strings.TrimSpace(record[col])
+		}
+		if corr >= 0 && corr < len(record) && strings.TrimSpace(record[corr]) != "" {
+			item.CorrelationID = strings.TrimSpace(record[corr])
+		}
+		if item.OriginalURL == "" {
+			return nil, fmt.Errorf("line %d: empty url", line)
+		}
+		items = append(items, item)
+	}
+}
+
+// readJSONItems - функция чтения url из JSON.
+func readJSONItems(r io.Reader) ([]client.BatchItem, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var urls []string
+	if err := json.Unmarshal(data, &urls); err == nil {
+		items := make([]client.BatchItem, 0, len(urls))
+		for i, u := range urls {
+			if strings.TrimSpace(u) == "" {
+				return nil, fmt.Errorf("item %d: empty url", i+1)
+			}
+			items = append(items, client.BatchItem{CorrelationID: strconv.Itoa(i + 1), OriginalURL: strings.TrimSpace(u)})
+		}
+		return items, nil
+	}
+	var items []client.BatchItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("decode json: %w", err)
+	}
+	for i := range items {
+		if strings.TrimSpace(items[i].OriginalURL) == "" {
+			return nil, fmt.Errorf("item %d: empty original_url", i+1)
+		}
+		if items[i].CorrelationID == "" {
+			items[i].CorrelationID = strconv.Itoa(i + 1)
+		}
+	}
+	return items, nil
+}
+
+// writeJSON - функция вывода значения в формате JSON с отступами.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeTable - функция вывода таблицы с выравниванием колонок.
+func writeTable(w io.Writer, headers []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,210 @@
+// shortenerctl - консольный клиент сервиса сокращения url для операторов.
+// Работает через REST или gRPC API с помощью pkg/client:
+//
+//	shortenerctl shorten https://go.dev/
+//	shortenerctl import -transport grpc -addr localhost:3200 links.csv
+//	shortenerctl list -output json
+//
+// Флаги указываются после команды и перед ее аргументами. Токен пользователя сохраняется в файл
+// (-token-file), поэтому последующие команды работают от имени того же пользователя.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Dorrrke/shortener-url/pkg/client"
+)
+
+// Транспорты API сервиса.
+const (
+	transportREST = "rest"
+	transportGRPC = "grpc"
+)
+
+// Адреса сервиса по умолчанию для транспортов.
+const (
+	defaultRESTAddr = "http://localhost:8080"
+	defaultGRPCAddr = "localhost:3200"
+)
+
+// exitUsage - код возврата при ошибке в аргументах команды.
+const exitUsage = 2
+
+// command - команда shortenerctl.
+type command struct {
+	// usage - аргументы команды для справки.
+	usage string
+	// summary - описание команды.
+	summary string
+	// flags - регистрация собственных флагов команды.
+	flags func(fs *flag.FlagSet)
+	// run - выполнение команды с аргументами после флагов.
+	run func(ctx context.Context, e *env, args []string) error
+}
+
+// commands - команды shortenerctl по имени.
+var commands = map[string]*command{
+	"shorten": shortenCommand,
+	"import":  importCommand,
+	"list":    listCommand,
+	"delete":  deleteCommand,
+	"restore": restoreCommand,
+	"stats":   statsCommand,
+	"export":  exportCommand,
+}
+
+// env - окружение выполнения команды: клиент сервиса, формат вывода и потоки.
+type env struct {
+	client client.Client
+	output string
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+// errUsage - ошибка в аргументах команды, после нее выводится справка по команде.
+var errUsage = errors.New("invalid arguments")
+
+// newClient - функция создания клиента сервиса, подменяется в тестах.
+var newClient = func(transport string, addr string, opts client.Options) (client.Client, error) {
+	switch transport {
+	case transportREST:
+		return client.NewREST(addr, nil, opts)
+	case transportGRPC:
+		return client.NewGRPC(addr, opts)
+	}
+	return nil, fmt.Errorf("unknown transport %q, use %s or %s", transport, transportREST, transportGRPC)
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run - функция выполнения команды args[0] с флагами и аргументами args[1:], возвращает код возврата.
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(stderr)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return 0
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n", args[0])
+		printUsage(stderr)
+		return exitUsage
+	}
+
+	fs := flag.NewFlagSet("shortenerctl "+args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	transport := fs.String("transport", envOr("SHORTENER_TRANSPORT", transportREST), "транспорт API: rest или grpc (SHORTENER_TRANSPORT)")
+	addr := fs.String("addr", os.Getenv("SHORTENER_ADDR"), "адрес сервиса (SHORTENER_ADDR), по умолчанию "+defaultRESTAddr+" для rest и "+defaultGRPCAddr+" для grpc")
+	token := fs.String("token", os.Getenv("SHORTENER_TOKEN"), "токен пользователя (SHORTENER_TOKEN), по умолчанию берется из -token-file")
+	tokenFile := fs.String("token-file", defaultTokenFile(), "файл, в котором хранится токен пользователя между запусками, пустое значение отключает сохранение")
+	output := fs.String("output", "table", "формат вывода: table или json")
+	timeout := fs.Duration("timeout", 30*time.Second, "таймаут выполнения команды")
+	if cmd.flags != nil {
+		cmd.flags(fs)
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Использование: shortenerctl %s [флаги] %s\n\n%s\n\nФлаги:\n", args[0], cmd.usage, cmd.summary)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return exitUsage
+	}
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(stderr, "unknown output format %q, use table or json\n", *output)
+		return exitUsage
+	}
+	if *addr == "" {
+		*addr = defaultRESTAddr
+		if *transport == transportGRPC {
+			*addr = defaultGRPCAddr
+		}
+	}
+
+	opts := client.Options{Token: *token}
+	if *tokenFile != "" {
+		opts.TokenStore = client.NewFileTokenStore(*tokenFile)
+	}
+	c, err := newClient(*transport, *addr, opts)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	err = cmd.run(ctx, &env{client: c, output: *output, stdin: stdin, stdout: stdout}, fs.Args())
+	if errors.Is(err, errUsage) {
+		fs.Usage()
+		return exitUsage
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// printUsage - функция вывода списка команд.
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Использование: shortenerctl <команда> [флаги] [аргументы]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Команды:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-8s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Справка по флагам команды: shortenerctl <команда> -h")
+}
+
+// envOr - функция получения переменной окружения key или значения по умолчанию.
+func envOr(key string, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
+
+// defaultTokenFile - функция получения пути к файлу токена по умолчанию в каталоге настроек пользователя.
+func defaultTokenFile() string {
+	if path := os.Getenv("SHORTENER_TOKEN_FILE"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "shortenerctl", "token")
+}
+
+// trimArgs - функция удаления пустых аргументов.
+func trimArgs(args []string) []string {
+	var result []string
+	for _, arg := range args {
+		if arg = strings.TrimSpace(arg); arg != "" {
+			result = append(result, arg)
+		}
+	}
+	return result
+}
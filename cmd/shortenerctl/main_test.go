@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dorrrke/shortener-url/internal/config"
+	"github.com/Dorrrke/shortener-url/internal/server"
+	"github.com/Dorrrke/shortener-url/internal/service"
+	"github.com/Dorrrke/shortener-url/internal/storage"
+	"github.com/Dorrrke/shortener-url/pkg/client"
+)
+
+// newTestServer - функция запуска REST API сервиса с хранилищем в памяти.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	cfg := config.AppConfig{}
+	sService := service.NewService(&storage.MemStorage{URLMap: make(map[string]string)}, &cfg)
+	t.Cleanup(func() { sService.Close(context.Background()) })
+	serv := server.New(sService)
+
+	r := chi.NewRouter()
+	r.Get("/{id}", serv.GetOriginalURLHandler)
+	r.Route("/api", func(r chi.Router) {
+		r.Get("/user/urls", serv.GetAllUrls)
+		r.Delete("/user/urls", serv.DeleteURLHandler)
+		r.Post("/user/urls/restore", serv.RestoreURLHandler)
+		r.Get("/internal/stats", serv.GetServiceStats)
+		r.Post("/shorten", serv.ShortenerJSONURLHandler)
+		r.Post("/shorten/batch", serv.InsertBatchHandler)
+	})
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// ctl - функция запуска shortenerctl, возвращает код возврата, stdout и stderr.
+func ctl(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	srv := newTestServer(t)
+	dir := t.TempDir()
+	flags := []string{"-addr", srv.URL, "-token-file", filepath.Join(dir, "token")}
+	with := func(cmd string, args ...string) []string {
+		return append(append([]string{cmd}, flags...), args...)
+	}
+
+	code, out, errOut := ctl(t, "", with("shorten", "https://go.dev/")...)
+	require.Equal(t, 0, code, errOut)
+	assert.Contains(t, out, "https://go.dev/")
+	assert.Contains(t, out, "created")
+
+	code, out, _ = ctl(t, "", with("shorten", "-output", "json", "https://go.dev/")...)
+	require.Equal(t, 0, code)
+	var shortened []shortenResult
+	require.NoError(t, json.Unmarshal([]byte(out), &shortened))
+	require.Len(t, shortened, 1)
+	assert.Equal(t, "exists", shortened[0].Status)
+	short := shortened[0].ShortURL
+
+	csvFile := filepath.Join(dir, "links.csv")
+	require.NoError(t, os.WriteFile(csvFile, []byte("correlation_id,original_url\na,https://example.com/1\nb,https://example.com/2\n"), 0o600))
+	code, out, errOut = ctl(t, "", with("import", csvFile)...)
+	require.Equal(t, 0, code, errOut)
+	assert.Contains(t, out, "https://example.com/2")
+
+	code, out, errOut = ctl(t, `["https://example.com/3"]`, with("import", "-format", "json", "-")...)
+	require.Equal(t, 0, code, errOut)
+	assert.Contains(t, out, "https://example.com/3")
+
+	code, out, _ = ctl(t, "", with("list", "-output", "json")...)
+	require.Equal(t, 0, code)
+	var urls []client.URL
+	require.NoError(t, json.Unmarshal([]byte(out), &urls))
+	assert.Len(t, urls, 4)
+
+	code, out, _ = ctl(t, "", with("export")...)
+	require.Equal(t, 0, code)
+	assert.True(t, strings.HasPrefix(out, "short_url,original_url\n"))
+	assert.Equal(t, 5, strings.Count(out, "\n"))
+
+	code, _, errOut = ctl(t, "", with("delete", short)...)
+	require.Equal(t, 0, code, errOut)
+	require.Eventually(t, func() bool {
+		code, out, _ := ctl(t, "", with("restore", "-output", "json", short)...)
+		return code == 0 && strings.Contains(out, short)
+	}, time.Second, 10*time.Millisecond)
+
+	code, _, errOut = ctl(t, "", with("stats")...)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errOut, "access_denied")
+
+	code, _, _ = ctl(t, "", with("delete")...)
+	assert.Equal(t, exitUsage, code)
+	code, _, _ = ctl(t, "", "unknown")
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestRunToken(t *testing.T) {
+	srv := newTestServer(t)
+	flags := []string{"-addr", srv.URL, "-token-file", ""}
+
+	code, _, errOut := ctl(t, "", append([]string{"shorten"}, append(flags, "https://go.dev/")...)...)
+	require.Equal(t, 0, code, errOut)
+
+	// Без сохраненного токена у нового пользователя ссылок нет.
+	code, out, _ := ctl(t, "", append([]string{"list", "-output", "json"}, flags...)...)
+	require.Equal(t, 0, code)
+	assert.JSONEq(t, "[]", out)
+
+	code, _, errOut = ctl(t, "", append([]string{"list", "-token", "bad"}, flags...)...)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errOut, "unauthorized")
+}
+
+func TestReadItems(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		input  string
+		want   []client.BatchItem
+		err    bool
+	}{
+		{
+			name:   "csv without header",
+			format: formatCSV,
+			input:  "https://a.ru/\n\nhttps://b.ru/\n",
+			want:   []client.BatchItem{{CorrelationID: "1", OriginalURL: "https://a.ru/"}, {CorrelationID: "3", OriginalURL: "https://b.ru/"}},
+		},
+		{
+			name:   "csv correlation id without header",
+			format: formatCSV,
+			input:  "x,https://a.ru/\n",
+			want:   []client.BatchItem{{CorrelationID: "x", OriginalURL: "https://a.ru/"}},
+		},
+		{
+			name:   "csv export",
+			format: formatCSV,
+			input:  "short_url,original_url\nhttp://localhost/abc,https://a.ru/\n",
+			want:   []client.BatchItem{{CorrelationID: "2", OriginalURL: "https://a.ru/"}},
+		},
+		{
+			name:   "csv empty url",
+			format: formatCSV,
+			input:  "original_url,correlation_id\n,x\n",
+			err:    true,
+		},
+		{
+			name:   "json objects",
+			format: formatJSON,
+			input:  `[{"original_url":"https://a.ru/"},{"correlation_id":"b","original_url":"https://b.ru/"}]`,
+			want:   []client.BatchItem{{CorrelationID: "1", OriginalURL: "https://a.ru/"}, {CorrelationID: "b", OriginalURL: "https://b.ru/"}},
+		},
+		{
+			name:   "json invalid",
+			format: formatJSON,
+			input:  `{"url":"https://a.ru/"}`,
+			err:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := readItems(strings.NewReader(tt.input), tt.format)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, items)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Пакет access содержит ограничение доступа к служебным маршрутам (статистика, метрики, pprof, аудит)
+// списком доверенных подсетей IPv4 и IPv6. Адрес клиента определяется по адресу подключения,
+// заголовки X-Real-IP и X-Forwarded-For учитываются только от доверенных прокси (см. пакет realip).
+package access
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+
+	"github.com/Dorrrke/shortener-url/internal/logger"
+	"github.com/Dorrrke/shortener-url/internal/problem"
+	"github.com/Dorrrke/shortener-url/internal/realip"
+	"github.com/Dorrrke/shortener-url/internal/service"
+)
+
+// Source - функция получения текущего списка доверенных подсетей через запятую,
+// например из снимка конфигурации. Список может меняться во время работы.
+type Source func() string
+
+// subnets - разобранный список подсетей вместе с исходной строкой.
+type subnets struct {
+	raw  string
+	nets []*net.IPNet
+}
+
+// Policy - проверка доступа по списку доверенных подсетей.
+// Если список пуст, доступ запрещен всем. Нулевой указатель на Policy также запрещает доступ.
+type Policy struct {
+	source   Source
+	resolver *realip.Resolver
+	parsed   atomic.Pointer[subnets]
+}
+
+// New - функция создания Policy. Список подсетей разбирается только при его изменении.
+func New(source Source, resolver *realip.Resolver) *Policy {
+	return &Policy{source: source, resolver: resolver}
+}
+
+// current - метод получения разобранного списка подсетей, при изменении исходной строки список разбирается заново.
+// Некорректные элементы пропускаются с ошибкой в логе.
+func (p *Policy) current() []*net.IPNet {
+	raw := p.source()
+	if cached := p.parsed.Load(); cached != nil && cached.raw == raw {
+		return cached.nets
+	}
+	next := &subnets{raw: raw}
+	for _, value := range strings.Split(raw, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		ipNet, err := realip.ParseNet(value)
+		if err != nil {
+			logger.Log.Error("Cannot parse trusted subnet", zap.String("subnet", value), zap.Error(err))
+			continue
+		}
+		next.nets = append(next.nets, ipNet)
+	}
+	p.parsed.Store(next)
+	return next.nets
+}
+
+// Allowed - метод проверки, что ip адрес входит в одну из доверенных подсетей.
+func (p *Policy) Allowed(ip string) bool {
+	if p == nil {
+		return false
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, ipNet := range p.current() {
+		if ipNet.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowRequest - метод проверки доступа для http запроса.
+func (p *Policy) AllowRequest(req *http.Request) bool {
+	if p == nil {
+		return false
+	}
+	return p.Allowed(p.resolver.FromRequest(req))
+}
+
+// AllowContext - метод проверки доступа для grpc вызова.
+func (p *Policy) AllowContext(ctx context.Context) bool {
+	if p == nil {
+		return false
+	}
+	return p.Allowed(p.resolver.FromContext(ctx))
+}
+
+// Middleware - middleware, пропускающий к хендлеру только запросы из доверенных подсетей,
+// иначе возвращается статус 403 с кодом access_denied в формате application/problem+json.
+func (p *Policy) Middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if !p.AllowRequest(req) {
+			problem.Write(res, req, service.ErrAccessDenied)
+			return
+		}
+		h.ServeHTTP(res, req)
+	})
+}
@@ -0,0 +1,135 @@
+package access
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/Dorrrke/shortener-url/internal/models"
+	"github.com/Dorrrke/shortener-url/internal/realip"
+	"github.com/Dorrrke/shortener-url/internal/service"
+)
+
+func TestPolicyAllowRequest(t *testing.T) {
+	resolver, err := realip.NewResolver([]string{"10.0.0.1"})
+	require.NoError(t, err)
+	policy := New(func() string { return "192.168.0.0/16, 2001:db8::/32,203.0.113.7" }, resolver)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		realIP     string
+		want       bool
+	}{
+		{
+			name:       "Test access #1 IPv4 subnet",
+			remoteAddr: "192.168.1.10:1234",
+			want:       true,
+		},
+		{
+			name:       "Test access #2 IPv6 subnet",
+			remoteAddr: "[2001:db8::5]:1234",
+			want:       true,
+		},
+		{
+			name:       "Test access #3 Single address",
+			remoteAddr: "203.0.113.7:1234",
+			want:       true,
+		},
+		{
+			name:       "Test access #4 Untrusted client spoofs X-Real-IP",
+			remoteAddr: "198.51.100.1:1234",
+			realIP:     "192.168.1.10",
+			want:       false,
+		},
+		{
+			name:       "Test access #5 Trusted proxy forwards client",
+			remoteAddr: "10.0.0.1:1234",
+			realIP:     "192.168.1.10",
+			want:       true,
+		},
+		{
+			name:       "Test access #6 Trusted proxy forwards untrusted client",
+			remoteAddr: "10.0.0.1:1234",
+			realIP:     "198.51.100.1",
+			want:       false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/internal/stats", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			assert.Equal(t, tt.want, policy.AllowRequest(req))
+		})
+	}
+}
+
+func TestPolicyEmptyAndInvalid(t *testing.T) {
+	var nilPolicy *Policy
+	assert.False(t, nilPolicy.Allowed("127.0.0.1"))
+
+	empty := New(func() string { return "" }, nil)
+	assert.False(t, empty.Allowed("127.0.0.1"))
+
+	invalid := New(func() string { return "not-a-subnet, 127.0.0.0/8" }, nil)
+	assert.True(t, invalid.Allowed("127.0.0.1"), "valid subnets are kept")
+	assert.False(t, invalid.Allowed("garbage"))
+}
+
+func TestPolicySourceChange(t *testing.T) {
+	subnet := "127.0.0.0/8"
+	policy := New(func() string { return subnet }, nil)
+	assert.True(t, policy.Allowed("127.0.0.1"))
+
+	subnet = "::1/128"
+	assert.False(t, policy.Allowed("127.0.0.1"))
+	assert.True(t, policy.Allowed("::1"))
+}
+
+func TestPolicyAllowContext(t *testing.T) {
+	policy := New(func() string { return "127.0.0.0/8" }, nil)
+
+	assert.False(t, policy.AllowContext(context.Background()), "missing peer and metadata")
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("198.51.100.1"), Port: 1234}})
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("X-Real-IP", "127.0.0.1"))
+	assert.False(t, policy.AllowContext(ctx), "metadata from untrusted peer is ignored")
+
+	ctx = peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 1234}})
+	assert.True(t, policy.AllowContext(ctx))
+}
+
+func TestMiddleware(t *testing.T) {
+	policy := New(func() string { return "127.0.0.0/8" }, nil)
+	h := policy.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.RemoteAddr = "127.0.0.1:1234"
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	req.RemoteAddr = "198.51.100.1:1234"
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, models.ProblemContentType, rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), service.CodeAccessDenied)
+
+	var nilPolicy *Policy
+	rec = httptest.NewRecorder()
+	nilPolicy.Middleware(http.NotFoundHandler()).ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
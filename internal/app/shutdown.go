@@ -0,0 +1,98 @@
+// Пакет app содержит порядок остановки сервиса.
+// Остановка выполняется последовательными шагами с общим таймаутом: сначала сервер перестает принимать
+// соединения и дожидается уже принятых запросов, затем завершаются очереди и сбрасываются файлы,
+// и только после этого закрываются соединения с хранилищами.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	"github.com/Dorrrke/shortener-url/internal/logger"
+)
+
+// Step - шаг остановки сервиса.
+type Step struct {
+	Name string
+	Stop func(ctx context.Context) error
+}
+
+// Shutdown - последовательность шагов остановки сервиса.
+type Shutdown struct {
+	// Timeout - общее время на выполнение всех шагов, 0 - без ограничения.
+	Timeout time.Duration
+
+	steps []Step
+}
+
+// Add - метод добавления шага в конец последовательности.
+func (s *Shutdown) Add(name string, stop func(ctx context.Context) error) {
+	s.steps = append(s.steps, Step{Name: name, Stop: stop})
+}
+
+// AddCloser - метод добавления шага, не принимающего контекст, например закрытия пула соединений.
+func (s *Shutdown) AddCloser(name string, closeFn func() error) {
+	s.Add(name, func(context.Context) error {
+		return closeFn()
+	})
+}
+
+// Run - метод выполнения всех шагов по порядку.
+// Ошибка шага не прерывает остановку: следующие шаги выполняются, ошибки возвращаются вместе.
+// При истечении Timeout оставшиеся шаги получают отмененный контекст и должны освободить ресурсы без ожидания.
+func (s *Shutdown) Run(ctx context.Context) error {
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+	var errs []error
+	for _, step := range s.steps {
+		start := time.Now()
+		if err := step.Stop(ctx); err != nil {
+			logger.Log.Error("Shutdown step failed", zap.String("step", step.Name), zap.Error(err))
+			errs = append(errs, fmt.Errorf("%s: %w", step.Name, err))
+			continue
+		}
+		logger.Log.Info("Shutdown step done", zap.String("step", step.Name), zap.Duration("duration", time.Since(start)))
+	}
+	return errors.Join(errs...)
+}
+
+// HTTPServer - функция создания шага остановки http сервера: новые соединения не принимаются,
+// принятые запросы обрабатываются до конца. При истечении ctx оставшиеся соединения закрываются.
+func HTTPServer(server *http.Server) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		err := server.Shutdown(ctx)
+		if err != nil {
+			server.Close()
+		}
+		return err
+	}
+}
+
+// GRPCServer - функция создания шага остановки grpc сервера: новые вызовы не принимаются,
+// выполняющиеся вызовы завершаются. При истечении ctx вызовы прерываются.
+func GRPCServer(server *grpc.Server) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		stopped := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			server.Stop()
+			<-stopped
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dorrrke/shortener-url/internal/config"
+	"github.com/Dorrrke/shortener-url/internal/server"
+	"github.com/Dorrrke/shortener-url/internal/service"
+	"github.com/Dorrrke/shortener-url/internal/storage"
+	"github.com/Dorrrke/shortener-url/internal/utils"
+)
+
+// slowStorage - хранилище, задерживающее запись, чтобы запросы оставались в обработке во время остановки.
+type slowStorage struct {
+	*storage.MemStorage
+	delay   time.Duration
+	entered atomic.Int64
+}
+
+func (s *slowStorage) InsertURL(ctx context.Context, originalURL string, shortURL string, userID string) error {
+	s.entered.Add(1)
+	time.Sleep(s.delay)
+	return s.MemStorage.InsertURL(ctx, originalURL, shortURL, userID)
+}
+
+func (s *slowStorage) SetDeleteURLStatus(ctx context.Context, value []string, userID string) ([]string, error) {
+	time.Sleep(s.delay)
+	return s.MemStorage.SetDeleteURLStatus(ctx, value, userID)
+}
+
+type testApp struct {
+	cfg      *config.AppConfig
+	stor     *slowStorage
+	sService *service.ShortenerService
+	http     *http.Server
+	url      string
+	shutdown *Shutdown
+}
+
+func startTestApp(t *testing.T) *testApp {
+	cfg := &config.AppConfig{
+		BaseURL:         "short.test",
+		FileStoragePath: filepath.Join(t.TempDir(), "storage.json"),
+	}
+	stor := &slowStorage{MemStorage: &storage.MemStorage{URLMap: make(map[string]string)}, delay: 200 * time.Millisecond}
+	sService := service.NewService(stor, cfg)
+	serv := server.New(sService)
+
+	r := chi.NewRouter()
+	r.Post("/", serv.ShortenerURLHandler)
+	r.Delete("/api/user/urls", serv.DeleteURLHandler)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	httpServer := &http.Server{Handler: r}
+	go httpServer.Serve(l)
+
+	shutdown := &Shutdown{Timeout: 5 * time.Second}
+	shutdown.Add("http server", HTTPServer(httpServer))
+	shutdown.Add("delete queue", sService.Close)
+	shutdown.AddCloser("file storage", sService.SyncFileStorage)
+
+	return &testApp{
+		cfg:      cfg,
+		stor:     stor,
+		sService: sService,
+		http:     httpServer,
+		url:      "http://" + l.Addr().String(),
+		shutdown: shutdown,
+	}
+}
+
+// waitFor - функция ожидания условия с ограничением по времени.
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not reached")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func countLines(t *testing.T, path string) int {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines++
+	}
+	require.NoError(t, scanner.Err())
+	return lines
+}
+
+func TestShutdownCompletesAcceptedRequests(t *testing.T) {
+	a := startTestApp(t)
+	const requests = 20
+
+	var wg sync.WaitGroup
+	codes := make([]int, requests)
+	errs := make([]error, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := http.Post(a.url+"/", "text/plain", strings.NewReader(fmt.Sprintf("https://example.com/%d", i)))
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			resp.Body.Close()
+			codes[i] = resp.StatusCode
+		}(i)
+	}
+	waitFor(t, func() bool { return a.stor.entered.Load() == requests })
+
+	require.NoError(t, a.shutdown.Run(context.Background()))
+	wg.Wait()
+
+	for i := 0; i < requests; i++ {
+		require.NoError(t, errs[i], "request %d", i)
+		assert.Equal(t, http.StatusCreated, codes[i], "request %d", i)
+	}
+	assert.Equal(t, requests, countLines(t, a.cfg.FileStoragePath))
+	count, err := a.stor.CountActiveURLs(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, requests, count)
+
+	_, err = http.Post(a.url+"/", "text/plain", strings.NewReader("https://example.com/late"))
+	assert.Error(t, err, "server must not accept connections after shutdown")
+}
+
+func TestShutdownFlushesDeleteQueue(t *testing.T) {
+	a := startTestApp(t)
+	ctx := context.Background()
+	var ids []string
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("id%d", i)
+		ids = append(ids, id)
+		require.NoError(t, a.stor.MemStorage.InsertURL(ctx, fmt.Sprintf("https://example.com/%d", i), "http://short.test/"+id, "user"))
+	}
+
+	token, err := utils.CreateJWTToken("user")
+	require.NoError(t, err)
+	for _, id := range ids {
+		req, err := http.NewRequest(http.MethodDelete, a.url+"/api/user/urls", strings.NewReader(`["`+id+`"]`))
+		require.NoError(t, err)
+		req.AddCookie(&http.Cookie{Name: "auth", Value: token})
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	}
+
+	require.NoError(t, a.shutdown.Run(ctx))
+	assert.Equal(t, 0, a.sService.DeleteQueueDepth())
+	for _, id := range ids {
+		_, deleted, err := a.stor.GetOriginalURLByShort(ctx, "http://short.test/"+id)
+		require.NoError(t, err)
+		assert.True(t, deleted, id)
+	}
+
+	// После остановки очередь не принимает новые url и не паникует.
+	a.sService.DeleteURL(ctx, []string{"late"}, "", "user")
+}
+
+func TestShutdownRun(t *testing.T) {
+	var order []string
+	shutdown := &Shutdown{Timeout: 50 * time.Millisecond}
+	shutdown.Add("first", func(ctx context.Context) error {
+		order = append(order, "first")
+		return errors.New("first failed")
+	})
+	shutdown.Add("hanging", func(ctx context.Context) error {
+		order = append(order, "hanging")
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	shutdown.AddCloser("last", func() error {
+		order = append(order, "last")
+		return nil
+	})
+
+	err := shutdown.Run(context.Background())
+	assert.Equal(t, []string{"first", "hanging", "last"}, order)
+	assert.ErrorContains(t, err, "first: first failed")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,153 @@
+// Пакет audit содержит подсистему аудита изменяющих операций сервиса.
+// Каждая операция (создание, удаление, восстановление ссылки) записывается в виде Record
+// и отправляется во все подключенные приемники (Sink): таблицу в postgres, файл в формате JSON lines или stdout.
+package audit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Dorrrke/shortener-url/internal/logger"
+)
+
+// ErrQueryNotSupported - ошибка при попытке получить записи аудита, когда ни один из приемников не поддерживает чтение.
+var ErrQueryNotSupported = errors.New("audit sinks do not support query")
+
+// Action - тип изменяющей операции.
+type Action string
+
+// Список операций, которые попадают в аудит.
+const (
+	ActionCreate  Action = "create"
+	ActionDelete  Action = "delete"
+	ActionRestore Action = "restore"
+)
+
+// Record - запись аудита: кто (Actor), что сделал (Action), с какой ссылкой (Target), когда и откуда.
+type Record struct {
+	Time   time.Time `json:"ts"`
+	Actor  string    `json:"actor"`
+	Action Action    `json:"action"`
+	Target string    `json:"target"`
+	IP     string    `json:"ip"`
+}
+
+// Filter - параметры выборки записей аудита. Пустые поля не участвуют в фильтрации.
+type Filter struct {
+	Actor  string
+	Action Action
+	Target string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+}
+
+// Match - метод проверки записи на соответствие фильтру.
+func (f Filter) Match(rec Record) bool {
+	if f.Actor != "" && rec.Actor != f.Actor {
+		return false
+	}
+	if f.Action != "" && rec.Action != f.Action {
+		return false
+	}
+	if f.Target != "" && rec.Target != f.Target {
+		return false
+	}
+	if !f.Since.IsZero() && rec.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && rec.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Sink - интерфейс приемника записей аудита.
+type Sink interface {
+	Write(ctx context.Context, rec Record) error
+	Close() error
+}
+
+// Querier - интерфейс приемника, из которого можно прочитать сохраненные записи.
+type Querier interface {
+	Query(ctx context.Context, f Filter) ([]Record, error)
+}
+
+// Auditor - рассылает записи аудита по всем подключенным приемникам.
+// Нулевой указатель на Auditor допустим и ничего не делает.
+type Auditor struct {
+	sinks []Sink
+	now   func() time.Time
+}
+
+// New - функция создания Auditor с переданными приемниками.
+func New(sinks ...Sink) *Auditor {
+	return &Auditor{
+		sinks: sinks,
+		now:   time.Now,
+	}
+}
+
+// Record - метод записи операции во все приемники.
+// Ошибки приемников не прерывают основную операцию и только логируются.
+func (a *Auditor) Record(ctx context.Context, action Action, actor string, target string) {
+	if a == nil || len(a.sinks) == 0 {
+		return
+	}
+	rec := Record{
+		Time:   a.now().UTC(),
+		Actor:  actor,
+		Action: action,
+		Target: target,
+		IP:     IPFromContext(ctx),
+	}
+	for _, sink := range a.sinks {
+		if err := sink.Write(ctx, rec); err != nil {
+			logger.Log.Error("cannot write audit record", zap.Error(err), zap.Any("record", rec))
+		}
+	}
+}
+
+// Query - метод получения записей аудита из первого приемника, поддерживающего чтение.
+func (a *Auditor) Query(ctx context.Context, f Filter) ([]Record, error) {
+	if a == nil {
+		return nil, ErrQueryNotSupported
+	}
+	for _, sink := range a.sinks {
+		if q, ok := sink.(Querier); ok {
+			return q.Query(ctx, f)
+		}
+	}
+	return nil, ErrQueryNotSupported
+}
+
+// Close - метод закрытия всех приемников.
+func (a *Auditor) Close() error {
+	if a == nil {
+		return nil
+	}
+	var errs []error
+	for _, sink := range a.sinks {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ipKey - ключ для хранения ip клиента в контексте.
+type ipKey struct{}
+
+// WithIP - функция сохранения ip клиента в контексте запроса.
+func WithIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, ipKey{}, ip)
+}
+
+// IPFromContext - функция получения ip клиента из контекста запроса.
+func IPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(ipKey{}).(string)
+	return ip
+}
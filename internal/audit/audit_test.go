@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditorRecord(t *testing.T) {
+	var buf bytes.Buffer
+	auditor := New(NewWriterSink(&buf))
+	auditor.now = func() time.Time {
+		return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	}
+
+	ctx := WithIP(context.Background(), "10.0.0.1")
+	auditor.Record(ctx, ActionCreate, "user-1", "http://localhost:8080/abc")
+
+	var rec Record
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &rec))
+	assert.Equal(t, Record{
+		Time:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Actor:  "user-1",
+		Action: ActionCreate,
+		Target: "http://localhost:8080/abc",
+		IP:     "10.0.0.1",
+	}, rec)
+}
+
+func TestNilAuditor(t *testing.T) {
+	var auditor *Auditor
+	auditor.Record(context.Background(), ActionDelete, "user-1", "http://localhost:8080/abc")
+	_, err := auditor.Query(context.Background(), Filter{})
+	assert.ErrorIs(t, err, ErrQueryNotSupported)
+	assert.NoError(t, auditor.Close())
+}
+
+func TestFileSinkQuery(t *testing.T) {
+	sink, err := NewFileSink(filepath.Join(t.TempDir(), "audit.jsonl"))
+	require.NoError(t, err)
+	auditor := New(sink, NewWriterSink(&bytes.Buffer{}))
+	defer auditor.Close()
+
+	ctx := WithIP(context.Background(), "127.0.0.1")
+	auditor.Record(ctx, ActionCreate, "user-1", "http://short/1")
+	auditor.Record(ctx, ActionCreate, "user-2", "http://short/2")
+	auditor.Record(ctx, ActionDelete, "user-1", "http://short/1")
+
+	tests := []struct {
+		name    string
+		filter  Filter
+		targets []string
+	}{
+		{
+			name:    "Test audit query #1 All records",
+			filter:  Filter{},
+			targets: []string{"http://short/1", "http://short/2", "http://short/1"},
+		},
+		{
+			name:    "Test audit query #2 By actor",
+			filter:  Filter{Actor: "user-1"},
+			targets: []string{"http://short/1", "http://short/1"},
+		},
+		{
+			name:    "Test audit query #3 By action",
+			filter:  Filter{Action: ActionCreate},
+			targets: []string{"http://short/2", "http://short/1"},
+		},
+		{
+			name:    "Test audit query #4 Limit",
+			filter:  Filter{Limit: 1},
+			targets: []string{"http://short/1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := auditor.Query(context.Background(), tt.filter)
+			require.NoError(t, err)
+			var targets []string
+			for _, rec := range records {
+				targets = append(targets, rec.Target)
+				assert.Equal(t, "127.0.0.1", rec.IP)
+			}
+			assert.Equal(t, tt.targets, targets)
+		})
+	}
+}
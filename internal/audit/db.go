@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// DBSink - приемник, сохраняющий записи аудита в таблицу audit_log базы данных PostgreSQL.
+// Поддерживает чтение записей (Querier).
+type DBSink struct {
+	// DB - ссылка на пул подключений к postgre.
+	DB *pgxpool.Pool
+}
+
+// CreateTable - метод создания таблицы аудита, если ее не существует.
+func (s *DBSink) CreateTable(ctx context.Context) error {
+	createTableStr := `CREATE TABLE IF NOT EXISTS audit_log
+	(
+		id bigserial PRIMARY KEY,
+		ts timestamptz NOT NULL DEFAULT now(),
+		actor text NOT NULL,
+		action text NOT NULL,
+		target text NOT NULL,
+		ip text NOT NULL
+	);
+
+	create INDEX IF NOT EXISTS audit_log_ts ON audit_log (ts)`
+	if _, err := s.DB.Exec(ctx, createTableStr); err != nil {
+		return errors.Wrap(err, "Error whitle creating audit table")
+	}
+	return nil
+}
+
+// Write - метод сохранения записи в таблицу.
+func (s *DBSink) Write(ctx context.Context, rec Record) error {
+	_, err := s.DB.Exec(ctx, "INSERT INTO audit_log (ts, actor, action, target, ip) values ($1, $2, $3, $4, $5)",
+		rec.Time, rec.Actor, string(rec.Action), rec.Target, rec.IP)
+	if err != nil {
+		return errors.Wrap(err, "Error while inserting audit row in db")
+	}
+	return nil
+}
+
+// Query - метод выборки записей аудита из таблицы, самые новые записи идут первыми.
+func (s *DBSink) Query(ctx context.Context, f Filter) ([]Record, error) {
+	var where []string
+	var args []any
+	addCond := func(cond string, value any) {
+		args = append(args, value)
+		where = append(where, cond+" $"+strconv.Itoa(len(args)))
+	}
+	if f.Actor != "" {
+		addCond("actor =", f.Actor)
+	}
+	if f.Action != "" {
+		addCond("action =", string(f.Action))
+	}
+	if f.Target != "" {
+		addCond("target =", f.Target)
+	}
+	if !f.Since.IsZero() {
+		addCond("ts >=", f.Since)
+	}
+	if !f.Until.IsZero() {
+		addCond("ts <=", f.Until)
+	}
+
+	query := "SELECT ts, actor, action, target, ip FROM audit_log"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY ts DESC"
+	if f.Limit > 0 {
+		args = append(args, f.Limit)
+		query += " LIMIT $" + strconv.Itoa(len(args))
+	}
+
+	rows, err := s.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while query audit log")
+	}
+	defer rows.Close()
+
+	var records []Record
+	for rows.Next() {
+		var rec Record
+		var action string
+		if err := rows.Scan(&rec.Time, &rec.Actor, &action, &rec.Target, &rec.IP); err != nil {
+			return nil, errors.Wrap(err, "Error parsing audit row")
+		}
+		rec.Action = Action(action)
+		records = append(records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Close - пулом подключений владеет main, поэтому метод ничего не закрывает.
+func (s *DBSink) Close() error {
+	return nil
+}
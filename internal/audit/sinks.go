@@ -0,0 +1,129 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// WriterSink - приемник, записывающий записи аудита в io.Writer в формате JSON lines.
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink - функция создания приемника для произвольного io.Writer.
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// NewStdoutSink - функция создания приемника, печатающего записи аудита в stdout.
+func NewStdoutSink() *WriterSink {
+	return NewWriterSink(os.Stdout)
+}
+
+// Write - метод записи в io.Writer.
+func (s *WriterSink) Write(ctx context.Context, rec Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return errors.Wrap(err, "encode audit record")
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(data); err != nil {
+		return errors.Wrap(err, "write audit record")
+	}
+	return nil
+}
+
+// Close - метод закрытия приемника. io.Writer не закрывается, так как им владеет вызывающая сторона.
+func (s *WriterSink) Close() error {
+	return nil
+}
+
+// FileSink - приемник, дописывающий записи аудита в файл в формате JSON lines.
+// Поддерживает чтение записей (Querier).
+type FileSink struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// NewFileSink - функция создания файлового приемника, файл открывается на дозапись.
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, errors.Wrap(err, "open audit file")
+	}
+	return &FileSink{path: path, file: file}, nil
+}
+
+// Write - метод записи в файл.
+func (s *FileSink) Write(ctx context.Context, rec Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return errors.Wrap(err, "encode audit record")
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(data); err != nil {
+		return errors.Wrap(err, "write audit record")
+	}
+	return nil
+}
+
+// Query - метод чтения записей из файла. Возвращаются последние Limit записей, подходящих под фильтр.
+// Файл читается целиком, поэтому метод подходит для небольших журналов и локальной отладки.
+func (s *FileSink) Query(ctx context.Context, f Filter) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, errors.Wrap(err, "open audit file")
+	}
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, errors.Wrap(err, "decode audit record")
+		}
+		if f.Match(rec) {
+			records = append(records, rec)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "read audit file")
+	}
+	// Как и DBSink, возвращаем самые новые записи первыми.
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+	if f.Limit > 0 && len(records) > f.Limit {
+		records = records[:f.Limit]
+	}
+	return records, nil
+}
+
+// Close - метод сброса записей на диск и закрытия файла.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.file.Sync(); err != nil {
+		s.file.Close()
+		return errors.Wrap(err, "sync audit file")
+	}
+	return s.file.Close()
+}
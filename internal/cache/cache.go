@@ -0,0 +1,91 @@
+// Пакет cache содержит кэши строковых значений с ограниченным временем жизни:
+// LRU в памяти процесса, Redis и многоуровневый кэш из нескольких кэшей.
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache - кэш строковых значений по строковому ключу.
+type Cache interface {
+	// Get - получение значения, второе значение false, если ключа нет или время жизни истекло.
+	Get(ctx context.Context, key string) (string, bool, error)
+	// Set - сохранение значения на время ttl.
+	Set(ctx context.Context, key string, value string, ttl time.Duration) error
+	// Delete - удаление ключей.
+	Delete(ctx context.Context, keys ...string) error
+	// Purge - удаление всех ключей кэша.
+	Purge(ctx context.Context) error
+}
+
+// Layered - многоуровневый кэш, уровни перечисляются от самого быстрого к самому медленному.
+// При попадании в медленный уровень значение копируется в более быстрые уровни.
+// Все уровни, кроме последнего, хранят значения не дольше UpperTTL, чтобы изменения,
+// сделанные в общем последнем уровне другими экземплярами сервиса, применялись быстро.
+type Layered struct {
+	Levels   []Cache
+	UpperTTL time.Duration
+}
+
+// upperTTL - метод вычисления времени жизни значения в верхних уровнях.
+func (l *Layered) upperTTL(ttl time.Duration) time.Duration {
+	if l.UpperTTL > 0 && (ttl <= 0 || ttl > l.UpperTTL) {
+		return l.UpperTTL
+	}
+	return ttl
+}
+
+// Get - метод поиска значения по уровням.
+func (l *Layered) Get(ctx context.Context, key string) (string, bool, error) {
+	for i, level := range l.Levels {
+		value, ok, err := level.Get(ctx, key)
+		if err != nil {
+			return "", false, err
+		}
+		if !ok {
+			continue
+		}
+		for _, upper := range l.Levels[:i] {
+			if err := upper.Set(ctx, key, value, l.upperTTL(0)); err != nil {
+				return "", false, err
+			}
+		}
+		return value, true, nil
+	}
+	return "", false, nil
+}
+
+// Set - метод сохранения значения во все уровни.
+func (l *Layered) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	for i, level := range l.Levels {
+		levelTTL := ttl
+		if i < len(l.Levels)-1 {
+			levelTTL = l.upperTTL(ttl)
+		}
+		if err := level.Set(ctx, key, value, levelTTL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete - метод удаления ключей из всех уровней.
+func (l *Layered) Delete(ctx context.Context, keys ...string) error {
+	for _, level := range l.Levels {
+		if err := level.Delete(ctx, keys...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Purge - метод очистки всех уровней.
+func (l *Layered) Purge(ctx context.Context) error {
+	for _, level := range l.Levels {
+		if err := level.Purge(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
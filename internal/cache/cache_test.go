@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewLRU(2)
+	c.now = func() time.Time { return now }
+
+	require.NoError(t, c.Set(ctx, "a", "1", time.Minute))
+	require.NoError(t, c.Set(ctx, "b", "2", 0))
+	value, ok, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "1", value)
+
+	require.NoError(t, c.Set(ctx, "c", "3", time.Minute))
+	_, ok, _ = c.Get(ctx, "b")
+	assert.False(t, ok, "least recently used key must be evicted")
+	assert.Equal(t, 2, c.Len())
+
+	now = now.Add(time.Minute)
+	_, ok, _ = c.Get(ctx, "a")
+	assert.False(t, ok, "expired key must not be returned")
+	assert.Equal(t, 1, c.Len())
+
+	require.NoError(t, c.Delete(ctx, "c"))
+	_, ok, _ = c.Get(ctx, "c")
+	assert.False(t, ok)
+
+	require.NoError(t, c.Set(ctx, "d", "4", 0))
+	require.NoError(t, c.Purge(ctx))
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	c := NewRedis(mr.Addr(), "test:")
+	defer c.Close()
+
+	require.NoError(t, c.Set(ctx, "a", "1", time.Minute))
+	require.NoError(t, c.Set(ctx, "b", "2", 0))
+	require.NoError(t, mr.Set("other", "x"))
+	assert.True(t, mr.Exists("test:a"))
+
+	value, ok, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "1", value)
+
+	mr.FastForward(time.Minute)
+	_, ok, err = c.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, c.Delete(ctx, "b"))
+	_, ok, _ = c.Get(ctx, "b")
+	assert.False(t, ok)
+
+	require.NoError(t, c.Set(ctx, "c", "3", 0))
+	require.NoError(t, c.Purge(ctx))
+	_, ok, _ = c.Get(ctx, "c")
+	assert.False(t, ok)
+	assert.True(t, mr.Exists("other"), "purge must keep keys without prefix")
+
+	mr.Close()
+	_, _, err = c.Get(ctx, "a")
+	assert.Error(t, err)
+}
+
+func TestLayered(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	remote := NewRedis(mr.Addr(), "")
+	defer remote.Close()
+	local := NewLRU(10)
+	c := &Layered{Levels: []Cache{local, remote}, UpperTTL: time.Minute}
+
+	require.NoError(t, remote.Set(ctx, "a", "1", 0))
+	value, ok, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "1", value)
+	value, ok, _ = local.Get(ctx, "a")
+	assert.True(t, ok, "value must be copied to the upper level")
+	assert.Equal(t, "1", value)
+
+	require.NoError(t, c.Set(ctx, "b", "2", time.Hour))
+	assert.True(t, mr.Exists("b"))
+	assert.Equal(t, time.Hour, mr.TTL("b"))
+	require.NoError(t, c.Delete(ctx, "a", "b"))
+	_, ok, _ = c.Get(ctx, "a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, local.Len())
+}
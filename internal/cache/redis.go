@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// scanBatch - количество ключей, запрашиваемых за один SCAN при очистке.
+const scanBatch = 100
+
+// Redis - кэш в Redis или совместимом сервере, позволяет разделять кэш между экземплярами сервиса.
+// Все ключи сохраняются с префиксом Prefix, чтобы Purge не затрагивал чужие данные.
+type Redis struct {
+	Client *redis.Client
+	Prefix string
+}
+
+// NewRedis - функция создания кэша по адресу сервера addr.
+func NewRedis(addr string, prefix string) *Redis {
+	return &Redis{
+		Client: redis.NewClient(&redis.Options{Addr: addr}),
+		Prefix: prefix,
+	}
+}
+
+// Get - метод получения значения.
+func (c *Redis) Get(ctx context.Context, key string) (string, bool, error) {
+	value, err := c.Client.Get(ctx, c.Prefix+key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// Set - метод сохранения значения, ttl <= 0 означает бессрочное хранение.
+func (c *Redis) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	if ttl < 0 {
+		ttl = 0
+	}
+	return c.Client.Set(ctx, c.Prefix+key, value, ttl).Err()
+}
+
+// Delete - метод удаления ключей.
+func (c *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixed := make([]string, 0, len(keys))
+	for _, key := range keys {
+		prefixed = append(prefixed, c.Prefix+key)
+	}
+	return c.Client.Del(ctx, prefixed...).Err()
+}
+
+// Purge - метод удаления всех ключей с префиксом кэша.
+func (c *Redis) Purge(ctx context.Context) error {
+	iter := c.Client.Scan(ctx, 0, c.Prefix+"*", scanBatch).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return c.Client.Del(ctx, keys...).Err()
+}
+
+// Close - метод закрытия соединений с сервером.
+func (c *Redis) Close() error {
+	return c.Client.Close()
+}
@@ -0,0 +1,144 @@
+// Пакет compress содержит сжатие ответов и распаковку запросов для http сервера с выбором кодировки
+// по заголовку Accept-Encoding (zstd, br, gzip, deflate), а также регистрацию компрессоров для grpc.
+package compress
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Имена поддерживаемых кодировок в заголовках Accept-Encoding и Content-Encoding.
+const (
+	Zstd    = "zstd"
+	Brotli  = "br"
+	Gzip    = "gzip"
+	Deflate = "deflate"
+)
+
+// DefaultMinSize - размер ответа в байтах, начиная с которого ответ сжимается.
+const DefaultMinSize = 1024
+
+// DefaultMaxDecodedSize - максимальный размер распакованного тела запроса в байтах.
+const DefaultMaxDecodedSize = 10 << 20
+
+// preference - порядок выбора кодировки при одинаковом q клиента.
+var preference = []string{Zstd, Brotli, Gzip, Deflate}
+
+// writer - потоковый компрессор, который можно переиспользовать через Reset.
+type writer interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// encoder - кодировка ответа с пулом компрессоров.
+type encoder struct {
+	name string
+	pool sync.Pool
+}
+
+// get - метод получения компрессора из пула, записывающего в w.
+func (e *encoder) get(w io.Writer) writer {
+	zw := e.pool.Get().(writer)
+	zw.Reset(w)
+	return zw
+}
+
+// put - метод возврата компрессора в пул.
+func (e *encoder) put(zw writer) {
+	zw.Reset(io.Discard)
+	e.pool.Put(zw)
+}
+
+// newEncoders - функция создания кодировок ответа с пулами компрессоров.
+func newEncoders() map[string]*encoder {
+	encoders := map[string]*encoder{
+		Zstd: {name: Zstd, pool: sync.Pool{New: func() interface{} {
+			zw, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+			return zw
+		}}},
+		Brotli: {name: Brotli, pool: sync.Pool{New: func() interface{} {
+			return brotli.NewWriterLevel(nil, 4)
+		}}},
+		Gzip: {name: Gzip, pool: sync.Pool{New: func() interface{} {
+			return gzip.NewWriter(nil)
+		}}},
+		Deflate: {name: Deflate, pool: sync.Pool{New: func() interface{} {
+			zw, _ := flate.NewWriter(nil, flate.DefaultCompression)
+			return zw
+		}}},
+	}
+	return encoders
+}
+
+// Negotiate - функция выбора кодировки ответа по заголовку Accept-Encoding с учетом q-значений.
+// Кодировка без q считается с q=1, "*" задает q для всех не перечисленных кодировок, q=0 запрещает кодировку.
+// При одинаковом q выбирается кодировка, стоящая раньше в порядке zstd, br, gzip, deflate.
+// Если подходящей кодировки нет, возвращается пустая строка - ответ не сжимается.
+func Negotiate(acceptEncoding string) string {
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if name == "x-gzip" {
+			name = Gzip
+		}
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || strings.TrimSpace(key) != "q" {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || parsed < 0 || parsed > 1 {
+				parsed = 0
+			}
+			q = parsed
+		}
+		weights[name] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, name := range preference {
+		q, ok := weights[name]
+		if !ok {
+			q = weights["*"]
+		}
+		if q > bestQ {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+// compressibleTypes - типы ответов, которые имеет смысл сжимать, помимо text/* и типов с суффиксами +json и +xml.
+var compressibleTypes = map[string]bool{
+	"application/json":       true,
+	"application/javascript": true,
+	"application/xml":        true,
+	"application/x-ndjson":   true,
+	"image/svg+xml":          true,
+}
+
+// Compressible - функция проверки, что ответ с типом contentType стоит сжимать.
+func Compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") ||
+		compressibleTypes[mediaType] ||
+		strings.HasSuffix(mediaType, "+json") ||
+		strings.HasSuffix(mediaType, "+xml")
+}
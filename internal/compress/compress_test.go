@@ -0,0 +1,228 @@
+package compress
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Dorrrke/shortener-url/internal/models"
+	"github.com/Dorrrke/shortener-url/internal/service"
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/encoding"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		want           string
+	}{
+		{name: "Test negotiate #1 Empty", acceptEncoding: "", want: ""},
+		{name: "Test negotiate #2 Single", acceptEncoding: "gzip", want: Gzip},
+		{name: "Test negotiate #3 Server preference on equal q", acceptEncoding: "gzip, deflate, br, zstd", want: Zstd},
+		{name: "Test negotiate #4 Highest q wins", acceptEncoding: "gzip;q=1.0, br;q=0.5, zstd;q=0.1", want: Gzip},
+		{name: "Test negotiate #5 Disabled encoding", acceptEncoding: "zstd;q=0, br", want: Brotli},
+		{name: "Test negotiate #6 Wildcard", acceptEncoding: "*;q=0.5, zstd;q=0", want: Brotli},
+		{name: "Test negotiate #7 Only identity", acceptEncoding: "identity", want: ""},
+		{name: "Test negotiate #8 Unknown and invalid q", acceptEncoding: "compress, gzip;q=abc, x-gzip;q=0.3", want: Gzip},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Negotiate(tt.acceptEncoding))
+		})
+	}
+}
+
+func decode(t *testing.T, encoding string, body []byte) string {
+	var r io.Reader
+	switch encoding {
+	case Gzip:
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		r = zr
+	case Deflate:
+		r = flate.NewReader(bytes.NewReader(body))
+	case Brotli:
+		r = brotli.NewReader(bytes.NewReader(body))
+	case Zstd:
+		zr, err := zstd.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		defer zr.Close()
+		r = zr
+	default:
+		return string(body)
+	}
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestMiddlewareResponse(t *testing.T) {
+	large := `{"result":"` + strings.Repeat("a", 2*DefaultMinSize) + `"}`
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		contentType    string
+		status         int
+		body           string
+		wantEncoding   string
+	}{
+		{name: "Test response #1 zstd", acceptEncoding: "zstd", contentType: "application/json", status: http.StatusOK, body: large, wantEncoding: Zstd},
+		{name: "Test response #2 br", acceptEncoding: "br", contentType: "application/json", status: http.StatusCreated, body: large, wantEncoding: Brotli},
+		{name: "Test response #3 gzip conflict status", acceptEncoding: "gzip", contentType: "application/json", status: http.StatusConflict, body: large, wantEncoding: Gzip},
+		{name: "Test response #4 deflate error status", acceptEncoding: "deflate", contentType: "text/html; charset=utf-8", status: http.StatusNotFound, body: large, wantEncoding: Deflate},
+		{name: "Test response #5 Small body", acceptEncoding: "gzip", contentType: "application/json", status: http.StatusOK, body: `{"result":"a"}`},
+		{name: "Test response #6 Binary type", acceptEncoding: "gzip", contentType: "image/png", status: http.StatusOK, body: large},
+		{name: "Test response #7 No Accept-Encoding", contentType: "application/json", status: http.StatusOK, body: large},
+		{name: "Test response #8 Sniffed type", acceptEncoding: "gzip", status: http.StatusOK, body: "<html>" + large, wantEncoding: Gzip},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Middleware(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				w.WriteHeader(tt.status)
+				// Тело пишется частями, чтобы решение о сжатии принималось по накопленному размеру.
+				for i := 0; i < len(tt.body); i += 100 {
+					_, err := w.Write([]byte(tt.body[i:min(i+100, len(tt.body))]))
+					require.NoError(t, err)
+				}
+			})
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			// Тип запроса не влияет на сжатие ответа.
+			req.Header.Set("Content-Type", "text/plain")
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+			h(rec, req)
+
+			res := rec.Result()
+			defer res.Body.Close()
+			assert.Equal(t, tt.status, res.StatusCode)
+			assert.Equal(t, tt.wantEncoding, res.Header.Get("Content-Encoding"))
+			assert.Equal(t, "Accept-Encoding", res.Header.Get("Vary"))
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, decode(t, tt.wantEncoding, body))
+		})
+	}
+}
+
+func TestMiddlewareNoContent(t *testing.T) {
+	h := Middleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	req := httptest.NewRequest(http.MethodDelete, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+	assert.Empty(t, rec.Body.String())
+}
+
+func encode(t *testing.T, encoding string, data string) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case Gzip:
+		w = gzip.NewWriter(&buf)
+	case Deflate:
+		fw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		require.NoError(t, err)
+		w = fw
+	case Zstd:
+		zw, err := zstd.NewWriter(&buf)
+		require.NoError(t, err)
+		w = zw
+	}
+	_, err := w.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestMiddlewareRequest(t *testing.T) {
+	const payload = `{"url":"https://example.com"}`
+	tests := []struct {
+		name            string
+		contentEncoding string
+		body            []byte
+		wantCode        int
+		wantBody        string
+	}{
+		{name: "Test request #1 gzip", contentEncoding: Gzip, body: encode(t, Gzip, payload), wantCode: http.StatusOK, wantBody: payload},
+		{name: "Test request #2 deflate", contentEncoding: Deflate, body: encode(t, Deflate, payload), wantCode: http.StatusOK, wantBody: payload},
+		{name: "Test request #3 zstd", contentEncoding: Zstd, body: encode(t, Zstd, payload), wantCode: http.StatusOK, wantBody: payload},
+		{name: "Test request #4 identity", body: []byte(payload), wantCode: http.StatusOK, wantBody: payload},
+		{name: "Test request #5 Malformed gzip", contentEncoding: Gzip, body: []byte("not gzip"), wantCode: http.StatusBadRequest},
+		{name: "Test request #6 Truncated zstd", contentEncoding: Zstd, body: encode(t, Zstd, payload)[:10], wantCode: http.StatusBadRequest},
+		{name: "Test request #7 Unknown encoding", contentEncoding: "compress", body: []byte(payload), wantCode: http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			h := Middleware(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantBody, string(body))
+				assert.Empty(t, r.Header.Get("Content-Encoding"))
+			})
+			req := httptest.NewRequest(http.MethodPost, "/api/shorten", bytes.NewReader(tt.body))
+			if tt.contentEncoding != "" {
+				req.Header.Set("Content-Encoding", tt.contentEncoding)
+			}
+			rec := httptest.NewRecorder()
+			h(rec, req)
+			assert.Equal(t, tt.wantCode, rec.Code)
+			assert.Equal(t, tt.wantCode == http.StatusOK, called)
+		})
+	}
+}
+
+func TestMiddlewareRequestTooLarge(t *testing.T) {
+	c := New(Options{MaxDecodedSize: 16})
+	h := c.Middleware(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler must not be called")
+	})
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(encode(t, Gzip, strings.Repeat("a", 1024))))
+	req.Header.Set("Content-Encoding", Gzip)
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Equal(t, models.ProblemContentType, rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), service.CodeBodyTooLarge)
+}
+
+func TestGRPCZstd(t *testing.T) {
+	RegisterGRPCCompressors()
+	c := encoding.GetCompressor(Zstd)
+	require.NotNil(t, c)
+	require.NotNil(t, encoding.GetCompressor(Gzip))
+
+	for i := 0; i < 3; i++ {
+		var buf bytes.Buffer
+		w, err := c.Compress(&buf)
+		require.NoError(t, err)
+		_, err = w.Write([]byte("message"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		r, err := c.Decompress(&buf)
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, "message", string(data))
+	}
+}
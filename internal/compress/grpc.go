@@ -0,0 +1,93 @@
+package compress
+
+import (
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc/encoding"
+
+	// Регистрирует компрессор gzip для grpc.
+	_ "google.golang.org/grpc/encoding/gzip"
+)
+
+// RegisterGRPCCompressors - функция регистрации компрессоров grpc: gzip и zstd.
+// Сервер отвечает той же кодировкой, которой клиент сжал запрос, клиент выбирает ее опцией
+// grpc.UseCompressor(compress.Zstd). Должна вызываться до создания grpc сервера или клиента.
+func RegisterGRPCCompressors() {
+	encoding.RegisterCompressor(&grpcZstd{})
+}
+
+// grpcZstd - компрессор zstd для grpc с пулами кодировщиков и декодировщиков.
+type grpcZstd struct {
+	encoders sync.Pool
+	decoders sync.Pool
+}
+
+// Name - имя кодировки в заголовке grpc-encoding.
+func (c *grpcZstd) Name() string {
+	return Zstd
+}
+
+// Compress - метод получения потока сжатия в w.
+func (c *grpcZstd) Compress(w io.Writer) (io.WriteCloser, error) {
+	enc, ok := c.encoders.Get().(*zstd.Encoder)
+	if !ok {
+		var err error
+		enc, err = zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		enc.Reset(w)
+	}
+	return &zstdWriteCloser{Encoder: enc, pool: &c.encoders}, nil
+}
+
+// Decompress - метод получения потока распаковки из r.
+func (c *grpcZstd) Decompress(r io.Reader) (io.Reader, error) {
+	dec, ok := c.decoders.Get().(*zstd.Decoder)
+	if !ok {
+		var err error
+		dec, err = zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+	} else if err := dec.Reset(r); err != nil {
+		c.decoders.Put(dec)
+		return nil, err
+	}
+	return &zstdReader{Decoder: dec, pool: &c.decoders}, nil
+}
+
+// zstdWriteCloser - поток сжатия, возвращающий кодировщик в пул при закрытии.
+type zstdWriteCloser struct {
+	*zstd.Encoder
+	pool *sync.Pool
+}
+
+// Close - метод завершения сжатия.
+func (w *zstdWriteCloser) Close() error {
+	err := w.Encoder.Close()
+	w.pool.Put(w.Encoder)
+	return err
+}
+
+// zstdReader - поток распаковки, возвращающий декодировщик в пул после чтения до конца.
+type zstdReader struct {
+	*zstd.Decoder
+	pool *sync.Pool
+}
+
+// Read - метод чтения распакованных данных.
+func (r *zstdReader) Read(p []byte) (int, error) {
+	if r.Decoder == nil {
+		return 0, io.EOF
+	}
+	n, err := r.Decoder.Read(p)
+	if err == io.EOF {
+		r.pool.Put(r.Decoder)
+		r.Decoder = nil
+	}
+	return n, err
+}
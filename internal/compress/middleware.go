@@ -0,0 +1,244 @@
+package compress
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/Dorrrke/shortener-url/internal/problem"
+	"github.com/Dorrrke/shortener-url/internal/service"
+)
+
+// errTooLarge - ошибка превышения размера распакованного тела запроса.
+var errTooLarge = errors.New("decoded body too large")
+
+// Options - параметры сжатия.
+type Options struct {
+	// MinSize - размер ответа, начиная с которого ответ сжимается, 0 - DefaultMinSize.
+	MinSize int
+	// MaxDecodedSize - максимальный размер распакованного тела запроса, 0 - DefaultMaxDecodedSize.
+	MaxDecodedSize int64
+}
+
+// Compressor - middleware сжатия ответов и распаковки запросов.
+type Compressor struct {
+	minSize        int
+	maxDecodedSize int64
+	encoders       map[string]*encoder
+}
+
+// New - функция создания Compressor.
+func New(opts Options) *Compressor {
+	if opts.MinSize <= 0 {
+		opts.MinSize = DefaultMinSize
+	}
+	if opts.MaxDecodedSize <= 0 {
+		opts.MaxDecodedSize = DefaultMaxDecodedSize
+	}
+	return &Compressor{
+		minSize:        opts.MinSize,
+		maxDecodedSize: opts.MaxDecodedSize,
+		encoders:       newEncoders(),
+	}
+}
+
+// defaultCompressor - Compressor с параметрами по умолчанию для Middleware.
+var defaultCompressor = New(Options{})
+
+// Middleware - middleware сжатия с параметрами по умолчанию.
+func Middleware(h http.HandlerFunc) http.HandlerFunc {
+	return defaultCompressor.Middleware(h)
+}
+
+// Middleware - middleware, распаковывающий тело запроса по Content-Encoding (gzip, deflate, zstd)
+// и сжимающий ответ кодировкой, выбранной по Accept-Encoding.
+// Некорректно сжатое тело запроса отклоняется со статусом 400, неизвестная кодировка - со статусом 415,
+// слишком большое после распаковки тело - со статусом 413, ответ отправляется в формате application/problem+json.
+// Ответ сжимается, только если его тип текстовый (json, html, text/*) и размер не меньше минимального.
+func (c *Compressor) Middleware(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := c.decodeRequest(r); err != nil {
+			problem.Write(w, r, err)
+			return
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+		enc := c.encoders[Negotiate(r.Header.Get("Accept-Encoding"))]
+		if enc == nil || r.Method == http.MethodHead {
+			h.ServeHTTP(w, r)
+			return
+		}
+		cw := &responseWriter{ResponseWriter: w, enc: enc, minSize: c.minSize}
+		defer cw.Close()
+		h.ServeHTTP(cw, r)
+	}
+}
+
+// decodeRequest - метод распаковки тела запроса. Тело распаковывается целиком, чтобы ошибку сжатия
+// можно было вернуть клиенту до вызова хендлера. Ошибка возвращается как ошибка сервиса с кодом для клиента.
+func (c *Compressor) decodeRequest(r *http.Request) error {
+	encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+	if encoding == "" || encoding == "identity" {
+		return nil
+	}
+
+	var dec io.Reader
+	var err error
+	switch encoding {
+	case Gzip, "x-gzip":
+		dec, err = gzip.NewReader(r.Body)
+	case Deflate:
+		dec = flate.NewReader(r.Body)
+	case Zstd:
+		var zr *zstd.Decoder
+		zr, err = zstd.NewReader(r.Body, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(uint64(c.maxDecodedSize)))
+		if err == nil {
+			defer zr.Close()
+			dec = zr
+		}
+	default:
+		return service.UnsupportedEncoding(encoding)
+	}
+	if err != nil {
+		return service.InvalidEncoding(err)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(dec, c.maxDecodedSize+1))
+	if err != nil {
+		return service.InvalidEncoding(err)
+	}
+	if int64(len(body)) > c.maxDecodedSize {
+		return service.BodyTooLarge(errTooLarge)
+	}
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	r.Header.Del("Content-Encoding")
+	r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
+
+// responseWriter - http.ResponseWriter, откладывающий решение о сжатии до получения первых minSize байт ответа
+// или до завершения хендлера: к этому моменту известны статус, тип и размер ответа.
+type responseWriter struct {
+	http.ResponseWriter
+	enc     *encoder
+	minSize int
+	status  int
+	buf     []byte
+	decided bool
+	zw      writer
+}
+
+// WriteHeader - метод запоминания статуса ответа, заголовки отправляются после решения о сжатии.
+func (w *responseWriter) WriteHeader(statusCode int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	if statusCode >= 100 && statusCode < 200 {
+		w.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	if w.status == 0 {
+		w.status = statusCode
+	}
+	if statusCode == http.StatusNoContent || statusCode == http.StatusNotModified {
+		w.decide()
+	}
+}
+
+// Write - метод записи тела ответа.
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, p...)
+		if len(w.buf) < w.minSize {
+			return len(p), nil
+		}
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if w.zw != nil {
+		return w.zw.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// decide - метод выбора между сжатием и передачей ответа как есть и отправки заголовков и накопленных данных.
+func (w *responseWriter) decide() error {
+	w.decided = true
+	header := w.Header()
+	if header.Get("Content-Type") == "" && len(w.buf) > 0 {
+		header.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	compress := len(w.buf) >= w.minSize &&
+		header.Get("Content-Encoding") == "" &&
+		w.status != http.StatusNoContent && w.status != http.StatusNotModified &&
+		Compressible(header.Get("Content-Type"))
+	if compress {
+		header.Set("Content-Encoding", w.enc.name)
+		header.Del("Content-Length")
+		w.zw = w.enc.get(w.ResponseWriter)
+	}
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if len(w.buf) == 0 {
+		return nil
+	}
+	buf := w.buf
+	w.buf = nil
+	if w.zw != nil {
+		_, err := w.zw.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// Flush - метод отправки накопленных данных клиенту.
+func (w *responseWriter) Flush() {
+	if !w.decided {
+		if err := w.decide(); err != nil {
+			return
+		}
+	}
+	if w.zw != nil {
+		if err := w.zw.Flush(); err != nil {
+			return
+		}
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap - метод получения исходного http.ResponseWriter для http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Close - метод завершения ответа: отправляет накопленные данные и возвращает компрессор в пул.
+func (w *responseWriter) Close() error {
+	if !w.decided {
+		if err := w.decide(); err != nil {
+			return err
+		}
+	}
+	if w.zw == nil {
+		return nil
+	}
+	err := w.zw.Close()
+	w.enc.put(w.zw)
+	w.zw = nil
+	return err
+}
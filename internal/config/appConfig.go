@@ -1,113 +1,163 @@
 // Модуль config - структуры для конфигурации сервиса из файла и в следствии всей конфигурации.
+// Конфигурация собирается слоями, каждый следующий слой переопределяет предыдущий:
+// значения по умолчанию, файл конфигурации (-c/-config или CONFIG), переменные окружения, флаги.
 package config
 
 import (
 	"encoding/json"
-	"errors"
-	"flag"
-	"os"
-	"strings"
-
-	"go.uber.org/zap"
-
-	"github.com/Dorrrke/shortener-url/internal/logger"
+	"fmt"
+	"time"
 )
 
 // FilePath — константа с названием файла для хранения данных при отсутствии подключения к бд.
 const FilePath string = "short-url-db.json"
 
+// AuditFilePath — константа с названием файла аудита по умолчанию.
+const AuditFilePath string = "audit.jsonl"
+
+// DefaultShutdownTimeout — время на остановку сервиса по умолчанию.
+const DefaultShutdownTimeout = 30 * time.Second
+
+// DefaultIdempotencyTTL — время хранения ответа на запрос с ключом идемпотентности по умолчанию.
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// DefaultCacheSize — количество записей кэша переходов в памяти процесса по умолчанию.
+const DefaultCacheSize int = 10000
+
+// DefaultPasswordAttempts — ограничение попыток ввода пароля защищенной ссылки по умолчанию: 5 попыток, затем одна в 10 секунд.
+var DefaultPasswordAttempts = RateLimit{RPS: 0.1, Burst: 5}
+
 // AppConfig - сттруктура для хранения конфигураци и конфигурации сервиса.
 type AppConfig struct {
-	ServerAddress   string `json:"server_address" env:"SERVER_ADDRESS,required"`
-	BaseURL         string `json:"base_url" env:"BASE_URL,required"`
-	FileStoragePath string `json:"file_storage_path" env:"FILE_STORAGE_PATH,required"`
-	DatabaseDsn     string `json:"database_dsn" env:"DATABASE_DSN,required"`
-	EnableHTTPS     bool   `json:"enable_https"`
-	TrustedSubnet   string `json:"trusted_subnet" env:"TRUSTED_SUBNET,required"`
+	ServerAddress   string `json:"server_address" env:"SERVER_ADDRESS"`
+	BaseURL         string `json:"base_url" env:"BASE_URL"`
+	FileStoragePath string `json:"file_storage_path" env:"FILE_STORAGE_PATH"`
+	DatabaseDsn     string `json:"database_dsn" env:"DATABASE_DSN"`
+	EnableHTTPS     bool   `json:"enable_https" env:"ENABLE_HTTPS"`
+	// EnableGRPC - запускать grpc сервер вместо http.
+	EnableGRPC bool `json:"enable_grpc" env:"ENABLE_GRPC"`
+	// TrustedSubnet - доверенные подсети IPv4 и IPv6 через запятую для служебных маршрутов (статистика, метрики, pprof, аудит).
+	TrustedSubnet string `json:"trusted_subnet" env:"TRUSTED_SUBNET"`
+	// AuditSinks - список приемников аудита через запятую: stdout, file, db.
+	AuditSinks string `json:"audit_sinks" env:"AUDIT_SINKS"`
+	// AuditFilePath - путь к файлу аудита для приемника file.
+	AuditFilePath string `json:"audit_file_path" env:"AUDIT_FILE_PATH"`
+	// RateLimits - ограничения частоты запросов по маршрутам, например "POST /api/shorten/batch"
+	// или "/shortenergrpc.Shortener/InsertBatch". Ключ "default" применяется к маршрутам без своего ограничения.
+	RateLimits map[string]RateLimit `json:"rate_limits" env:"RATE_LIMITS"`
+	// TrustedProxies - список подсетей или адресов прокси, которым разрешено передавать X-Real-IP и X-Forwarded-For.
+	TrustedProxies []string `json:"trusted_proxies" env:"TRUSTED_PROXIES"`
+	// MaxLinksPerUser - максимальное количество неудаленных ссылок одного пользователя, 0 - без ограничений.
+	MaxLinksPerUser int `json:"max_links_per_user" env:"MAX_LINKS_PER_USER"`
+	// MaxBatchSize - максимальное количество ссылок в одном пакетном запросе, 0 - без ограничений.
+	MaxBatchSize int `json:"max_batch_size" env:"MAX_BATCH_SIZE"`
+	// MaxURLLength - максимальная длина сокращаемого url, 0 - значение по умолчанию (2048).
+	MaxURLLength int `json:"max_url_length" env:"MAX_URL_LENGTH"`
+	// KeepURLFragment - не отбрасывать фрагмент (#...) при нормализации url.
+	KeepURLFragment bool `json:"keep_url_fragment" env:"KEEP_URL_FRAGMENT"`
+	// KeepDefaultPort - не отбрасывать порт по умолчанию (80/443) при нормализации url.
+	KeepDefaultPort bool `json:"keep_default_port" env:"KEEP_DEFAULT_PORT"`
+	// RejectPrivateURLs - запрещать сокращение url, указывающих на localhost, loopback и приватные сети.
+	RejectPrivateURLs bool `json:"reject_private_urls" env:"REJECT_PRIVATE_URLS"`
+	// BlocklistFile - путь к файлу блоклиста доменов, файл перечитывается при изменении.
+	BlocklistFile string `json:"blocklist_file" env:"BLOCKLIST_FILE"`
+	// URLRulesFile - путь к файлу регулярных выражений запрещенных url.
+	URLRulesFile string `json:"url_rules_file" env:"URL_RULES_FILE"`
+	// ReputationURL - адрес сервиса репутации url.
+	ReputationURL string `json:"reputation_url" env:"REPUTATION_URL"`
+	// ReputationFailClosed - запрещать url, если сервис репутации недоступен.
+	ReputationFailClosed bool `json:"reputation_fail_closed" env:"REPUTATION_FAIL_CLOSED"`
+	// CacheSize - количество записей кэша переходов в памяти процесса, 0 - DefaultCacheSize, отрицательное значение отключает кэш.
+	CacheSize int `json:"cache_size" env:"CACHE_SIZE"`
+	// CacheTTL - время жизни найденного url в кэше в секундах, 0 - значение по умолчанию.
+	CacheTTL int `json:"cache_ttl" env:"CACHE_TTL"`
+	// CacheNegativeTTL - время жизни отметки об отсутствии url в кэше в секундах, 0 - значение по умолчанию.
+	CacheNegativeTTL int `json:"cache_negative_ttl" env:"CACHE_NEGATIVE_TTL"`
+	// RedisAddr - адрес Redis для общего кэша переходов между экземплярами сервиса.
+	RedisAddr string `json:"redis_addr" env:"REDIS_ADDR"`
+	// MetricsAddress - адрес отдельного http сервера метрик, если не указан, /metrics доступен на основном сервере из TrustedSubnet.
+	MetricsAddress string `json:"metrics_address" env:"METRICS_ADDRESS"`
+	// TracingExporter - экспортер спанов OpenTelemetry: otlp или stdout, пустое значение выключает трассировку.
+	TracingExporter string `json:"tracing_exporter" env:"TRACING_EXPORTER"`
+	// TracingEndpoint - адрес OTLP коллектора (host:port), по умолчанию берется из OTEL_EXPORTER_OTLP_ENDPOINT.
+	TracingEndpoint string `json:"tracing_endpoint" env:"TRACING_ENDPOINT"`
+	// LogLevel - уровень логирования: debug, info, warn, error, по умолчанию info.
+	LogLevel string `json:"log_level" env:"LOG_LEVEL"`
+	// LogFormat - формат логов: json или console, по умолчанию json.
+	LogFormat string `json:"log_format" env:"LOG_FORMAT"`
+	// LogSampling - сэмплирование частых сообщений debug и info: после первых 100 одинаковых за секунду пишется каждое LogSampling-е, 0 - без сэмплирования.
+	LogSampling int `json:"log_sampling" env:"LOG_SAMPLING"`
+	// ShutdownTimeout - время на остановку сервиса: завершение запросов, очереди удаления и закрытие соединений, по умолчанию 30s.
+	ShutdownTimeout Duration `json:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	// OperationTimeouts - таймауты операций сервиса, например "SaveURL" или "GetOriginalURL".
+	// Ключ "default" применяется к операциям без своего таймаута, отсутствие таймаута - только контекст запроса.
+	OperationTimeouts map[string]Duration `json:"operation_timeouts" env:"OPERATION_TIMEOUTS"`
+	// IdempotencyTTL - время хранения ответа на запрос с заголовком Idempotency-Key, по умолчанию 24h.
+	IdempotencyTTL Duration `json:"idempotency_ttl" env:"IDEMPOTENCY_TTL"`
+	// PasswordAttempts - ограничение попыток ввода пароля, отдельное для каждой защищенной ссылки, по умолчанию DefaultPasswordAttempts.
+	PasswordAttempts RateLimit `json:"password_attempts" env:"PASSWORD_ATTEMPTS"`
 }
 
-// MustLoad - обязательная к запуску функция создающая файл конфига.
-// Функция парсит переменные оркужения, флаги и данные из файла конфига.
-func MustLoad() (*AppConfig, bool) {
-	var cfg AppConfig
-
-	var cfgFilePath string
-	flag.StringVar(&cfgFilePath, "config", "", "config file path")
-	flag.StringVar(&cfg.ServerAddress, "a", "", "address and port to run server")
-	flag.StringVar(&cfg.BaseURL, "b", "", "address and port to run short URL")
-	flag.StringVar(&cfg.FileStoragePath, "f", "", "storage file path")
-	flag.StringVar(&cfg.DatabaseDsn, "d", "", "databse addr")
-	flag.StringVar(&cfg.TrustedSubnet, "t", "", "trusted subnet")
-	httpsFlag := flag.Bool("s", false, "use https server")
-	grpcEnable := flag.Bool("g", false, "use https server")
-	flag.Parse()
-	cfg.EnableHTTPS = *httpsFlag
-
-	if strings.Contains(cfg.BaseURL, "http://") {
-		correctURL := strings.Replace(cfg.BaseURL, "http://", "", -1)
-		cfg.BaseURL = correctURL
-	}
-
-	logger.Log.Info("config from flags", zap.Any("cfg", cfg))
-	logger.Log.Info("config file path", zap.String("cfg file", cfgFilePath))
+// Duration - продолжительность, которая в json задается строкой формата time.ParseDuration, например "500ms".
+type Duration time.Duration
 
-	if cfg.BaseURL == "" {
-		cfg.BaseURL = os.Getenv("BASE_URL")
-	}
-	if cfg.DatabaseDsn == "" {
-		cfg.DatabaseDsn = os.Getenv("DATABASE_DSN")
+// UnmarshalJSON - разбор продолжительности из строки или числа наносекунд.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
 	}
-	if cfg.TrustedSubnet == "" {
-		cfg.TrustedSubnet = os.Getenv("TRUSTED_SUBNET")
-	}
-	if cfg.FileStoragePath == "" {
-		cfg.FileStoragePath = os.Getenv("FILE_STORAGE_PATH")
-		if cfg.FileStoragePath == "" {
-			cfg.FileStoragePath = FilePath
+	switch v := value.(type) {
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return err
 		}
+		*d = Duration(parsed)
+	case float64:
+		*d = Duration(time.Duration(v))
+	default:
+		return fmt.Errorf("invalid duration %s", string(data))
 	}
-	if cfg.ServerAddress == "" {
-		cfg.ServerAddress = os.Getenv("SERVER_ADDRESS")
-	}
+	return nil
+}
 
-	if cfg.TrustedSubnet == "" {
-		cfg.TrustedSubnet = os.Getenv("TRUSTED_SUBNET")
+// UnmarshalText - разбор продолжительности из переменной окружения или флага, например "500ms".
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
 	}
+	*d = Duration(parsed)
+	return nil
+}
 
-	if cfg.ServerAddress == "" && cfg.BaseURL == "" && cfg.DatabaseDsn == "" {
-		logger.Log.Info("Check config file")
-		fileConfig, err := uploadConfigFromFile(cfgFilePath)
-		if err != nil {
-			logger.Log.Error("config parsing from file error", zap.Error(err))
-			return &cfg, *grpcEnable
-		}
-		return &fileConfig, *grpcEnable
-	}
+// String - запись продолжительности в формате time.Duration.
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
 
-	return &cfg, *grpcEnable
+// MarshalJSON - запись продолжительности строкой, например "1.5s".
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
 }
 
-// uploadConfigFromFile - функция составления конфига из файла.
-func uploadConfigFromFile(cfgPath string) (AppConfig, error) {
-	var config AppConfig
-	if cfgPath == "" {
-		return AppConfig{}, errors.New("config path is empty")
-	}
-	if _, err := os.Stat(cfgPath); os.IsNotExist(err) {
-		logger.Log.Error("Config file is not exist", zap.Error(err))
-		panic(err)
-	}
-	f, err := os.Open(cfgPath)
-	if err != nil {
-		logger.Log.Error("Error open file", zap.Error(err))
-		panic(err)
+// DefaultOperationTimeout - ключ OperationTimeouts, применяемый к операциям без своего таймаута.
+const DefaultOperationTimeout = "default"
+
+// OperationTimeout - функция получения таймаута операции, 0 - таймаут не задан.
+func (c *AppConfig) OperationTimeout(op string) time.Duration {
+	if c == nil {
+		return 0
 	}
-	defer f.Close()
-	dec := json.NewDecoder(f)
-	if err := dec.Decode(&config); err != nil {
-		logger.Log.Error("error parse config file")
-		panic(err)
+	if timeout, ok := c.OperationTimeouts[op]; ok {
+		return time.Duration(timeout)
 	}
-	logger.Log.Info("config from json", zap.Any("config", config))
-	return config, nil
+	return time.Duration(c.OperationTimeouts[DefaultOperationTimeout])
+}
+
+// RateLimit - параметры token bucket: скорость пополнения в запросах в секунду и размер корзины.
+type RateLimit struct {
+	RPS   float64 `json:"rps"`
+	Burst int     `json:"burst"`
 }
@@ -0,0 +1,252 @@
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ConfigEnv - переменная окружения с путем к файлу конфигурации.
+const ConfigEnv = "CONFIG"
+
+// Default - функция получения конфигурации со значениями по умолчанию.
+func Default() AppConfig {
+	return AppConfig{
+		FileStoragePath:  FilePath,
+		AuditFilePath:    AuditFilePath,
+		CacheSize:        DefaultCacheSize,
+		ShutdownTimeout:  Duration(DefaultShutdownTimeout),
+		IdempotencyTTL:   Duration(DefaultIdempotencyTTL),
+		PasswordAttempts: DefaultPasswordAttempts,
+	}
+}
+
+// MustLoad - обязательная к запуску функция загрузки конфигурации из файла, переменных окружения и флагов.
+// При ошибке разбора или проверки конфигурации выводит все ошибки и завершает процесс с кодом 2.
+func MustLoad() *AppConfig {
+	cfg, err := Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid config:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	return cfg
+}
+
+// Load - функция загрузки конфигурации: значения по умолчанию, затем файл, затем переменные окружения, затем флаги.
+// Возвращает все ошибки разбора и проверки одной ошибкой, при этом конфигурация заполняется всем, что удалось разобрать.
+func Load(args []string, getenv func(string) string) (*AppConfig, error) {
+	// Первый проход по флагам нужен только для пути к файлу конфигурации и проверки синтаксиса флагов.
+	var scratch AppConfig
+	var configPath string
+	fs := newFlagSet(&scratch, &configPath, io.Discard)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			newFlagSet(&scratch, &configPath, os.Stderr).Usage()
+		}
+		return nil, err
+	}
+	if configPath == "" {
+		configPath = getenv(ConfigEnv)
+	}
+
+	cfg := Default()
+	var errs []error
+	if configPath != "" {
+		if err := loadFile(&cfg, configPath); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	errs = append(errs, loadEnv(&cfg, getenv)...)
+	// Второй проход записывает заданные флаги поверх файла и окружения.
+	if err := newFlagSet(&cfg, &configPath, io.Discard).Parse(args); err != nil {
+		errs = append(errs, err)
+	}
+	cfg.normalize()
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return &cfg, errors.Join(errs...)
+}
+
+// normalize - метод приведения значений к виду, который ожидает сервис.
+func (c *AppConfig) normalize() {
+	c.BaseURL = strings.TrimPrefix(strings.TrimPrefix(c.BaseURL, "http://"), "https://")
+	if c.CacheSize == 0 {
+		c.CacheSize = DefaultCacheSize
+	}
+	if c.ShutdownTimeout == 0 {
+		c.ShutdownTimeout = Duration(DefaultShutdownTimeout)
+	}
+	if c.IdempotencyTTL == 0 {
+		c.IdempotencyTTL = Duration(DefaultIdempotencyTTL)
+	}
+}
+
+// newFlagSet - функция описания флагов командной строки, значения записываются в cfg.
+// Значения по умолчанию берутся из cfg, поэтому незаданные флаги не меняют конфигурацию.
+func newFlagSet(cfg *AppConfig, configPath *string, output io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("shortener", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(configPath, "config", *configPath, "config file path")
+	fs.StringVar(configPath, "c", *configPath, "config file path (shorthand)")
+	fs.StringVar(&cfg.ServerAddress, "a", cfg.ServerAddress, "address and port to run server")
+	fs.StringVar(&cfg.BaseURL, "b", cfg.BaseURL, "address and port to run short URL")
+	fs.StringVar(&cfg.FileStoragePath, "f", cfg.FileStoragePath, "storage file path")
+	fs.StringVar(&cfg.DatabaseDsn, "d", cfg.DatabaseDsn, "databse addr")
+	fs.StringVar(&cfg.TrustedSubnet, "t", cfg.TrustedSubnet, "comma separated trusted subnets (IPv4/IPv6 CIDR)")
+	fs.BoolVar(&cfg.EnableHTTPS, "s", cfg.EnableHTTPS, "use https server")
+	fs.BoolVar(&cfg.EnableGRPC, "g", cfg.EnableGRPC, "use grpc server instead of http")
+	fs.StringVar(&cfg.AuditSinks, "audit-sinks", cfg.AuditSinks, "audit sinks: stdout,file,db")
+	fs.StringVar(&cfg.AuditFilePath, "audit-file", cfg.AuditFilePath, "audit file path")
+	fs.Var(jsonFlag{&cfg.RateLimits}, "rate-limits", `rate limits json, e.g. {"POST /":{"rps":5,"burst":10}}`)
+	fs.Func("trusted-proxies", "comma separated trusted proxies", func(value string) error {
+		cfg.TrustedProxies = splitList(value)
+		return nil
+	})
+	fs.IntVar(&cfg.MaxLinksPerUser, "max-links", cfg.MaxLinksPerUser, "max active links per user, 0 - unlimited")
+	fs.IntVar(&cfg.MaxBatchSize, "max-batch", cfg.MaxBatchSize, "max batch size, 0 - unlimited")
+	fs.IntVar(&cfg.MaxURLLength, "max-url-length", cfg.MaxURLLength, "max url length, 0 - default")
+	fs.BoolVar(&cfg.RejectPrivateURLs, "reject-private", cfg.RejectPrivateURLs, "reject private and loopback urls")
+	fs.StringVar(&cfg.BlocklistFile, "blocklist", cfg.BlocklistFile, "domain blocklist file path")
+	fs.StringVar(&cfg.URLRulesFile, "url-rules", cfg.URLRulesFile, "url regex rules file path")
+	fs.StringVar(&cfg.ReputationURL, "reputation-url", cfg.ReputationURL, "url reputation service endpoint")
+	fs.IntVar(&cfg.CacheSize, "cache-size", cfg.CacheSize, "redirect cache size, 0 - default, negative - disabled")
+	fs.StringVar(&cfg.RedisAddr, "redis", cfg.RedisAddr, "redis address for redirect cache")
+	fs.StringVar(&cfg.MetricsAddress, "metrics-addr", cfg.MetricsAddress, "separate listener address for /metrics")
+	fs.StringVar(&cfg.TracingExporter, "tracing", cfg.TracingExporter, "tracing exporter: otlp or stdout")
+	fs.StringVar(&cfg.TracingEndpoint, "tracing-endpoint", cfg.TracingEndpoint, "otlp collector endpoint")
+	fs.StringVar(&cfg.LogLevel, "log-level", cfg.LogLevel, "log level: debug, info, warn, error")
+	fs.StringVar(&cfg.LogFormat, "log-format", cfg.LogFormat, "log format: json or console")
+	fs.IntVar(&cfg.LogSampling, "log-sampling", cfg.LogSampling, "log every N-th repeated debug/info message after 100 per second, 0 - disabled")
+	fs.Var(textFlag{&cfg.ShutdownTimeout}, "shutdown-timeout", "graceful shutdown timeout, e.g. 30s")
+	fs.Var(textFlag{&cfg.IdempotencyTTL}, "idempotency-ttl", "how long responses to requests with Idempotency-Key are kept, e.g. 24h")
+	fs.Var(jsonFlag{&cfg.OperationTimeouts}, "operation-timeouts", `operation timeouts json, e.g. {"default":"2s","SaveURL":"500ms"}`)
+	fs.Var(jsonFlag{&cfg.PasswordAttempts}, "password-attempts", `password attempts limit per protected link json, e.g. {"rps":0.1,"burst":5}`)
+	return fs
+}
+
+// textFlag - флаг, значение которого разбирается через encoding.TextUnmarshaler.
+type textFlag struct {
+	value encoding.TextUnmarshaler
+}
+
+// String - текущее значение флага для справки.
+func (f textFlag) String() string {
+	if f.value == nil {
+		return ""
+	}
+	if s, ok := f.value.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return ""
+}
+
+// Set - разбор значения флага.
+func (f textFlag) Set(value string) error {
+	return f.value.UnmarshalText([]byte(value))
+}
+
+// jsonFlag - флаг, значение которого задается json, например ограничения по маршрутам.
+type jsonFlag struct {
+	value interface{}
+}
+
+// String - значение флага для справки.
+func (f jsonFlag) String() string {
+	return ""
+}
+
+// Set - разбор значения флага.
+func (f jsonFlag) Set(value string) error {
+	return json.Unmarshal([]byte(value), f.value)
+}
+
+// loadFile - функция чтения файла конфигурации поверх уже заданных значений.
+// В файле достаточно указать только те поля, которые нужно переопределить.
+func loadFile(cfg *AppConfig, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open config file: %w", err)
+	}
+	defer f.Close()
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// loadEnv - функция чтения переменных окружения, перечисленных в тегах env полей AppConfig.
+// Пустые переменные не меняют значение, ошибки разбора возвращаются списком.
+func loadEnv(cfg *AppConfig, getenv func(string) string) []error {
+	var errs []error
+	v := reflect.ValueOf(cfg).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		value := getenv(name)
+		if value == "" {
+			continue
+		}
+		if err := setField(v.Field(i), value); err != nil {
+			errs = append(errs, fmt.Errorf("env %s: %w", name, err))
+		}
+	}
+	return errs
+}
+
+// setField - функция записи строкового значения в поле конфигурации по его типу.
+func setField(field reflect.Value, value string) error {
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		field.Set(reflect.ValueOf(splitList(value)))
+	case reflect.Map, reflect.Struct:
+		return json.Unmarshal([]byte(value), field.Addr().Interface())
+	default:
+		return fmt.Errorf("unsupported config field type %s", field.Type())
+	}
+	return nil
+}
+
+// splitList - функция разбора списка через запятую без пустых элементов.
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
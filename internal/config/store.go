@@ -0,0 +1,86 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"sync/atomic"
+)
+
+// reloadable - поля конфигурации (по json имени), которые применяются без перезапуска сервиса.
+// Изменения остальных полей при перезагрузке игнорируются и возвращаются в ReloadResult.Ignored.
+var reloadable = map[string]bool{
+	"trusted_subnet":         true,
+	"rate_limits":            true,
+	"blocklist_file":         true,
+	"url_rules_file":         true,
+	"reputation_url":         true,
+	"reputation_fail_closed": true,
+	"log_level":              true,
+	"max_links_per_user":     true,
+	"max_batch_size":         true,
+	"operation_timeouts":     true,
+	"idempotency_ttl":        true,
+}
+
+// Reloadable - функция проверки, что поле с json именем name применяется без перезапуска.
+func Reloadable(name string) bool {
+	return reloadable[name]
+}
+
+// Store - хранилище текущей конфигурации с атомарной заменой.
+// Возвращаемые снимки нельзя изменять: при перезагрузке создается новый снимок.
+type Store struct {
+	current atomic.Pointer[AppConfig]
+}
+
+// NewStore - функция создания хранилища с начальной конфигурацией.
+func NewStore(cfg *AppConfig) *Store {
+	s := &Store{}
+	s.current.Store(cfg)
+	return s
+}
+
+// Get - метод получения текущего снимка конфигурации.
+func (s *Store) Get() *AppConfig {
+	return s.current.Load()
+}
+
+// ReloadResult - результат перезагрузки: примененные поля и поля, изменение которых требует перезапуска.
+type ReloadResult struct {
+	Applied []string
+	Ignored []string
+}
+
+// Changed - метод проверки, что поле с json именем name было применено.
+func (r ReloadResult) Changed(name string) bool {
+	for _, applied := range r.Applied {
+		if applied == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Reload - метод применения новой конфигурации. В новый снимок переносятся только изменившиеся
+// перезагружаемые поля, остальные поля остаются прежними. Снимок заменяется атомарно.
+func (s *Store) Reload(next *AppConfig) ReloadResult {
+	updated := *s.Get()
+	var result ReloadResult
+	cur := reflect.ValueOf(&updated).Elem()
+	nextValue := reflect.ValueOf(next).Elem()
+	t := cur.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if reflect.DeepEqual(cur.Field(i).Interface(), nextValue.Field(i).Interface()) {
+			continue
+		}
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if !reloadable[name] {
+			result.Ignored = append(result.Ignored, name)
+			continue
+		}
+		cur.Field(i).Set(nextValue.Field(i))
+		result.Applied = append(result.Applied, name)
+	}
+	s.current.Store(&updated)
+	return result
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreReload(t *testing.T) {
+	initial := &AppConfig{
+		ServerAddress:   "localhost:8080",
+		TrustedSubnet:   "10.0.0.0/8",
+		MaxLinksPerUser: 10,
+		LogLevel:        "info",
+	}
+	store := NewStore(initial)
+	assert.Same(t, initial, store.Get())
+
+	next := *initial
+	next.ServerAddress = "localhost:9090"
+	next.TrustedSubnet = "192.168.0.0/16"
+	next.MaxLinksPerUser = 20
+	next.RateLimits = map[string]RateLimit{"POST /": {RPS: 1, Burst: 1}}
+	next.OperationTimeouts = map[string]Duration{DefaultOperationTimeout: Duration(time.Second)}
+	result := store.Reload(&next)
+
+	assert.ElementsMatch(t, []string{"trusted_subnet", "max_links_per_user", "rate_limits", "operation_timeouts"}, result.Applied)
+	assert.Equal(t, []string{"server_address"}, result.Ignored)
+	assert.True(t, result.Changed("rate_limits"))
+	assert.False(t, result.Changed("log_level"))
+
+	current := store.Get()
+	assert.Equal(t, "localhost:8080", current.ServerAddress, "non-reloadable field is kept")
+	assert.Equal(t, "192.168.0.0/16", current.TrustedSubnet)
+	assert.Equal(t, 20, current.MaxLinksPerUser)
+	assert.Equal(t, time.Second, current.OperationTimeout("SaveURL"))
+
+	assert.Equal(t, "10.0.0.0/8", initial.TrustedSubnet, "previous snapshot is not modified")
+	assert.Equal(t, 10, initial.MaxLinksPerUser)
+}
+
+func TestStoreReloadWithoutChanges(t *testing.T) {
+	store := NewStore(&AppConfig{BaseURL: "short.ru"})
+	next := *store.Get()
+	result := store.Reload(&next)
+	assert.Empty(t, result.Applied)
+	assert.Empty(t, result.Ignored)
+	assert.Equal(t, "short.ru", store.Get().BaseURL)
+}
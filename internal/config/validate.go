@@ -0,0 +1,179 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Validate - метод проверки конфигурации. Возвращает все найденные ошибки одной ошибкой.
+func (c *AppConfig) Validate() error {
+	var errs []error
+	check := func(field string, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", field, err))
+		}
+	}
+
+	check("server_address", validateAddress(c.ServerAddress))
+	check("metrics_address", validateAddress(c.MetricsAddress))
+	check("redis_addr", validateAddress(c.RedisAddr))
+	check("base_url", validateBaseURL(c.BaseURL))
+	for _, subnet := range splitList(c.TrustedSubnet) {
+		check("trusted_subnet", validateIPOrCIDR(subnet))
+	}
+	for _, proxy := range c.TrustedProxies {
+		check("trusted_proxies", validateIPOrCIDR(proxy))
+	}
+	if c.ReputationURL != "" {
+		check("reputation_url", validateHTTPURL(c.ReputationURL))
+	}
+	for _, sink := range splitList(c.AuditSinks) {
+		switch sink {
+		case "stdout", "file":
+		case "db":
+			if c.DatabaseDsn == "" {
+				check("audit_sinks", errors.New("sink db requires database_dsn"))
+			}
+		default:
+			check("audit_sinks", fmt.Errorf("unknown sink %q", sink))
+		}
+	}
+	for route, limit := range c.RateLimits {
+		if limit.RPS <= 0 || limit.Burst <= 0 {
+			check("rate_limits", fmt.Errorf("%q: rps and burst must be positive", route))
+		}
+	}
+	if c.PasswordAttempts.RPS <= 0 || c.PasswordAttempts.Burst <= 0 {
+		check("password_attempts", errors.New("rps and burst must be positive"))
+	}
+	for op, timeout := range c.OperationTimeouts {
+		if timeout < 0 {
+			check("operation_timeouts", fmt.Errorf("%q: negative timeout", op))
+		}
+	}
+	if c.ShutdownTimeout < 0 {
+		check("shutdown_timeout", errors.New("must not be negative"))
+	}
+	if c.IdempotencyTTL < 0 {
+		check("idempotency_ttl", errors.New("must not be negative"))
+	}
+	for field, value := range map[string]int{
+		"max_links_per_user": c.MaxLinksPerUser,
+		"max_batch_size":     c.MaxBatchSize,
+		"max_url_length":     c.MaxURLLength,
+		"cache_ttl":          c.CacheTTL,
+		"cache_negative_ttl": c.CacheNegativeTTL,
+		"log_sampling":       c.LogSampling,
+	} {
+		if value < 0 {
+			check(field, errors.New("must not be negative"))
+		}
+	}
+	switch c.TracingExporter {
+	case "", "stdout", "otlp":
+	default:
+		check("tracing_exporter", fmt.Errorf("unknown exporter %q", c.TracingExporter))
+	}
+	switch c.LogFormat {
+	case "", "json", "console":
+	default:
+		check("log_format", fmt.Errorf("unknown format %q", c.LogFormat))
+	}
+	if c.LogLevel != "" {
+		_, err := zapcore.ParseLevel(c.LogLevel)
+		check("log_level", err)
+	}
+	if c.EnableHTTPS && c.EnableGRPC {
+		check("enable_https", errors.New("https is not supported for grpc server"))
+	}
+	return errors.Join(errs...)
+}
+
+// validateAddress - функция проверки адреса вида host:port, пустой адрес допустим.
+func validateAddress(addr string) error {
+	if addr == "" {
+		return nil
+	}
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if port == "" {
+		return fmt.Errorf("address %s: missing port", addr)
+	}
+	return nil
+}
+
+// validateBaseURL - функция проверки базового адреса коротких ссылок без схемы, например short.ru или localhost:8080.
+func validateBaseURL(baseURL string) error {
+	if baseURL == "" {
+		return nil
+	}
+	u, err := url.Parse("http://" + baseURL)
+	if err != nil {
+		return err
+	}
+	if u.Host == "" || u.Path != "" || u.RawQuery != "" {
+		return fmt.Errorf("invalid base url %q", baseURL)
+	}
+	return nil
+}
+
+// validateHTTPURL - функция проверки абсолютного http(s) url.
+func validateHTTPURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid http url %q", raw)
+	}
+	return nil
+}
+
+// validateIPOrCIDR - функция проверки ip адреса или подсети.
+func validateIPOrCIDR(value string) error {
+	if strings.Contains(value, "/") {
+		_, _, err := net.ParseCIDR(value)
+		return err
+	}
+	if net.ParseIP(value) == nil {
+		return fmt.Errorf("invalid ip %q", value)
+	}
+	return nil
+}
+
+// passwordParam - пароль в строке подключения формата key=value.
+var passwordParam = regexp.MustCompile(`(?i)(password\s*=\s*)('[^']*'|\S+)`)
+
+// redactedValue - значение, которым заменяются секреты.
+const redactedValue = "REDACTED"
+
+// Redacted - метод получения копии конфигурации, в которой пароли заменены на REDACTED.
+func (c AppConfig) Redacted() AppConfig {
+	c.DatabaseDsn = redactURL(c.DatabaseDsn)
+	c.DatabaseDsn = passwordParam.ReplaceAllString(c.DatabaseDsn, "${1}"+redactedValue)
+	c.ReputationURL = redactURL(c.ReputationURL)
+	c.RedisAddr = redactURL(c.RedisAddr)
+	c.TracingEndpoint = redactURL(c.TracingEndpoint)
+	return c
+}
+
+// redactURL - функция замены пароля в url, строки без пароля возвращаются без изменений.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	if _, ok := u.User.Password(); !ok {
+		return raw
+	}
+	u.User = url.UserPassword(u.User.Username(), redactedValue)
+	return u.String()
+}
@@ -2,17 +2,17 @@ package handlers
 
 import (
 	"context"
+	"errors"
 
 	"github.com/Dorrrke/shortener-url/internal/config"
 	shortenergrpcv1 "github.com/Dorrrke/shortener-url/internal/grpc/gen/shortenergrpc.v1"
 	"github.com/Dorrrke/shortener-url/internal/logger"
 	"github.com/Dorrrke/shortener-url/internal/service"
+	"github.com/Dorrrke/shortener-url/internal/urlcheck"
 	"go.uber.org/zap"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
-func GetOriginalURLHandlerGrpc(ctx context.Context, cfg config.AppConfig, sService service.ShortenerService, shortURL string) (*shortenergrpcv1.GetOriginalURLResponce, error) {
+func GetOriginalURLHandlerGrpc(ctx context.Context, cfg *config.AppConfig, sService service.ShortenerService, shortURL string, password string) (*shortenergrpcv1.GetOriginalURLResponce, error) {
 	var short string
 	if cfg.BaseURL == "" {
 		short = "http://" + cfg.ServerAddress + "/" + shortURL
@@ -20,17 +20,16 @@ func GetOriginalURLHandlerGrpc(ctx context.Context, cfg config.AppConfig, sServi
 		short = "http://" + cfg.BaseURL + "/" + shortURL
 	}
 
-	url, delete, err := sService.GetOriginalURL(short)
+	url, err := sService.GetOriginalURL(ctx, short, password)
 	if err != nil {
-		logger.Log.Error("Error when read from base: ", zap.Error(err))
-		return nil, status.Error(codes.Internal, "Internal error")
+		return nil, StatusError(ctx, err)
 	}
-
-	if delete {
-		return nil, status.Error(codes.NotFound, "Url was deleted")
-	}
-	if url != "" {
-		return &shortenergrpcv1.GetOriginalURLResponce{OriginalUrl: url}, nil
+	if err := sService.CheckURL(ctx, url); err != nil {
+		if errors.Is(err, urlcheck.ErrBlocked) {
+			logger.FromContext(ctx).Info("Redirect to blocked url", zap.String("short", short), zap.Error(err))
+			return nil, StatusError(ctx, err)
+		}
+		logger.FromContext(ctx).Error("Error check url", zap.Error(err))
 	}
-	return nil, status.Error(codes.InvalidArgument, "Bad request")
+	return &shortenergrpcv1.GetOriginalURLResponce{OriginalUrl: url}, nil
 }
@@ -3,49 +3,44 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/Dorrrke/shortener-url/internal/config"
 	shortenergrpcv1 "github.com/Dorrrke/shortener-url/internal/grpc/gen/shortenergrpc.v1"
-	"github.com/Dorrrke/shortener-url/internal/logger"
 	"github.com/Dorrrke/shortener-url/internal/service"
 	"github.com/Dorrrke/shortener-url/internal/utils"
 	"github.com/google/uuid"
-	"go.uber.org/zap"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
-	"google.golang.org/grpc/status"
 )
 
-func DeleteURLHandlerGrpc(ctx context.Context, cfg config.AppConfig, sService service.ShortenerService, URLs string) (*shortenergrpcv1.DeleteURLResponce, error) {
+func DeleteURLHandlerGrpc(ctx context.Context, cfg *config.AppConfig, sService service.ShortenerService, URLs string) (*shortenergrpcv1.DeleteURLResponce, error) {
+	var userID string
 	md, ok := metadata.FromIncomingContext(ctx)
 	if ok {
 		values := md.Get("auth")
 		if len(values) > 0 {
 			token := values[0]
-			userID := utils.GetUID(token)
+			userID = utils.GetUID(token)
 			if userID == "" {
-				logger.Log.Error("User id from token is empty")
-				return nil, status.Error(codes.Unauthenticated, "User id from token is empty")
+				return nil, StatusError(ctx, service.ErrUnauthorized)
 			}
 			header := metadata.Pairs("auth", token)
 			grpc.SetHeader(ctx, header)
 		} else {
-			userID := uuid.New().String()
+			userID = uuid.New().String()
 			token, err := utils.CreateJWTToken(userID)
 			if err != nil {
-				logger.Log.Error("cannot create token", zap.Error(err))
-				return nil, status.Error(codes.Internal, "Create token error")
+				return nil, StatusError(ctx, fmt.Errorf("cannot create token: %w", err))
 			}
 			header := metadata.Pairs("auth", token)
 			grpc.SetHeader(ctx, header)
 		}
 	} else {
-		userID := uuid.New().String()
+		userID = uuid.New().String()
 		token, err := utils.CreateJWTToken(userID)
 		if err != nil {
-			logger.Log.Error("cannot create token", zap.Error(err))
-			return nil, status.Error(codes.Internal, "Create token error")
+			return nil, StatusError(ctx, fmt.Errorf("cannot create token: %w", err))
 		}
 		header := metadata.Pairs("auth", token)
 		grpc.SetHeader(ctx, header)
@@ -53,10 +48,9 @@ func DeleteURLHandlerGrpc(ctx context.Context, cfg config.AppConfig, sService se
 
 	var moodel []string
 	if err := json.Unmarshal([]byte(URLs), &moodel); err != nil {
-		logger.Log.Debug("cannot decod boby json", zap.Error(err))
-		return nil, status.Error(codes.Internal, "Internal error")
+		return nil, StatusError(ctx, service.InvalidRequest(err))
 	}
 
-	go sService.DeleteURL(moodel, cfg.ServerAddress)
+	sService.DeleteURL(ctx, moodel, cfg.ServerAddress, userID)
 	return &shortenergrpcv1.DeleteURLResponce{}, nil
 }
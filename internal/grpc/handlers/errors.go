@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/Dorrrke/shortener-url/internal/logger"
+	"github.com/Dorrrke/shortener-url/internal/service"
+)
+
+// ErrorDomain - домен ошибок в errdetails.ErrorInfo.
+const ErrorDomain = "shortener"
+
+// kindCodes - коды grpc видов ошибок сервиса.
+var kindCodes = map[service.Kind]codes.Code{
+	service.KindValidation:     codes.InvalidArgument,
+	service.KindNotFound:       codes.NotFound,
+	service.KindConflict:       codes.AlreadyExists,
+	service.KindUnauthorized:   codes.Unauthenticated,
+	service.KindForbidden:      codes.PermissionDenied,
+	service.KindQuota:          codes.ResourceExhausted,
+	service.KindGone:           codes.NotFound,
+	service.KindUnavailable:    codes.Unavailable,
+	service.KindNotImplemented: codes.Unimplemented,
+	service.KindCanceled:       codes.Canceled,
+	service.KindTimeout:        codes.DeadlineExceeded,
+}
+
+// codeCodes - коды grpc отдельных кодов ошибок, отличающиеся от кода их вида.
+var codeCodes = map[string]codes.Code{
+	service.CodeIdempotencyPending: codes.Aborted,
+}
+
+// StatusError - функция преобразования ошибки в статус grpc.
+// Ошибка приводится к ошибке сервиса через service.Classify, к статусу добавляются детали:
+// errdetails.ErrorInfo с машиночитаемым кодом ошибки, errdetails.BadRequest для ошибок валидации поля
+// и errdetails.QuotaFailure для превышения квоты, errdetails.RetryInfo - если известно, когда повторить запрос. Внутренние ошибки логируются, клиенту возвращается только код.
+func StatusError(ctx context.Context, err error) error {
+	e := service.Classify(err)
+	code, ok := codeCodes[e.Code]
+	if !ok {
+		code, ok = kindCodes[e.Kind]
+	}
+	if !ok {
+		code = codes.Internal
+	}
+	log := logger.FromContext(ctx)
+	switch e.Kind {
+	case service.KindInternal, service.KindUnavailable:
+		log.Error("request failed", zap.String("code", e.Code), zap.Error(err))
+	case service.KindCanceled:
+		log.Info("request canceled", zap.Error(err))
+	default:
+		log.Debug("request rejected", zap.String("code", e.Code), zap.Error(err))
+	}
+
+	info := &errdetails.ErrorInfo{Reason: e.Code, Domain: ErrorDomain}
+	if e.ExistingShortURL != "" {
+		info.Metadata = map[string]string{"short_url": e.ExistingShortURL}
+	}
+	st, detErr := status.New(code, e.Message).WithDetails(info)
+	if detErr != nil {
+		return status.Error(code, e.Message)
+	}
+	if e.Field != "" {
+		reason := e.Message
+		if e.Err != nil {
+			reason = e.Err.Error()
+		}
+		if withField, detErr := st.WithDetails(&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: e.Field, Description: reason}},
+		}); detErr == nil {
+			st = withField
+		}
+	}
+	if e.Kind == service.KindQuota {
+		subject := "user"
+		switch e.Code {
+		case service.CodePasswordAttempts:
+			subject = "short_url"
+		case service.CodeRateLimited:
+			subject = "client"
+		}
+		if withQuota, detErr := st.WithDetails(&errdetails.QuotaFailure{
+			Violations: []*errdetails.QuotaFailure_Violation{{Subject: subject, Description: e.Message}},
+		}); detErr == nil {
+			st = withQuota
+		}
+	}
+	if e.RetryAfter > 0 {
+		if withRetry, detErr := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(e.RetryAfter)}); detErr == nil {
+			st = withRetry
+		}
+	}
+	return st.Err()
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Dorrrke/shortener-url/internal/ratelimit"
+	"github.com/Dorrrke/shortener-url/internal/service"
+)
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		code      codes.Code
+		reason    string
+		field     string
+		quotaInfo bool
+		retry     time.Duration
+		existing  string
+	}{
+		{
+			name:   "Internal error",
+			err:    errors.New("connection refused"),
+			code:   codes.Internal,
+			reason: service.CodeInternal,
+		},
+		{
+			name:   "Invalid url",
+			err:    service.InvalidURL("original_url", errors.New("missing scheme")),
+			code:   codes.InvalidArgument,
+			reason: service.CodeInvalidURL,
+			field:  "original_url",
+		},
+		{
+			name:      "Quota exceeded",
+			err:       service.ErrQuotaExceeded,
+			code:      codes.ResourceExhausted,
+			reason:    service.CodeQuotaExceeded,
+			quotaInfo: true,
+		},
+		{
+			name:      "Too many password attempts",
+			err:       service.TooManyPasswordAttempts(10 * time.Second),
+			code:      codes.ResourceExhausted,
+			reason:    service.CodePasswordAttempts,
+			quotaInfo: true,
+			retry:     10 * time.Second,
+		},
+		{
+			name:      "Rate limited",
+			err:       &ratelimit.Error{RetryAfter: 2 * time.Second},
+			code:      codes.ResourceExhausted,
+			reason:    service.CodeRateLimited,
+			quotaInfo: true,
+			retry:     2 * time.Second,
+		},
+		{
+			name:     "Password not applied",
+			err:      service.PasswordNotApplied("http://localhost:8080/abc"),
+			code:     codes.AlreadyExists,
+			reason:   service.CodePasswordNotApplied,
+			existing: "http://localhost:8080/abc",
+		},
+		{
+			name:   "Wrong password",
+			err:    service.ErrWrongPassword,
+			code:   codes.Unauthenticated,
+			reason: service.CodeWrongPassword,
+		},
+		{
+			name:   "Unauthorized",
+			err:    service.ErrUnauthorized,
+			code:   codes.Unauthenticated,
+			reason: service.CodeUnauthorized,
+		},
+		{
+			name:   "Canceled",
+			err:    context.Canceled,
+			code:   codes.Canceled,
+			reason: service.CodeCanceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, ok := status.FromError(StatusError(context.Background(), tt.err))
+			require.True(t, ok)
+			assert.Equal(t, tt.code, st.Code())
+
+			var info *errdetails.ErrorInfo
+			var badRequest *errdetails.BadRequest
+			var quota *errdetails.QuotaFailure
+			var retry *errdetails.RetryInfo
+			for _, detail := range st.Details() {
+				switch d := detail.(type) {
+				case *errdetails.ErrorInfo:
+					info = d
+				case *errdetails.BadRequest:
+					badRequest = d
+				case *errdetails.QuotaFailure:
+					quota = d
+				case *errdetails.RetryInfo:
+					retry = d
+				}
+			}
+			require.NotNil(t, info)
+			assert.Equal(t, tt.reason, info.GetReason())
+			assert.Equal(t, ErrorDomain, info.GetDomain())
+			assert.Equal(t, tt.existing, info.GetMetadata()["short_url"])
+			if tt.field != "" {
+				require.NotNil(t, badRequest)
+				assert.Equal(t, tt.field, badRequest.GetFieldViolations()[0].GetField())
+			} else {
+				assert.Nil(t, badRequest)
+			}
+			assert.Equal(t, tt.quotaInfo, quota != nil)
+			if tt.retry > 0 {
+				require.NotNil(t, retry)
+				assert.Equal(t, tt.retry, retry.GetRetryDelay().AsDuration())
+			} else {
+				assert.Nil(t, retry)
+			}
+		})
+	}
+}
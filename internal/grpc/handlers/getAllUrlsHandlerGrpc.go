@@ -3,66 +3,59 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/Dorrrke/shortener-url/internal/config"
 	shortenergrpcv1 "github.com/Dorrrke/shortener-url/internal/grpc/gen/shortenergrpc.v1"
-	"github.com/Dorrrke/shortener-url/internal/logger"
 	"github.com/Dorrrke/shortener-url/internal/service"
 	"github.com/Dorrrke/shortener-url/internal/utils"
 	"github.com/google/uuid"
-	"go.uber.org/zap"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
-	"google.golang.org/grpc/status"
 )
 
-func GetAllURLsHandlerGrpc(ctx context.Context, cfg config.AppConfig, sService service.ShortenerService) (*shortenergrpcv1.GetAllURLsResponce, error) {
+func GetAllURLsHandlerGrpc(ctx context.Context, cfg *config.AppConfig, sService service.ShortenerService) (*shortenergrpcv1.GetAllURLsResponce, error) {
 	var userID string
 	md, ok := metadata.FromIncomingContext(ctx)
 	if ok {
 		values := md.Get("auth")
 		if len(values) > 0 {
 			token := values[0]
-			userID := utils.GetUID(token)
+			userID = utils.GetUID(token)
 			if userID == "" {
-				logger.Log.Error("User id from token is empty")
-				return nil, status.Error(codes.Unauthenticated, "User id from token is empty")
+				return nil, StatusError(ctx, service.ErrUnauthorized)
 			}
 			header := metadata.Pairs("auth", token)
 			grpc.SetHeader(ctx, header)
 		} else {
-			userID := uuid.New().String()
+			userID = uuid.New().String()
 			token, err := utils.CreateJWTToken(userID)
 			if err != nil {
-				logger.Log.Error("cannot create token", zap.Error(err))
-				return nil, status.Error(codes.Internal, "Create token error")
+				return nil, StatusError(ctx, fmt.Errorf("cannot create token: %w", err))
 			}
 			header := metadata.Pairs("auth", token)
 			grpc.SetHeader(ctx, header)
 		}
 	} else {
-		userID := uuid.New().String()
+		userID = uuid.New().String()
 		token, err := utils.CreateJWTToken(userID)
 		if err != nil {
-			logger.Log.Error("cannot create token", zap.Error(err))
-			return nil, status.Error(codes.Internal, "Create token error")
+			return nil, StatusError(ctx, fmt.Errorf("cannot create token: %w", err))
 		}
 		header := metadata.Pairs("auth", token)
 		grpc.SetHeader(ctx, header)
 	}
 
-	urls, err := sService.GetAllURLsByID(userID)
+	urls, err := sService.GetAllURLsByID(ctx, userID)
 	if err != nil {
-		return nil, status.Error(codes.Internal, "Internal error")
+		return nil, StatusError(ctx, err)
 	}
 	if len(urls) == 0 {
-		return nil, status.Error(codes.NotFound, "No data")
+		return nil, StatusError(ctx, service.ErrNoContent)
 	}
 	jsonURLs, err := json.Marshal(urls)
 	if err != nil {
-		logger.Log.Debug("cannot encode to json", zap.Error(err))
-		return nil, status.Error(codes.Internal, "Internal error")
+		return nil, StatusError(ctx, err)
 	}
 	return &shortenergrpcv1.GetAllURLsResponce{AllUrlsJson: string(jsonURLs)}, nil
 }
@@ -2,9 +2,13 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -59,10 +63,9 @@ func TestShortenerURLHandlerGrpc(t *testing.T) {
 			}
 			sService := service.NewService(&storage.MemStorage{URLMap: make(map[string]string)}, &cfg)
 
-			res, err := ShortenerURLHandlerGrpc(ctx, cfg, *sService, tt.originalURL)
+			res, err := ShortenerURLHandlerGrpc(ctx, sService.Config(), *sService, tt.originalURL, "")
 			if !tt.want.shortURL {
-				testErr := status.Error(codes.InvalidArgument, "Bad request")
-				assert.ErrorIs(t, err, testErr)
+				assert.Equal(t, codes.InvalidArgument, status.Code(err))
 			} else {
 				assert.NoError(t, err)
 				assert.True(t, res.ShortUrl != "")
@@ -71,3 +74,21 @@ func TestShortenerURLHandlerGrpc(t *testing.T) {
 		})
 	}
 }
+
+func TestShortenerJSONHandlerGrpc(t *testing.T) {
+	cfg := config.AppConfig{ServerAddress: "localhost:8080"}
+	sService := service.NewService(&storage.MemStorage{URLMap: make(map[string]string)}, &cfg)
+
+	res, err := ShortenerJSONHandlerGrpc(context.Background(), sService.Config(), *sService, `{"url":"https://go.dev/"}`)
+	require.NoError(t, err)
+	var shortURL string
+	require.NoError(t, json.Unmarshal([]byte(res.ShortUrlJson), &shortURL))
+	assert.True(t, strings.HasPrefix(shortURL, "http://localhost:8080/"))
+
+	res, err = ShortenerJSONHandlerGrpc(context.Background(), sService.Config(), *sService, `{"url":"https://go.dev/"}`)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Quote(shortURL), res.ShortUrlJson, "existing short url is returned on conflict")
+
+	_, err = ShortenerJSONHandlerGrpc(context.Background(), sService.Config(), *sService, `{"url":"/"}`)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,129 @@
+package grpcserver
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Dorrrke/shortener-url/internal/grpc/handlers"
+	"github.com/Dorrrke/shortener-url/internal/idempotency"
+	"github.com/Dorrrke/shortener-url/internal/logger"
+	"github.com/Dorrrke/shortener-url/internal/realip"
+	"github.com/Dorrrke/shortener-url/internal/utils"
+)
+
+// idempotent - функция выполнения вызова method с учетом ключа идемпотентности из метаданных idempotency-key.
+// Успешный ответ сохраняется вместе с метаданными, установленными вызовом (выданный токен auth, url-already-exists),
+// повтор с тем же ключом и запросом получает их без вызова call и метаданные idempotent-replayed: true.
+// Ошибки не сохраняются, вызов с тем же ключом после ошибки выполняется заново.
+// Ключи разделяются по пользователю из метаданных auth, а анонимные - по ip адресу клиента, как и в http.
+// Для анонимных ключей токен auth не сохраняется и не повторяется: за одним ip могут быть разные клиенты.
+func idempotent[T proto.Message](ctx context.Context, keeper *idempotency.Keeper, resolver *realip.Resolver, method string, req proto.Message, newResp func() T, call func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+	md, _ := metadata.FromIncomingContext(ctx)
+	keys := md.Get(idempotency.MetadataKey)
+	if len(keys) == 0 {
+		return call(ctx)
+	}
+	key := keys[0]
+	scope := idempotency.AnonymousScope(resolver.FromContext(ctx))
+	anonymous := true
+	if tokens := md.Get("auth"); len(tokens) > 0 {
+		if userID := utils.GetUID(tokens[0]); userID != "" {
+			scope = userID
+			anonymous = false
+		}
+	}
+	body, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return zero, handlers.StatusError(ctx, err)
+	}
+	stored, err := keeper.Begin(ctx, scope, key, idempotency.Hash([]byte(method), body))
+	if err != nil {
+		return zero, handlers.StatusError(ctx, err)
+	}
+	if stored != nil {
+		resp := newResp()
+		if err := proto.Unmarshal(stored.Body, resp); err != nil {
+			return zero, handlers.StatusError(ctx, err)
+		}
+		logger.FromContext(ctx).Debug("Replay idempotent response", zap.String("key", key))
+		header := metadata.MD(stored.Headers).Copy()
+		if anonymous {
+			delete(header, "auth")
+		}
+		grpc.SetHeader(ctx, metadata.Join(header, metadata.Pairs(idempotency.ReplayedHeader, "true")))
+		return resp, nil
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	var recorder *headerRecorder
+	if stream := grpc.ServerTransportStreamFromContext(ctx); stream != nil {
+		recorder = &headerRecorder{ServerTransportStream: stream}
+		ctx = grpc.NewContextWithServerTransportStream(ctx, recorder)
+	}
+	resp, err := call(ctx)
+	if err != nil {
+		keeper.Release(ctx, scope, key)
+		return resp, err
+	}
+	data, marshalErr := proto.Marshal(resp)
+	if marshalErr != nil {
+		keeper.Release(ctx, scope, key)
+		return resp, nil
+	}
+	var headers map[string][]string
+	if recorder != nil {
+		headers = recorder.headers()
+	}
+	if anonymous && headers != nil {
+		headers = metadata.MD(headers).Copy()
+		delete(headers, "auth")
+	}
+	keeper.Complete(ctx, scope, key, http.StatusOK, "application/protobuf", headers, data)
+	return resp, nil
+}
+
+// headerRecorder - grpc.ServerTransportStream, запоминающий метаданные ответа, установленные вызовом.
+type headerRecorder struct {
+	grpc.ServerTransportStream
+	mu sync.Mutex
+	md metadata.MD
+}
+
+// SetHeader - метод установки метаданных ответа с их сохранением.
+func (r *headerRecorder) SetHeader(md metadata.MD) error {
+	if err := r.ServerTransportStream.SetHeader(md); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.md = metadata.Join(r.md, md)
+	return nil
+}
+
+// SendHeader - метод отправки метаданных ответа с их сохранением.
+func (r *headerRecorder) SendHeader(md metadata.MD) error {
+	if err := r.ServerTransportStream.SendHeader(md); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.md = metadata.Join(r.md, md)
+	return nil
+}
+
+// headers - метод получения сохраненных метаданных ответа.
+func (r *headerRecorder) headers() map[string][]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.md) == 0 {
+		return nil
+	}
+	return r.md
+}
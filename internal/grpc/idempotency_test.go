@@ -0,0 +1,72 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	shortenergrpcv1 "github.com/Dorrrke/shortener-url/internal/grpc/gen/shortenergrpc.v1"
+	"github.com/Dorrrke/shortener-url/internal/idempotency"
+	"github.com/Dorrrke/shortener-url/internal/realip"
+	"github.com/Dorrrke/shortener-url/internal/storage"
+)
+
+// testStream - grpc.ServerTransportStream, собирающий установленные метаданные ответа.
+type testStream struct {
+	header metadata.MD
+}
+
+func (s *testStream) Method() string { return shortenergrpcv1.Shortener_ShortenerURL_FullMethodName }
+
+func (s *testStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *testStream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+
+func (s *testStream) SetTrailer(metadata.MD) error { return nil }
+
+func TestIdempotentReplaysMetadata(t *testing.T) {
+	keeper := idempotency.New(&storage.MemStorage{}, func() time.Duration { return time.Minute })
+	resolver, err := realip.NewResolver([]string{"127.0.0.1/32"})
+	require.NoError(t, err)
+	req := &shortenergrpcv1.ShortenerURLRequest{OriginalUrl: "https://go.dev/"}
+
+	calls := 0
+	send := func(ip string) (*shortenergrpcv1.ShortenerURLResponce, metadata.MD) {
+		stream := &testStream{}
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(idempotency.MetadataKey, "key", "x-real-ip", ip))
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5000}})
+		ctx = grpc.NewContextWithServerTransportStream(ctx, stream)
+		resp, err := idempotent(ctx, keeper, resolver, shortenergrpcv1.Shortener_ShortenerURL_FullMethodName, req,
+			func() *shortenergrpcv1.ShortenerURLResponce { return &shortenergrpcv1.ShortenerURLResponce{} },
+			func(ctx context.Context) (*shortenergrpcv1.ShortenerURLResponce, error) {
+				calls++
+				grpc.SetHeader(ctx, metadata.Pairs("auth", "token", "url-already-exists", "true"))
+				return &shortenergrpcv1.ShortenerURLResponce{ShortUrl: "http://localhost/a"}, nil
+			})
+		require.NoError(t, err)
+		return resp, stream.header
+	}
+
+	_, header := send("10.0.0.1")
+	assert.Equal(t, []string{"token"}, header.Get("auth"))
+
+	resp, header := send("10.0.0.1")
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, "http://localhost/a", resp.GetShortUrl())
+	assert.Empty(t, header.Get("auth"), "anonymous replay must not return the issued token")
+	assert.Equal(t, []string{"true"}, header.Get("url-already-exists"))
+	assert.Equal(t, []string{"true"}, header.Get(idempotency.ReplayedHeader))
+
+	send("10.0.0.2")
+	assert.Equal(t, 2, calls, "anonymous keys of different clients must not be shared")
+}
@@ -5,59 +5,83 @@ import (
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 
-	"github.com/Dorrrke/shortener-url/internal/config"
+	"github.com/Dorrrke/shortener-url/internal/access"
+	"github.com/Dorrrke/shortener-url/internal/audit"
 	shortenergrpcv1 "github.com/Dorrrke/shortener-url/internal/grpc/gen/shortenergrpc.v1"
 	"github.com/Dorrrke/shortener-url/internal/grpc/handlers"
 	"github.com/Dorrrke/shortener-url/internal/logger"
+	"github.com/Dorrrke/shortener-url/internal/realip"
 	"github.com/Dorrrke/shortener-url/internal/service"
 )
 
 type ShortenerGRPCServer struct {
 	shortenergrpcv1.UnimplementedShortenerServer
-	sService *service.ShortenerService
-	cfg      *config.AppConfig
+	sService   *service.ShortenerService
+	ipResolver *realip.Resolver
+	acl        *access.Policy
 }
 
-func RegisterGrpcService(gRPC *grpc.Server, sService *service.ShortenerService, cfg *config.AppConfig) {
-	shortenergrpcv1.RegisterShortenerServer(gRPC, &ShortenerGRPCServer{sService: sService, cfg: cfg})
+func RegisterGrpcService(gRPC *grpc.Server, sService *service.ShortenerService) {
+	resolver, err := realip.NewResolver(sService.Config().TrustedProxies)
+	if err != nil {
+		logger.Log.Error("Error parse trusted proxies", zap.Error(err))
+	}
+	acl := access.New(func() string {
+		return sService.Config().TrustedSubnet
+	}, resolver)
+	shortenergrpcv1.RegisterShortenerServer(gRPC, &ShortenerGRPCServer{sService: sService, ipResolver: resolver, acl: acl})
 }
 
 func (s *ShortenerGRPCServer) GetOriginalURL(ctx context.Context, req *shortenergrpcv1.GetOriginalURLRequest) (*shortenergrpcv1.GetOriginalURLResponce, error) {
-	return handlers.GetOriginalURLHandlerGrpc(ctx, *s.cfg, *s.sService, req.GetShortUrl())
+	return handlers.GetOriginalURLHandlerGrpc(s.auditContext(ctx), s.sService.Config(), *s.sService, req.GetShortUrl(), req.GetPassword())
 }
 
 func (s *ShortenerGRPCServer) ShortenerURL(ctx context.Context, req *shortenergrpcv1.ShortenerURLRequest) (*shortenergrpcv1.ShortenerURLResponce, error) {
-	return handlers.ShortenerURLHandlerGrpc(ctx, *s.cfg, *s.sService, req.GetOriginalUrl())
+	return idempotent(ctx, s.sService.Idempotency(), s.ipResolver, shortenergrpcv1.Shortener_ShortenerURL_FullMethodName, req,
+		func() *shortenergrpcv1.ShortenerURLResponce { return &shortenergrpcv1.ShortenerURLResponce{} },
+		func(ctx context.Context) (*shortenergrpcv1.ShortenerURLResponce, error) {
+			return handlers.ShortenerURLHandlerGrpc(s.auditContext(ctx), s.sService.Config(), *s.sService, req.GetOriginalUrl(), req.GetPassword())
+		})
 }
 
 func (s *ShortenerGRPCServer) ShortenerJSON(ctx context.Context, req *shortenergrpcv1.ShortenerJSONRequest) (*shortenergrpcv1.ShortenerJSONResponce, error) {
-	return handlers.ShortenerJSONHandlerGrpc(ctx, *s.cfg, *s.sService, req.GetOrignalUrl())
+	return idempotent(ctx, s.sService.Idempotency(), s.ipResolver, shortenergrpcv1.Shortener_ShortenerJSON_FullMethodName, req,
+		func() *shortenergrpcv1.ShortenerJSONResponce { return &shortenergrpcv1.ShortenerJSONResponce{} },
+		func(ctx context.Context) (*shortenergrpcv1.ShortenerJSONResponce, error) {
+			return handlers.ShortenerJSONHandlerGrpc(s.auditContext(ctx), s.sService.Config(), *s.sService, req.GetOrignalUrl())
+		})
 }
 
 func (s *ShortenerGRPCServer) CheckDBConnection(ctx context.Context, req *shortenergrpcv1.CheckDBConnectionRequest) (*shortenergrpcv1.CheckDBConnectionResponce, error) {
-	err := s.sService.CheckDBConnection()
-	if err != nil {
-		logger.Log.Error("Error check db connect", zap.Error(err))
-		return nil, status.Error(codes.Internal, "Internal error")
+	if err := s.sService.CheckDBConnection(ctx); err != nil {
+		return nil, handlers.StatusError(ctx, err)
 	}
 	return &shortenergrpcv1.CheckDBConnectionResponce{}, nil
 }
 
 func (s *ShortenerGRPCServer) GetAllURLs(ctx context.Context, req *shortenergrpcv1.GetAllURLsRequest) (*shortenergrpcv1.GetAllURLsResponce, error) {
-	return handlers.GetAllURLsHandlerGrpc(ctx, *s.cfg, *s.sService)
+	return handlers.GetAllURLsHandlerGrpc(ctx, s.sService.Config(), *s.sService)
 }
 
 func (s *ShortenerGRPCServer) InsertBatch(ctx context.Context, req *shortenergrpcv1.InsertBatchRequest) (*shortenergrpcv1.InsertBatchResponce, error) {
-	return handlers.InsertBatchHandlerGrpc(ctx, *s.cfg, *s.sService, req.GetUrlsJson())
+	return idempotent(ctx, s.sService.Idempotency(), s.ipResolver, shortenergrpcv1.Shortener_InsertBatch_FullMethodName, req,
+		func() *shortenergrpcv1.InsertBatchResponce { return &shortenergrpcv1.InsertBatchResponce{} },
+		func(ctx context.Context) (*shortenergrpcv1.InsertBatchResponce, error) {
+			return handlers.InsertBatchHandlerGrpc(s.auditContext(ctx), s.sService.Config(), *s.sService, req.GetUrlsJson())
+		})
 }
 
 func (s *ShortenerGRPCServer) DeleteURL(ctx context.Context, req *shortenergrpcv1.DeleteURLRequest) (*shortenergrpcv1.DeleteURLResponce, error) {
-	return handlers.DeleteURLHandlerGrpc(ctx, *s.cfg, *s.sService, req.GetUrls())
+	return handlers.DeleteURLHandlerGrpc(s.auditContext(ctx), s.sService.Config(), *s.sService, req.GetUrls())
 }
 
 func (s *ShortenerGRPCServer) ServiceStat(ctx context.Context, req *shortenergrpcv1.ServiceStatRequest) (*shortenergrpcv1.ServiceStatResponce, error) {
-	return handlers.ServiceStatHandlerGrpc(ctx, *s.cfg, *s.sService)
+	return handlers.ServiceStatHandlerGrpc(ctx, s.acl, *s.sService)
+}
+
+// auditContext - метод подготовки контекста вызова для аудита, в контекст сохраняется ip клиента.
+// Метаданные X-Real-IP и X-Forwarded-For учитываются только от доверенных прокси.
+func (s *ShortenerGRPCServer) auditContext(ctx context.Context) context.Context {
+	return audit.WithIP(ctx, s.ipResolver.FromContext(ctx))
 }
@@ -0,0 +1,50 @@
+package health
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/Dorrrke/shortener-url/internal/logger"
+)
+
+// DefaultWatchInterval - период обновления статуса grpc.health.v1 по умолчанию.
+const DefaultWatchInterval = 10 * time.Second
+
+// Watch - метод периодического обновления статуса сервера grpc.health.v1 по результатам проверок готовности.
+// Статус выставляется для общего сервиса ("") и для каждого имени из services. Метод блокируется до отмены ctx.
+func (c *Checker) Watch(ctx context.Context, server *health.Server, interval time.Duration, services ...string) {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		c.updateServingStatus(ctx, server, services)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// updateServingStatus - метод выполнения проверок и записи статуса в сервер grpc.health.v1.
+func (c *Checker) updateServingStatus(ctx context.Context, server *health.Server, services []string) {
+	report := c.Check(ctx)
+	if ctx.Err() != nil {
+		return
+	}
+	status := healthpb.HealthCheckResponse_SERVING
+	if !report.OK() {
+		logger.Log.Warn("service is not ready", zap.Strings("failed", report.Failed()))
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	server.SetServingStatus("", status)
+	for _, name := range services {
+		server.SetServingStatus(name, status)
+	}
+}
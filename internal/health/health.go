@@ -0,0 +1,151 @@
+// Пакет health содержит проверки живости и готовности сервиса для http (/healthz, /readyz) и grpc.health.v1.
+// Живость означает, что процесс запущен и отвечает, готовность - что все компоненты сервиса
+// (хранилище, таблицы, файл хранилища, очередь удаления) работоспособны и сервис может принимать запросы.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Dorrrke/shortener-url/internal/logger"
+)
+
+// Статусы проверок.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// DefaultCheckTimeout - время на выполнение одной проверки по умолчанию.
+const DefaultCheckTimeout = 2 * time.Second
+
+// CheckFunc - проверка компонента, nil означает, что компонент работоспособен.
+type CheckFunc func(ctx context.Context) error
+
+// ComponentStatus - результат проверки компонента.
+type ComponentStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report - результат проверки готовности: общий статус и статусы компонентов.
+type Report struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components,omitempty"`
+}
+
+// OK - метод проверки, что все компоненты работоспособны.
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+// namedCheck - проверка с именем компонента.
+type namedCheck struct {
+	name  string
+	check CheckFunc
+}
+
+// Checker - набор проверок готовности.
+type Checker struct {
+	// Timeout - время на выполнение одной проверки, 0 - DefaultCheckTimeout.
+	Timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []namedCheck
+}
+
+// New - функция создания пустого набора проверок.
+func New() *Checker {
+	return &Checker{}
+}
+
+// Add - метод добавления проверки компонента name.
+func (c *Checker) Add(name string, check CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Check - метод параллельного выполнения всех проверок.
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.RUnlock()
+
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+	results := make([]ComponentStatus, len(checks))
+	var wg sync.WaitGroup
+	for i, nc := range checks {
+		wg.Add(1)
+		go func(i int, nc namedCheck) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			if err := nc.check(checkCtx); err != nil {
+				results[i] = ComponentStatus{Status: StatusFail, Error: err.Error()}
+				return
+			}
+			results[i] = ComponentStatus{Status: StatusOK}
+		}(i, nc)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Components: make(map[string]ComponentStatus, len(checks))}
+	for i, nc := range checks {
+		report.Components[nc.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// Failed - метод получения имен неработоспособных компонентов в алфавитном порядке.
+func (r Report) Failed() []string {
+	var failed []string
+	for name, component := range r.Components {
+		if component.Status != StatusOK {
+			failed = append(failed, name)
+		}
+	}
+	sort.Strings(failed)
+	return failed
+}
+
+// LivenessHandler - хендлер /healthz, всегда отвечает 200, пока процесс обслуживает запросы.
+func LivenessHandler(res http.ResponseWriter, req *http.Request) {
+	writeReport(res, http.StatusOK, Report{Status: StatusOK})
+}
+
+// ReadinessHandler - метод создания хендлера /readyz.
+// Отвечает 200, если все компоненты работоспособны, иначе 503 (StatusServiceUnavailable); в теле - статусы компонентов.
+func (c *Checker) ReadinessHandler() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		report := c.Check(req.Context())
+		code := http.StatusOK
+		if !report.OK() {
+			logger.FromContext(req.Context()).Warn("service is not ready", zap.Strings("failed", report.Failed()))
+			code = http.StatusServiceUnavailable
+		}
+		writeReport(res, code, report)
+	}
+}
+
+// writeReport - функция отправки результата проверки в формате json.
+func writeReport(res http.ResponseWriter, code int, report Report) {
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Cache-Control", "no-store")
+	res.WriteHeader(code)
+	if err := json.NewEncoder(res).Encode(report); err != nil {
+		logger.Log.Error("Error encode health report", zap.Error(err))
+	}
+}
@@ -0,0 +1,117 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestReadinessHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		checks   map[string]CheckFunc
+		wantCode int
+		want     Report
+	}{
+		{
+			name:     "No checks",
+			wantCode: http.StatusOK,
+			want:     Report{Status: StatusOK},
+		},
+		{
+			name: "All components ok",
+			checks: map[string]CheckFunc{
+				"storage":      func(ctx context.Context) error { return nil },
+				"delete_queue": func(ctx context.Context) error { return nil },
+			},
+			wantCode: http.StatusOK,
+			want: Report{Status: StatusOK, Components: map[string]ComponentStatus{
+				"storage":      {Status: StatusOK},
+				"delete_queue": {Status: StatusOK},
+			}},
+		},
+		{
+			name: "Failed component",
+			checks: map[string]CheckFunc{
+				"storage":      func(ctx context.Context) error { return errors.New("no connect") },
+				"delete_queue": func(ctx context.Context) error { return nil },
+			},
+			wantCode: http.StatusServiceUnavailable,
+			want: Report{Status: StatusFail, Components: map[string]ComponentStatus{
+				"storage":      {Status: StatusFail, Error: "no connect"},
+				"delete_queue": {Status: StatusOK},
+			}},
+		},
+		{
+			name: "Check timeout",
+			checks: map[string]CheckFunc{
+				"storage": func(ctx context.Context) error {
+					<-ctx.Done()
+					return ctx.Err()
+				},
+			},
+			wantCode: http.StatusServiceUnavailable,
+			want: Report{Status: StatusFail, Components: map[string]ComponentStatus{
+				"storage": {Status: StatusFail, Error: context.DeadlineExceeded.Error()},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := New()
+			checker.Timeout = 10 * time.Millisecond
+			for name, check := range tt.checks {
+				checker.Add(name, check)
+			}
+			rec := httptest.NewRecorder()
+			checker.ReadinessHandler()(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			assert.Equal(t, tt.wantCode, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			var got Report
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLivenessHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	LivenessHandler(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+}
+
+func TestWatch(t *testing.T) {
+	var failing error
+	checker := New()
+	checker.Add("storage", func(ctx context.Context) error { return failing })
+	server := health.NewServer()
+	ctx := context.Background()
+
+	checker.updateServingStatus(ctx, server, []string{"shortenergrpc.Shortener"})
+	for _, service := range []string{"", "shortenergrpc.Shortener"} {
+		resp, err := server.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+	}
+
+	failing = errors.New("no connect")
+	checker.updateServingStatus(ctx, server, []string{"shortenergrpc.Shortener"})
+	resp, err := server.Check(ctx, &healthpb.HealthCheckRequest{Service: "shortenergrpc.Shortener"})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	checker.Watch(watchCtx, server, time.Millisecond)
+}
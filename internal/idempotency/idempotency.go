@@ -0,0 +1,157 @@
+// Пакет idempotency содержит обработку ключей идемпотентности: повтор запроса с тем же ключом
+// возвращает сохраненный ответ вместо повторного выполнения, а тот же ключ с другим запросом отклоняется.
+// Записи ключей хранятся в хранилище сервиса (MemStorage или DBStorage) ограниченное время.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Dorrrke/shortener-url/internal/logger"
+	"github.com/Dorrrke/shortener-url/internal/models"
+	"github.com/Dorrrke/shortener-url/internal/storage"
+)
+
+// Header - http заголовок с ключом идемпотентности.
+const Header = "Idempotency-Key"
+
+// MetadataKey - ключ метаданных grpc с ключом идемпотентности.
+const MetadataKey = "idempotency-key"
+
+// ReplayedHeader - заголовок ответа, которым помечается повторно отданный сохраненный ответ.
+const ReplayedHeader = "Idempotent-Replayed"
+
+// MaxKeyLength - максимальная длина ключа идемпотентности.
+const MaxKeyLength = 255
+
+// Ошибки обработки ключей идемпотентности.
+var (
+	// ErrInvalidKey - ключ пустой, слишком длинный или содержит недопустимые символы.
+	ErrInvalidKey = errors.New("invalid idempotency key")
+	// ErrKeyReused - ключ уже использован с другим запросом.
+	ErrKeyReused = errors.New("idempotency key is already used with a different request")
+	// ErrInProgress - запрос с этим ключом еще выполняется.
+	ErrInProgress = errors.New("request with this idempotency key is in progress")
+)
+
+// Store - хранилище записей ключей идемпотентности, реализуется storage.Storage.
+type Store interface {
+	ReserveIdempotencyKey(ctx context.Context, rec models.IdempotencyRecord) (models.IdempotencyRecord, error)
+	CompleteIdempotencyKey(ctx context.Context, rec models.IdempotencyRecord) error
+	ReleaseIdempotencyKey(ctx context.Context, scope string, key string) error
+}
+
+// Keeper - обработка ключей идемпотентности поверх хранилища.
+type Keeper struct {
+	store Store
+	ttl   func() time.Duration
+}
+
+// New - функция создания Keeper. Время хранения записи берется из ttl при каждом запросе,
+// поэтому может меняться при перезагрузке конфигурации.
+func New(store Store, ttl func() time.Duration) *Keeper {
+	return &Keeper{store: store, ttl: ttl}
+}
+
+// ValidKey - функция проверки ключа: от 1 до MaxKeyLength печатных ASCII символов.
+func ValidKey(key string) error {
+	if key == "" || len(key) > MaxKeyLength {
+		return ErrInvalidKey
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
+
+// Hash - функция вычисления хэша запроса по его частям, например методу, маршруту и телу.
+func Hash(parts ...[]byte) string {
+	h := sha256.New()
+	var size [8]byte
+	for _, part := range parts {
+		binary.BigEndian.PutUint64(size[:], uint64(len(part)))
+		h.Write(size[:])
+		h.Write(part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// AnonymousScope - функция получения области ключей анонимного клиента по его ip адресу:
+// ключи разных анонимных клиентов не пересекаются, а области пользователей (uuid) не совпадают с ней.
+func AnonymousScope(ip string) string {
+	return "ip:" + ip
+}
+
+// Begin - метод начала запроса с ключом key в области scope (обычно id пользователя).
+// Если запрос с таким ключом уже выполнен, возвращается сохраненный ответ, и запрос выполнять не нужно.
+// Если ключ использован с другим запросом, возвращается ErrKeyReused, если запрос еще выполняется - ErrInProgress.
+// Иначе возвращается nil, и после выполнения запроса нужно вызвать Complete или Release.
+func (k *Keeper) Begin(ctx context.Context, scope string, key string, hash string) (*models.IdempotencyRecord, error) {
+	if err := ValidKey(key); err != nil {
+		return nil, err
+	}
+	rec := models.IdempotencyRecord{
+		Scope:       scope,
+		Key:         key,
+		RequestHash: hash,
+		ExpiresAt:   time.Now().Add(k.ttl()),
+	}
+	existing, err := k.store.ReserveIdempotencyKey(ctx, rec)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, storage.ErrConflict) {
+		return nil, err
+	}
+	if existing.RequestHash != hash {
+		return nil, ErrKeyReused
+	}
+	if existing.Status == 0 {
+		return nil, ErrInProgress
+	}
+	return &existing, nil
+}
+
+// Complete - метод сохранения ответа на запрос, начатый Begin, вместе с заголовками headers, которые нужно повторить.
+// Ошибка сохранения только логируется: ответ клиенту уже отправлен, а незавершенная запись истечет через ttl.
+func (k *Keeper) Complete(ctx context.Context, scope string, key string, status int, contentType string, headers map[string][]string, body []byte) {
+	err := k.store.CompleteIdempotencyKey(ctx, models.IdempotencyRecord{
+		Scope:       scope,
+		Key:         key,
+		Status:      status,
+		ContentType: contentType,
+		Body:        body,
+		Headers:     headers,
+	})
+	if err != nil {
+		logger.FromContext(ctx).Error("Cannot save idempotent response", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// Release - метод освобождения ключа запроса, начатого Begin, если ответ не нужно сохранять,
+// например при внутренней ошибке: клиент сможет повторить запрос с тем же ключом.
+func (k *Keeper) Release(ctx context.Context, scope string, key string) {
+	if err := k.store.ReleaseIdempotencyKey(ctx, scope, key); err != nil {
+		logger.FromContext(ctx).Error("Cannot release idempotency key", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// Storable - функция проверки, что ответ с http статусом status нужно сохранить для повторов.
+// Не сохраняются ответы на внутренние ошибки, отмену запроса, таймаут и превышение частоты запросов.
+func Storable(status int) bool {
+	switch {
+	case status >= 500:
+		return false
+	case status == 408, status == 429, status == 499:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dorrrke/shortener-url/internal/storage"
+)
+
+func TestKeeper(t *testing.T) {
+	ctx := context.Background()
+	keeper := New(&storage.MemStorage{}, func() time.Duration { return time.Minute })
+	hash := Hash([]byte("POST"), []byte("/api/shorten"), []byte(`{"url":"https://go.dev/"}`))
+
+	stored, err := keeper.Begin(ctx, "user", "key-1", hash)
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+
+	_, err = keeper.Begin(ctx, "user", "key-1", hash)
+	assert.ErrorIs(t, err, ErrInProgress)
+
+	keeper.Complete(ctx, "user", "key-1", http.StatusCreated, "application/json", map[string][]string{"Set-Cookie": {"auth=token"}}, []byte(`{"result":"http://localhost/a"}`))
+
+	stored, err = keeper.Begin(ctx, "user", "key-1", hash)
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, http.StatusCreated, stored.Status)
+	assert.Equal(t, "application/json", stored.ContentType)
+	assert.Equal(t, `{"result":"http://localhost/a"}`, string(stored.Body))
+	assert.Equal(t, map[string][]string{"Set-Cookie": {"auth=token"}}, stored.Headers)
+
+	_, err = keeper.Begin(ctx, "user", "key-1", Hash([]byte("POST"), []byte("/api/shorten"), []byte(`{"url":"https://github.com/"}`)))
+	assert.ErrorIs(t, err, ErrKeyReused)
+
+	stored, err = keeper.Begin(ctx, "other", "key-1", hash)
+	require.NoError(t, err, "keys are scoped by user")
+	assert.Nil(t, stored)
+}
+
+func TestKeeperRelease(t *testing.T) {
+	ctx := context.Background()
+	keeper := New(&storage.MemStorage{}, func() time.Duration { return time.Minute })
+
+	_, err := keeper.Begin(ctx, "", "key", "hash")
+	require.NoError(t, err)
+	keeper.Release(ctx, "", "key")
+
+	stored, err := keeper.Begin(ctx, "", "key", "hash")
+	require.NoError(t, err, "released key can be used again")
+	assert.Nil(t, stored)
+}
+
+func TestKeeperExpired(t *testing.T) {
+	ctx := context.Background()
+	keeper := New(&storage.MemStorage{}, func() time.Duration { return time.Millisecond })
+
+	_, err := keeper.Begin(ctx, "user", "key", "hash")
+	require.NoError(t, err)
+	keeper.Complete(ctx, "user", "key", http.StatusCreated, "text/plain", nil, []byte("http://localhost/a"))
+	time.Sleep(5 * time.Millisecond)
+
+	stored, err := keeper.Begin(ctx, "user", "key", "another hash")
+	require.NoError(t, err, "expired key can be reused")
+	assert.Nil(t, stored)
+}
+
+func TestValidKey(t *testing.T) {
+	assert.NoError(t, ValidKey("8e03978e-40d5-43e8-bc93-6894a57f9324"))
+	assert.ErrorIs(t, ValidKey(""), ErrInvalidKey)
+	assert.ErrorIs(t, ValidKey("with space"), ErrInvalidKey)
+	assert.ErrorIs(t, ValidKey(strings.Repeat("a", MaxKeyLength+1)), ErrInvalidKey)
+}
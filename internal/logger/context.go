@@ -0,0 +1,115 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/Dorrrke/shortener-url/internal/utils"
+)
+
+// RequestIDHeader - заголовок http и ключ метаданных grpc с id запроса.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength - максимальная длина принимаемого от клиента id запроса.
+const maxRequestIDLength = 128
+
+// ctxKey - тип ключей контекста пакета.
+type ctxKey int
+
+const (
+	loggerKey ctxKey = iota
+	requestIDKey
+)
+
+// FromContext - функция получения логгера запроса с полем request_id.
+// Если контекст не содержит логгера, возвращается Log.
+func FromContext(ctx context.Context) *zap.Logger {
+	if l, ok := ctx.Value(loggerKey).(*zap.Logger); ok {
+		return l
+	}
+	return Log
+}
+
+// RequestIDFromContext - функция получения id запроса из контекста.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithRequestID - функция сохранения id запроса и логгера с этим id в контекст.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey, requestID)
+	return context.WithValue(ctx, loggerKey, Log.With(zap.String("request_id", requestID)))
+}
+
+// withRequestID - функция подготовки запроса: id берется из заголовка X-Request-ID или генерируется,
+// сохраняется в контекст и возвращается клиенту в том же заголовке.
+func withRequestID(w http.ResponseWriter, r *http.Request) *http.Request {
+	requestID := r.Header.Get(RequestIDHeader)
+	if !validRequestID(requestID) {
+		requestID = newRequestID()
+	}
+	w.Header().Set(RequestIDHeader, requestID)
+	return r.WithContext(WithRequestID(r.Context(), requestID))
+}
+
+// UnaryServerInterceptor - функция создания grpc interceptor с id запроса и журналом доступа.
+// id берется из метаданных x-request-id или генерируется и возвращается клиенту в заголовке ответа.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		var requestID, userID string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(RequestIDHeader); len(values) > 0 {
+				requestID = values[0]
+			}
+			if values := md.Get("auth"); len(values) > 0 {
+				userID = utils.GetUID(values[0])
+			}
+		}
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, requestID))
+		ctx = WithRequestID(ctx, requestID)
+
+		resp, err := handler(ctx, req)
+
+		FromContext(ctx).Info("Request",
+			zap.String("method", info.FullMethod),
+			zap.String("code", status.Code(err).String()),
+			zap.Duration("duration", time.Since(start)),
+			zap.String("user_id", userID))
+		return resp, err
+	}
+}
+
+// validRequestID - функция проверки id запроса от клиента: непустая строка из печатных ascii символов ограниченной длины.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID - функция генерации случайного id запроса.
+func newRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
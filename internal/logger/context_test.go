@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/Dorrrke/shortener-url/internal/utils"
+)
+
+func observeLog(t *testing.T) *observer.ObservedLogs {
+	core, logs := observer.New(zapcore.DebugLevel)
+	prev := Log
+	Log = zap.New(core)
+	t.Cleanup(func() { Log = prev })
+	return logs
+}
+
+func TestWithLogging(t *testing.T) {
+	token, err := utils.CreateJWTToken("user-1")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		requestID string
+		wantID    string
+	}{
+		{name: "Incoming request id", requestID: "abc-123", wantID: "abc-123"},
+		{name: "Generated request id"},
+		{name: "Invalid request id replaced", requestID: "bad id\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := observeLog(t)
+			var handlerID string
+			h := WithLogging(func(w http.ResponseWriter, r *http.Request) {
+				handlerID = RequestIDFromContext(r.Context())
+				FromContext(r.Context()).Info("handler")
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte("hello"))
+			})
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tt.requestID != "" {
+				req.Header.Set(RequestIDHeader, tt.requestID)
+			}
+			req.AddCookie(&http.Cookie{Name: "auth", Value: token})
+			rec := httptest.NewRecorder()
+			h(rec, req)
+
+			responseID := rec.Header().Get(RequestIDHeader)
+			if tt.wantID != "" {
+				assert.Equal(t, tt.wantID, responseID)
+			} else {
+				assert.Len(t, responseID, 32)
+			}
+			assert.Equal(t, responseID, handlerID)
+
+			entries := logs.All()
+			require.Len(t, entries, 2)
+			for _, e := range entries {
+				assert.Equal(t, responseID, e.ContextMap()["request_id"])
+			}
+			access := entries[1].ContextMap()
+			assert.Equal(t, int64(http.StatusCreated), access["status"])
+			assert.Equal(t, int64(5), access["size"])
+			assert.Equal(t, "user-1", access["user_id"])
+		})
+	}
+}
+
+func TestInitializeWithOptions(t *testing.T) {
+	prev := Log
+	defer func() { Log = prev }()
+
+	assert.NoError(t, InitializeWithOptions(Options{Level: "debug", Format: "console", Sampling: 10}))
+	assert.True(t, Log.Core().Enabled(zapcore.DebugLevel))
+	assert.Error(t, InitializeWithOptions(Options{Format: "xml"}))
+	assert.Error(t, InitializeWithOptions(Options{Level: "loud"}))
+}
+
+func TestHotPathSampler(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := zap.New(newHotPathSampler(core, 1000))
+	for i := 0; i < samplingInitial+50; i++ {
+		l.Info("hot")
+		l.Error("failure")
+	}
+	assert.Equal(t, samplingInitial, logs.FilterMessage("hot").Len())
+	assert.Equal(t, samplingInitial+50, logs.FilterMessage("failure").Len())
+}
@@ -2,34 +2,136 @@
 package logger
 
 import (
+	"fmt"
 	"net/http"
 	"time"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/Dorrrke/shortener-url/internal/utils"
 )
 
 // Log - Singletone логгера.
 var Log *zap.Logger = zap.NewNop()
 
+// level - текущий уровень логирования, может меняться во время работы через SetLevel.
+var level = zap.NewAtomicLevel()
+
+// Options - параметры логгера.
+type Options struct {
+	// Level - уровень логирования: debug, info, warn, error.
+	Level string
+	// Format - формат записей: json (по умолчанию) или console.
+	Format string
+	// Sampling - после первых 100 одинаковых сообщений уровня ниже warn за секунду записывается каждое Sampling-е, 0 - без сэмплирования.
+	Sampling int
+}
+
+// samplingInitial - количество одинаковых сообщений за секунду, записываемых без сэмплирования.
+const samplingInitial = 100
+
 // Initialize - функция инициализации zap.Logger.
 func Initialize(level string) error {
-	lvl, err := zap.ParseAtomicLevel(level)
+	return InitializeWithOptions(Options{Level: level})
+}
+
+// InitializeWithOptions - функция инициализации zap.Logger с уровнем, форматом и сэмплированием.
+// Сэмплирование применяется только к частым сообщениям уровней debug и info, предупреждения и ошибки пишутся всегда.
+func InitializeWithOptions(opts Options) error {
+	if opts.Level == "" {
+		opts.Level = zap.InfoLevel.String()
+	}
+	lvl, err := zap.ParseAtomicLevel(opts.Level)
 	if err != nil {
 		return err
 	}
 
 	cfg := zap.NewProductionConfig()
 	cfg.Level = lvl
+	cfg.Sampling = nil
+	switch opts.Format {
+	case "", "json":
+	case "console":
+		cfg.Encoding = "console"
+		cfg.EncoderConfig = zap.NewDevelopmentEncoderConfig()
+	default:
+		return fmt.Errorf("unknown log format %q", opts.Format)
+	}
 
-	zl, err := cfg.Build()
+	var buildOpts []zap.Option
+	if opts.Sampling > 0 {
+		buildOpts = append(buildOpts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return newHotPathSampler(core, opts.Sampling)
+		}))
+	}
+	zl, err := cfg.Build(buildOpts...)
 	if err != nil {
 		return err
 	}
 
+	level = lvl
 	Log = zl
 	return nil
 }
 
+// SetLevel - функция изменения уровня логирования без пересоздания логгера.
+func SetLevel(lvl string) error {
+	parsed, err := zapcore.ParseLevel(lvl)
+	if err != nil {
+		return err
+	}
+	level.SetLevel(parsed)
+	return nil
+}
+
+// newHotPathSampler - функция создания ядра, сэмплирующего сообщения уровней ниже warn.
+func newHotPathSampler(core zapcore.Core, thereafter int) zapcore.Core {
+	sampled := zapcore.NewSamplerWithOptions(levelFilter{Core: core, enabled: func(l zapcore.Level) bool {
+		return l < zapcore.WarnLevel
+	}}, time.Second, samplingInitial, thereafter)
+	return zapcore.NewTee(sampled, levelFilter{Core: core, enabled: func(l zapcore.Level) bool {
+		return l >= zapcore.WarnLevel
+	}})
+}
+
+// levelFilter - ядро, пропускающее только записи выбранных уровней.
+type levelFilter struct {
+	zapcore.Core
+	enabled func(zapcore.Level) bool
+}
+
+// Enabled - метод проверки уровня записи.
+func (f levelFilter) Enabled(l zapcore.Level) bool {
+	return f.enabled(l) && f.Core.Enabled(l)
+}
+
+// With - метод добавления полей с сохранением фильтра уровней.
+func (f levelFilter) With(fields []zapcore.Field) zapcore.Core {
+	return levelFilter{Core: f.Core.With(fields), enabled: f.enabled}
+}
+
+// Check - метод добавления ядра в запись, если уровень проходит фильтр.
+func (f levelFilter) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if f.Enabled(e.Level) {
+		return ce.AddCore(e, f)
+	}
+	return ce
+}
+
+// RequestObserver - получатель данных о запросе, собранных WithLogging, например для метрик.
+// status равен 0, если хендлер не вызывал WriteHeader явно.
+type RequestObserver func(r *http.Request, status int, size int, duration time.Duration)
+
+// requestObservers - получатели данных о запросах.
+var requestObservers []RequestObserver
+
+// AddRequestObserver - функция подключения получателя данных о запросах.
+// Должна вызываться до запуска сервера.
+func AddRequestObserver(observer RequestObserver) {
+	requestObservers = append(requestObservers, observer)
+}
+
 // Структуры данных для логирования запросов.
 type (
 	responceData struct {
@@ -57,10 +159,13 @@ func (r *loggingResponseWriter) WriteHeader(statusCode int) {
 }
 
 // WithLogging - middleware для логгирвоания запростов к серверу.
+// Для каждого запроса пишется одна строка журнала доступа с id запроса, id пользователя, размером ответа и временем обработки.
 func WithLogging(h http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
+		r = withRequestID(w, r)
+
 		responceData := &responceData{
 			status: 0,
 			size:   0,
@@ -71,21 +176,38 @@ func WithLogging(h http.HandlerFunc) http.HandlerFunc {
 			responceData:   responceData,
 		}
 
-		uri := r.RequestURI
-
-		method := r.Method
-
 		h.ServeHTTP(&lw, r)
 
 		duration := time.Since(start)
+		for _, observe := range requestObservers {
+			observe(r, responceData.status, responceData.size, duration)
+		}
 
-		Log.Info("Request: ",
-			zap.String("method", method),
-			zap.String("uri", uri),
-			zap.String("duration", duration.String()))
-
-		Log.Info("Response: ",
-			zap.Int("status", responceData.status),
-			zap.Int("size", (responceData.size)))
+		status := responceData.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		FromContext(r.Context()).Info("Request",
+			zap.String("method", r.Method),
+			zap.String("uri", r.RequestURI),
+			zap.Int("status", status),
+			zap.Int("size", responceData.size),
+			zap.Duration("duration", duration),
+			zap.String("user_id", requestUserID(w, r)),
+			zap.String("remote_addr", r.RemoteAddr))
 	})
 }
+
+// requestUserID - функция получения id пользователя запроса из cookie auth.
+// Если хендлер выдал новый токен, id берется из ответа.
+func requestUserID(w http.ResponseWriter, r *http.Request) string {
+	for _, cookie := range (&http.Response{Header: w.Header()}).Cookies() {
+		if cookie.Name == "auth" {
+			return utils.GetUID(cookie.Value)
+		}
+	}
+	if cookie, err := r.Cookie("auth"); err == nil {
+		return utils.GetUID(cookie.Value)
+	}
+	return ""
+}
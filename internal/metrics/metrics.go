@@ -0,0 +1,163 @@
+// Пакет metrics содержит метрики сервиса в формате Prometheus:
+// запросы http и grpc, время операций хранилища, очередь удаления, кэш переходов и количество активных ссылок.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+
+	"github.com/Dorrrke/shortener-url/internal/logger"
+	"github.com/Dorrrke/shortener-url/internal/storage"
+)
+
+// namespace - общий префикс имен метрик.
+const namespace = "shortener"
+
+// collectTimeout - время ожидания хранилища при сборе количества активных ссылок.
+const collectTimeout = 2 * time.Second
+
+// Metrics - набор метрик сервиса со своим реестром.
+type Metrics struct {
+	Registry        *prometheus.Registry
+	httpRequests    *prometheus.CounterVec
+	httpDuration    *prometheus.HistogramVec
+	grpcRequests    *prometheus.CounterVec
+	grpcDuration    *prometheus.HistogramVec
+	storageDuration *prometheus.HistogramVec
+}
+
+// New - функция создания и регистрации метрик, в реестр также добавляются метрики рантайма go и процесса.
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of http requests by method, route and status.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of http requests by method, route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		grpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "grpc_requests_total",
+			Help:      "Number of grpc calls by method and code.",
+		}, []string{"method", "code"}),
+		grpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "grpc_request_duration_seconds",
+			Help:      "Latency of grpc calls by method and code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+		storageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "storage_operation_duration_seconds",
+			Help:      "Latency of storage operations by operation and result.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"operation", "result"}),
+	}
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.grpcRequests,
+		m.grpcDuration,
+		m.storageDuration,
+	)
+	return m
+}
+
+// Handler - метод создания http хендлера, отдающего метрики.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{Registry: m.Registry})
+}
+
+// ObserveHTTP - метод учета http запроса, подключается к logger.WithLogging через logger.AddRequestObserver.
+// Маршрутом служит шаблон chi, например /{id}, чтобы количество рядов не зависело от количества ссылок.
+func (m *Metrics) ObserveHTTP(r *http.Request, status int, _ int, duration time.Duration) {
+	if status == 0 {
+		status = http.StatusOK
+	}
+	route := "unknown"
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+		route = rctx.RoutePattern()
+	}
+	labels := prometheus.Labels{"method": r.Method, "route": route, "status": strconv.Itoa(status)}
+	m.httpRequests.With(labels).Inc()
+	m.httpDuration.With(labels).Observe(duration.Seconds())
+}
+
+// RegisterDeleteQueue - метод регистрации метрики глубины очереди удаления.
+func (m *Metrics) RegisterDeleteQueue(depth func() int) {
+	m.Registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "delete_queue_depth",
+		Help:      "Number of urls waiting in the deletion queue.",
+	}, func() float64 {
+		return float64(depth())
+	}))
+}
+
+// RegisterCache - метод регистрации счетчиков попаданий и промахов кэша переходов.
+func (m *Metrics) RegisterCache(cached *storage.CachedStorage) {
+	m.Registry.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_hits_total",
+			Help:      "Number of redirect cache hits.",
+		}, func() float64 {
+			return float64(cached.Stats().Hits)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_misses_total",
+			Help:      "Number of redirect cache misses.",
+		}, func() float64 {
+			return float64(cached.Stats().Misses)
+		}),
+	)
+}
+
+// RegisterActiveLinks - метод регистрации метрики количества неудаленных ссылок.
+// Количество запрашивается у хранилища при каждом сборе метрик, при ошибке метрика пропускается.
+func (m *Metrics) RegisterActiveLinks(stor storage.Storage) {
+	m.Registry.MustRegister(&activeLinksCollector{
+		stor: stor,
+		desc: prometheus.NewDesc(namespace+"_active_links", "Number of not deleted short urls.", nil, nil),
+	})
+}
+
+// activeLinksCollector - сборщик количества активных ссылок.
+type activeLinksCollector struct {
+	stor storage.Storage
+	desc *prometheus.Desc
+}
+
+// Describe - метод описания метрик сборщика.
+func (c *activeLinksCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect - метод сбора метрик.
+func (c *activeLinksCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+	count, err := c.stor.CountActiveURLs(ctx)
+	if err != nil {
+		logger.Log.Error("Error count active urls", zap.Error(err))
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count))
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Dorrrke/shortener-url/internal/cache"
+	"github.com/Dorrrke/shortener-url/internal/logger"
+	"github.com/Dorrrke/shortener-url/internal/storage"
+)
+
+func TestObserveHTTP(t *testing.T) {
+	m := New()
+	logger.AddRequestObserver(m.ObserveHTTP)
+
+	r := chi.NewRouter()
+	r.Get("/{id}", logger.WithLogging(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTemporaryRedirect)
+	}))
+	r.Get("/ping", logger.WithLogging(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	for _, target := range []string{"/abc", "/def", "/ping"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.httpRequests.WithLabelValues(http.MethodGet, "/{id}", "307")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.httpRequests.WithLabelValues(http.MethodGet, "/ping", "200")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.httpDuration))
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	m := New()
+	interceptor := m.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/shortenergrpc.Shortener/GetOriginalURL"}
+
+	_, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		return "ok", nil
+	})
+	require.NoError(t, err)
+	_, err = interceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		return nil, status.Error(codes.NotFound, "Url was deleted")
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.grpcRequests.WithLabelValues(info.FullMethod, "OK")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.grpcRequests.WithLabelValues(info.FullMethod, "NotFound")))
+}
+
+func TestStorageMetrics(t *testing.T) {
+	ctx := context.Background()
+	m := New()
+	stor := storage.NewCachedStorage(m.WrapStorage(&storage.MemStorage{URLMap: make(map[string]string)}), cache.NewLRU(10), time.Minute, time.Minute)
+	m.RegisterCache(stor)
+	m.RegisterActiveLinks(stor)
+	m.RegisterDeleteQueue(func() int { return 3 })
+
+	require.NoError(t, stor.InsertURL(ctx, "https://go.dev/", "http://localhost/a", "user"))
+	require.NoError(t, stor.InsertURL(ctx, "https://github.com/", "http://localhost/b", "user"))
+	_, err := stor.SetDeleteURLStatus(ctx, []string{"http://localhost/b"}, "user")
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, _, err := stor.GetOriginalURLByShort(ctx, "http://localhost/a")
+		require.NoError(t, err)
+	}
+
+	expected := `
+# HELP shortener_active_links Number of not deleted short urls.
+# TYPE shortener_active_links gauge
+shortener_active_links 1
+# HELP shortener_cache_hits_total Number of redirect cache hits.
+# TYPE shortener_cache_hits_total counter
+shortener_cache_hits_total 2
+# HELP shortener_cache_misses_total Number of redirect cache misses.
+# TYPE shortener_cache_misses_total counter
+shortener_cache_misses_total 1
+# HELP shortener_delete_queue_depth Number of urls waiting in the deletion queue.
+# TYPE shortener_delete_queue_depth gauge
+shortener_delete_queue_depth 3
+`
+	require.NoError(t, testutil.GatherAndCompare(m.Registry, strings.NewReader(expected),
+		"shortener_active_links", "shortener_cache_hits_total", "shortener_cache_misses_total", "shortener_delete_queue_depth"))
+
+	count, err := testutil.GatherAndCount(m.Registry, "shortener_storage_operation_duration_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 4, count, "insert_url, set_deleted, get_original_url and count_active_urls")
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "go_goroutines")
+}
@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Dorrrke/shortener-url/internal/models"
+	"github.com/Dorrrke/shortener-url/internal/storage"
+)
+
+// instrumentedStorage - декоратор Storage, измеряющий время операций хранилища.
+type instrumentedStorage struct {
+	stor    storage.Storage
+	metrics *Metrics
+}
+
+// WrapStorage - метод оборачивания хранилища измерением времени операций.
+func (m *Metrics) WrapStorage(stor storage.Storage) storage.Storage {
+	return &instrumentedStorage{stor: stor, metrics: m}
+}
+
+// observe - метод учета времени операции, результат - ok, not_found, conflict или error.
+func (s *instrumentedStorage) observe(operation string, start time.Time, err error) {
+	result := "ok"
+	switch {
+	case err == nil:
+	case errors.Is(err, storage.ErrNotFound):
+		result = "not_found"
+	case errors.Is(err, storage.ErrConflict):
+		result = "conflict"
+	default:
+		result = "error"
+	}
+	s.metrics.storageDuration.WithLabelValues(operation, result).Observe(time.Since(start).Seconds())
+}
+
+func (s *instrumentedStorage) InsertURL(ctx context.Context, originalURL string, shortURL string, userID string) (err error) {
+	defer func(start time.Time) { s.observe("insert_url", start, err) }(time.Now())
+	return s.stor.InsertURL(ctx, originalURL, shortURL, userID)
+}
+
+func (s *instrumentedStorage) InsertProtectedURL(ctx context.Context, originalURL string, shortURL string, userID string, passwordHash string) (err error) {
+	defer func(start time.Time) { s.observe("insert_url", start, err) }(time.Now())
+	return s.stor.InsertProtectedURL(ctx, originalURL, shortURL, userID, passwordHash)
+}
+
+func (s *instrumentedStorage) GetURLPassword(ctx context.Context, shortURL string) (_ string, err error) {
+	defer func(start time.Time) { s.observe("get_password", start, err) }(time.Now())
+	return s.stor.GetURLPassword(ctx, shortURL)
+}
+
+func (s *instrumentedStorage) GetAllUrls(ctx context.Context, userID string) (_ []models.URLModel, err error) {
+	defer func(start time.Time) { s.observe("get_all_urls", start, err) }(time.Now())
+	return s.stor.GetAllUrls(ctx, userID)
+}
+
+func (s *instrumentedStorage) GetOriginalURLByShort(ctx context.Context, shotURL string) (_ string, _ bool, err error) {
+	defer func(start time.Time) { s.observe("get_original_url", start, err) }(time.Now())
+	return s.stor.GetOriginalURLByShort(ctx, shotURL)
+}
+
+func (s *instrumentedStorage) GetShortByOriginalURL(ctx context.Context, original string) (_ string, err error) {
+	defer func(start time.Time) { s.observe("get_short_url", start, err) }(time.Now())
+	return s.stor.GetShortByOriginalURL(ctx, original)
+}
+
+func (s *instrumentedStorage) CheckDBConnect(ctx context.Context) (err error) {
+	defer func(start time.Time) { s.observe("check_connect", start, err) }(time.Now())
+	return s.stor.CheckDBConnect(ctx)
+}
+
+func (s *instrumentedStorage) CreateTable(ctx context.Context) (err error) {
+	defer func(start time.Time) { s.observe("create_table", start, err) }(time.Now())
+	return s.stor.CreateTable(ctx)
+}
+
+func (s *instrumentedStorage) InsertBanchURL(ctx context.Context, value []models.BantchURL) (err error) {
+	defer func(start time.Time) { s.observe("insert_batch", start, err) }(time.Now())
+	return s.stor.InsertBanchURL(ctx, value)
+}
+
+func (s *instrumentedStorage) SetDeleteURLStatus(ctx context.Context, value []string, userID string) (_ []string, err error) {
+	defer func(start time.Time) { s.observe("set_deleted", start, err) }(time.Now())
+	return s.stor.SetDeleteURLStatus(ctx, value, userID)
+}
+
+func (s *instrumentedStorage) RestoreURLs(ctx context.Context, value []string, userID string) (_ []string, err error) {
+	defer func(start time.Time) { s.observe("restore", start, err) }(time.Now())
+	return s.stor.RestoreURLs(ctx, value, userID)
+}
+
+func (s *instrumentedStorage) ExportURLs(ctx context.Context, userID string, fn func(models.ExportURL) error) (err error) {
+	defer func(start time.Time) { s.observe("export", start, err) }(time.Now())
+	return s.stor.ExportURLs(ctx, userID, fn)
+}
+
+func (s *instrumentedStorage) LoadURLs(ctx context.Context, urls []models.ExportURL) (_ int, err error) {
+	defer func(start time.Time) { s.observe("load", start, err) }(time.Now())
+	return s.stor.LoadURLs(ctx, urls)
+}
+
+func (s *instrumentedStorage) GetStats(ctx context.Context) (_ int, _ int, err error) {
+	defer func(start time.Time) { s.observe("get_stats", start, err) }(time.Now())
+	return s.stor.GetStats(ctx)
+}
+
+func (s *instrumentedStorage) CountUserURLs(ctx context.Context, userID string) (_ int, err error) {
+	defer func(start time.Time) { s.observe("count_user_urls", start, err) }(time.Now())
+	return s.stor.CountUserURLs(ctx, userID)
+}
+
+func (s *instrumentedStorage) CountActiveURLs(ctx context.Context) (_ int, err error) {
+	defer func(start time.Time) { s.observe("count_active_urls", start, err) }(time.Now())
+	return s.stor.CountActiveURLs(ctx)
+}
+
+func (s *instrumentedStorage) Clear(ctx context.Context) (err error) {
+	defer func(start time.Time) { s.observe("clear", start, err) }(time.Now())
+	return s.stor.Clear(ctx)
+}
+
+func (s *instrumentedStorage) ReserveIdempotencyKey(ctx context.Context, rec models.IdempotencyRecord) (_ models.IdempotencyRecord, err error) {
+	defer func(start time.Time) { s.observe("reserve_idempotency_key", start, err) }(time.Now())
+	return s.stor.ReserveIdempotencyKey(ctx, rec)
+}
+
+func (s *instrumentedStorage) CompleteIdempotencyKey(ctx context.Context, rec models.IdempotencyRecord) (err error) {
+	defer func(start time.Time) { s.observe("complete_idempotency_key", start, err) }(time.Now())
+	return s.stor.CompleteIdempotencyKey(ctx, rec)
+}
+
+func (s *instrumentedStorage) ReleaseIdempotencyKey(ctx context.Context, scope string, key string) (err error) {
+	defer func(start time.Time) { s.observe("release_idempotency_key", start, err) }(time.Now())
+	return s.stor.ReleaseIdempotencyKey(ctx, scope, key)
+}
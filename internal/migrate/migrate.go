@@ -0,0 +1,230 @@
+// Пакет migrate содержит перенос сокращенных url между хранилищами: из файла хранилища в базу данных и обратно.
+// Url переносятся вместе с владельцами, отметками удаления и временем создания пачками через Storage.LoadURLs.
+package migrate
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/Dorrrke/shortener-url/internal/models"
+	"github.com/Dorrrke/shortener-url/internal/storage"
+)
+
+// DefaultChunkSize - размер пачки по умолчанию.
+const DefaultChunkSize = 500
+
+// maxMissing - максимальное количество не найденных при проверке url в результате.
+const maxMissing = 20
+
+// ErrVerify - после переноса не все url источника найдены в хранилище назначения.
+var ErrVerify = errors.New("verification failed")
+
+// Options - параметры переноса.
+type Options struct {
+	// ChunkSize - количество url, сохраняемых за один вызов LoadURLs, по умолчанию DefaultChunkSize.
+	ChunkSize int
+	// DryRun - только подсчитать, сколько url будет перенесено, не изменяя хранилище назначения и позицию.
+	DryRun bool
+	// StatePath - файл позиции для продолжения прерванного переноса, пустой путь отключает сохранение позиции.
+	// После успешной проверки файл удаляется.
+	StatePath string
+	// Key - идентификатор пары хранилищ: позиция, сохраненная для другой пары, не используется.
+	Key string
+	// Progress - функция, вызываемая после каждой пачки.
+	Progress func(Result)
+}
+
+// Result - итоги переноса.
+type Result struct {
+	// Source - количество url в источнике.
+	Source int `json:"source"`
+	// Resumed - количество url, пропущенных по сохраненной позиции прерванного переноса.
+	Resumed int `json:"resumed"`
+	// Copied - количество сохраненных url, при DryRun - количество url, которые будут сохранены.
+	Copied int `json:"copied"`
+	// Skipped - количество url, которые уже есть в хранилище назначения или конфликтуют с ним по оригинальному url.
+	Skipped int `json:"skipped"`
+	// Verified - количество url источника, найденных в хранилище назначения с тем же оригинальным url.
+	Verified int `json:"verified"`
+	// Missing - первые url источника, не найденные в хранилище назначения.
+	Missing []string `json:"missing,omitempty"`
+}
+
+// state - позиция переноса в файле Options.StatePath.
+type state struct {
+	Key  string `json:"key"`
+	Done int    `json:"done"`
+}
+
+// Run - функция переноса url из src в dst.
+// Url читаются из источника по порядку пачками по opts.ChunkSize, после каждой пачки позиция сохраняется в файл,
+// поэтому прерванный перенос продолжается с последней сохраненной пачки. Повтор пачки безопасен:
+// LoadURLs пропускает уже сохраненные url. В конце url источника сверяются с хранилищем назначения,
+// при расхождении возвращается ошибка, оборачивающая ErrVerify.
+func Run(ctx context.Context, src storage.Storage, dst storage.Storage, opts Options) (Result, error) {
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = DefaultChunkSize
+	}
+	var res Result
+	done := 0
+	if !opts.DryRun {
+		saved, err := loadState(opts.StatePath)
+		if err != nil {
+			return res, err
+		}
+		if saved.Key == opts.Key {
+			done = saved.Done
+		}
+	}
+
+	chunk := make([]models.ExportURL, 0, opts.ChunkSize)
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		copied, err := copyChunk(ctx, dst, chunk, opts.DryRun)
+		if err != nil {
+			return err
+		}
+		res.Copied += copied
+		res.Skipped += len(chunk) - copied
+		if !opts.DryRun {
+			if err := saveState(opts.StatePath, state{Key: opts.Key, Done: res.Source}); err != nil {
+				return err
+			}
+		}
+		chunk = chunk[:0]
+		if opts.Progress != nil {
+			opts.Progress(res)
+		}
+		return nil
+	}
+	err := src.ExportURLs(ctx, "", func(url models.ExportURL) error {
+		res.Source++
+		if res.Source <= done {
+			res.Resumed++
+			return nil
+		}
+		chunk = append(chunk, url)
+		if len(chunk) < opts.ChunkSize {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return res, errors.Wrapf(err, "copy urls after %d of source", res.Source-len(chunk))
+	}
+	if opts.DryRun {
+		return res, nil
+	}
+
+	if err := verify(ctx, src, dst, &res); err != nil {
+		return res, err
+	}
+	if opts.StatePath != "" {
+		if err := os.Remove(opts.StatePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// copyChunk - функция сохранения пачки url, возвращает количество сохраненных url.
+// При dryRun url не сохраняются, а проверяются: url, сокращенный или оригинальный url которого уже есть в dst, будет пропущен.
+func copyChunk(ctx context.Context, dst storage.Storage, chunk []models.ExportURL, dryRun bool) (int, error) {
+	if !dryRun {
+		return dst.LoadURLs(ctx, chunk)
+	}
+	copied := 0
+	for _, url := range chunk {
+		exists, err := existsIn(ctx, dst, url)
+		if err != nil {
+			return 0, err
+		}
+		if !exists {
+			copied++
+		}
+	}
+	return copied, nil
+}
+
+// existsIn - функция проверки, что сокращенный или оригинальный url уже сохранен в stor.
+func existsIn(ctx context.Context, stor storage.Storage, url models.ExportURL) (bool, error) {
+	if _, _, err := stor.GetOriginalURLByShort(ctx, url.ShortURL); !errors.Is(err, storage.ErrNotFound) {
+		return err == nil, err
+	}
+	if _, err := stor.GetShortByOriginalURL(ctx, url.OriginalURL); !errors.Is(err, storage.ErrNotFound) {
+		return err == nil, err
+	}
+	return false, nil
+}
+
+// verify - функция сверки url источника с хранилищем назначения.
+func verify(ctx context.Context, src storage.Storage, dst storage.Storage, res *Result) error {
+	copied := make(map[string]string)
+	if err := dst.ExportURLs(ctx, "", func(url models.ExportURL) error {
+		copied[url.ShortURL] = url.OriginalURL
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "read destination")
+	}
+	missing := 0
+	if err := src.ExportURLs(ctx, "", func(url models.ExportURL) error {
+		if original, ok := copied[url.ShortURL]; ok && original == url.OriginalURL {
+			res.Verified++
+			return nil
+		}
+		missing++
+		if len(res.Missing) < maxMissing {
+			res.Missing = append(res.Missing, url.ShortURL)
+		}
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "read source")
+	}
+	if missing > 0 {
+		return errors.Wrapf(ErrVerify, "%d of %d source urls not found in destination", missing, res.Verified+missing)
+	}
+	return nil
+}
+
+// loadState - функция чтения позиции переноса, отсутствующий файл означает перенос с начала.
+func loadState(path string) (state, error) {
+	var st state
+	if path == "" {
+		return st, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return st, err
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		return st, errors.Wrap(err, "decode migration state")
+	}
+	return st, nil
+}
+
+// saveState - функция сохранения позиции переноса через временный файл, чтобы прерывание не оставило файл поврежденным.
+func saveState(path string, st state) error {
+	if path == "" {
+		return nil
+	}
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
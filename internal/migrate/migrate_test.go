@@ -0,0 +1,129 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dorrrke/shortener-url/internal/models"
+	"github.com/Dorrrke/shortener-url/internal/storage"
+)
+
+// failingStorage - хранилище, возвращающее ошибку после failAfter сохраненных пачек.
+type failingStorage struct {
+	*storage.MemStorage
+	failAfter int
+	loads     int
+}
+
+func (s *failingStorage) LoadURLs(ctx context.Context, urls []models.ExportURL) (int, error) {
+	if s.loads == s.failAfter {
+		return 0, errors.New("connection lost")
+	}
+	s.loads++
+	return s.MemStorage.LoadURLs(ctx, urls)
+}
+
+// newSource - функция создания файла хранилища с count url, каждый третий url удален.
+func newSource(t *testing.T, count int) storage.Storage {
+	t.Helper()
+	ctx := context.Background()
+	src, err := storage.OpenFileStorage(ctx, filepath.Join(t.TempDir(), "short-url-db.json"))
+	require.NoError(t, err)
+	urls := make([]models.ExportURL, 0, count)
+	for i := 0; i < count; i++ {
+		urls = append(urls, models.ExportURL{
+			ShortURL:    fmt.Sprintf("http://localhost/%03d", i),
+			OriginalURL: fmt.Sprintf("https://example.com/%d", i),
+			UserID:      fmt.Sprintf("user-%d", i%4),
+			Deleted:     i%3 == 0,
+			CreatedAt:   time.Date(2024, 1, 1, 0, i, 0, 0, time.UTC),
+		})
+	}
+	_, err = src.LoadURLs(ctx, urls)
+	require.NoError(t, err)
+	return src
+}
+
+// exportAll - функция выгрузки всех url хранилища.
+func exportAll(t *testing.T, stor storage.Storage) []models.ExportURL {
+	t.Helper()
+	var urls []models.ExportURL
+	require.NoError(t, stor.ExportURLs(context.Background(), "", func(url models.ExportURL) error {
+		urls = append(urls, url)
+		return nil
+	}))
+	return urls
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	src := newSource(t, 10)
+	dst := &storage.MemStorage{URLMap: make(map[string]string)}
+	require.NoError(t, dst.InsertURL(ctx, "https://example.com/1", "http://localhost/001", "user-1"))
+
+	res, err := Run(ctx, src, dst, Options{ChunkSize: 3, DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, Result{Source: 10, Copied: 9, Skipped: 1}, res)
+	assert.Len(t, exportAll(t, dst), 1, "dry run does not change destination")
+
+	statePath := filepath.Join(t.TempDir(), "state")
+	var progress []int
+	res, err = Run(ctx, src, dst, Options{ChunkSize: 3, StatePath: statePath, Progress: func(r Result) {
+		progress = append(progress, r.Source)
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, Result{Source: 10, Copied: 9, Skipped: 1, Verified: 10}, res)
+	assert.Equal(t, []int{3, 6, 9, 10}, progress)
+	assert.NoFileExists(t, statePath)
+
+	srcURLs := exportAll(t, src)
+	dstURLs := exportAll(t, dst)
+	require.Len(t, dstURLs, 10)
+	for i := range srcURLs {
+		if srcURLs[i].ShortURL == "http://localhost/001" {
+			continue
+		}
+		assert.Equal(t, srcURLs[i], dstURLs[i], "owner, deleted flag and time are copied")
+	}
+}
+
+func TestRunResume(t *testing.T) {
+	ctx := context.Background()
+	src := newSource(t, 10)
+	dst := &failingStorage{MemStorage: &storage.MemStorage{URLMap: make(map[string]string)}, failAfter: 2}
+	statePath := filepath.Join(t.TempDir(), "state")
+	opts := Options{ChunkSize: 3, StatePath: statePath, Key: "file->mem"}
+
+	_, err := Run(ctx, src, dst, opts)
+	require.Error(t, err)
+	assert.FileExists(t, statePath)
+	assert.Len(t, exportAll(t, dst), 6)
+
+	dst.failAfter = -1
+	res, err := Run(ctx, src, dst, opts)
+	require.NoError(t, err)
+	assert.Equal(t, Result{Source: 10, Resumed: 6, Copied: 4, Verified: 10}, res)
+	assert.NoFileExists(t, statePath)
+}
+
+func TestRunVerify(t *testing.T) {
+	ctx := context.Background()
+	src := newSource(t, 3)
+	dst := &storage.MemStorage{URLMap: make(map[string]string)}
+	// Оригинальный url уже сокращен в назначении под другим сокращенным url.
+	require.NoError(t, dst.InsertURL(ctx, "https://example.com/2", "http://localhost/other", "user-2"))
+
+	res, err := Run(ctx, src, dst, Options{StatePath: filepath.Join(t.TempDir(), "state")})
+	require.ErrorIs(t, err, ErrVerify)
+	assert.Equal(t, 2, res.Copied)
+	assert.Equal(t, 1, res.Skipped)
+	assert.Equal(t, 2, res.Verified)
+	assert.Equal(t, []string{"http://localhost/002"}, res.Missing)
+}
@@ -1,9 +1,13 @@
 // Пакет с описание моделей для запросов к базе данных и сериализации и десириализации в и из json.
 package models
 
+import "time"
+
 // RequestURLJson - модель для работы с запросом в теле которого приходит url для сокращения в формате json.
 type RequestURLJson struct {
 	URLAddres string `json:"url"`
+	// Password - необязательный пароль, без которого нельзя перейти по сокращенному url.
+	Password string `json:"password,omitempty"`
 }
 
 // ResponseURLJson - модель для работы с ответом на запрос, в теле которого отправляется сокращенный url в формате json.
@@ -33,6 +37,16 @@ type URLModel struct {
 type StatModel struct {
 	URLsCount  int `json:"urls"`
 	UsercCount int `json:"users"`
+	// CacheHits и CacheMisses - счетчики кэша переходов, заполняются при включенном кэше.
+	CacheHits   int64 `json:"cache_hits,omitempty"`
+	CacheMisses int64 `json:"cache_misses,omitempty"`
+}
+
+// QuotaModel - модель для возврата текущего использования квот пользователя, 0 в лимите означает отсутствие ограничения.
+type QuotaModel struct {
+	LinksUsed  int `json:"links_used"`
+	LinksLimit int `json:"links_limit"`
+	BatchLimit int `json:"batch_limit"`
 }
 
 // BantchURL - для отправки на сохранение в базу данных нескольких скоращнных url сразу.
@@ -42,7 +56,100 @@ type BantchURL struct {
 	UserID      string
 }
 
-type RestorURL struct {
+// ExportURL - модель сокращенного url для выгрузки и загрузки ссылок вместе с владельцем и отметкой удаления.
+type ExportURL struct {
 	ShortURL    string `json:"short_url"`
 	OriginalURL string `json:"original_url"`
+	UserID      string `json:"user_id,omitempty"`
+	Deleted     bool   `json:"deleted"`
+	// CreatedAt - время сокращения, нулевое, если неизвестно.
+	CreatedAt time.Time `json:"created_at"`
+	// PasswordHash - bcrypt хеш пароля защищенной ссылки, записывается только в полную выгрузку.
+	PasswordHash string `json:"password_hash,omitempty"`
+	// Protected - отметка защищенной паролем ссылки в выгрузке пользователя, где хеш пароля не записывается.
+	Protected bool `json:"protected,omitempty"`
+}
+
+// ImportReport - модель итогов загрузки ссылок.
+type ImportReport struct {
+	Total    int `json:"total"`
+	Imported int `json:"imported"`
+	Existing int `json:"existing"`
+	Failed   int `json:"failed"`
+	// Errors - ошибки строк, не больше ограничения, при превышении ErrorsTruncated равен true.
+	Errors          []ImportError `json:"errors"`
+	ErrorsTruncated bool          `json:"errors_truncated,omitempty"`
+}
+
+// ImportError - модель ошибки строки загружаемого файла.
+type ImportError struct {
+	Line   int    `json:"line"`
+	Field  string `json:"field,omitempty"`
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
+}
+
+// RestorURL - модель записи файла хранилища.
+// Запись с оригинальным url сохраняет новый url, запись без него - изменение отметки удаления ранее сохраненного url.
+// В записях старого формата есть только сокращенный и оригинальный url.
+type RestorURL struct {
+	ShortURL    string     `json:"short_url"`
+	OriginalURL string     `json:"original_url,omitempty"`
+	UserID      string     `json:"user_id,omitempty"`
+	Deleted     bool       `json:"deleted,omitempty"`
+	Restored    bool       `json:"restored,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	// PasswordHash - хеш пароля защищенной ссылки.
+	PasswordHash string `json:"password_hash,omitempty"`
+}
+
+// ProblemContentType - тип ответа с описанием ошибки по RFC 7807.
+const ProblemContentType = "application/problem+json"
+
+// Problem - модель ответа с описанием ошибки по RFC 7807.
+type Problem struct {
+	// Type - ссылка на описание вида ошибки.
+	Type string `json:"type"`
+	// Title - краткое описание вида ошибки.
+	Title string `json:"title"`
+	// Status - http статус ответа.
+	Status int `json:"status"`
+	// Detail - описание конкретной ошибки.
+	Detail string `json:"detail,omitempty"`
+	// Instance - путь запроса, на который получена ошибка.
+	Instance string `json:"instance,omitempty"`
+	// Code - машиночитаемый код ошибки.
+	Code string `json:"code"`
+	// RequestID - id запроса для поиска в логах.
+	RequestID string `json:"request_id,omitempty"`
+	// InvalidParams - поля запроса, не прошедшие проверку.
+	InvalidParams []InvalidParam `json:"invalid_params,omitempty"`
+	// ExistingShortURL - уже сохраненный сокращенный url, с которым конфликтует запрос.
+	ExistingShortURL string `json:"existing_short_url,omitempty"`
+}
+
+// InvalidParam - поле запроса, не прошедшее проверку.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// IdempotencyRecord - запрос с ключом идемпотентности и сохраненный ответ на него.
+type IdempotencyRecord struct {
+	// Scope - область ключа: id пользователя или пустая строка для анонимных запросов.
+	Scope string
+	// Key - ключ идемпотентности из заголовка Idempotency-Key или метаданных idempotency-key.
+	Key string
+	// RequestHash - хэш метода, маршрута и тела запроса.
+	RequestHash string
+	// Status - статус ответа, 0 - запрос еще выполняется.
+	Status int
+	// ContentType - тип тела ответа.
+	ContentType string
+	// Body - тело ответа.
+	Body []byte
+	// Headers - заголовки ответа (в gRPC - метаданные), повторяемые вместе с ним, например выданная анонимному клиенту cookie auth.
+	Headers map[string][]string
+	// ExpiresAt - время, после которого ключ можно использовать заново.
+	ExpiresAt time.Time
 }
@@ -0,0 +1,465 @@
+// Генератор кода по спецификации OpenAPI сервиса: интерфейс хендлеров для пакета internal/openapi
+// и типизированный REST клиент для пакета pkg/api. Запускается через go generate ./internal/openapi.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/Dorrrke/shortener-url/internal/openapi"
+)
+
+// problemMediaType - тип ответа с ошибкой по RFC 7807, такие ответы разбираются в поле Problem.
+const problemMediaType = "application/problem+json"
+
+// initialisms - сокращения, которые в именах Go пишутся заглавными буквами.
+var initialisms = map[string]string{
+	"api":  "API",
+	"db":   "DB",
+	"id":   "ID",
+	"ip":   "IP",
+	"json": "JSON",
+	"url":  "URL",
+	"urls": "URLs",
+}
+
+func main() {
+	specPath := flag.String("spec", "openapi.json", "путь к спецификации OpenAPI")
+	serverPath := flag.String("server", "", "файл для интерфейса хендлеров")
+	clientPath := flag.String("client", "", "файл для REST клиента")
+	flag.Parse()
+
+	data, err := os.ReadFile(*specPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	doc, err := openapi.Load(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *serverPath != "" {
+		if err := write(*serverPath, func() ([]byte, error) { return generateServer(doc) }); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *clientPath != "" {
+		if err := write(*clientPath, func() ([]byte, error) { return generateClient(doc) }); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// write - функция записи сгенерированного кода в файл path.
+func write(path string, generate func() ([]byte, error)) error {
+	src, err := generate()
+	if err != nil {
+		return fmt.Errorf("generate %s: %w", path, err)
+	}
+	return os.WriteFile(path, src, 0o644)
+}
+
+// serverOp - операция для шаблона интерфейса хендлеров.
+type serverOp struct {
+	ID      string
+	Method  string
+	Path    string
+	Summary string
+}
+
+// generateServer - функция генерации интерфейса хендлеров операций.
+func generateServer(doc *openapi.Document) ([]byte, error) {
+	var ops []serverOp
+	for _, op := range doc.Operations() {
+		ops = append(ops, serverOp{ID: op.OperationID, Method: op.Method, Path: op.Path, Summary: op.Summary})
+	}
+	return render(serverTemplate, ops)
+}
+
+// typeDef - тип, сгенерированный по схеме из components.schemas.
+type typeDef struct {
+	Name   string
+	Doc    string
+	Alias  string
+	Fields []fieldDef
+}
+
+// fieldDef - поле типа.
+type fieldDef struct {
+	Name string
+	Type string
+	Tag  string
+	Doc  string
+}
+
+// argDef - аргумент метода клиента, соответствующий параметру пути.
+type argDef struct {
+	Name string
+	Type string
+}
+
+// paramDef - параметр строки запроса или заголовка в структуре параметров операции.
+type paramDef struct {
+	Field  string
+	Type   string
+	Key    string
+	In     string
+	Value  string
+	Doc    string
+	Header bool
+}
+
+// bodyDef - тело запроса операции.
+type bodyDef struct {
+	Type        string
+	ContentType string
+	JSON        bool
+}
+
+// responseDef - разбираемый ответ операции.
+type responseDef struct {
+	Field     string
+	Type      string
+	Status    int
+	MediaType string
+}
+
+// clientOp - операция для шаблона клиента.
+type clientOp struct {
+	Name        string
+	ID          string
+	Method      string
+	HTTPMethod  string
+	Path        string
+	Doc         string
+	PathExpr    string
+	Args        []argDef
+	ParamsName  string
+	Params      []paramDef
+	HasQuery    bool
+	Body        *bodyDef
+	Responses   []responseDef
+	ProblemType string
+}
+
+// clientFile - данные шаблона клиента.
+type clientFile struct {
+	Title   string
+	Version string
+	Imports []string
+	Types   []typeDef
+	Ops     []clientOp
+}
+
+// generator - состояние генерации клиента: документ и используемые пакеты.
+type generator struct {
+	doc     *openapi.Document
+	imports map[string]bool
+}
+
+// generateClient - функция генерации типов схем и REST клиента.
+func generateClient(doc *openapi.Document) ([]byte, error) {
+	g := &generator{doc: doc, imports: map[string]bool{
+		"bytes": true, "context": true, "encoding/json": true, "io": true,
+		"mime": true, "net/http": true, "net/url": true, "strings": true,
+	}}
+	file := clientFile{Title: doc.Info.Title, Version: doc.Info.Version}
+
+	names := make([]string, 0, len(doc.Components.Schemas))
+	for name := range doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		t, err := g.typeDef(name, doc.Components.Schemas[name])
+		if err != nil {
+			return nil, fmt.Errorf("schema %s: %w", name, err)
+		}
+		file.Types = append(file.Types, t)
+	}
+	for _, op := range doc.Operations() {
+		c, err := g.clientOp(op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %s: %w", op.OperationID, err)
+		}
+		file.Ops = append(file.Ops, c)
+	}
+	for pkg := range g.imports {
+		file.Imports = append(file.Imports, pkg)
+	}
+	sort.Strings(file.Imports)
+	return render(clientTemplate, file)
+}
+
+// typeDef - метод построения типа по схеме компонента.
+func (g *generator) typeDef(name string, s *openapi.Schema) (typeDef, error) {
+	t := typeDef{Name: name, Doc: docLine(name, s.Description)}
+	if s.Type != "object" || len(s.Properties) == 0 {
+		alias, err := g.goType(s)
+		t.Alias = alias
+		return t, err
+	}
+	required := make(map[string]bool)
+	for _, key := range s.Required {
+		required[key] = true
+	}
+	for _, key := range s.PropertyOrder {
+		prop := s.Properties[key]
+		typ, err := g.goType(prop)
+		if err != nil {
+			return t, fmt.Errorf("property %s: %w", key, err)
+		}
+		field := prop.GoName
+		if field == "" {
+			field = goName(key)
+		}
+		tag := key
+		if !required[key] {
+			tag += ",omitempty"
+		}
+		doc := prop.Description
+		if len(prop.Enum) > 0 {
+			doc = strings.TrimSuffix(doc, ".") + ", одно из значений: " + strings.Join(prop.Enum, ", ") + "."
+		}
+		t.Fields = append(t.Fields, fieldDef{Name: field, Type: typ, Tag: tag, Doc: docLine(field, doc)})
+	}
+	return t, nil
+}
+
+// goType - метод получения типа Go для схемы.
+func (g *generator) goType(s *openapi.Schema) (string, error) {
+	if s == nil {
+		return "any", nil
+	}
+	if s.Ref != "" {
+		return openapi.SchemaName(s.Ref), nil
+	}
+	switch s.Type {
+	case "string":
+		if s.Format == "date-time" {
+			g.imports["time"] = true
+			return "time.Time", nil
+		}
+		return "string", nil
+	case "integer":
+		if s.Format == "int64" {
+			return "int64", nil
+		}
+		return "int", nil
+	case "number":
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		item, err := g.goType(s.Items)
+		return "[]" + item, err
+	case "object":
+		if len(s.Properties) == 0 {
+			return "map[string]any", nil
+		}
+		return "", fmt.Errorf("inline object schemas are not supported, use $ref")
+	}
+	return "", fmt.Errorf("unsupported schema type %q", s.Type)
+}
+
+// clientOp - метод построения метода клиента по операции.
+func (g *generator) clientOp(op *openapi.Operation) (clientOp, error) {
+	name := strings.TrimSuffix(op.OperationID, "Handler")
+	c := clientOp{
+		Name:       name,
+		ID:         op.OperationID,
+		Method:     methodConst(op.Method),
+		HTTPMethod: op.Method,
+		Path:       op.Path,
+		Doc:        docLine(name, op.Summary),
+		ParamsName: name + "Params",
+	}
+
+	pathArgs := make(map[string]string)
+	for _, p := range op.Parameters {
+		param := g.doc.ResolveParameter(p)
+		typ, err := g.goType(g.doc.ResolveSchema(param.Schema))
+		if err != nil {
+			return c, fmt.Errorf("parameter %s: %w", param.Name, err)
+		}
+		field := goName(param.Name)
+		switch param.In {
+		case "path":
+			arg := lowerName(field)
+			pathArgs[param.Name] = arg
+			c.Args = append(c.Args, argDef{Name: arg, Type: "string"})
+		case "query", "header":
+			value, err := g.formatValue(typ, "*params."+field)
+			if err != nil {
+				return c, fmt.Errorf("parameter %s: %w", param.Name, err)
+			}
+			c.Params = append(c.Params, paramDef{
+				Field:  field,
+				Type:   typ,
+				Key:    param.Name,
+				In:     param.In,
+				Value:  value,
+				Doc:    docLine(field, param.Description),
+				Header: param.In == "header",
+			})
+			c.HasQuery = c.HasQuery || param.In == "query"
+		default:
+			return c, fmt.Errorf("parameter %s: unsupported location %q", param.Name, param.In)
+		}
+	}
+	var parts []string
+	for _, segment := range strings.Split(strings.TrimPrefix(op.Path, "/"), "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			arg, ok := pathArgs[segment[1:len(segment)-1]]
+			if !ok {
+				return c, fmt.Errorf("path parameter %s is not described", segment)
+			}
+			parts = append(parts, `"/" + url.PathEscape(`+arg+`)`)
+			continue
+		}
+		parts = append(parts, strconv.Quote("/"+segment))
+	}
+	c.PathExpr = strings.ReplaceAll(strings.Join(parts, " + "), `" + "`, "")
+
+	if op.RequestBody != nil {
+		mediaTypes := make([]string, 0, len(op.RequestBody.Content))
+		for mediaType := range op.RequestBody.Content {
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+		sort.SliceStable(mediaTypes, func(i, j int) bool {
+			return openapi.IsJSON(mediaTypes[i]) && !openapi.IsJSON(mediaTypes[j])
+		})
+		if len(mediaTypes) > 0 {
+			mediaType := mediaTypes[0]
+			body := &bodyDef{Type: "string", ContentType: mediaType, JSON: openapi.IsJSON(mediaType)}
+			if body.JSON {
+				typ, err := g.goType(op.RequestBody.Content[mediaType].Schema)
+				if err != nil {
+					return c, fmt.Errorf("request body: %w", err)
+				}
+				body.Type = typ
+			}
+			c.Body = body
+		}
+	}
+
+	statuses := make([]string, 0, len(op.Responses))
+	for status := range op.Responses {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		resp := g.doc.ResolveResponse(op.Responses[status])
+		code, _ := strconv.Atoi(status)
+		for mediaType, media := range resp.Content {
+			switch {
+			case mediaType == problemMediaType:
+				typ, err := g.goType(media.Schema)
+				if err != nil {
+					return c, fmt.Errorf("response %s: %w", status, err)
+				}
+				c.ProblemType = typ
+			case openapi.IsJSON(mediaType):
+				typ, err := g.goType(media.Schema)
+				if err != nil {
+					return c, fmt.Errorf("response %s: %w", status, err)
+				}
+				field := "JSON" + status
+				if code == 0 {
+					field = "JSONDefault"
+				}
+				c.Responses = append(c.Responses, responseDef{Field: field, Type: typ, Status: code, MediaType: mediaType})
+			}
+		}
+	}
+	return c, nil
+}
+
+// formatValue - метод получения выражения Go, переводящего значение параметра в строку.
+func (g *generator) formatValue(typ string, expr string) (string, error) {
+	switch typ {
+	case "string":
+		return expr, nil
+	case "int":
+		g.imports["strconv"] = true
+		return "strconv.Itoa(" + expr + ")", nil
+	case "int64":
+		g.imports["strconv"] = true
+		return "strconv.FormatInt(" + expr + ", 10)", nil
+	case "float64":
+		g.imports["strconv"] = true
+		return "strconv.FormatFloat(" + expr + ", 'f', -1, 64)", nil
+	case "bool":
+		g.imports["strconv"] = true
+		return "strconv.FormatBool(" + expr + ")", nil
+	case "time.Time":
+		return strings.TrimPrefix(expr, "*") + ".Format(time.RFC3339Nano)", nil
+	}
+	return "", fmt.Errorf("unsupported parameter type %s", typ)
+}
+
+// methodConst - функция получения константы пакета net/http для http метода.
+func methodConst(method string) string {
+	return "http.Method" + string(method[0]) + strings.ToLower(method[1:])
+}
+
+// goName - функция получения экспортируемого имени Go из имени свойства или параметра, например short_url - ShortURL.
+func goName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' || r == '.' }) {
+		if initialism, ok := initialisms[strings.ToLower(part)]; ok {
+			b.WriteString(initialism)
+			continue
+		}
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
+
+// lowerName - функция получения неэкспортируемого имени из экспортируемого, например ID - id, ShortURL - shortURL.
+func lowerName(name string) string {
+	if strings.ToUpper(name) == name {
+		return strings.ToLower(name)
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+// docLine - функция получения комментария вида "Name - описание." в стиле репозитория.
+func docLine(name string, description string) string {
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return name + "."
+	}
+	runes := []rune(description)
+	runes[0] = unicode.ToLower(runes[0])
+	description = string(runes)
+	if !strings.HasSuffix(description, ".") {
+		description += "."
+	}
+	return name + " - " + description
+}
+
+// render - функция выполнения шаблона и форматирования результата.
+func render(tmpl *template.Template, data any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w\n%s", err, buf.String())
+	}
+	return src, nil
+}
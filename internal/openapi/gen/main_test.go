@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dorrrke/shortener-url/internal/openapi"
+)
+
+// TestGeneratedUpToDate проверяет, что сгенерированный код соответствует openapi.json.
+func TestGeneratedUpToDate(t *testing.T) {
+	doc, err := openapi.Load(openapi.Spec())
+	require.NoError(t, err)
+
+	tests := []struct {
+		path     string
+		generate func(*openapi.Document) ([]byte, error)
+	}{
+		{path: "../server.gen.go", generate: generateServer},
+		{path: "../../../pkg/api/client.gen.go", generate: generateClient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			want, err := tt.generate(doc)
+			require.NoError(t, err)
+			got, err := os.ReadFile(tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), string(got), "run go generate ./internal/openapi")
+		})
+	}
+}
+
+func TestNames(t *testing.T) {
+	assert.Equal(t, "ShortURL", goName("short_url"))
+	assert.Equal(t, "CorrelationID", goName("correlation_id"))
+	assert.Equal(t, "IdempotencyKey", goName("Idempotency-Key"))
+	assert.Equal(t, "URLs", goName("urls"))
+	assert.Equal(t, "id", lowerName("ID"))
+	assert.Equal(t, "shortURL", lowerName("ShortURL"))
+	assert.Equal(t, "Name - описание.", docLine("Name", "Описание"))
+}
@@ -0,0 +1,256 @@
+package main
+
+import "text/template"
+
+// generatedHeader - заголовок сгенерированных файлов.
+const generatedHeader = "// Code generated by internal/openapi/gen from internal/openapi/openapi.json. DO NOT EDIT.\n\n"
+
+// serverTemplate - шаблон интерфейса хендлеров для пакета internal/openapi.
+var serverTemplate = template.Must(template.New("server").Parse(generatedHeader + `package openapi
+
+import "net/http"
+
+// ServerInterface - хендлеры операций спецификации, имена методов совпадают с operationId.
+type ServerInterface interface {
+{{- range .}}
+	// {{.ID}} - {{.Method}} {{.Path}}: {{.Summary}}.
+	{{.ID}}(res http.ResponseWriter, req *http.Request)
+{{- end}}
+}
+
+// Handlers - функция получения хендлеров реализации si по operationId.
+func Handlers(si ServerInterface) map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+{{- range .}}
+		"{{.ID}}": si.{{.ID}},
+{{- end}}
+	}
+}
+`))
+
+// clientTemplate - шаблон типов и REST клиента для пакета pkg/api.
+var clientTemplate = template.Must(template.New("client").Parse(generatedHeader + `// Пакет api содержит типизированный REST клиент сервиса {{.Title}} (версия API {{.Version}}),
+// сгенерированный по спецификации OpenAPI. Спецификация также доступна у сервиса по адресу /api/openapi.json.
+package api
+
+import (
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
+)
+{{range .Types}}
+// {{.Doc}}
+{{- if .Alias}}
+type {{.Name}} {{.Alias}}
+{{- else}}
+type {{.Name}} struct {
+{{- range .Fields}}
+	// {{.Doc}}
+	{{.Name}} {{.Type}} ` + "`" + `json:"{{.Tag}}"` + "`" + `
+{{- end}}
+}
+{{- end}}
+{{end}}
+// HTTPRequestDoer - http клиент, которым отправляются запросы, например *http.Client.
+type HTTPRequestDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// RequestEditorFn - функция изменения запроса перед отправкой, например для установки cookie или заголовков.
+type RequestEditorFn func(ctx context.Context, req *http.Request) error
+
+// Client - REST клиент сервиса.
+type Client struct {
+	// Server - базовый адрес сервиса, например http://localhost:8080.
+	Server string
+	// HTTPClient - http клиент для отправки запросов.
+	HTTPClient HTTPRequestDoer
+	// RequestEditors - функции, применяемые ко всем запросам клиента.
+	RequestEditors []RequestEditorFn
+}
+
+// ClientOption - опция создания клиента.
+type ClientOption func(*Client) error
+
+// NewClient - функция создания клиента сервиса по адресу server.
+// По умолчанию используется http клиент, который не следует перенаправлениям,
+// чтобы ответ на переход по сокращенной ссылке содержал оригинальный url в заголовке Location.
+func NewClient(server string, opts ...ClientOption) (*Client, error) {
+	client := Client{Server: strings.TrimSuffix(server, "/")}
+	for _, opt := range opts {
+		if err := opt(&client); err != nil {
+			return nil, err
+		}
+	}
+	if client.HTTPClient == nil {
+		client.HTTPClient = &http.Client{
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+	return &client, nil
+}
+
+// WithHTTPClient - опция установки http клиента, например с cookie jar для сохранения cookie auth.
+func WithHTTPClient(doer HTTPRequestDoer) ClientOption {
+	return func(c *Client) error {
+		c.HTTPClient = doer
+		return nil
+	}
+}
+
+// WithRequestEditorFn - опция добавления функции изменения всех запросов клиента.
+func WithRequestEditorFn(fn RequestEditorFn) ClientOption {
+	return func(c *Client) error {
+		c.RequestEditors = append(c.RequestEditors, fn)
+		return nil
+	}
+}
+{{range .Ops}}
+{{- if .Params}}
+// {{.ParamsName}} - параметры запроса {{.Name}}.
+type {{.ParamsName}} struct {
+{{- range .Params}}
+	// {{.Doc}}
+	{{.Field}} *{{.Type}}
+{{- end}}
+}
+{{end}}
+// {{.Name}}Response - ответ на запрос {{.Name}}.
+type {{.Name}}Response struct {
+	// HTTPResponse - http ответ, тело уже прочитано в Body.
+	HTTPResponse *http.Response
+	// Body - тело ответа.
+	Body []byte
+{{- range .Responses}}
+	// {{.Field}} - разобранное тело ответа со статусом {{if .Status}}{{.Status}}{{else}}не из описанных{{end}}.
+	{{.Field}} *{{.Type}}
+{{- end}}
+{{- if .ProblemType}}
+	// Problem - описание ошибки, если сервис вернул ответ application/problem+json.
+	Problem *{{.ProblemType}}
+{{- end}}
+}
+
+// StatusCode - метод получения http статуса ответа.
+func (r *{{.Name}}Response) StatusCode() int {
+	if r.HTTPResponse == nil {
+		return 0
+	}
+	return r.HTTPResponse.StatusCode
+}
+
+// {{.Doc}}
+// Операция {{.ID}}: {{.HTTPMethod}} {{.Path}}.
+func (c *Client) {{.Name}}(ctx context.Context
+{{- range .Args}}, {{.Name}} {{.Type}}{{end}}
+{{- if .Params}}, params *{{.ParamsName}}{{end}}
+{{- if .Body}}, body {{.Body.Type}}{{end}}, reqEditors ...RequestEditorFn) (*{{.Name}}Response, error) {
+{{- if .Body}}
+{{- if .Body.JSON}}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+{{- else}}
+	payload := []byte(body)
+{{- end}}
+	req, err := c.newRequest(ctx, {{.Method}}, {{.PathExpr}}, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "{{.Body.ContentType}}")
+{{- else}}
+	req, err := c.newRequest(ctx, {{.Method}}, {{.PathExpr}}, nil)
+	if err != nil {
+		return nil, err
+	}
+{{- end}}
+{{- if .Params}}
+	if params != nil {
+{{- if .HasQuery}}
+		query := req.URL.Query()
+{{- end}}
+{{- range .Params}}
+		if params.{{.Field}} != nil {
+{{- if .Header}}
+			req.Header.Set("{{.Key}}", {{.Value}})
+{{- else}}
+			query.Set("{{.Key}}", {{.Value}})
+{{- end}}
+		}
+{{- end}}
+{{- if .HasQuery}}
+		req.URL.RawQuery = query.Encode()
+{{- end}}
+	}
+{{- end}}
+	res, payloadRes, err := c.do(ctx, req, reqEditors)
+	if err != nil {
+		return nil, err
+	}
+	response := &{{.Name}}Response{HTTPResponse: res, Body: payloadRes}
+{{- if or .Responses .ProblemType}}
+	mediaType := responseMediaType(res)
+	switch {
+{{- range .Responses}}
+	case {{if .Status}}res.StatusCode == {{.Status}} && {{end}}mediaType == "{{.MediaType}}":
+		var dest {{.Type}}
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.{{.Field}} = &dest
+{{- end}}
+{{- if .ProblemType}}
+	case mediaType == "application/problem+json":
+		var dest {{.ProblemType}}
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.Problem = &dest
+{{- end}}
+	}
+{{- end}}
+	return response, nil
+}
+{{end}}
+// newRequest - метод создания запроса к пути path сервиса.
+func (c *Client) newRequest(ctx context.Context, method string, path string, body io.Reader) (*http.Request, error) {
+	target, err := url.Parse(c.Server + path)
+	if err != nil {
+		return nil, err
+	}
+	return http.NewRequestWithContext(ctx, method, target.String(), body)
+}
+
+// do - метод применения функций изменения запроса, отправки запроса и чтения тела ответа.
+func (c *Client) do(ctx context.Context, req *http.Request, reqEditors []RequestEditorFn) (*http.Response, []byte, error) {
+	for _, editors := range [][]RequestEditorFn{c.RequestEditors, reqEditors} {
+		for _, edit := range editors {
+			if err := edit(ctx, req); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, body, nil
+}
+
+// responseMediaType - функция получения типа тела ответа без параметров.
+func responseMediaType(res *http.Response) string {
+	mediaType, _, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+`))
@@ -0,0 +1,395 @@
+// Пакет openapi содержит спецификацию OpenAPI 3 REST API сервиса, проверку запросов по ней
+// и сгенерированный по ней интерфейс хендлеров. Типизированный клиент генерируется в пакет pkg/api.
+// После изменения openapi.json код нужно сгенерировать заново: go generate ./internal/openapi.
+package openapi
+
+//go:generate go run ./gen -spec openapi.json -server server.gen.go -client ../../pkg/api/client.gen.go
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+// ContentType - тип ответа со спецификацией.
+const ContentType = "application/json"
+
+// Document - спецификация OpenAPI. Описаны только поля, которые используются в сервисе и генераторе.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+// Info - общие сведения об API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+// PathItem - операции одного пути.
+type PathItem struct {
+	Get    *Operation `json:"get"`
+	Post   *Operation `json:"post"`
+	Put    *Operation `json:"put"`
+	Patch  *Operation `json:"patch"`
+	Delete *Operation `json:"delete"`
+}
+
+// Operation - операция спецификации.
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Tags        []string             `json:"tags"`
+	Summary     string               `json:"summary"`
+	Description string               `json:"description"`
+	Parameters  []*Parameter         `json:"parameters"`
+	RequestBody *RequestBody         `json:"requestBody"`
+	Responses   map[string]*Response `json:"responses"`
+
+	// Method и Path - http метод и путь операции, заполняются при загрузке спецификации.
+	Method string `json:"-"`
+	Path   string `json:"-"`
+}
+
+// Parameter - параметр операции в пути, строке запроса или заголовке.
+type Parameter struct {
+	Ref         string  `json:"$ref"`
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody - тело запроса операции.
+type RequestBody struct {
+	Description string                `json:"description"`
+	Required    bool                  `json:"required"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+// MediaType - схема тела запроса или ответа одного типа.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Response - ответ операции.
+type Response struct {
+	Ref         string                `json:"$ref"`
+	Description string                `json:"description"`
+	Headers     map[string]*Header    `json:"headers"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+// Header - заголовок ответа.
+type Header struct {
+	Description string  `json:"description"`
+	Schema      *Schema `json:"schema"`
+}
+
+// Schema - схема значения. Поддерживается подмножество JSON Schema, достаточное для API сервиса.
+type Schema struct {
+	Ref         string             `json:"$ref"`
+	Type        string             `json:"type"`
+	Format      string             `json:"format"`
+	Description string             `json:"description"`
+	Properties  map[string]*Schema `json:"properties"`
+	Required    []string           `json:"required"`
+	Items       *Schema            `json:"items"`
+	Enum        []string           `json:"enum"`
+	MinLength   *int               `json:"minLength"`
+	MaxLength   *int               `json:"maxLength"`
+	MinItems    *int               `json:"minItems"`
+	MaxItems    *int               `json:"maxItems"`
+	Minimum     *float64           `json:"minimum"`
+	// GoName - имя поля в сгенерированном коде, если оно не выводится из имени свойства.
+	GoName string `json:"x-go-name"`
+
+	// PropertyOrder - имена свойств в порядке их описания в спецификации.
+	PropertyOrder []string `json:"-"`
+}
+
+// UnmarshalJSON - метод разбора схемы с сохранением порядка свойств для генератора.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	type plain Schema
+	if err := json.Unmarshal(data, (*plain)(s)); err != nil {
+		return err
+	}
+	var raw struct {
+		Properties json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil || len(raw.Properties) == 0 {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw.Properties))
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		s.PropertyOrder = append(s.PropertyOrder, key.(string))
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Components - переиспользуемые части спецификации.
+type Components struct {
+	Schemas    map[string]*Schema    `json:"schemas"`
+	Parameters map[string]*Parameter `json:"parameters"`
+	Responses  map[string]*Response  `json:"responses"`
+}
+
+// Operations - метод получения операций пути в порядке GET, POST, PUT, PATCH, DELETE.
+func (p *PathItem) Operations() []*Operation {
+	var ops []*Operation
+	for _, op := range []*Operation{p.Get, p.Post, p.Put, p.Patch, p.Delete} {
+		if op != nil {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// Load - функция разбора спецификации. Проверяется, что у всех операций есть уникальный operationId
+// и что все ссылки $ref указывают на существующие компоненты.
+func Load(data []byte) (*Document, error) {
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse openapi spec: %w", err)
+	}
+	ids := make(map[string]string)
+	for path, item := range doc.Paths {
+		methods := map[string]*Operation{
+			http.MethodGet: item.Get, http.MethodPost: item.Post, http.MethodPut: item.Put,
+			http.MethodPatch: item.Patch, http.MethodDelete: item.Delete,
+		}
+		for method, op := range methods {
+			if op == nil {
+				continue
+			}
+			op.Method, op.Path = method, path
+			where := method + " " + path
+			if op.OperationID == "" {
+				return nil, fmt.Errorf("%s: operationId is required", where)
+			}
+			if other, ok := ids[op.OperationID]; ok {
+				return nil, fmt.Errorf("%s: operationId %s is already used by %s", where, op.OperationID, other)
+			}
+			ids[op.OperationID] = where
+			if err := doc.checkRefs(op); err != nil {
+				return nil, fmt.Errorf("%s: %w", where, err)
+			}
+		}
+	}
+	for name, schema := range doc.Components.Schemas {
+		if err := doc.checkSchema(schema); err != nil {
+			return nil, fmt.Errorf("schema %s: %w", name, err)
+		}
+	}
+	return &doc, nil
+}
+
+var (
+	defaultOnce sync.Once
+	defaultDoc  *Document
+)
+
+// Default - функция получения разобранной встроенной спецификации сервиса.
+func Default() *Document {
+	defaultOnce.Do(func() {
+		doc, err := Load(spec)
+		if err != nil {
+			panic(err)
+		}
+		defaultDoc = doc
+	})
+	return defaultDoc
+}
+
+// Spec - функция получения встроенной спецификации в формате json.
+func Spec() []byte {
+	return spec
+}
+
+// Operations - метод получения всех операций, отсортированных по пути и методу.
+func (d *Document) Operations() []*Operation {
+	paths := make([]string, 0, len(d.Paths))
+	for path := range d.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var ops []*Operation
+	for _, path := range paths {
+		ops = append(ops, d.Paths[path].Operations()...)
+	}
+	return ops
+}
+
+// Find - метод поиска операции по методу и пути запроса. Сегменты пути вида {name} совпадают с любым
+// непустым сегментом, при нескольких совпадениях выбирается путь с большим количеством постоянных сегментов.
+// Возвращает операцию и значения параметров пути, или nil, если путь не описан в спецификации.
+func (d *Document) Find(method string, path string) (*Operation, map[string]string) {
+	segments := splitPath(path)
+	var (
+		found  *Operation
+		params map[string]string
+		best   = -1
+	)
+	for pattern, item := range d.Paths {
+		values, literal, ok := matchPath(splitPath(pattern), segments)
+		if !ok || literal <= best {
+			continue
+		}
+		for _, op := range item.Operations() {
+			if op.Method == method {
+				found, params, best = op, values, literal
+			}
+		}
+	}
+	return found, params
+}
+
+// splitPath - функция разбиения пути на сегменты без учета завершающего слэша.
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// matchPath - функция сравнения сегментов шаблона пути спецификации с сегментами пути запроса.
+func matchPath(pattern []string, segments []string) (map[string]string, int, bool) {
+	if len(pattern) != len(segments) {
+		return nil, 0, false
+	}
+	values := make(map[string]string)
+	literal := 0
+	for i, part := range pattern {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if segments[i] == "" {
+				return nil, 0, false
+			}
+			values[part[1:len(part)-1]] = segments[i]
+			continue
+		}
+		if part != segments[i] {
+			return nil, 0, false
+		}
+		literal++
+	}
+	return values, literal, true
+}
+
+// ResolveSchema - метод получения схемы по ссылке $ref, схема без ссылки возвращается как есть.
+func (d *Document) ResolveSchema(s *Schema) *Schema {
+	for s != nil && s.Ref != "" {
+		s = d.Components.Schemas[refName(s.Ref, "schemas")]
+	}
+	return s
+}
+
+// ResolveParameter - метод получения параметра по ссылке $ref.
+func (d *Document) ResolveParameter(p *Parameter) *Parameter {
+	if p != nil && p.Ref != "" {
+		return d.Components.Parameters[refName(p.Ref, "parameters")]
+	}
+	return p
+}
+
+// ResolveResponse - метод получения ответа по ссылке $ref.
+func (d *Document) ResolveResponse(r *Response) *Response {
+	if r != nil && r.Ref != "" {
+		return d.Components.Responses[refName(r.Ref, "responses")]
+	}
+	return r
+}
+
+// SchemaName - функция получения имени компонента схемы из ссылки $ref.
+func SchemaName(ref string) string {
+	return refName(ref, "schemas")
+}
+
+// refName - функция получения имени компонента вида kind из локальной ссылки #/components/<kind>/<name>.
+func refName(ref string, kind string) string {
+	prefix := "#/components/" + kind + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		return ""
+	}
+	return strings.TrimPrefix(ref, prefix)
+}
+
+// checkRefs - метод проверки ссылок $ref в параметрах, теле запроса и ответах операции.
+func (d *Document) checkRefs(op *Operation) error {
+	for _, p := range op.Parameters {
+		param := d.ResolveParameter(p)
+		if param == nil {
+			return fmt.Errorf("unresolved parameter %s", p.Ref)
+		}
+		if err := d.checkSchema(param.Schema); err != nil {
+			return fmt.Errorf("parameter %s: %w", param.Name, err)
+		}
+	}
+	if op.RequestBody != nil {
+		for _, media := range op.RequestBody.Content {
+			if err := d.checkSchema(media.Schema); err != nil {
+				return fmt.Errorf("request body: %w", err)
+			}
+		}
+	}
+	for status, r := range op.Responses {
+		resp := d.ResolveResponse(r)
+		if resp == nil {
+			return fmt.Errorf("response %s: unresolved %s", status, r.Ref)
+		}
+		for _, media := range resp.Content {
+			if err := d.checkSchema(media.Schema); err != nil {
+				return fmt.Errorf("response %s: %w", status, err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkSchema - метод проверки ссылок $ref в схеме и вложенных схемах.
+func (d *Document) checkSchema(s *Schema) error {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		if d.ResolveSchema(s) == nil {
+			return fmt.Errorf("unresolved schema %s", s.Ref)
+		}
+		return nil
+	}
+	for _, prop := range s.Properties {
+		if err := d.checkSchema(prop); err != nil {
+			return err
+		}
+	}
+	return d.checkSchema(s.Items)
+}
+
+// Handler - хендлер отдачи встроенной спецификации.
+func Handler(res http.ResponseWriter, req *http.Request) {
+	res.Header().Set("Content-Type", ContentType)
+	res.WriteHeader(http.StatusOK)
+	res.Write(spec)
+}
@@ -0,0 +1,279 @@
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefault(t *testing.T) {
+	doc := Default()
+	require.NotNil(t, doc)
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	ops := doc.Operations()
+	assert.Len(t, ops, 17)
+	for _, op := range ops {
+		assert.NotEmpty(t, op.Method, op.OperationID)
+		assert.NotEmpty(t, op.Path, op.OperationID)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		want string
+	}{
+		{
+			name: "malformed json",
+			spec: `{"paths":`,
+			want: "parse openapi spec",
+		},
+		{
+			name: "missing operationId",
+			spec: `{"paths":{"/":{"get":{}}}}`,
+			want: "operationId is required",
+		},
+		{
+			name: "duplicate operationId",
+			spec: `{"paths":{"/a":{"get":{"operationId":"A"}},"/b":{"get":{"operationId":"A"}}}}`,
+			want: "is already used",
+		},
+		{
+			name: "unresolved schema",
+			spec: `{"paths":{"/":{"post":{"operationId":"A","requestBody":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/Missing"}}}}}}}}`,
+			want: "unresolved schema",
+		},
+		{
+			name: "unresolved response",
+			spec: `{"paths":{"/":{"get":{"operationId":"A","responses":{"default":{"$ref":"#/components/responses/Missing"}}}}}}`,
+			want: "unresolved",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load([]byte(tt.spec))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestSchemaPropertyOrder(t *testing.T) {
+	schema := Default().Components.Schemas["AuditRecord"]
+	assert.Equal(t, []string{"ts", "actor", "action", "target", "ip"}, schema.PropertyOrder)
+}
+
+func TestFind(t *testing.T) {
+	doc := Default()
+	tests := []struct {
+		method string
+		path   string
+		wantID string
+		params map[string]string
+	}{
+		{method: http.MethodPost, path: "/", wantID: "ShortenerURLHandler"},
+		{method: http.MethodGet, path: "/abc123", wantID: "GetOriginalURLHandler", params: map[string]string{"id": "abc123"}},
+		{method: http.MethodGet, path: "/ping", wantID: "CheckDBConnectionHandler"},
+		{method: http.MethodPost, path: "/api/shorten/", wantID: "ShortenerJSONURLHandler"},
+		{method: http.MethodPost, path: "/api/shorten", wantID: "ShortenerJSONURLHandler"},
+		{method: http.MethodDelete, path: "/api/user/urls", wantID: "DeleteURLHandler"},
+		{method: http.MethodGet, path: "/api/user/urls", wantID: "GetAllUrls"},
+		{method: http.MethodPut, path: "/api/user/urls"},
+		{method: http.MethodGet, path: "/metrics/extra"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			op, params := doc.Find(tt.method, tt.path)
+			if tt.wantID == "" {
+				assert.Nil(t, op)
+				return
+			}
+			require.NotNil(t, op)
+			assert.Equal(t, tt.wantID, op.OperationID)
+			if tt.params != nil {
+				assert.Equal(t, tt.params, params)
+			}
+		})
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	doc := Default()
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		header      map[string]string
+		body        string
+		wantField   string
+	}{
+		{
+			name:        "valid json url",
+			method:      http.MethodPost,
+			target:      "/api/shorten",
+			contentType: "application/json",
+			body:        `{"url":"https://go.dev/"}`,
+		},
+		{
+			name:      "missing body",
+			method:    http.MethodPost,
+			target:    "/api/shorten",
+			wantField: "body",
+		},
+		{
+			name:      "malformed json",
+			method:    http.MethodPost,
+			target:    "/api/shorten",
+			body:      `{"url":`,
+			wantField: "body",
+		},
+		{
+			name:      "missing url field",
+			method:    http.MethodPost,
+			target:    "/api/shorten",
+			body:      `{"link":"https://go.dev/"}`,
+			wantField: "url",
+		},
+		{
+			name:      "url of wrong type",
+			method:    http.MethodPost,
+			target:    "/api/shorten",
+			body:      `{"url":42}`,
+			wantField: "url",
+		},
+		{
+			name:   "valid batch",
+			method: http.MethodPost,
+			target: "/api/shorten/batch",
+			body:   `[{"correlation_id":"1","original_url":"https://go.dev/"}]`,
+		},
+		{
+			name:      "empty batch",
+			method:    http.MethodPost,
+			target:    "/api/shorten/batch",
+			body:      `[]`,
+			wantField: "body",
+		},
+		{
+			name:      "batch item without url",
+			method:    http.MethodPost,
+			target:    "/api/shorten/batch",
+			body:      `[{"correlation_id":"1","original_url":"https://go.dev/"},{"correlation_id":"2"}]`,
+			wantField: "[1].original_url",
+		},
+		{
+			name:        "plain text url with form content type",
+			method:      http.MethodPost,
+			target:      "/",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "https://go.dev/",
+		},
+		{
+			name:      "too long idempotency key",
+			method:    http.MethodPost,
+			target:    "/",
+			header:    map[string]string{"Idempotency-Key": strings.Repeat("k", 256)},
+			body:      "https://go.dev/",
+			wantField: "Idempotency-Key",
+		},
+		{
+			name:   "delete without body",
+			method: http.MethodDelete,
+			target: "/api/user/urls",
+		},
+		{
+			name:      "delete with wrong ids",
+			method:    http.MethodDelete,
+			target:    "/api/user/urls",
+			body:      `[1, 2]`,
+			wantField: "[0]",
+		},
+		{
+			name:   "valid audit query",
+			method: http.MethodGet,
+			target: "/api/internal/audit?action=delete&since=2024-01-02T15:04:05Z&limit=10",
+		},
+		{
+			name:      "audit limit below minimum",
+			method:    http.MethodGet,
+			target:    "/api/internal/audit?limit=0",
+			wantField: "limit",
+		},
+		{
+			name:      "audit limit not a number",
+			method:    http.MethodGet,
+			target:    "/api/internal/audit?limit=ten",
+			wantField: "limit",
+		},
+		{
+			name:      "audit unknown action",
+			method:    http.MethodGet,
+			target:    "/api/internal/audit?action=update",
+			wantField: "action",
+		},
+		{
+			name:      "audit malformed date",
+			method:    http.MethodGet,
+			target:    "/api/internal/audit?until=yesterday",
+			wantField: "until",
+		},
+		{
+			name:        "password form",
+			method:      http.MethodPost,
+			target:      "/abc123",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "password=secret",
+		},
+		{
+			name:        "malformed password form",
+			method:      http.MethodPost,
+			target:      "/abc123",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "password=%zz",
+			wantField:   "body",
+		},
+		{
+			name:      "too long link password",
+			method:    http.MethodPost,
+			target:    "/",
+			header:    map[string]string{"X-Link-Password": strings.Repeat("p", 73)},
+			body:      "https://go.dev/",
+			wantField: "X-Link-Password",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			op, params := doc.Find(req.Method, req.URL.Path)
+			require.NotNil(t, op)
+			err := doc.ValidateRequest(op, req, params, []byte(tt.body))
+			if tt.wantField == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var verr *ValidationError
+			require.ErrorAs(t, err, &verr)
+			assert.Equal(t, tt.wantField, verr.Field, verr.Reason)
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler(rec, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+	assert.Equal(t, Spec(), rec.Body.Bytes())
+}
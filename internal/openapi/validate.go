@@ -0,0 +1,270 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// BodyField - имя поля ошибки, относящейся к телу запроса целиком.
+const BodyField = "body"
+
+// FormContentType - тип тела html формы.
+const FormContentType = "application/x-www-form-urlencoded"
+
+// ValidationError - ошибка проверки запроса по спецификации.
+type ValidationError struct {
+	// Field - параметр или поле тела запроса, например limit, url или [0].original_url.
+	Field string
+	// Reason - причина ошибки.
+	Reason string
+}
+
+// Error - текст ошибки.
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Reason
+}
+
+// ValidateRequest - метод проверки параметров и тела запроса req по операции op.
+// Тело передается отдельно, так как оно уже прочитано вызывающим. Возвращает *ValidationError для первой найденной ошибки.
+// Неизвестные параметры и поля тела не считаются ошибкой.
+func (d *Document) ValidateRequest(op *Operation, req *http.Request, pathParams map[string]string, body []byte) error {
+	query := req.URL.Query()
+	for _, p := range op.Parameters {
+		param := d.ResolveParameter(p)
+		var (
+			value   string
+			present bool
+		)
+		switch param.In {
+		case "path":
+			value, present = pathParams[param.Name]
+		case "query":
+			present = query.Has(param.Name)
+			value = query.Get(param.Name)
+		case "header":
+			values := req.Header.Values(param.Name)
+			present = len(values) > 0
+			if present {
+				value = values[0]
+			}
+		default:
+			continue
+		}
+		if !present {
+			if param.Required {
+				return &ValidationError{Field: param.Name, Reason: "is required"}
+			}
+			continue
+		}
+		if err := d.validateParam(d.ResolveSchema(param.Schema), value, param.Name); err != nil {
+			return err
+		}
+	}
+	if op.RequestBody == nil {
+		return nil
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		if op.RequestBody.Required {
+			return &ValidationError{Field: BodyField, Reason: "is required"}
+		}
+		return nil
+	}
+	mediaType, media := selectMedia(op.RequestBody.Content, req.Header.Get("Content-Type"))
+	if media == nil {
+		return &ValidationError{Field: BodyField, Reason: "unsupported content type"}
+	}
+	if mediaType == FormContentType {
+		return d.validateForm(media.Schema, body)
+	}
+	if !IsJSON(mediaType) {
+		return d.validateValue(media.Schema, string(body), "")
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return &ValidationError{Field: BodyField, Reason: "malformed json: " + err.Error()}
+	}
+	return d.validateValue(media.Schema, value, "")
+}
+
+// validateForm - метод проверки тела html формы: поля проверяются как строковые параметры, берется первое значение поля.
+func (d *Document) validateForm(s *Schema, body []byte) error {
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return &ValidationError{Field: BodyField, Reason: "malformed form: " + err.Error()}
+	}
+	value := make(map[string]any, len(form))
+	for name := range form {
+		value[name] = form.Get(name)
+	}
+	return d.validateValue(s, value, "")
+}
+
+// selectMedia - функция выбора описания тела по заголовку Content-Type.
+// Если тип не указан или не описан, но описан только один тип, используется он:
+// клиенты часто отправляют текст или json без заголовка или с типом по умолчанию.
+func selectMedia(content map[string]*MediaType, contentType string) (string, *MediaType) {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		if media, ok := content[mediaType]; ok {
+			return mediaType, media
+		}
+	}
+	if len(content) == 1 {
+		for mediaType, media := range content {
+			return mediaType, media
+		}
+	}
+	return "", nil
+}
+
+// IsJSON - функция проверки, что тип содержимого - json, в том числе с суффиксом +json.
+func IsJSON(mediaType string) bool {
+	if parsed, _, err := mime.ParseMediaType(mediaType); err == nil {
+		mediaType = parsed
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// validateParam - метод проверки строкового значения параметра с приведением к типу схемы.
+func (d *Document) validateParam(s *Schema, raw string, field string) error {
+	if s == nil {
+		return nil
+	}
+	var value any = raw
+	switch s.Type {
+	case "integer", "number":
+		value = json.Number(raw)
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return &ValidationError{Field: field, Reason: "must be a boolean"}
+		}
+		value = b
+	}
+	return d.validateValue(s, value, field)
+}
+
+// validateValue - метод проверки значения по схеме, field - путь к значению для текста ошибки.
+func (d *Document) validateValue(s *Schema, value any, field string) error {
+	s = d.ResolveSchema(s)
+	if s == nil {
+		return nil
+	}
+	name := field
+	if name == "" {
+		name = BodyField
+	}
+	switch s.Type {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return &ValidationError{Field: name, Reason: "must be an object"}
+		}
+		for _, key := range s.Required {
+			if _, ok := obj[key]; !ok {
+				return &ValidationError{Field: joinField(field, key), Reason: "is required"}
+			}
+		}
+		for _, key := range s.PropertyOrder {
+			if v, ok := obj[key]; ok {
+				if err := d.validateValue(s.Properties[key], v, joinField(field, key)); err != nil {
+					return err
+				}
+			}
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return &ValidationError{Field: name, Reason: "must be an array"}
+		}
+		if s.MinItems != nil && len(items) < *s.MinItems {
+			return &ValidationError{Field: name, Reason: fmt.Sprintf("must contain at least %d items", *s.MinItems)}
+		}
+		if s.MaxItems != nil && len(items) > *s.MaxItems {
+			return &ValidationError{Field: name, Reason: fmt.Sprintf("must contain at most %d items", *s.MaxItems)}
+		}
+		for i, item := range items {
+			if err := d.validateValue(s.Items, item, fmt.Sprintf("%s[%d]", field, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return &ValidationError{Field: name, Reason: "must be a string"}
+		}
+		return validateString(s, str, name)
+	case "integer", "number":
+		num, ok := value.(json.Number)
+		if !ok {
+			return &ValidationError{Field: name, Reason: "must be a number"}
+		}
+		var f float64
+		if s.Type == "integer" {
+			i, err := num.Int64()
+			if err != nil {
+				return &ValidationError{Field: name, Reason: "must be an integer"}
+			}
+			f = float64(i)
+		} else {
+			var err error
+			if f, err = num.Float64(); err != nil {
+				return &ValidationError{Field: name, Reason: "must be a number"}
+			}
+		}
+		if s.Minimum != nil && f < *s.Minimum {
+			return &ValidationError{Field: name, Reason: fmt.Sprintf("must be at least %v", *s.Minimum)}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return &ValidationError{Field: name, Reason: "must be a boolean"}
+		}
+	}
+	return nil
+}
+
+// validateString - функция проверки строки по ограничениям длины, перечислению и формату схемы.
+func validateString(s *Schema, value string, field string) error {
+	length := utf8.RuneCountInString(value)
+	if s.MinLength != nil && length < *s.MinLength {
+		if *s.MinLength == 1 {
+			return &ValidationError{Field: field, Reason: "must not be empty"}
+		}
+		return &ValidationError{Field: field, Reason: fmt.Sprintf("must be at least %d characters", *s.MinLength)}
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		return &ValidationError{Field: field, Reason: fmt.Sprintf("must be at most %d characters", *s.MaxLength)}
+	}
+	if len(s.Enum) > 0 {
+		found := false
+		for _, v := range s.Enum {
+			found = found || v == value
+		}
+		if !found {
+			return &ValidationError{Field: field, Reason: "must be one of " + strings.Join(s.Enum, ", ")}
+		}
+	}
+	if s.Format == "date-time" {
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return &ValidationError{Field: field, Reason: "must be a RFC 3339 date-time"}
+		}
+	}
+	return nil
+}
+
+// joinField - функция получения пути к полю объекта.
+func joinField(parent string, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
@@ -0,0 +1,109 @@
+// Пакет problem содержит отправку ответов на ошибки http запросов в формате application/problem+json (RFC 7807).
+// Ошибки приводятся к ошибкам сервиса, статус ответа выбирается по виду и коду ошибки.
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/Dorrrke/shortener-url/internal/logger"
+	"github.com/Dorrrke/shortener-url/internal/models"
+	"github.com/Dorrrke/shortener-url/internal/ratelimit"
+	"github.com/Dorrrke/shortener-url/internal/service"
+)
+
+// StatusClientClosedRequest - нестандартный статус 499: клиент закрыл соединение до получения ответа.
+const StatusClientClosedRequest = 499
+
+// problemTypePrefix - префикс ссылки на описание вида ошибки, к нему добавляется код ошибки.
+const problemTypePrefix = "/problems/"
+
+// kindStatus - http статусы видов ошибок сервиса.
+var kindStatus = map[service.Kind]int{
+	service.KindValidation:     http.StatusBadRequest,
+	service.KindUnauthorized:   http.StatusUnauthorized,
+	service.KindForbidden:      http.StatusForbidden,
+	service.KindQuota:          http.StatusForbidden,
+	service.KindNotFound:       http.StatusNotFound,
+	service.KindConflict:       http.StatusConflict,
+	service.KindGone:           http.StatusGone,
+	service.KindUnavailable:    http.StatusServiceUnavailable,
+	service.KindNotImplemented: http.StatusNotImplemented,
+	service.KindCanceled:       StatusClientClosedRequest,
+	service.KindTimeout:        http.StatusGatewayTimeout,
+}
+
+// codeStatus - http статусы отдельных кодов ошибок, отличающиеся от статуса их вида.
+var codeStatus = map[string]int{
+	service.CodeBatchTooLarge:       http.StatusRequestEntityTooLarge,
+	service.CodeURLBlocked:          http.StatusUnprocessableEntity,
+	service.CodeIdempotencyReused:   http.StatusUnprocessableEntity,
+	service.CodePasswordAttempts:    http.StatusTooManyRequests,
+	service.CodeRateLimited:         http.StatusTooManyRequests,
+	service.CodeUnsupportedEncoding: http.StatusUnsupportedMediaType,
+	service.CodeBodyTooLarge:        http.StatusRequestEntityTooLarge,
+}
+
+// errorStatus - функция выбора http статуса для ошибки сервиса.
+func errorStatus(e *service.Error) int {
+	if status, ok := codeStatus[e.Code]; ok {
+		return status
+	}
+	if status, ok := kindStatus[e.Kind]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Write - функция отправки ответа на ошибку в формате application/problem+json (RFC 7807).
+// Ошибка приводится к ошибке сервиса через service.Classify, внутренние ошибки логируются,
+// а клиенту возвращается только их код без подробностей. При отмене запроса клиентом тело не отправляется.
+// Если ошибка сообщает, когда повторить запрос, отправляется заголовок Retry-After.
+func Write(res http.ResponseWriter, req *http.Request, err error) {
+	e := service.Classify(err)
+	status := errorStatus(e)
+	log := logger.FromContext(req.Context())
+	switch e.Kind {
+	case service.KindCanceled:
+		log.Info("request canceled", zap.Error(err))
+		res.WriteHeader(status)
+		return
+	case service.KindTimeout:
+		log.Warn("request deadline exceeded", zap.Error(err))
+	case service.KindInternal, service.KindUnavailable:
+		log.Error("request failed", zap.String("code", e.Code), zap.Error(err))
+	default:
+		log.Debug("request rejected", zap.String("code", e.Code), zap.Error(err))
+	}
+
+	problem := models.Problem{
+		Type:      problemTypePrefix + e.Code,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    e.Message,
+		Instance:  req.URL.Path,
+		Code:      e.Code,
+		RequestID: logger.RequestIDFromContext(req.Context()),
+
+		ExistingShortURL: e.ExistingShortURL,
+	}
+	if e.Field != "" {
+		reason := e.Message
+		if e.Err != nil {
+			reason = e.Err.Error()
+		}
+		problem.InvalidParams = []models.InvalidParam{{Name: e.Field, Reason: reason}}
+	}
+	res.Header().Set("Content-Type", models.ProblemContentType)
+	res.Header().Set("X-Content-Type-Options", "nosniff")
+	if e.RetryAfter > 0 {
+		res.Header().Set("Retry-After", strconv.Itoa(ratelimit.RetryAfterSeconds(e.RetryAfter)))
+	}
+	res.WriteHeader(status)
+	if err := json.NewEncoder(res).Encode(problem); err != nil {
+		log.Debug("error encoding problem", zap.Error(err))
+	}
+}
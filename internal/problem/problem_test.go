@@ -0,0 +1,156 @@
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dorrrke/shortener-url/internal/models"
+	"github.com/Dorrrke/shortener-url/internal/ratelimit"
+	"github.com/Dorrrke/shortener-url/internal/service"
+	"github.com/Dorrrke/shortener-url/internal/storage"
+	"github.com/Dorrrke/shortener-url/internal/urlcheck"
+)
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		param  string
+	}{
+		{
+			name:   "Internal error",
+			err:    errors.New("connection refused"),
+			status: http.StatusInternalServerError,
+			code:   service.CodeInternal,
+		},
+		{
+			name:   "Invalid url",
+			err:    service.InvalidURL("url", errors.New("missing scheme")),
+			status: http.StatusBadRequest,
+			code:   service.CodeInvalidURL,
+			param:  "url",
+		},
+		{
+			name:   "Quota exceeded",
+			err:    service.ErrQuotaExceeded,
+			status: http.StatusForbidden,
+			code:   service.CodeQuotaExceeded,
+		},
+		{
+			name:   "Batch too large",
+			err:    service.ErrBatchTooLarge,
+			status: http.StatusRequestEntityTooLarge,
+			code:   service.CodeBatchTooLarge,
+		},
+		{
+			name:   "Blocked url",
+			err:    urlcheck.ErrBlocked,
+			status: http.StatusUnprocessableEntity,
+			code:   service.CodeURLBlocked,
+		},
+		{
+			name:   "Url exists",
+			err:    storage.ErrConflict,
+			status: http.StatusConflict,
+			code:   service.CodeURLExists,
+		},
+		{
+			name:   "Deleted url",
+			err:    service.ErrURLDeleted,
+			status: http.StatusGone,
+			code:   service.CodeURLDeleted,
+		},
+		{
+			name:   "Rate limited",
+			err:    &ratelimit.Error{RetryAfter: time.Second},
+			status: http.StatusTooManyRequests,
+			code:   service.CodeRateLimited,
+		},
+		{
+			name:   "Malformed compressed body",
+			err:    service.InvalidEncoding(errors.New("gzip: invalid header")),
+			status: http.StatusBadRequest,
+			code:   service.CodeInvalidEncoding,
+		},
+		{
+			name:   "Unsupported content encoding",
+			err:    service.UnsupportedEncoding("compress"),
+			status: http.StatusUnsupportedMediaType,
+			code:   service.CodeUnsupportedEncoding,
+		},
+		{
+			name:   "Decoded body too large",
+			err:    service.BodyTooLarge(errors.New("decoded body too large")),
+			status: http.StatusRequestEntityTooLarge,
+			code:   service.CodeBodyTooLarge,
+		},
+		{
+			name:   "Timeout",
+			err:    context.DeadlineExceeded,
+			status: http.StatusGatewayTimeout,
+			code:   service.CodeTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/shorten", nil)
+			rec := httptest.NewRecorder()
+			Write(rec, req, tt.err)
+
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Equal(t, models.ProblemContentType, rec.Header().Get("Content-Type"))
+
+			var problem models.Problem
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+			assert.Equal(t, tt.status, problem.Status)
+			assert.Equal(t, tt.code, problem.Code)
+			assert.Equal(t, "/problems/"+tt.code, problem.Type)
+			assert.Equal(t, http.StatusText(tt.status), problem.Title)
+			assert.Equal(t, "/api/shorten", problem.Instance)
+			if tt.param == "" {
+				assert.Empty(t, problem.InvalidParams)
+			} else {
+				require.Len(t, problem.InvalidParams, 1)
+				assert.Equal(t, tt.param, problem.InvalidParams[0].Name)
+			}
+		})
+	}
+}
+
+func TestWriteCanceled(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/user/urls", nil)
+	rec := httptest.NewRecorder()
+	Write(rec, req, context.Canceled)
+
+	assert.Equal(t, StatusClientClosedRequest, rec.Code)
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestWriteHidesInternalDetails(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	rec := httptest.NewRecorder()
+	Write(rec, req, errors.New("password authentication failed for user postgres"))
+
+	assert.NotContains(t, rec.Body.String(), "postgres")
+}
+
+func TestWriteRateLimited(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	rec := httptest.NewRecorder()
+	Write(rec, req, &ratelimit.Error{RetryAfter: 2500 * time.Millisecond})
+
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "3", rec.Header().Get("Retry-After"))
+	assert.Equal(t, models.ProblemContentType, rec.Header().Get("Content-Type"))
+}
@@ -0,0 +1,127 @@
+// Пакет ratelimit содержит ограничение частоты запросов по алгоритму token bucket.
+// Корзина заводится на пару (маршрут, клиент), где клиент - ip адрес, а для запросов с токеном еще и id пользователя.
+// Пакет предоставляет middleware для http сервера и interceptor для grpc сервера.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/Dorrrke/shortener-url/internal/config"
+)
+
+// DefaultRoute - ключ ограничения, применяемого к маршрутам без собственного ограничения.
+const DefaultRoute = "default"
+
+// idleTTL - время, после которого неиспользуемая корзина удаляется из памяти.
+const idleTTL = 10 * time.Minute
+
+// bucket - корзина токенов одного клиента на одном маршруте.
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Limiter - ограничитель частоты запросов.
+// Нулевой указатель на Limiter допустим и пропускает все запросы.
+type Limiter struct {
+	mu        sync.Mutex
+	rules     map[string]config.RateLimit
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// New - функция создания Limiter с ограничениями по маршрутам.
+func New(rules map[string]config.RateLimit) *Limiter {
+	return &Limiter{
+		rules:   rules,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// SetRules - метод замены ограничений по маршрутам, например при перезагрузке конфигурации.
+// Накопленные корзины сбрасываются, чтобы новые ограничения применялись сразу.
+func (l *Limiter) SetRules(rules map[string]config.RateLimit) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rules = rules
+	l.buckets = make(map[string]*bucket)
+}
+
+// rule - метод получения ограничения маршрута, вызывается под блокировкой.
+func (l *Limiter) rule(route string) (config.RateLimit, bool) {
+	if r, ok := l.rules[route]; ok {
+		return r, r.RPS > 0
+	}
+	r, ok := l.rules[DefaultRoute]
+	return r, ok && r.RPS > 0
+}
+
+// Allow - метод проверки, может ли клиент key выполнить запрос к маршруту route.
+// Если лимит исчерпан, возвращается false и время, через которое появится свободный токен.
+func (l *Limiter) Allow(route string, key string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	r, ok := l.rule(route)
+	if !ok {
+		return true, 0
+	}
+
+	l.sweep(now)
+	id := route + "|" + key
+	b, ok := l.buckets[id]
+	if !ok {
+		burst := r.Burst
+		if burst <= 0 {
+			burst = int(math.Ceil(r.RPS))
+		}
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(r.RPS), burst)}
+		l.buckets[id] = b
+	}
+	b.lastSeen = now
+
+	reservation := b.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return false, time.Second
+	}
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// sweep - метод удаления давно неиспользуемых корзин, вызывается под блокировкой.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleTTL {
+		return
+	}
+	for id, b := range l.buckets {
+		if now.Sub(b.lastSeen) > idleTTL {
+			delete(l.buckets, id)
+		}
+	}
+	l.lastSweep = now
+}
+
+// RetryAfterSeconds - функция округления времени ожидания до целых секунд вверх для заголовка Retry-After.
+func RetryAfterSeconds(d time.Duration) int {
+	seconds := int(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
@@ -0,0 +1,164 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Dorrrke/shortener-url/internal/config"
+	"github.com/Dorrrke/shortener-url/internal/utils"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New(map[string]config.RateLimit{
+		"POST /":     {RPS: 1, Burst: 2},
+		DefaultRoute: {RPS: 10, Burst: 1},
+	})
+	l.now = func() time.Time { return now }
+
+	ok, _ := l.Allow("POST /", "ip:1")
+	assert.True(t, ok)
+	ok, _ = l.Allow("POST /", "ip:1")
+	assert.True(t, ok)
+	ok, retryAfter := l.Allow("POST /", "ip:1")
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, retryAfter)
+
+	ok, _ = l.Allow("POST /", "ip:2")
+	assert.True(t, ok, "other client has its own bucket")
+
+	ok, _ = l.Allow("GET /{id}", "ip:1")
+	assert.True(t, ok)
+	ok, _ = l.Allow("GET /{id}", "ip:1")
+	assert.False(t, ok, "default rule applies to unlisted routes")
+
+	now = now.Add(time.Second)
+	ok, _ = l.Allow("POST /", "ip:1")
+	assert.True(t, ok, "bucket refills over time")
+}
+
+func TestLimiterWithoutRules(t *testing.T) {
+	var nilLimiter *Limiter
+	ok, _ := nilLimiter.Allow("POST /", "ip:1")
+	assert.True(t, ok)
+
+	l := New(nil)
+	for i := 0; i < 100; i++ {
+		ok, _ := l.Allow("POST /", "ip:1")
+		require.True(t, ok)
+	}
+}
+
+func TestLimiterSetRules(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New(map[string]config.RateLimit{"POST /": {RPS: 1, Burst: 1}})
+	l.now = func() time.Time { return now }
+
+	ok, _ := l.Allow("POST /", "ip:1")
+	assert.True(t, ok)
+	ok, _ = l.Allow("POST /", "ip:1")
+	assert.False(t, ok)
+
+	l.SetRules(map[string]config.RateLimit{"POST /": {RPS: 1, Burst: 3}})
+	for i := 0; i < 3; i++ {
+		ok, _ = l.Allow("POST /", "ip:1")
+		assert.True(t, ok, "new burst applies immediately")
+	}
+	ok, _ = l.Allow("POST /", "ip:1")
+	assert.False(t, ok)
+
+	l.SetRules(nil)
+	ok, _ = l.Allow("POST /", "ip:1")
+	assert.True(t, ok, "removed rule no longer limits")
+}
+
+func TestMiddleware(t *testing.T) {
+	l := New(map[string]config.RateLimit{"POST /": {RPS: 0.5, Burst: 1}})
+	writeError := func(w http.ResponseWriter, r *http.Request, err error) {
+		var limitErr *Error
+		require.ErrorAs(t, err, &limitErr)
+		w.Header().Set("Retry-After", strconv.Itoa(RetryAfterSeconds(limitErr.RetryAfter)))
+		w.WriteHeader(http.StatusTooManyRequests)
+	}
+	h := l.Middleware("POST /", nil, writeError)(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		code       int
+		retryAfter string
+	}{
+		{name: "Test rate limit #1 First request", remoteAddr: "10.0.0.1:1234", code: http.StatusCreated},
+		{name: "Test rate limit #2 Limit exceeded", remoteAddr: "10.0.0.1:1235", code: http.StatusTooManyRequests, retryAfter: "2"},
+		{name: "Test rate limit #3 Other client", remoteAddr: "10.0.0.2:1234", code: http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			w := httptest.NewRecorder()
+			h(w, req)
+			result := w.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.code, result.StatusCode)
+			assert.Equal(t, tt.retryAfter, result.Header.Get("Retry-After"))
+		})
+	}
+}
+
+func TestMiddlewareAuthCookie(t *testing.T) {
+	l := New(map[string]config.RateLimit{"POST /": {RPS: 0.5, Burst: 1}})
+	h := l.Middleware("POST /", nil, func(w http.ResponseWriter, r *http.Request, err error) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	})(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	do := func(remoteAddr string, userID string) int {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.RemoteAddr = remoteAddr
+		token, err := utils.CreateJWTToken(userID)
+		require.NoError(t, err)
+		req.AddCookie(&http.Cookie{Name: "auth", Value: token})
+		w := httptest.NewRecorder()
+		h(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusCreated, do("10.0.0.1:1234", "user-1"))
+	assert.Equal(t, http.StatusTooManyRequests, do("10.0.0.1:1234", "user-2"), "new cookie must not reset ip limit")
+	assert.Equal(t, http.StatusTooManyRequests, do("10.0.0.2:1234", "user-1"), "user limit applies from another ip")
+	assert.Equal(t, http.StatusCreated, do("10.0.0.3:1234", "user-3"))
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	l := New(map[string]config.RateLimit{"/shortenergrpc.Shortener/InsertBatch": {RPS: 1, Burst: 1}})
+	interceptor := l.UnaryServerInterceptor(nil, func(ctx context.Context, err error) error {
+		var limitErr *Error
+		require.ErrorAs(t, err, &limitErr)
+		assert.Positive(t, limitErr.RetryAfter)
+		return status.Error(codes.ResourceExhausted, err.Error())
+	})
+	info := &grpc.UnaryServerInfo{FullMethod: "/shortenergrpc.Shortener/InsertBatch"}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return "ok", nil
+	}
+
+	res, err := interceptor(context.Background(), nil, info, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", res)
+
+	_, err = interceptor(context.Background(), nil, info, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
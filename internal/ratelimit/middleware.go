@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/Dorrrke/shortener-url/internal/logger"
+	"github.com/Dorrrke/shortener-url/internal/realip"
+	"github.com/Dorrrke/shortener-url/internal/utils"
+)
+
+// Error - ошибка превышения ограничения частоты запросов.
+type Error struct {
+	// RetryAfter - время, через которое запрос можно повторить.
+	RetryAfter time.Duration
+}
+
+// Error - текст ошибки.
+func (e *Error) Error() string {
+	return "too many requests, retry after " + e.RetryAfter.String()
+}
+
+// Middleware - метод создания http middleware ограничения частоты запросов для маршрута route.
+// Запрос учитывается в корзине ip адреса клиента, а при наличии cookie auth - еще и в корзине пользователя.
+// При превышении лимита ответ отправляет writeError с ошибкой *Error, в которой указано, когда повторить запрос.
+func (l *Limiter) Middleware(route string, resolver *realip.Resolver, writeError func(http.ResponseWriter, *http.Request, error)) func(http.HandlerFunc) http.HandlerFunc {
+	return func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			var userID string
+			if cookie, err := r.Cookie("auth"); err == nil {
+				userID = utils.GetUID(cookie.Value)
+			}
+			ok, retryAfter := l.allowClient(route, userID, resolver.FromRequest(r))
+			if !ok {
+				logger.FromContext(r.Context()).Info("rate limit exceeded", zap.String("route", route))
+				writeError(w, r, &Error{RetryAfter: retryAfter})
+				return
+			}
+			h.ServeHTTP(w, r)
+		}
+	}
+}
+
+// UnaryServerInterceptor - метод создания grpc interceptor ограничения частоты вызовов.
+// Маршрутом служит полное имя метода, вызов учитывается в корзине ip адреса клиента,
+// а при наличии токена в метаданных auth - еще и в корзине пользователя.
+// При превышении лимита возвращается ошибка *Error, преобразованная функцией statusError в статус grpc.
+func (l *Limiter) UnaryServerInterceptor(resolver *realip.Resolver, statusError func(context.Context, error) error) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var userID string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get("auth"); len(values) > 0 {
+				userID = utils.GetUID(values[0])
+			}
+		}
+		ok, retryAfter := l.allowClient(info.FullMethod, userID, resolver.FromContext(ctx))
+		if !ok {
+			logger.FromContext(ctx).Info("rate limit exceeded", zap.String("method", info.FullMethod))
+			return nil, statusError(ctx, &Error{RetryAfter: retryAfter})
+		}
+		return handler(ctx, req)
+	}
+}
+
+// allowClient - метод проверки лимита клиента на маршруте route.
+// Запрос всегда списывается с корзины ip адреса, поэтому удаление или смена cookie не позволяют обойти лимит,
+// а запрос пользователя дополнительно списывается с корзины пользователя.
+func (l *Limiter) allowClient(route string, userID string, ip string) (bool, time.Duration) {
+	if ok, retryAfter := l.Allow(route, "ip:"+ip); !ok {
+		return false, retryAfter
+	}
+	if userID == "" {
+		return true, 0
+	}
+	return l.Allow(route, "user:"+userID)
+}
@@ -0,0 +1,135 @@
+// Пакет realip содержит определение реального ip клиента с учетом доверенных прокси.
+// Заголовки X-Real-IP и X-Forwarded-For (и аналогичные метаданные grpc) учитываются
+// только если запрос пришел непосредственно от доверенного прокси.
+package realip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// Resolver - определяет ip клиента по адресу подключения и заголовкам доверенных прокси.
+// Нулевой указатель на Resolver допустим и не доверяет ни одному прокси.
+type Resolver struct {
+	trusted []*net.IPNet
+}
+
+// NewResolver - функция создания Resolver из списка доверенных прокси.
+// Элементы списка могут быть как подсетями в нотации CIDR, так и отдельными ip адресами.
+// В случае ошибки возвращается Resolver с корректными элементами списка и ошибка с некорректными.
+func NewResolver(trustedProxies []string) (*Resolver, error) {
+	r := &Resolver{}
+	var bad []string
+	for _, value := range trustedProxies {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		ipNet, err := ParseNet(value)
+		if err != nil {
+			bad = append(bad, value)
+			continue
+		}
+		r.trusted = append(r.trusted, ipNet)
+	}
+	if len(bad) > 0 {
+		return r, fmt.Errorf("invalid trusted proxies: %s", strings.Join(bad, ", "))
+	}
+	return r, nil
+}
+
+// ParseNet - функция разбора подсети в нотации CIDR или отдельного ip адреса (как подсети из одного адреса).
+func ParseNet(value string) (*net.IPNet, error) {
+	if strings.Contains(value, "/") {
+		_, ipNet, err := net.ParseCIDR(value)
+		return ipNet, err
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid ip address %q", value)
+	}
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		bits = 8 * net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// isTrusted - метод проверки, что адрес принадлежит доверенному прокси.
+func (r *Resolver) isTrusted(ip net.IP) bool {
+	if r == nil || ip == nil {
+		return false
+	}
+	for _, ipNet := range r.trusted {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// FromRequest - метод получения ip клиента для http запроса.
+func (r *Resolver) FromRequest(req *http.Request) string {
+	return r.resolve(req.RemoteAddr, req.Header.Get("X-Real-IP"), req.Header.Values("X-Forwarded-For"))
+}
+
+// FromContext - метод получения ip клиента для grpc вызова.
+func (r *Resolver) FromContext(ctx context.Context) string {
+	var remoteAddr string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		remoteAddr = p.Addr.String()
+	}
+	var realIP string
+	var forwarded []string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("X-Real-IP"); len(values) > 0 {
+			realIP = values[0]
+		}
+		forwarded = md.Get("X-Forwarded-For")
+	}
+	return r.resolve(remoteAddr, realIP, forwarded)
+}
+
+// resolve - метод выбора ip клиента.
+// Если подключение пришло не от доверенного прокси, клиентом считается сам адрес подключения.
+// Иначе используется X-Real-IP, а при его отсутствии - первый справа адрес X-Forwarded-For, не принадлежащий доверенным прокси.
+func (r *Resolver) resolve(remoteAddr string, realIP string, forwarded []string) string {
+	host := hostOnly(remoteAddr)
+	if !r.isTrusted(net.ParseIP(host)) {
+		return host
+	}
+	if ip := net.ParseIP(strings.TrimSpace(realIP)); ip != nil {
+		return ip.String()
+	}
+	var chain []string
+	for _, value := range forwarded {
+		chain = append(chain, strings.Split(value, ",")...)
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(chain[i]))
+		if ip == nil {
+			break
+		}
+		if !r.isTrusted(ip) {
+			return ip.String()
+		}
+		host = ip.String()
+	}
+	return host
+}
+
+// hostOnly - функция отбрасывания порта из адреса подключения.
+func hostOnly(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
@@ -0,0 +1,76 @@
+package realip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolverFromRequest(t *testing.T) {
+	resolver, err := NewResolver([]string{"10.0.0.0/8", "192.168.1.1", "2001:db8::/32"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		realIP     string
+		forwarded  string
+		want       string
+	}{
+		{
+			name:       "Test real ip #1 Direct client ignores headers",
+			remoteAddr: "203.0.113.5:1234",
+			realIP:     "1.2.3.4",
+			forwarded:  "5.6.7.8",
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "Test real ip #2 Trusted proxy with X-Real-IP",
+			remoteAddr: "10.1.2.3:1234",
+			realIP:     "1.2.3.4",
+			want:       "1.2.3.4",
+		},
+		{
+			name:       "Test real ip #3 Trusted proxy chain in X-Forwarded-For",
+			remoteAddr: "192.168.1.1:1234",
+			forwarded:  "6.6.6.6, 1.2.3.4, 10.0.0.7",
+			want:       "1.2.3.4",
+		},
+		{
+			name:       "Test real ip #4 IPv6 trusted proxy",
+			remoteAddr: "[2001:db8::1]:443",
+			forwarded:  "2a00:1450::1",
+			want:       "2a00:1450::1",
+		},
+		{
+			name:       "Test real ip #5 Trusted proxy without headers",
+			remoteAddr: "10.1.2.3:1234",
+			want:       "10.1.2.3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			assert.Equal(t, tt.want, resolver.FromRequest(req))
+		})
+	}
+}
+
+func TestNewResolverInvalid(t *testing.T) {
+	resolver, err := NewResolver([]string{"10.0.0.0/8", "bad"})
+	assert.Error(t, err)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1"
+	req.Header.Set("X-Real-IP", "1.2.3.4")
+	assert.Equal(t, "1.2.3.4", resolver.FromRequest(req))
+}
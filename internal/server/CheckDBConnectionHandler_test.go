@@ -1,7 +1,6 @@
 package server
 
 import (
-	"context"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -37,7 +36,7 @@ func TestCheckDBConnectionHandler(t *testing.T) {
 		request   string
 		method    string
 		dbConnect bool
-		value     context.Context
+		value     gomock.Matcher
 		want      want
 	}{
 		{
@@ -45,7 +44,7 @@ func TestCheckDBConnectionHandler(t *testing.T) {
 			request:   "/ping",
 			method:    http.MethodGet,
 			dbConnect: true,
-			value:     context.Background(),
+			value:     gomock.Any(),
 			want: want{
 				code: http.StatusOK,
 			},
@@ -55,7 +54,7 @@ func TestCheckDBConnectionHandler(t *testing.T) {
 			request:   "/ping",
 			method:    http.MethodGet,
 			dbConnect: false,
-			value:     context.Background(),
+			value:     gomock.Any(),
 			want: want{
 				code: http.StatusInternalServerError,
 			},
@@ -77,7 +76,7 @@ func TestCheckDBConnectionHandler(t *testing.T) {
 
 			var cfg config.AppConfig
 			sService := service.NewService(m, &cfg)
-			serverHTTP = *New(&cfg, sService)
+			serverHTTP = *New(sService)
 			getReq := resty.New().R()
 			getReq.Method = tt.method
 			getReq.URL = srv.URL + tt.request
@@ -106,11 +105,11 @@ func BenchmarkCheckDBConnectionHandler(b *testing.B) {
 
 		m := mock_storage.NewMockStorage(ctrl)
 
-		m.EXPECT().CheckDBConnect(context.Background()).Return(nil)
+		m.EXPECT().CheckDBConnect(gomock.Any()).Return(nil)
 
 		var cfg config.AppConfig
 		sService := service.NewService(m, &cfg)
-		serverHTTP = *New(&cfg, sService)
+		serverHTTP = *New(sService)
 		getReq := resty.New().R()
 		getReq.Method = http.MethodGet
 		getReq.URL = srv.URL + "/ping"
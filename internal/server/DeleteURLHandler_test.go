@@ -1,21 +1,27 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-resty/resty/v2"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 
+	"github.com/Dorrrke/shortener-url/internal/audit"
 	"github.com/Dorrrke/shortener-url/internal/config"
 	"github.com/Dorrrke/shortener-url/internal/logger"
 	"github.com/Dorrrke/shortener-url/internal/service"
+	"github.com/Dorrrke/shortener-url/internal/storage"
 	mock_storage "github.com/Dorrrke/shortener-url/mocks"
 )
 
@@ -31,15 +37,6 @@ func TestDeleteURLHandler(t *testing.T) {
 
 	srv := httptest.NewServer(r)
 
-	cfg := config.AppConfig{
-		ServerAddress:   srv.Config.Addr,
-		BaseURL:         "",
-		FileStoragePath: "",
-		DatabaseDsn:     "",
-		EnableHTTPS:     false,
-	}
-	server.Config = &cfg
-
 	type want struct {
 		code    int
 		getCode int
@@ -89,7 +86,7 @@ func TestDeleteURLHandler(t *testing.T) {
 
 			if tt.dbCall {
 				// m.EXPECT().SetDeleteURLStatus(context.Background(), tt.value).Return(nil)
-				m.EXPECT().GetOriginalURLByShort(context.Background(), srv.URL+"/"+tt.value).Return("url1", true, nil)
+				m.EXPECT().GetOriginalURLByShort(gomock.Any(), srv.URL+"/"+tt.value).Return("url1", true, nil)
 			}
 			token, err := createJWTToken(userID)
 			if err != nil {
@@ -98,7 +95,7 @@ func TestDeleteURLHandler(t *testing.T) {
 
 			var cfg config.AppConfig
 			sService := service.NewService(m, &cfg)
-			server = *New(&cfg, sService)
+			server = *New(sService)
 			getReq := resty.New().R()
 			getReq.Method = tt.method
 			getReq.URL = srv.URL + tt.request
@@ -155,7 +152,7 @@ func BenchmarkDeleteURLHandler(b *testing.B) {
 
 		var cfg config.AppConfig
 		sService := service.NewService(m, &cfg)
-		server = *New(&cfg, sService)
+		server = *New(sService)
 		getReq := resty.New().R()
 		getReq.Method = http.MethodDelete
 		getReq.URL = srv.URL + "/api/user/urls"
@@ -169,3 +166,51 @@ func BenchmarkDeleteURLHandler(b *testing.B) {
 		srv.Close()
 	}
 }
+
+func TestDeleteURLAudit(t *testing.T) {
+	r := chi.NewRouter()
+	var server Server
+	r.Delete("/api/user/urls", server.DeleteURLHandler)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	ctx := context.Background()
+	host := strings.TrimPrefix(srv.URL, "http://")
+	stor := &storage.MemStorage{URLMap: make(map[string]string)}
+	require.NoError(t, stor.InsertURL(ctx, "https://go.dev/", "http://"+host+"/own", "owner"))
+	require.NoError(t, stor.InsertURL(ctx, "https://github.com/", "http://"+host+"/foreign", "other"))
+
+	var buf bytes.Buffer
+	sService := service.NewService(stor, &config.AppConfig{ServerAddress: host})
+	sService.SetAuditor(audit.New(audit.NewWriterSink(&buf)))
+	server = *New(sService)
+
+	token, err := createJWTToken("owner")
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		resp, err := resty.New().R().
+			SetCookie(&http.Cookie{Name: "auth", Value: token}).
+			SetBody(`["own","foreign","missing"]`).
+			Delete(srv.URL + "/api/user/urls")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusAccepted, resp.StatusCode())
+	}
+	require.NoError(t, sService.Close(ctx))
+
+	_, deleted, err := stor.GetOriginalURLByShort(ctx, "http://"+host+"/foreign")
+	require.NoError(t, err)
+	assert.False(t, deleted, "url of other user must not be deleted")
+
+	var records []audit.Record
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var rec audit.Record
+		require.NoError(t, dec.Decode(&rec))
+		records = append(records, rec)
+	}
+	require.Len(t, records, 1, "only the url actually deleted is audited, once")
+	assert.Equal(t, audit.ActionDelete, records[0].Action)
+	assert.Equal(t, "owner", records[0].Actor)
+	assert.Equal(t, "http://"+host+"/own", records[0].Target)
+	assert.Equal(t, "127.0.0.1", records[0].IP)
+}
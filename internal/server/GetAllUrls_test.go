@@ -1,7 +1,6 @@
 package server
 
 import (
-	"context"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -80,8 +79,8 @@ func TestGetAllUrls(t *testing.T) {
 			dbCall:  false,
 			want: want{
 				code:        http.StatusUnauthorized,
-				contentType: "text/plain; charset=utf-8",
-				body:        `User unauth`,
+				contentType: "application/problem+json",
+				body:        `{"type":"/problems/unauthorized","title":"Unauthorized","status":401,"detail":"user is not authorized","instance":"/api/user/urls","code":"unauthorized"}`,
 			},
 		},
 		{
@@ -93,7 +92,7 @@ func TestGetAllUrls(t *testing.T) {
 			value:   []models.URLModel{},
 			want: want{
 				code:        http.StatusNoContent,
-				contentType: "text/plain; charset=utf-8",
+				contentType: "",
 				body:        ``,
 			},
 		},
@@ -108,7 +107,7 @@ func TestGetAllUrls(t *testing.T) {
 			userID := tt.userID
 
 			if tt.dbCall {
-				m.EXPECT().GetAllUrls(context.Background(), userID).Return(tt.value, nil)
+				m.EXPECT().GetAllUrls(gomock.Any(), userID).Return(tt.value, nil)
 			}
 			token, err := createJWTToken(userID)
 			if err != nil {
@@ -117,7 +116,7 @@ func TestGetAllUrls(t *testing.T) {
 
 			var cfg config.AppConfig
 			sService := service.NewService(m, &cfg)
-			server = *New(&cfg, sService)
+			server = *New(sService)
 			getReq := resty.New().R()
 			getReq.Method = tt.method
 			getReq.URL = srv.URL + tt.request
@@ -166,7 +165,7 @@ func BenchmarkGetAllUrls(b *testing.B) {
 			},
 		}
 
-		m.EXPECT().GetAllUrls(context.Background(), userID).Return(value, nil)
+		m.EXPECT().GetAllUrls(gomock.Any(), userID).Return(value, nil)
 
 		token, err := createJWTToken(userID)
 		if err != nil {
@@ -175,7 +174,7 @@ func BenchmarkGetAllUrls(b *testing.B) {
 
 		var cfg config.AppConfig
 		sService := service.NewService(m, &cfg)
-		server = *New(&cfg, sService)
+		server = *New(sService)
 		getReq := resty.New().R()
 		getReq.Method = http.MethodGet
 		getReq.URL = srv.URL + "/api/user/urls"
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dorrrke/shortener-url/internal/config"
+	"github.com/Dorrrke/shortener-url/internal/models"
+	"github.com/Dorrrke/shortener-url/internal/service"
+	"github.com/Dorrrke/shortener-url/internal/storage"
+)
+
+func TestUserQuota(t *testing.T) {
+	r := chi.NewRouter()
+	var server Server
+
+	r.Route("/", func(r chi.Router) {
+		r.Post("/", server.ShortenerURLHandler)
+		r.Post("/api/shorten/batch", server.InsertBatchHandler)
+		r.Get("/api/user/quota", server.GetUserQuota)
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	cfg := config.AppConfig{
+		ServerAddress:   srv.Config.Addr,
+		MaxLinksPerUser: 2,
+		MaxBatchSize:    3,
+	}
+	sService := service.NewService(&storage.MemStorage{URLMap: make(map[string]string)}, &cfg)
+	server = *New(sService)
+
+	token, err := createJWTToken("quota-user")
+	require.NoError(t, err)
+	authCookie := &http.Cookie{Name: "auth", Value: token, Path: "/"}
+
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		body   string
+		code   int
+	}{
+		{
+			name:   "Test quota #1 First link",
+			method: http.MethodPost,
+			url:    "/",
+			body:   "https://practicum.yandex.ru/",
+			code:   http.StatusCreated,
+		},
+		{
+			name:   "Test quota #2 Batch too large",
+			method: http.MethodPost,
+			url:    "/api/shorten/batch",
+			body:   `[{"correlation_id":"1","original_url":"https://a.ru/"},{"correlation_id":"2","original_url":"https://b.ru/"},{"correlation_id":"3","original_url":"https://c.ru/"},{"correlation_id":"4","original_url":"https://d.ru/"}]`,
+			code:   http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:   "Test quota #3 Batch too large is rejected before url validation",
+			method: http.MethodPost,
+			url:    "/api/shorten/batch",
+			body:   `[{"correlation_id":"1","original_url":"not a url"},{"correlation_id":"2","original_url":"https://b.ru/"},{"correlation_id":"3","original_url":"https://c.ru/"},{"correlation_id":"4","original_url":"https://d.ru/"}]`,
+			code:   http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:   "Test quota #4 Batch exceeds links quota",
+			method: http.MethodPost,
+			url:    "/api/shorten/batch",
+			body:   `[{"correlation_id":"1","original_url":"https://a.ru/"},{"correlation_id":"2","original_url":"https://b.ru/"}]`,
+			code:   http.StatusForbidden,
+		},
+		{
+			name:   "Test quota #5 Second link",
+			method: http.MethodPost,
+			url:    "/",
+			body:   "https://go.dev/",
+			code:   http.StatusCreated,
+		},
+		{
+			name:   "Test quota #6 Links quota exceeded",
+			method: http.MethodPost,
+			url:    "/",
+			body:   "https://github.com/",
+			code:   http.StatusForbidden,
+		},
+		{
+			name:   "Test quota #7 Existing url at limit is a conflict",
+			method: http.MethodPost,
+			url:    "/",
+			body:   "https://go.dev/",
+			code:   http.StatusConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := resty.New().R()
+			req.Method = tt.method
+			req.URL = srv.URL + tt.url
+			req.Body = tt.body
+			req.Cookies = append(req.Cookies, authCookie)
+			resp, err := req.Send()
+			require.NoError(t, err)
+			assert.Equal(t, tt.code, resp.StatusCode())
+		})
+	}
+
+	req := resty.New().R()
+	req.Method = http.MethodGet
+	req.URL = srv.URL + "/api/user/quota"
+	req.Cookies = append(req.Cookies, authCookie)
+	resp, err := req.Send()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+
+	var quota models.QuotaModel
+	require.NoError(t, json.Unmarshal(resp.Body(), &quota))
+	assert.Equal(t, models.QuotaModel{LinksUsed: 2, LinksLimit: 2, BatchLimit: 3}, quota)
+
+	unauthReq := resty.New().R()
+	unauthReq.Method = http.MethodGet
+	unauthReq.URL = srv.URL + "/api/user/quota"
+	resp, err = unauthReq.Send()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode())
+}
@@ -0,0 +1,231 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dorrrke/shortener-url/internal/config"
+	"github.com/Dorrrke/shortener-url/internal/models"
+	"github.com/Dorrrke/shortener-url/internal/service"
+	"github.com/Dorrrke/shortener-url/internal/storage"
+)
+
+func TestImportExportURLs(t *testing.T) {
+	r := chi.NewRouter()
+	var server Server
+
+	r.Route("/", func(r chi.Router) {
+		r.Get("/api/user/urls/export", server.ExportUserURLs)
+		r.Post("/api/user/urls/import", server.ImportUserURLs)
+		r.Get("/api/internal/urls/export", server.ExportAllURLs)
+		r.Post("/api/internal/urls/import", server.ImportAllURLs)
+		r.Get("/{id}", server.GetOriginalURLHandler)
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	ctx := context.Background()
+	host := strings.TrimPrefix(srv.URL, "http://")
+	stor := &storage.MemStorage{URLMap: make(map[string]string)}
+	require.NoError(t, stor.InsertURL(ctx, "https://github.com/", "http://"+host+"/taken", "other-user"))
+
+	cfg := config.AppConfig{ServerAddress: host}
+	server = *New(service.NewService(stor, &cfg))
+
+	token, err := createJWTToken("import-user")
+	require.NoError(t, err)
+	authCookie := &http.Cookie{Name: "auth", Value: token, Path: "/"}
+
+	send := func(method, path, body string, auth bool) *resty.Response {
+		req := resty.New().R()
+		req.Method = method
+		req.URL = srv.URL + path
+		req.Body = body
+		if auth {
+			req.Cookies = append(req.Cookies, authCookie)
+		}
+		resp, err := req.Send()
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := send(http.MethodPost, "/api/user/urls/import", "short_url,original_url\nhttp://old/abc,https://go.dev/\n", false)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode())
+
+	resp = send(http.MethodPost, "/api/user/urls/import", "short_url\nhttp://old/abc\n", true)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode(), "header without original_url")
+
+	resp = send(http.MethodPost, "/api/user/urls/import?format=xml", "original_url\nhttps://go.dev/\n", true)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode())
+
+	body := "short_url,original_url,deleted,user_id\n" +
+		"http://old.example/abc,https://go.dev/,false,someone-else\n" +
+		"http://old.example/gone,https://go.dev/blog,true,\n" +
+		",https://example.com/,,\n" +
+		"http://old.example/bad,not a url,false,\n" +
+		"http://old.example/flag,https://example.org/,maybe,\n" +
+		"http://old.example/taken,https://example.net/,false,\n" +
+		"http://other.example/abc,https://go.dev/,false,\n"
+	resp = send(http.MethodPost, "/api/user/urls/import", body, true)
+	require.Equal(t, http.StatusOK, resp.StatusCode(), string(resp.Body()))
+	var report models.ImportReport
+	require.NoError(t, json.Unmarshal(resp.Body(), &report))
+	assert.Equal(t, 7, report.Total)
+	assert.Equal(t, 3, report.Imported)
+	assert.Equal(t, 1, report.Existing)
+	assert.Equal(t, 3, report.Failed)
+	require.Len(t, report.Errors, 3)
+	assert.Equal(t, 5, report.Errors[0].Line)
+	assert.Equal(t, "original_url", report.Errors[0].Field)
+	assert.Equal(t, models.ImportError{Line: 6, Field: "deleted", Code: service.CodeInvalidRequest, Reason: "must be true or false"}, report.Errors[1])
+	assert.Equal(t, 7, report.Errors[2].Line)
+	assert.Equal(t, service.CodeURLExists, report.Errors[2].Code)
+
+	client := resty.New().SetRedirectPolicy(resty.NoRedirectPolicy())
+	resp, _ = client.R().Get(srv.URL + "/abc")
+	assert.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode())
+	resp, _ = client.R().Get(srv.URL + "/gone")
+	assert.Equal(t, http.StatusGone, resp.StatusCode())
+
+	resp = send(http.MethodGet, "/api/user/urls/export", "", true)
+	require.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Contains(t, resp.Header().Get("Content-Type"), "text/csv")
+	lines := strings.Split(strings.TrimSpace(string(resp.Body())), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, "short_url,original_url,deleted,created_at,protected", lines[0])
+	assert.Contains(t, string(resp.Body()), "http://"+host+"/abc,https://go.dev/,false,")
+	assert.Contains(t, string(resp.Body()), ",false\n")
+	assert.Contains(t, string(resp.Body()), "http://"+host+"/gone,https://go.dev/blog,true,")
+
+	resp = send(http.MethodGet, "/api/user/urls/export?format=jsonl", "", true)
+	require.Equal(t, http.StatusOK, resp.StatusCode())
+	lines = strings.Split(strings.TrimSpace(string(resp.Body())), "\n")
+	require.Len(t, lines, 3)
+	var exported models.ExportURL
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &exported))
+	assert.Empty(t, exported.UserID)
+	assert.False(t, exported.CreatedAt.IsZero())
+
+	// Без доверенной подсети полная выгрузка и загрузка запрещены.
+	resp = send(http.MethodGet, "/api/internal/urls/export", "", false)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode())
+
+	cfg.TrustedSubnet = "127.0.0.1/32"
+	server = *New(service.NewService(stor, &cfg))
+
+	resp = send(http.MethodGet, "/api/internal/urls/export?format=jsonl", "", false)
+	require.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, 4, strings.Count(string(resp.Body()), "\n"))
+	assert.Contains(t, string(resp.Body()), `"user_id":"import-user"`)
+	assert.Contains(t, string(resp.Body()), `"user_id":"other-user"`)
+
+	body = `{"short_url":"http://old.example/admin","original_url":"https://admin.example/","user_id":"owner"}` + "\n\n" +
+		`{"original_url":"https://orphan.example/"}` + "\n" +
+		`{"original_url":` + "\n"
+	resp = send(http.MethodPost, "/api/internal/urls/import?format=jsonl", body, false)
+	require.Equal(t, http.StatusOK, resp.StatusCode(), string(resp.Body()))
+	report = models.ImportReport{}
+	require.NoError(t, json.Unmarshal(resp.Body(), &report))
+	assert.Equal(t, 3, report.Total)
+	assert.Equal(t, 1, report.Imported)
+	require.Len(t, report.Errors, 2)
+	assert.Equal(t, models.ImportError{Line: 3, Field: "user_id", Code: service.CodeInvalidRequest, Reason: "user id is required"}, report.Errors[0])
+	assert.Equal(t, 4, report.Errors[1].Line)
+
+	urls, err := stor.GetAllUrls(ctx, "owner")
+	require.NoError(t, err)
+	assert.Equal(t, []models.URLModel{{ShortID: "http://" + host + "/admin", OriginalID: "https://admin.example/"}}, urls)
+}
+
+func TestImportExportProtectedURL(t *testing.T) {
+	r := chi.NewRouter()
+	var server Server
+
+	r.Route("/", func(r chi.Router) {
+		r.Get("/api/user/urls/export", server.ExportUserURLs)
+		r.Post("/api/user/urls/import", server.ImportUserURLs)
+		r.Get("/api/internal/urls/export", server.ExportAllURLs)
+		r.Post("/api/internal/urls/import", server.ImportAllURLs)
+		r.Get("/{id}", server.GetOriginalURLHandler)
+		r.Post("/{id}", server.UnlockURLHandler)
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	ctx := context.Background()
+	host := strings.TrimPrefix(srv.URL, "http://")
+	cfg := config.AppConfig{ServerAddress: host, TrustedSubnet: "127.0.0.1/32"}
+	source := service.NewService(&storage.MemStorage{URLMap: make(map[string]string)}, &cfg)
+	_, err := source.SaveProtectedURL(ctx, "https://docs.internal/", "http://"+host+"/secret", "owner", "pw")
+	require.NoError(t, err)
+	server = *New(source)
+
+	token, err := createJWTToken("owner")
+	require.NoError(t, err)
+	client := resty.New().SetRedirectPolicy(resty.RedirectPolicyFunc(func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}))
+
+	resp, err := client.R().SetCookie(&http.Cookie{Name: "auth", Value: token}).Get(srv.URL + "/api/user/urls/export?format=jsonl")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode())
+	userExport := string(resp.Body())
+	assert.Contains(t, userExport, `"protected":true`)
+	assert.NotContains(t, userExport, "password_hash")
+
+	resp, err = client.R().Get(srv.URL + "/api/internal/urls/export")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode())
+	fullExport := string(resp.Body())
+	assert.Contains(t, fullExport, ",password_hash\n")
+
+	server = *New(service.NewService(&storage.MemStorage{URLMap: make(map[string]string)}, &cfg))
+
+	resp, err = client.R().SetCookie(&http.Cookie{Name: "auth", Value: token}).SetBody(userExport).Post(srv.URL + "/api/user/urls/import?format=jsonl")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode(), string(resp.Body()))
+	var report models.ImportReport
+	require.NoError(t, json.Unmarshal(resp.Body(), &report))
+	assert.Equal(t, 0, report.Imported)
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, "password_hash", report.Errors[0].Field)
+
+	resp, err = client.R().Get(srv.URL + "/secret")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode(), "protected url must not be imported without password hash")
+
+	resp, err = client.R().SetBody(fullExport).Post(srv.URL + "/api/internal/urls/import")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode(), string(resp.Body()))
+	report = models.ImportReport{}
+	require.NoError(t, json.Unmarshal(resp.Body(), &report))
+	assert.Equal(t, 1, report.Imported)
+
+	// Защищенная ссылка на уже сокращенный url под другим идентификатором учитывается как существующая.
+	resp, err = client.R().SetBody(strings.Replace(fullExport, "/secret,", "/renamed,", 1)).Post(srv.URL + "/api/internal/urls/import")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode(), string(resp.Body()))
+	report = models.ImportReport{}
+	require.NoError(t, json.Unmarshal(resp.Body(), &report))
+	assert.Equal(t, 1, report.Existing)
+	assert.Equal(t, 0, report.Failed)
+
+	resp, err = client.R().Get(srv.URL + "/secret")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode(), "imported url must stay protected")
+	assert.Empty(t, resp.Header().Get("Location"))
+
+	resp, err = client.R().SetFormData(map[string]string{"password": "pw"}).Post(srv.URL + "/secret")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusSeeOther, resp.StatusCode())
+	assert.Equal(t, "https://docs.internal/", resp.Header().Get("Location"))
+}
@@ -1,7 +1,6 @@
 package server
 
 import (
-	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -74,7 +73,7 @@ func TestInsertBatchHandler(t *testing.T) {
 			dbCall:  false,
 			want: want{
 				code:        http.StatusUnauthorized,
-				contentType: "text/plain; charset=utf-8",
+				contentType: "application/problem+json",
 			},
 		},
 	}
@@ -88,7 +87,7 @@ func TestInsertBatchHandler(t *testing.T) {
 
 			m := mock_storage.NewMockStorage(ctrl)
 			if tt.dbCall {
-				m.EXPECT().InsertBanchURL(context.Background(), gomock.All()).Return(nil)
+				m.EXPECT().InsertBanchURL(gomock.Any(), gomock.All()).Return(nil)
 			}
 			token, err := createJWTToken(userID)
 			if err != nil {
@@ -96,7 +95,7 @@ func TestInsertBatchHandler(t *testing.T) {
 			}
 
 			sService := service.NewService(m, &cfg)
-			server = *New(&cfg, sService)
+			server = *New(sService)
 
 			getReq := resty.New().R()
 			getReq.Method = tt.method
@@ -131,7 +130,7 @@ func BenchmarkInsertBatchHandler(b *testing.B) {
 		defer ctrl.Finish()
 
 		m := mock_storage.NewMockStorage(ctrl)
-		m.EXPECT().InsertBanchURL(context.Background(), gomock.All()).Return(nil)
+		m.EXPECT().InsertBanchURL(gomock.Any(), gomock.All()).Return(nil)
 
 		cfg := config.AppConfig{
 			ServerAddress:   srv.Config.Addr,
@@ -141,7 +140,7 @@ func BenchmarkInsertBatchHandler(b *testing.B) {
 			EnableHTTPS:     false,
 		}
 		sService := service.NewService(m, &cfg)
-		server = *New(&cfg, sService)
+		server = *New(sService)
 
 		userID := "asgds-ryew24-nbf45"
 
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dorrrke/shortener-url/internal/config"
+	"github.com/Dorrrke/shortener-url/internal/service"
+	"github.com/Dorrrke/shortener-url/internal/storage"
+)
+
+func TestRestoreURLHandler(t *testing.T) {
+	r := chi.NewRouter()
+	var server Server
+
+	r.Route("/", func(r chi.Router) {
+		r.Post("/api/user/urls/restore", server.RestoreURLHandler)
+		r.Get("/{id}", server.GetOriginalURLHandler)
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	ctx := context.Background()
+	host := strings.TrimPrefix(srv.URL, "http://")
+	stor := &storage.MemStorage{URLMap: make(map[string]string)}
+	require.NoError(t, stor.InsertURL(ctx, "https://go.dev/", "http://"+host+"/own", "restore-user"))
+	require.NoError(t, stor.InsertURL(ctx, "https://github.com/", "http://"+host+"/other", "other-user"))
+	require.NoError(t, stor.InsertURL(ctx, "https://example.com/", "http://"+host+"/active", "restore-user"))
+	_, err := stor.SetDeleteURLStatus(ctx, []string{"http://" + host + "/own"}, "restore-user")
+	require.NoError(t, err)
+	_, err = stor.SetDeleteURLStatus(ctx, []string{"http://" + host + "/other"}, "other-user")
+	require.NoError(t, err)
+
+	cfg := config.AppConfig{ServerAddress: host}
+	server = *New(service.NewService(stor, &cfg))
+
+	token, err := createJWTToken("restore-user")
+	require.NoError(t, err)
+	authCookie := &http.Cookie{Name: "auth", Value: token, Path: "/"}
+
+	tests := []struct {
+		name     string
+		body     string
+		auth     bool
+		code     int
+		restored []string
+	}{
+		{
+			name: "Test restore #1 Without cookie",
+			body: `["own"]`,
+			code: http.StatusUnauthorized,
+		},
+		{
+			name: "Test restore #2 Invalid body",
+			body: `{"id":"own"}`,
+			auth: true,
+			code: http.StatusBadRequest,
+		},
+		{
+			name:     "Test restore #3 Only own deleted urls",
+			body:     `["own","other","active","unknown"]`,
+			auth:     true,
+			code:     http.StatusOK,
+			restored: []string{"http://" + host + "/own"},
+		},
+		{
+			name:     "Test restore #4 Already restored",
+			body:     `["own"]`,
+			auth:     true,
+			code:     http.StatusOK,
+			restored: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := resty.New().R()
+			req.Method = http.MethodPost
+			req.URL = srv.URL + "/api/user/urls/restore"
+			req.Body = tt.body
+			if tt.auth {
+				req.Cookies = append(req.Cookies, authCookie)
+			}
+			resp, err := req.Send()
+			require.NoError(t, err)
+			assert.Equal(t, tt.code, resp.StatusCode())
+			if tt.restored != nil {
+				var restored []string
+				require.NoError(t, json.Unmarshal(resp.Body(), &restored))
+				assert.Equal(t, tt.restored, restored)
+			}
+		})
+	}
+
+	client := resty.New().SetRedirectPolicy(resty.NoRedirectPolicy())
+	resp, _ := client.R().Get(srv.URL + "/own")
+	assert.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode())
+	resp, _ = client.R().Get(srv.URL + "/other")
+	assert.Equal(t, http.StatusGone, resp.StatusCode())
+}
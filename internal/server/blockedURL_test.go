@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dorrrke/shortener-url/internal/config"
+	"github.com/Dorrrke/shortener-url/internal/service"
+	"github.com/Dorrrke/shortener-url/internal/storage"
+	"github.com/Dorrrke/shortener-url/internal/urlcheck"
+)
+
+// switchChecker - проверка, блокирующая url с подстрокой evil, если включена.
+type switchChecker struct {
+	enabled atomic.Bool
+}
+
+func (c *switchChecker) Check(_ context.Context, rawURL string) error {
+	if c.enabled.Load() && strings.Contains(rawURL, "evil") {
+		return urlcheck.ErrBlocked
+	}
+	return nil
+}
+
+func TestBlockedURL(t *testing.T) {
+	r := chi.NewRouter()
+	var server Server
+
+	r.Route("/", func(r chi.Router) {
+		r.Post("/", server.ShortenerURLHandler)
+		r.Get("/{id}", server.GetOriginalURLHandler)
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	cfg := config.AppConfig{
+		ServerAddress: srv.Config.Addr,
+	}
+	sService := service.NewService(&storage.MemStorage{URLMap: make(map[string]string)}, &cfg)
+	checker := &switchChecker{}
+	sService.SetURLChecker(checker)
+	server = *New(sService)
+
+	client := resty.New().SetRedirectPolicy(resty.NoRedirectPolicy())
+
+	resp, err := client.R().SetBody("https://evil.com/login").Post(srv.URL + "/")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode())
+	short := string(resp.Body())
+
+	checker.enabled.Store(true)
+
+	resp, err = client.R().SetBody("https://evil.org/").Post(srv.URL + "/")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode())
+
+	resp, err = client.R().Get(srv.URL + short[strings.LastIndex(short, "/"):])
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode())
+	assert.Contains(t, resp.Header().Get("Content-Type"), "text/html")
+	assert.Empty(t, resp.Header().Get("Location"))
+	assert.Contains(t, string(resp.Body()), "https://evil.com/login")
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Dorrrke/shortener-url/internal/config"
+	"github.com/Dorrrke/shortener-url/internal/service"
+	mock_storage "github.com/Dorrrke/shortener-url/mocks"
+)
+
+func TestContextErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeouts map[string]config.Duration
+		cancel   bool
+		want     int
+	}{
+		{
+			name:     "Operation timeout",
+			timeouts: map[string]config.Duration{service.OpCheckDBConnection: config.Duration(10 * time.Millisecond)},
+			want:     http.StatusGatewayTimeout,
+		},
+		{
+			name:     "Default operation timeout",
+			timeouts: map[string]config.Duration{config.DefaultOperationTimeout: config.Duration(10 * time.Millisecond)},
+			want:     http.StatusGatewayTimeout,
+		},
+		{
+			name:   "Client closed request",
+			cancel: true,
+			want:   StatusClientClosedRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mock_storage.NewMockStorage(ctrl)
+			m.EXPECT().CheckDBConnect(gomock.Any()).DoAndReturn(func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			})
+
+			cfg := config.AppConfig{OperationTimeouts: tt.timeouts}
+			serverHTTP := New(service.NewService(m, &cfg))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil).WithContext(ctx)
+			rec := httptest.NewRecorder()
+			serverHTTP.CheckDBConnectionHandler(rec, req)
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
@@ -35,7 +35,7 @@ func ExampleServer_GetAllUrls() {
 
 	var cfg config.AppConfig
 	sService := service.NewService(&storage.DBStorage{DB: pool}, &cfg)
-	server = *New(&cfg, sService)
+	server = *New(sService)
 
 	// Создаем jwt токен с id пользвователя
 	token, err := createJWTToken("asgds-ryew24-nbf45")
@@ -60,7 +60,7 @@ func ExampleServer_GetOriginalURLHandler() {
 
 	var cfg config.AppConfig
 	sService := service.NewService(&storage.MemStorage{URLMap: make(map[string]string)}, &cfg)
-	URLServer = *New(&cfg, sService)
+	URLServer = *New(sService)
 
 	r.Route("/", func(r chi.Router) {
 		r.Post("/", URLServer.ShortenerURLHandler)
@@ -81,7 +81,7 @@ func ExampleServer_ShortenerURLHandler() {
 	var URLServer Server
 	var cfg config.AppConfig
 	sService := service.NewService(&storage.MemStorage{URLMap: make(map[string]string)}, &cfg)
-	URLServer = *New(&cfg, sService)
+	URLServer = *New(sService)
 
 	body := strings.NewReader("https://www.youtube.com/")
 	request := httptest.NewRequest(http.MethodPost, "/", body)
@@ -93,7 +93,7 @@ func ExampleServer_ShortenerJSONURLHandler() {
 	var URLServer Server
 	var cfg config.AppConfig
 	sService := service.NewService(&storage.MemStorage{URLMap: make(map[string]string)}, &cfg)
-	URLServer = *New(&cfg, sService)
+	URLServer = *New(sService)
 
 	body := strings.NewReader(`{"url":"https://www.youtube.com/"}`)
 	request := httptest.NewRequest(http.MethodPost, "/api/shorten", body)
@@ -124,7 +124,7 @@ func ExampleServer_InsertBatchHandler() {
 	}
 	var cfg config.AppConfig
 	sService := service.NewService(&storage.DBStorage{DB: pool}, &cfg)
-	server = *New(&cfg, sService)
+	server = *New(sService)
 
 	getReq := resty.New().R()
 	getReq.Method = http.MethodPost
@@ -159,7 +159,7 @@ func ExampleServer_DeleteURLHandler() {
 	}
 	var cfg config.AppConfig
 	sService := service.NewService(&storage.DBStorage{DB: pool}, &cfg)
-	server = *New(&cfg, sService)
+	server = *New(sService)
 	getReq := resty.New().R()
 	getReq.Method = http.MethodDelete
 	getReq.URL = srv.URL + "/api/user/urls"
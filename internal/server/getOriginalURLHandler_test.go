@@ -36,7 +36,7 @@ func TestGetOriginalURLHandler(t *testing.T) {
 	}
 
 	sService := service.NewService(&storage.MemStorage{URLMap: make(map[string]string)}, &cfg)
-	URLServer = *New(&cfg, sService)
+	URLServer = *New(sService)
 
 	type want struct {
 		code     int
@@ -117,7 +117,7 @@ func BenchmarkGetOriginalURLHandler(b *testing.B) {
 			EnableHTTPS:     false,
 		}
 		sService := service.NewService(&storage.MemStorage{URLMap: make(map[string]string)}, &cfg)
-		URLServer = *New(&cfg, sService)
+		URLServer = *New(sService)
 
 		postReq := resty.New().R()
 		postReq.Method = http.MethodPost
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/Dorrrke/shortener-url/internal/idempotency"
+	"github.com/Dorrrke/shortener-url/internal/logger"
+	"github.com/Dorrrke/shortener-url/internal/service"
+	"go.uber.org/zap"
+)
+
+// Idempotent - middleware обработки заголовка Idempotency-Key для запросов сокращения.
+// Ответ на первый запрос с ключом сохраняется на время config.AppConfig.IdempotencyTTL, повтор с тем же ключом
+// и тем же телом получает сохраненный ответ с заголовком Idempotent-Replayed: true без повторного выполнения.
+// Тот же ключ с другим запросом отклоняется со статусом 422, а пока первый запрос выполняется - со статусом 409.
+// Ключи разделяются по пользователю из cookie auth, ключи анонимных запросов - по ip адресу клиента.
+// Выданная cookie auth сохраняется и повторяется только в области пользователя: за одним ip могут быть разные
+// анонимные клиенты, и токен одного из них не должен попасть к другому. Запросы без заголовка обрабатываются как обычно.
+func (s *Server) Idempotent(h http.HandlerFunc) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		key := req.Header.Get(idempotency.Header)
+		if key == "" {
+			h.ServeHTTP(res, req)
+			return
+		}
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			writeError(res, req, service.InvalidRequest(err))
+			return
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := idempotency.AnonymousScope(s.ipResolver.FromRequest(req))
+		anonymous := true
+		if cookie, err := req.Cookie("auth"); err == nil {
+			if userID := GetUID(cookie.Value); userID != "" {
+				scope = userID
+				anonymous = false
+			}
+		}
+		keeper := s.sService.Idempotency()
+		stored, err := keeper.Begin(req.Context(), scope, key, idempotency.Hash([]byte(req.Method), []byte(req.URL.Path), body))
+		if err != nil {
+			writeError(res, req, err)
+			return
+		}
+		if stored != nil {
+			logger.FromContext(req.Context()).Debug("Replay idempotent response", zap.String("key", key))
+			for name, values := range stored.Headers {
+				if anonymous && http.CanonicalHeaderKey(name) == "Set-Cookie" {
+					continue
+				}
+				for _, value := range values {
+					res.Header().Add(name, value)
+				}
+			}
+			if stored.ContentType != "" {
+				res.Header().Set("Content-Type", stored.ContentType)
+			}
+			res.Header().Set("Content-Length", strconv.Itoa(len(stored.Body)))
+			res.Header().Set(idempotency.ReplayedHeader, "true")
+			res.WriteHeader(stored.Status)
+			res.Write(stored.Body)
+			return
+		}
+
+		rec := &recordingWriter{ResponseWriter: res}
+		completed := false
+		// Ответ сохраняется и после отмены запроса клиентом: он уже выполнен и будет нужен при повторе.
+		ctx := context.WithoutCancel(req.Context())
+		defer func() {
+			if !completed {
+				keeper.Release(ctx, scope, key)
+			}
+		}()
+		h.ServeHTTP(rec, req)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		if idempotency.Storable(rec.status) {
+			var headers map[string][]string
+			if cookies := rec.Header().Values("Set-Cookie"); len(cookies) > 0 && !anonymous {
+				headers = map[string][]string{"Set-Cookie": cookies}
+			}
+			keeper.Complete(ctx, scope, key, rec.status, rec.Header().Get("Content-Type"), headers, rec.body.Bytes())
+			completed = true
+		}
+	}
+}
+
+// recordingWriter - http.ResponseWriter, запоминающий статус и тело ответа для сохранения.
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// WriteHeader - метод записи статуса ответа.
+func (w *recordingWriter) WriteHeader(statusCode int) {
+	if w.status == 0 {
+		w.status = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write - метод записи тела ответа.
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap - метод получения исходного http.ResponseWriter для http.ResponseController.
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
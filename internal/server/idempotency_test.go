@@ -0,0 +1,117 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dorrrke/shortener-url/internal/config"
+	"github.com/Dorrrke/shortener-url/internal/idempotency"
+	"github.com/Dorrrke/shortener-url/internal/models"
+	"github.com/Dorrrke/shortener-url/internal/service"
+	"github.com/Dorrrke/shortener-url/internal/storage"
+)
+
+func TestIdempotent(t *testing.T) {
+	cfg := config.AppConfig{ServerAddress: "localhost:8080"}
+	sService := service.NewService(&storage.MemStorage{URLMap: make(map[string]string)}, &cfg)
+	server := New(sService)
+	handler := server.Idempotent(server.InsertBatchHandler)
+
+	token, err := createJWTToken("user")
+	require.NoError(t, err)
+	send := func(key string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/shorten/batch", strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: "auth", Value: token})
+		if key != "" {
+			req.Header.Set(idempotency.Header, key)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+	batch := `[{"correlation_id":"1","original_url":"https://go.dev/"},{"correlation_id":"2","original_url":"https://github.com/"}]`
+
+	first := send("batch-1", batch)
+	require.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(idempotency.ReplayedHeader))
+
+	replay := send("batch-1", batch)
+	assert.Equal(t, http.StatusCreated, replay.Code)
+	assert.Equal(t, "application/json", replay.Header().Get("Content-Type"))
+	assert.Equal(t, "true", replay.Header().Get(idempotency.ReplayedHeader))
+	assert.Equal(t, first.Body.String(), replay.Body.String(), "replay must return the original short urls")
+
+	reused := send("batch-1", `[{"correlation_id":"1","original_url":"https://example.com/"}]`)
+	assert.Equal(t, http.StatusUnprocessableEntity, reused.Code)
+	var problem models.Problem
+	require.NoError(t, json.NewDecoder(reused.Body).Decode(&problem))
+	assert.Equal(t, service.CodeIdempotencyReused, problem.Code)
+
+	invalid := send("bad key", batch)
+	assert.Equal(t, http.StatusBadRequest, invalid.Code)
+
+	without := send("", `[{"correlation_id":"1","original_url":"https://example.org/"}]`)
+	assert.Equal(t, http.StatusCreated, without.Code)
+}
+
+func TestIdempotentNotStoredOnError(t *testing.T) {
+	cfg := config.AppConfig{ServerAddress: "localhost:8080"}
+	sService := service.NewService(&storage.MemStorage{URLMap: make(map[string]string)}, &cfg)
+	server := New(sService)
+	calls := 0
+	handler := server.Idempotent(func(res http.ResponseWriter, req *http.Request) {
+		calls++
+		if calls == 1 {
+			http.Error(res, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		res.WriteHeader(http.StatusCreated)
+	})
+
+	for _, want := range []int{http.StatusServiceUnavailable, http.StatusCreated, http.StatusCreated} {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("https://go.dev/"))
+		req.Header.Set(idempotency.Header, "key")
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		assert.Equal(t, want, rec.Code)
+	}
+	assert.Equal(t, 2, calls, "failed request must not be stored, successful one must be replayed")
+}
+
+func TestIdempotentAnonymous(t *testing.T) {
+	cfg := config.AppConfig{ServerAddress: "localhost:8080"}
+	sService := service.NewService(&storage.MemStorage{URLMap: make(map[string]string)}, &cfg)
+	server := New(sService)
+	handler := server.Idempotent(server.ShortenerURLHandler)
+
+	send := func(remoteAddr string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.RemoteAddr = remoteAddr
+		req.Header.Set(idempotency.Header, "anon-key")
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	first := send("10.0.0.1:1234", "https://go.dev/")
+	require.Equal(t, http.StatusCreated, first.Code)
+	cookie := first.Header().Get("Set-Cookie")
+	require.Contains(t, cookie, "auth=")
+
+	// За одним ip (NAT) могут быть разные клиенты: токен первого не должен повторяться второму.
+	replay := send("10.0.0.1:5678", "https://go.dev/")
+	assert.Equal(t, http.StatusCreated, replay.Code)
+	assert.Equal(t, "true", replay.Header().Get(idempotency.ReplayedHeader))
+	assert.Empty(t, replay.Header().Get("Set-Cookie"), "anonymous replay must not return the issued auth cookie")
+	assert.Equal(t, first.Body.String(), replay.Body.String())
+
+	other := send("10.0.0.2:1234", "https://go.dev/")
+	assert.Empty(t, other.Header().Get(idempotency.ReplayedHeader), "anonymous keys of different clients must not be shared")
+	assert.NotEqual(t, cookie, other.Header().Get("Set-Cookie"))
+}
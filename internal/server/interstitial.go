@@ -0,0 +1,37 @@
+package server
+
+import (
+	"html/template"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/Dorrrke/shortener-url/internal/logger"
+)
+
+// interstitialTemplate - страница-предупреждение, показываемая вместо перенаправления на заблокированный url.
+// Адрес назначения выводится текстом, а не ссылкой, чтобы по нему нельзя было перейти одним кликом.
+var interstitialTemplate = template.Must(template.New("interstitial").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>Ссылка заблокирована</title>
+</head>
+<body>
+<h1>Ссылка заблокирована</h1>
+<p>Адрес, на который ведет эта короткая ссылка, отмечен как опасный: фишинг, вредоносное ПО или мошенничество.</p>
+<p>Адрес назначения: <code>{{.}}</code></p>
+<p>Не вводите на этом сайте пароли и платежные данные.</p>
+</body>
+</html>
+`))
+
+// writeInterstitial - функция отправки страницы-предупреждения со статусом 403 (StatusForbidden).
+func writeInterstitial(res http.ResponseWriter, original string) {
+	res.Header().Set("Content-Type", "text/html; charset=utf-8")
+	res.Header().Set("Cache-Control", "no-store")
+	res.WriteHeader(http.StatusForbidden)
+	if err := interstitialTemplate.Execute(res, original); err != nil {
+		logger.Log.Error("cannot render interstitial", zap.Error(err))
+	}
+}
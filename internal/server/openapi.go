@@ -0,0 +1,54 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/Dorrrke/shortener-url/internal/openapi"
+	"github.com/Dorrrke/shortener-url/internal/service"
+)
+
+// Server реализует все операции спецификации OpenAPI: при расхождении хендлеров и openapi.json сборка не пройдет.
+var _ openapi.ServerInterface = (*Server)(nil)
+
+// GetOpenAPISpec - хендлер отдачи спецификации OpenAPI REST API сервиса.
+func (s *Server) GetOpenAPISpec(res http.ResponseWriter, req *http.Request) {
+	openapi.Handler(res, req)
+}
+
+// Validated - middleware проверки параметров и тела запроса по спецификации OpenAPI.
+// Запрос, не прошедший проверку, отклоняется со статусом 400 до вызова хендлера, в ответе указывается поле с ошибкой.
+// Запросы к путям, не описанным в спецификации, передаются хендлеру без проверки.
+// Тело проверяется после распаковки, поэтому middleware ставится после compress.Middleware.
+func (s *Server) Validated(h http.HandlerFunc) http.HandlerFunc {
+	doc := openapi.Default()
+	return func(res http.ResponseWriter, req *http.Request) {
+		op, pathParams := doc.Find(req.Method, req.URL.Path)
+		if op == nil {
+			h.ServeHTTP(res, req)
+			return
+		}
+		var body []byte
+		if op.RequestBody != nil {
+			var err error
+			body, err = io.ReadAll(req.Body)
+			if err != nil {
+				writeError(res, req, service.InvalidRequest(err))
+				return
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		if err := doc.ValidateRequest(op, req, pathParams, body); err != nil {
+			var verr *openapi.ValidationError
+			if errors.As(err, &verr) {
+				err = service.InvalidParam(verr.Field, errors.New(verr.Reason))
+			}
+			writeError(res, req, err)
+			return
+		}
+		h.ServeHTTP(res, req)
+	}
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dorrrke/shortener-url/internal/config"
+	"github.com/Dorrrke/shortener-url/internal/models"
+	"github.com/Dorrrke/shortener-url/internal/service"
+	"github.com/Dorrrke/shortener-url/internal/storage"
+)
+
+func TestValidated(t *testing.T) {
+	cfg := config.AppConfig{ServerAddress: "localhost:8080"}
+	sService := service.NewService(&storage.MemStorage{URLMap: make(map[string]string)}, &cfg)
+	server := New(sService)
+
+	tests := []struct {
+		name      string
+		target    string
+		body      string
+		code      int
+		wantParam string
+	}{
+		{
+			name:   "valid batch",
+			target: "/api/shorten/batch",
+			body:   `[{"correlation_id":"1","original_url":"https://go.dev/"}]`,
+			code:   http.StatusCreated,
+		},
+		{
+			name:      "batch item without url",
+			target:    "/api/shorten/batch",
+			body:      `[{"correlation_id":"1","original_url":"https://go.dev/"},{"correlation_id":"2","original_url":""}]`,
+			code:      http.StatusBadRequest,
+			wantParam: "[1].original_url",
+		},
+		{
+			name:      "batch is not an array",
+			target:    "/api/shorten/batch",
+			body:      `{"correlation_id":"1","original_url":"https://go.dev/"}`,
+			code:      http.StatusBadRequest,
+			wantParam: "body",
+		},
+		{
+			name:   "path not described in spec",
+			target: "/api/unknown",
+			body:   `[{"correlation_id":"1","original_url":"https://example.org/"}]`,
+			code:   http.StatusCreated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			server.Validated(server.InsertBatchHandler)(rec, req)
+
+			assert.Equal(t, tt.code, rec.Code, rec.Body.String())
+			if tt.wantParam == "" {
+				return
+			}
+			assert.Equal(t, models.ProblemContentType, rec.Header().Get("Content-Type"))
+			var problem models.Problem
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+			assert.Equal(t, service.CodeInvalidRequest, problem.Code)
+			require.Len(t, problem.InvalidParams, 1)
+			assert.Equal(t, tt.wantParam, problem.InvalidParams[0].Name)
+		})
+	}
+}
+
+func TestGetOpenAPISpec(t *testing.T) {
+	var server Server
+	rec := httptest.NewRecorder()
+	server.GetOpenAPISpec(rec, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var spec struct {
+		OpenAPI string                    `json:"openapi"`
+		Paths   map[string]map[string]any `json:"paths"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&spec))
+	assert.Equal(t, "3.0.3", spec.OpenAPI)
+	assert.Contains(t, spec.Paths, "/api/shorten/batch")
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"html/template"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/Dorrrke/shortener-url/internal/logger"
+)
+
+// PasswordHeader - заголовок запроса сокращения url с паролем, без которого по сокращенному url нельзя перейти.
+const PasswordHeader = "X-Link-Password"
+
+// maxPasswordFormSize - максимальный размер тела формы ввода пароля.
+const maxPasswordFormSize = 4 << 10
+
+// passwordTemplate - страница ввода пароля защищенного url.
+// Форма отправляется методом POST на тот же сокращенный url, адрес назначения до проверки пароля не показывается.
+var passwordTemplate = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>Ссылка защищена паролем</title>
+</head>
+<body>
+<h1>Ссылка защищена паролем</h1>
+{{if .}}<p><strong>{{.}}</strong></p>
+{{end}}<form method="post">
+<label>Пароль: <input type="password" name="password" autocomplete="current-password" autofocus required></label>
+<button type="submit">Перейти</button>
+</form>
+</body>
+</html>
+`))
+
+// writePasswordForm - функция отправки страницы ввода пароля со статусом status и сообщением message.
+// Страницу нельзя встроить во фрейм, чтобы ввод пароля нельзя было подменить на чужом сайте.
+func writePasswordForm(res http.ResponseWriter, status int, message string) {
+	res.Header().Set("Content-Type", "text/html; charset=utf-8")
+	res.Header().Set("Cache-Control", "no-store")
+	res.Header().Set("X-Frame-Options", "DENY")
+	res.WriteHeader(status)
+	if err := passwordTemplate.Execute(res, message); err != nil {
+		logger.Log.Error("cannot render password form", zap.Error(err))
+	}
+}
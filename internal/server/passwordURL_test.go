@@ -0,0 +1,116 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dorrrke/shortener-url/internal/config"
+	"github.com/Dorrrke/shortener-url/internal/models"
+	"github.com/Dorrrke/shortener-url/internal/service"
+	"github.com/Dorrrke/shortener-url/internal/storage"
+)
+
+func TestPasswordURL(t *testing.T) {
+	r := chi.NewRouter()
+	var server Server
+
+	r.Route("/", func(r chi.Router) {
+		r.Post("/", server.ShortenerURLHandler)
+		r.Post("/api/shorten", server.ShortenerJSONURLHandler)
+		r.Get("/{id}", server.GetOriginalURLHandler)
+		r.Post("/{id}", server.UnlockURLHandler)
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	cfg := config.AppConfig{
+		ServerAddress:    srv.Config.Addr,
+		PasswordAttempts: config.RateLimit{RPS: 0.001, Burst: 2},
+		TrustedProxies:   []string{"127.0.0.1"},
+	}
+	sService := service.NewService(&storage.MemStorage{URLMap: make(map[string]string)}, &cfg)
+	server = *New(sService)
+
+	client := resty.New().SetRedirectPolicy(resty.RedirectPolicyFunc(func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}))
+	path := func(short string) string {
+		return srv.URL + short[strings.LastIndex(short, "/"):]
+	}
+
+	resp, err := client.R().SetHeader(PasswordHeader, "secret").SetBody("https://docs.internal/design").Post(srv.URL + "/")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode())
+	short := string(resp.Body())
+
+	resp, err = client.R().Get(path(short))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode())
+	assert.Contains(t, resp.Header().Get("Content-Type"), "text/html")
+	assert.Empty(t, resp.Header().Get("Location"))
+	assert.Contains(t, string(resp.Body()), `name="password"`)
+	assert.NotContains(t, string(resp.Body()), "docs.internal")
+
+	resp, err = client.R().SetFormData(map[string]string{"password": "guess"}).Post(path(short))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode())
+	assert.Contains(t, string(resp.Body()), "Неверный пароль")
+
+	resp, err = client.R().SetFormData(map[string]string{"password": "secret"}).Post(path(short))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusSeeOther, resp.StatusCode())
+	assert.Equal(t, "https://docs.internal/design", resp.Header().Get("Location"))
+
+	resp, err = client.R().SetFormData(map[string]string{"password": "secret"}).Post(path(short))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode())
+	assert.NotEmpty(t, resp.Header().Get("Retry-After"))
+	assert.Empty(t, resp.Header().Get("Location"))
+
+	resp, err = client.R().SetHeader("X-Real-IP", "203.0.113.7").SetFormData(map[string]string{"password": "secret"}).Post(path(short))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusSeeOther, resp.StatusCode(), "attempts of other clients must not lock the link")
+
+	resp, err = client.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(models.RequestURLJson{URLAddres: "https://go.dev/", Password: strings.Repeat("p", 73)}).
+		Post(srv.URL + "/api/shorten")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode())
+
+	resp, err = client.R().SetBody("https://go.dev/").Post(srv.URL + "/")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode())
+	existing := string(resp.Body())
+
+	resp, err = client.R().Get(path(existing))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode())
+	assert.Equal(t, "https://go.dev/", resp.Header().Get("Location"))
+
+	resp, err = client.R().SetHeader(PasswordHeader, "secret").SetBody("https://go.dev/").Post(srv.URL + "/")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode())
+	assert.Equal(t, models.ProblemContentType, resp.Header().Get("Content-Type"))
+	var problem models.Problem
+	require.NoError(t, json.Unmarshal(resp.Body(), &problem))
+	assert.Equal(t, service.CodePasswordNotApplied, problem.Code)
+	assert.Equal(t, existing, problem.ExistingShortURL)
+}
+
+func TestWriteErrorRetryAfter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/abc", nil)
+	writeError(rec, req, service.TooManyPasswordAttempts(1500*time.Millisecond))
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+}
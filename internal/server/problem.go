@@ -0,0 +1,15 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/Dorrrke/shortener-url/internal/problem"
+)
+
+// StatusClientClosedRequest - нестандартный статус 499: клиент закрыл соединение до получения ответа.
+const StatusClientClosedRequest = problem.StatusClientClosedRequest
+
+// writeError - функция отправки ответа на ошибку в формате application/problem+json, см. problem.Write.
+func writeError(res http.ResponseWriter, req *http.Request, err error) {
+	problem.Write(res, req, err)
+}
@@ -2,26 +2,31 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
-	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
-	"github.com/jackc/pgerrcode"
-	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 
+	"github.com/Dorrrke/shortener-url/internal/access"
+	"github.com/Dorrrke/shortener-url/internal/audit"
 	"github.com/Dorrrke/shortener-url/internal/config"
 	"github.com/Dorrrke/shortener-url/internal/logger"
 	"github.com/Dorrrke/shortener-url/internal/models"
+	"github.com/Dorrrke/shortener-url/internal/ratelimit"
+	"github.com/Dorrrke/shortener-url/internal/realip"
 	"github.com/Dorrrke/shortener-url/internal/service"
 	"github.com/Dorrrke/shortener-url/internal/storage"
+	"github.com/Dorrrke/shortener-url/internal/urlcheck"
 )
 
 // SecretKey - Секретный ключ для создания JWT токена.
@@ -29,8 +34,9 @@ const SecretKey = "Secret123Key345Super"
 
 // структура сервера, с данными о хранилище, конфиге, логгере и каналом для удаления url.
 type Server struct {
-	Config   *config.AppConfig
-	sService service.ShortenerService
+	sService   service.ShortenerService
+	ipResolver *realip.Resolver
+	acl        *access.Policy
 }
 
 // структура Claims используется для созадния JWT Token.
@@ -40,45 +46,105 @@ type Claims struct {
 }
 
 // New - метод создание экземпляра типа Server.
-func New(cfg *config.AppConfig, service *service.ShortenerService) *Server {
+func New(service *service.ShortenerService) *Server {
+	resolver, err := realip.NewResolver(service.Config().TrustedProxies)
+	if err != nil {
+		logger.Log.Error("Error parse trusted proxies", zap.Error(err))
+	}
 	server := Server{
-		Config:   cfg,
-		sService: *service,
+		sService:   *service,
+		ipResolver: resolver,
+		acl: access.New(func() string {
+			return service.Config().TrustedSubnet
+		}, resolver),
 	}
 	return &server
 }
 
+// Config - метод получения текущего снимка конфигурации сервиса.
+func (s *Server) Config() *config.AppConfig {
+	return s.sService.Config()
+}
+
 // GetOriginalURLHandler - хендлер для перехода на оригинальный адресс по сокращенной ссылке.
 // В качестве ответа, хендлер находит в хранилище оригинальый url соответсвующий полученному сокращенному url и возвращает его в теле ответа с статус кодом 307 (StatusTemporaryRedirect).
 // В том случае, если адрес удален, возвращается ошибка с кодм 410 (StatusGone).
+// Для url, защищенного паролем, возвращается страница ввода пароля со статусом 401 (StatusUnauthorized).
 func (s *Server) GetOriginalURLHandler(res http.ResponseWriter, req *http.Request) {
 	URLId := chi.URLParam(req, "id")
-	if URLId != "" {
-		var shortURL string
-		if s.Config.BaseURL == "" {
-			shortURL = "http://" + req.Host + "/" + URLId
-		} else {
-			shortURL = "http://" + s.Config.BaseURL + "/" + URLId
-		}
-		url, deteted, err := s.sService.GetOriginalURL(shortURL)
-
-		if err != nil {
-			logger.Log.Error("Error when read from base: ", zap.Error(err))
-			http.Error(res, "Не корректный запрос", http.StatusBadRequest)
+	if URLId == "" {
+		writeError(res, req, service.ErrURLNotFound)
+		return
+	}
+	shortURL := s.shortURL(req, URLId)
+	url, err := s.sService.GetOriginalURL(req.Context(), shortURL, "")
+	if err != nil {
+		if errors.Is(err, service.ErrPasswordRequired) {
+			writePasswordForm(res, http.StatusUnauthorized, "")
 			return
 		}
-		if deteted {
-			res.WriteHeader(http.StatusGone)
-			return
+		writeError(res, req, err)
+		return
+	}
+	s.redirect(res, req, shortURL, url, http.StatusTemporaryRedirect)
+}
+
+// UnlockURLHandler - хендлер проверки пароля, отправленного со страницы ввода пароля защищенного url.
+// Пароль передается в поле password формы, при верном пароле выполняется перенаправление со статусом 303 (StatusSeeOther).
+// При неверном пароле страница ввода возвращается снова со статусом 401 (StatusUnauthorized),
+// при превышении числа попыток - со статусом 429 (StatusTooManyRequests) и заголовком Retry-After.
+func (s *Server) UnlockURLHandler(res http.ResponseWriter, req *http.Request) {
+	URLId := chi.URLParam(req, "id")
+	if URLId == "" {
+		writeError(res, req, service.ErrURLNotFound)
+		return
+	}
+	req.Body = http.MaxBytesReader(res, req.Body, maxPasswordFormSize)
+	if err := req.ParseForm(); err != nil {
+		writeError(res, req, service.InvalidRequest(err))
+		return
+	}
+	shortURL := s.shortURL(req, URLId)
+	url, err := s.sService.GetOriginalURL(s.auditContext(req), shortURL, req.PostForm.Get("password"))
+	if err != nil {
+		e := service.Classify(err)
+		switch e.Code {
+		case service.CodePasswordRequired:
+			writePasswordForm(res, http.StatusUnauthorized, "Введите пароль.")
+		case service.CodeWrongPassword:
+			writePasswordForm(res, http.StatusUnauthorized, "Неверный пароль.")
+		case service.CodePasswordAttempts:
+			res.Header().Set("Retry-After", strconv.Itoa(ratelimit.RetryAfterSeconds(e.RetryAfter)))
+			writePasswordForm(res, http.StatusTooManyRequests, "Слишком много попыток, повторите позже.")
+		default:
+			writeError(res, req, err)
 		}
-		if url != "" {
-			res.Header().Add("Location", url)
-			res.WriteHeader(http.StatusTemporaryRedirect)
+		return
+	}
+	s.redirect(res, req, shortURL, url, http.StatusSeeOther)
+}
+
+// shortURL - метод получения сокращенного url по его id с учетом базового адреса из конфигурации.
+func (s *Server) shortURL(req *http.Request, id string) string {
+	if s.Config().BaseURL == "" {
+		return "http://" + req.Host + "/" + id
+	}
+	return "http://" + s.Config().BaseURL + "/" + id
+}
+
+// redirect - метод перенаправления на оригинальный url со статусом status.
+// Вместо перенаправления на заблокированный url возвращается страница-предупреждение.
+func (s *Server) redirect(res http.ResponseWriter, req *http.Request, shortURL string, url string, status int) {
+	if err := s.sService.CheckURL(req.Context(), url); err != nil {
+		if errors.Is(err, urlcheck.ErrBlocked) {
+			logger.FromContext(req.Context()).Info("Redirect to blocked url", zap.String("short", shortURL), zap.Error(err))
+			writeInterstitial(res, url)
 			return
 		}
-		http.Error(res, "Не корректный запрос", http.StatusBadRequest)
+		logger.FromContext(req.Context()).Error("Error check url", zap.Error(err))
 	}
-	http.Error(res, "Не корректный запрос", http.StatusBadRequest)
+	res.Header().Add("Location", url)
+	res.WriteHeader(status)
 }
 
 // ShortenerURLHandler - хендлер для сокращения url.
@@ -88,16 +154,16 @@ func (s *Server) GetOriginalURLHandler(res http.ResponseWriter, req *http.Reques
 //
 // После чего сохраняет полученный адррес в базу данных и возварщает его в теле ответа пользователю со статусом 210 (StatusCreated).
 // В том случае если аддрес уже сохраняли, хендлер вернет сокращенный url со статусом 409 (StatusConflict).
+// Необязательный пароль сокращенного url передается в заголовке X-Link-Password.
 func (s *Server) ShortenerURLHandler(res http.ResponseWriter, req *http.Request) {
 
 	var userID string
 	reqCookie, err := req.Cookie("auth")
 	if err != nil {
-		logger.Log.Info("Cookie false")
 		userID = uuid.New().String()
 		token, err := createJWTToken(userID)
 		if err != nil {
-			logger.Log.Error("cannot create token", zap.Error(err))
+			logger.FromContext(req.Context()).Error("cannot create token", zap.Error(err))
 		}
 		cookie := http.Cookie{
 			Name:  "auth",
@@ -107,11 +173,9 @@ func (s *Server) ShortenerURLHandler(res http.ResponseWriter, req *http.Request)
 
 		http.SetCookie(res, &cookie)
 	} else {
-		logger.Log.Info("Cookie true")
-
 		userID = GetUID(reqCookie.Value)
 		if userID == "" {
-			http.Error(res, "User unauth", http.StatusUnauthorized)
+			writeError(res, req, service.ErrUnauthorized)
 			return
 		}
 		http.SetCookie(res, reqCookie)
@@ -119,60 +183,34 @@ func (s *Server) ShortenerURLHandler(res http.ResponseWriter, req *http.Request)
 
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
-		http.Error(res, err.Error(), 500)
+		writeError(res, req, service.InvalidRequest(err))
 		return
 	}
-	if !validationURL(string(body)) {
-		http.Error(res, "Не корректный запрос", http.StatusBadRequest)
+	original, err := s.sService.NormalizeURL(string(body))
+	if err != nil {
+		writeError(res, req, service.InvalidURL("url", err))
 		return
 	}
 	urlID := strings.Split(uuid.New().String(), "-")[0]
 	var result string
-	if s.Config.BaseURL == "" {
+	if s.Config().BaseURL == "" {
 		result = "http://" + req.Host + "/" + urlID
 	} else {
-		result = "http://" + s.Config.BaseURL + "/" + urlID
-	}
-
-	if err := s.sService.SaveURL(string(body), result, userID); err != nil {
-		if errors.Is(err, storage.ErrMemStorageError) {
-			shortURL, err := s.sService.GetShortByOriginal(string(body))
-			if err != nil {
-				logger.Log.Error("Error when read from base: ", zap.Error(err))
-				http.Error(res, "Не корректный запрос", http.StatusBadRequest)
-				return
-			}
-			result = shortURL
-			res.Header().Set("content-type", "text/plain")
-			res.WriteHeader(http.StatusConflict)
-			res.Write([]byte(result))
-			return
-		}
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if !pgerrcode.IsIntegrityConstraintViolation(pgErr.Code) {
-				logger.Log.Info("cannot save URL in file", zap.Error(err))
-				http.Error(res, "Не корректный запрос", http.StatusBadRequest)
-				return
-			}
-
-			shortURL, err := s.sService.GetShortByOriginal(string(body))
-			if err != nil {
-				logger.Log.Error("Error when read from base: ", zap.Error(err))
-				http.Error(res, "Не корректный запрос", http.StatusBadRequest)
-				return
-			}
-			result = shortURL
-			res.Header().Set("content-type", "text/plain")
-			res.WriteHeader(http.StatusConflict)
-			res.Write([]byte(result))
+		result = "http://" + s.Config().BaseURL + "/" + urlID
+	}
+
+	status := http.StatusCreated
+	result, err = s.sService.SaveProtectedURL(s.auditContext(req), original, result, userID, req.Header.Get(PasswordHeader))
+	if err != nil {
+		if !errors.Is(err, storage.ErrConflict) {
+			writeError(res, req, err)
 			return
 		}
+		status = http.StatusConflict
 	}
 	res.Header().Set("content-type", "text/plain")
-	res.WriteHeader(http.StatusCreated)
+	res.WriteHeader(status)
 	res.Write([]byte(result))
-
 }
 
 // ShortenerJSONURLHandler - работатет аналогично ShortenerURLHandler только в теле запроса получает url в формате json.
@@ -182,6 +220,7 @@ func (s *Server) ShortenerURLHandler(res http.ResponseWriter, req *http.Request)
 //
 // После чего сохраняет полученный адррес в базу данных и возварщает его в теле ответа пользователю со статусом 210 (StatusCreated).
 // В том случае если аддрес уже сохраняли, хендлер вернет сокращенный url со статусом 409 (StatusConflict).
+// Необязательный пароль сокращенного url передается в поле password.
 func (s *Server) ShortenerJSONURLHandler(res http.ResponseWriter, req *http.Request) {
 
 	var userID string
@@ -190,7 +229,7 @@ func (s *Server) ShortenerJSONURLHandler(res http.ResponseWriter, req *http.Requ
 		userID = uuid.New().String()
 		token, err := createJWTToken(userID)
 		if err != nil {
-			logger.Log.Info("cannot create token", zap.Error(err))
+			logger.FromContext(req.Context()).Info("cannot create token", zap.Error(err))
 		}
 		cookie := http.Cookie{
 			Name:  "auth",
@@ -199,99 +238,56 @@ func (s *Server) ShortenerJSONURLHandler(res http.ResponseWriter, req *http.Requ
 		}
 		http.SetCookie(res, &cookie)
 	} else {
-		userID = uuid.New().String()
+		userID = GetUID(reqCookie.Value)
+		if userID == "" {
+			writeError(res, req, service.ErrUnauthorized)
+			return
+		}
 		http.SetCookie(res, reqCookie)
 	}
 
 	dec := json.NewDecoder(req.Body)
 	var modelURL models.RequestURLJson
-
 	if err := dec.Decode(&modelURL); err != nil {
-		logger.Log.Debug("cannot decod boby json", zap.Error(err))
+		writeError(res, req, service.InvalidRequest(err))
+		return
 	}
-	if !validationURL(string(modelURL.URLAddres)) {
-		http.Error(res, "Не корректный запрос", http.StatusBadRequest)
+	original, err := s.sService.NormalizeURL(modelURL.URLAddres)
+	if err != nil {
+		writeError(res, req, service.InvalidURL("url", err))
 		return
 	}
 	urlID := strings.Split(uuid.New().String(), "-")[0]
 	var result string
-	if s.Config.BaseURL == "" {
+	if s.Config().BaseURL == "" {
 		result = "http://" + req.Host + "/" + urlID
 	} else {
-		result = "http://" + s.Config.BaseURL + "/" + urlID
-	}
-	if err := s.sService.SaveURL(modelURL.URLAddres, result, userID); err != nil {
-		if errors.Is(err, storage.ErrMemStorageError) {
-			shortURL, err := s.sService.GetShortByOriginal(modelURL.URLAddres)
-			if err != nil {
-				logger.Log.Error("Error when read from base: ", zap.Error(err))
-				http.Error(res, "Не корректный запрос", http.StatusBadRequest)
-				return
-			}
-			result = shortURL
-			res.Header().Set("Content-Type", "application/json")
-			res.WriteHeader(http.StatusConflict)
-
-			enc := json.NewEncoder(res)
-			resultJSON := models.ResponseURLJson{
-				URLAddres: shortURL,
-			}
-			if err := enc.Encode(resultJSON); err != nil {
-				logger.Log.Debug("error encoding responce", zap.Error(err))
-				http.Error(res, "Не корректный запрос", http.StatusInternalServerError)
-			}
+		result = "http://" + s.Config().BaseURL + "/" + urlID
+	}
+
+	status := http.StatusCreated
+	result, err = s.sService.SaveProtectedURL(s.auditContext(req), original, result, userID, modelURL.Password)
+	if err != nil {
+		if !errors.Is(err, storage.ErrConflict) {
+			writeError(res, req, err)
 			return
 		}
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgerrcode.IsIntegrityConstraintViolation(pgErr.Code) {
-				shortURL, err := s.sService.GetShortByOriginal(modelURL.URLAddres)
-				if err != nil {
-					logger.Log.Error("Error when read from base: ", zap.Error(err))
-					http.Error(res, "Не корректный запрос", http.StatusBadRequest)
-					return
-				}
-				result = shortURL
-				res.Header().Set("Content-Type", "application/json")
-				res.WriteHeader(http.StatusConflict)
-
-				enc := json.NewEncoder(res)
-				resultJSON := models.ResponseURLJson{
-					URLAddres: shortURL,
-				}
-				if err := enc.Encode(resultJSON); err != nil {
-					logger.Log.Debug("error encoding responce", zap.Error(err))
-					http.Error(res, "Не корректный запрос", http.StatusInternalServerError)
-				}
-				return
-
-			} else {
-				logger.Log.Info("cannot save URL in file", zap.Error(err))
-			}
-		}
+		status = http.StatusConflict
 	}
-
 	res.Header().Set("Content-Type", "application/json")
-	res.WriteHeader(http.StatusCreated)
+	res.WriteHeader(status)
 	enc := json.NewEncoder(res)
-	resultJSON := models.ResponseURLJson{
-		URLAddres: result,
-	}
-	if err := enc.Encode(resultJSON); err != nil {
-		logger.Log.Debug("error encoding responce", zap.Error(err))
-		http.Error(res, "Не корректный запрос", http.StatusInternalServerError)
+	if err := enc.Encode(models.ResponseURLJson{URLAddres: result}); err != nil {
+		logger.FromContext(req.Context()).Debug("error encoding responce", zap.Error(err))
 	}
-
 }
 
 // CheckDBConnectionHandler - хендлер для проверки подключения к базе данных.
 // Если подключение есть, веренет статус код 200 (StatusOK).
 // В случае если подключния нет, вернет статус код 500 (StatusInternalServerError).
 func (s *Server) CheckDBConnectionHandler(res http.ResponseWriter, req *http.Request) {
-	err := s.sService.CheckDBConnection()
-	if err != nil {
-		logger.Log.Error("Error check db connect", zap.Error(err))
-		res.WriteHeader(http.StatusInternalServerError)
+	if err := s.sService.CheckDBConnection(req.Context()); err != nil {
+		writeError(res, req, err)
 		return
 	}
 	res.WriteHeader(http.StatusOK)
@@ -308,7 +304,7 @@ func (s *Server) GetAllUrls(res http.ResponseWriter, req *http.Request) {
 		userID = uuid.New().String()
 		token, err := createJWTToken(userID)
 		if err != nil {
-			logger.Log.Info("cannot create token", zap.Error(err))
+			logger.FromContext(req.Context()).Info("cannot create token", zap.Error(err))
 		}
 		cookie := http.Cookie{
 			Name:  "auth",
@@ -317,36 +313,63 @@ func (s *Server) GetAllUrls(res http.ResponseWriter, req *http.Request) {
 		}
 
 		http.SetCookie(res, &cookie)
-		http.Error(res, "User unauth", http.StatusUnauthorized)
+		writeError(res, req, service.ErrUnauthorized)
 		return
 	} else {
 		userID = GetUID(reqCookie.Value)
 		if userID == "" {
-			http.Error(res, "User unauth", http.StatusUnauthorized)
+			writeError(res, req, service.ErrUnauthorized)
 			return
 		}
 
 		http.SetCookie(res, reqCookie)
 	}
-	urls, err := s.sService.GetAllURLsByID(userID)
+	urls, err := s.sService.GetAllURLsByID(req.Context(), userID)
 	if err != nil {
-		http.Error(res, "Не корректный запрос", http.StatusInternalServerError)
+		writeError(res, req, err)
 		return
 	}
 	if len(urls) == 0 {
-		http.Error(res, "Нет сохраненных адресов", http.StatusNoContent)
+		res.WriteHeader(http.StatusNoContent)
 		return
 	}
 	res.Header().Set("Content-Type", "application/json")
 	res.WriteHeader(http.StatusOK)
 	enc := json.NewEncoder(res)
 	if err := enc.Encode(urls); err != nil {
-		logger.Log.Debug("error encoding responce", zap.Error(err))
-		http.Error(res, "Не корректный запрос", http.StatusInternalServerError)
+		logger.FromContext(req.Context()).Debug("error encoding responce", zap.Error(err))
 	}
 
 }
 
+// GetUserQuota - хендлер для получения текущего использования квот пользователя.
+// Сервис проверяет id пользователся из jwt токена хранящегося в cookie, если такого пользователя нет или id путое возвращает ошибку со статусом 401 (StatusUnauthorized).
+// В ответе возвращается количество неудаленных ссылок пользователя и лимиты на количество ссылок и размер пакета, 0 означает отсутствие ограничения.
+func (s *Server) GetUserQuota(res http.ResponseWriter, req *http.Request) {
+	reqCookie, err := req.Cookie("auth")
+	if err != nil {
+		writeError(res, req, service.ErrUnauthorized)
+		return
+	}
+	userID := GetUID(reqCookie.Value)
+	if userID == "" {
+		writeError(res, req, service.ErrUnauthorized)
+		return
+	}
+
+	quota, err := s.sService.GetUserQuota(req.Context(), userID)
+	if err != nil {
+		writeError(res, req, err)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(quota); err != nil {
+		logger.FromContext(req.Context()).Debug("error encoding responce", zap.Error(err))
+	}
+}
+
 // InsertBatchHandler - хендлер для сохранения нескольок url за раз.
 // Сервис проверяет id пользователся из jwt токена хранящегося в cookie, если такого пользователя нет или id путое возвращает ошибку со статусом 401 (StatusUnauthorized).
 // В случае если id существует, десериализует данные из json, сокращает все адреса, сохраняет их в бд и возвращает пользователю список новых сокращенных адресов.
@@ -357,8 +380,7 @@ func (s *Server) InsertBatchHandler(res http.ResponseWriter, req *http.Request)
 		userID = uuid.New().String()
 		token, err := createJWTToken(userID)
 		if err != nil {
-			logger.Log.Info("cannot create token", zap.Error(err))
-			http.Error(res, "Cannot create token", http.StatusInternalServerError)
+			writeError(res, req, errors.Wrap(err, "cannot create token"))
 			return
 		}
 		cookie := http.Cookie{
@@ -371,7 +393,7 @@ func (s *Server) InsertBatchHandler(res http.ResponseWriter, req *http.Request)
 	} else {
 		userID = GetUID(reqCookie.Value)
 		if userID == "" {
-			http.Error(res, "User unauth", http.StatusUnauthorized)
+			writeError(res, req, service.ErrUnauthorized)
 			return
 		}
 
@@ -381,54 +403,52 @@ func (s *Server) InsertBatchHandler(res http.ResponseWriter, req *http.Request)
 	dec := json.NewDecoder(req.Body)
 	var modelURL []models.RequestBatchURLModel
 	if err := dec.Decode(&modelURL); err != nil {
-		logger.Log.Error("cannot decod boby json", zap.Error(err))
-		http.Error(res, "Ошибка при разборе данных", http.StatusInternalServerError)
+		writeError(res, req, service.InvalidRequest(err))
 		return
 	}
 	if len(modelURL) == 0 {
-		http.Error(res, "Не корректный запрос", http.StatusBadRequest)
+		writeError(res, req, service.InvalidRequest(errors.New("empty batch")))
+		return
+	}
+	if err := s.sService.CheckBatchSize(len(modelURL)); err != nil {
+		writeError(res, req, err)
 		return
 	}
 	var bantchValues []models.BantchURL
 	var resBatchValues []models.ResponseBatchURLModel
-	for _, v := range modelURL {
-		if validationURL(v.OriginalURL) {
-			urlID := strings.Split(uuid.New().String(), "-")[0]
-			var shortURL string
-			if s.Config.BaseURL == "" {
-				shortURL = "http://" + req.Host + "/" + urlID
-			} else {
-				shortURL = "http://" + s.Config.BaseURL + "/" + urlID
-			}
-			bantchValues = append(bantchValues, models.BantchURL{
-				OriginalURL: v.OriginalURL,
-				ShortURL:    shortURL,
-				UserID:      userID,
-			})
-			resBatchValues = append(resBatchValues, models.ResponseBatchURLModel{
-				CorrID:      v.CorrID,
-				OriginalURL: shortURL,
-			})
-		} else {
-			http.Error(res, "Не корректный запрос", http.StatusBadRequest)
+	for i, v := range modelURL {
+		original, err := s.sService.NormalizeURL(v.OriginalURL)
+		if err != nil {
+			writeError(res, req, service.InvalidURL(fmt.Sprintf("[%d].original_url", i), err))
 			return
 		}
-	}
-
-	if err := s.sService.SaveURLBatch(bantchValues); err != nil {
-		logger.Log.Error("Error while save batch", zap.Error(err))
-		http.Error(res, "Ошибка при сохарнении данных", http.StatusInternalServerError)
+		urlID := strings.Split(uuid.New().String(), "-")[0]
+		var shortURL string
+		if s.Config().BaseURL == "" {
+			shortURL = "http://" + req.Host + "/" + urlID
+		} else {
+			shortURL = "http://" + s.Config().BaseURL + "/" + urlID
+		}
+		bantchValues = append(bantchValues, models.BantchURL{
+			OriginalURL: original,
+			ShortURL:    shortURL,
+			UserID:      userID,
+		})
+		resBatchValues = append(resBatchValues, models.ResponseBatchURLModel{
+			CorrID:      v.CorrID,
+			OriginalURL: shortURL,
+		})
+	}
+
+	if err := s.sService.SaveURLBatch(s.auditContext(req), bantchValues); err != nil {
+		writeError(res, req, err)
 		return
 	}
 	res.Header().Set("Content-Type", "application/json")
 	res.WriteHeader(http.StatusCreated)
 	enc := json.NewEncoder(res)
-	// resultJSON := models.ResponseURLJson{
-	// 	URLAddres: result,
-	// }
 	if err := enc.Encode(resBatchValues); err != nil {
-		logger.Log.Debug("error encoding responce", zap.Error(err))
-		http.Error(res, "Не корректный запрос", http.StatusInternalServerError)
+		logger.FromContext(req.Context()).Debug("error encoding responce", zap.Error(err))
 	}
 }
 
@@ -443,8 +463,7 @@ func (s *Server) DeleteURLHandler(res http.ResponseWriter, req *http.Request) {
 		userID = uuid.New().String()
 		token, err := createJWTToken(userID)
 		if err != nil {
-			logger.Log.Info("cannot create token", zap.Error(err))
-			http.Error(res, "Cannot create token", http.StatusInternalServerError)
+			writeError(res, req, errors.Wrap(err, "cannot create token"))
 			return
 		}
 		cookie := http.Cookie{
@@ -457,7 +476,7 @@ func (s *Server) DeleteURLHandler(res http.ResponseWriter, req *http.Request) {
 	} else {
 		userID = GetUID(reqCookie.Value)
 		if userID == "" {
-			http.Error(res, "User unauth", http.StatusUnauthorized)
+			writeError(res, req, service.ErrUnauthorized)
 			return
 		}
 
@@ -466,34 +485,64 @@ func (s *Server) DeleteURLHandler(res http.ResponseWriter, req *http.Request) {
 
 	dec := json.NewDecoder(req.Body)
 	var moodel []string
-	if err := dec.Decode(&moodel); err != nil {
-		logger.Log.Error("cannot decod boby json", zap.Error(err))
+	if err := dec.Decode(&moodel); err != nil && !errors.Is(err, io.EOF) {
+		writeError(res, req, service.InvalidRequest(err))
+		return
 	}
-	go s.sService.DeleteURL(moodel, req.Host)
+	s.sService.DeleteURL(s.auditContext(req), moodel, req.Host, userID)
 	res.WriteHeader(http.StatusAccepted)
 }
 
-// GetServiceStats - хендлер возвращающий статистику сервиса: количество пользователей и количество сокращенных URL.
-// Хендлрер работает тольок в том случае, если при конфигурации сервиса было указанно строковое представление бесскалссовой адресации.
-// Если при запросе хендлера, переданный в заглоловке X-Real-IP не в ходит в доврененную подсеть, хендлер возвращает статус 403.
-// Если подсеть не указана вообще, то доступ к хендлеру запрещен вовсе.
-func (s *Server) GetServiceStats(res http.ResponseWriter, req *http.Request) {
-	if s.Config.TrustedSubnet == "" {
-		http.Error(res, "Access is denied", http.StatusForbidden)
+// RestoreURLHandler - хендлер восстановления удаленных url пользователя.
+// Принимает в теле запроса список идентификаторов сокращенных url, как DELETE /api/user/urls, и возвращает список восстановленных сокращенных url.
+// Url других пользователей и неудаленные url пропускаются. Без cookie пользователя возвращается статус 401.
+func (s *Server) RestoreURLHandler(res http.ResponseWriter, req *http.Request) {
+	reqCookie, err := req.Cookie("auth")
+	if err != nil {
+		writeError(res, req, service.ErrUnauthorized)
 		return
 	}
-	realIP := req.Header.Get("X-Real-IP")
-	headerIP := net.ParseIP(realIP)
-	_, IPnet, _ := net.ParseCIDR(s.Config.TrustedSubnet)
-	if !IPnet.Contains(headerIP) {
-		http.Error(res, "Access is denied", http.StatusForbidden)
+	userID := GetUID(reqCookie.Value)
+	if userID == "" {
+		writeError(res, req, service.ErrUnauthorized)
 		return
 	}
 
-	statModel, err := s.sService.GetServiceStat()
+	var ids []string
+	if err := json.NewDecoder(req.Body).Decode(&ids); err != nil {
+		writeError(res, req, service.InvalidRequest(err))
+		return
+	}
+	restored, err := s.sService.RestoreURL(s.auditContext(req), ids, req.Host, userID)
 	if err != nil {
-		logger.Log.Error("Get stat error", zap.Error(err))
-		http.Error(res, "Internal Server Error", http.StatusInternalServerError)
+		writeError(res, req, err)
+		return
+	}
+	if restored == nil {
+		restored = []string{}
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(restored); err != nil {
+		logger.FromContext(req.Context()).Debug("error encoding responce", zap.Error(err))
+	}
+}
+
+// GetServiceStats - хендлер возвращающий статистику сервиса: количество пользователей и количество сокращенных URL.
+// Хендлрер работает тольок в том случае, если при конфигурации сервиса были указаны доверенные подсети (IPv4 или IPv6, через запятую).
+// Если адрес клиента не входит ни в одну доверенную подсеть, хендлер возвращает статус 403.
+// Адрес клиента берется из заголовка X-Real-IP только если запрос пришел от доверенного прокси.
+// Если подсети не указаны вообще, то доступ к хендлеру запрещен вовсе.
+func (s *Server) GetServiceStats(res http.ResponseWriter, req *http.Request) {
+	if !s.acl.AllowRequest(req) {
+		writeError(res, req, service.ErrAccessDenied)
+		return
+	}
+
+	statModel, err := s.sService.GetServiceStat(req.Context())
+	if err != nil {
+		writeError(res, req, err)
 		return
 	}
 
@@ -501,17 +550,77 @@ func (s *Server) GetServiceStats(res http.ResponseWriter, req *http.Request) {
 	res.WriteHeader(http.StatusOK)
 	enc := json.NewEncoder(res)
 	if err := enc.Encode(statModel); err != nil {
-		logger.Log.Debug("error encoding responce", zap.Error(err))
-		http.Error(res, "Не корректный запрос", http.StatusInternalServerError)
+		logger.FromContext(req.Context()).Debug("error encoding responce", zap.Error(err))
 	}
 }
 
-// validationURL - метод валидации адреса.
-func validationURL(URL string) bool {
-	if strings.HasPrefix(URL, "http://") || strings.HasPrefix(URL, "https://") {
-		return true
+// GetAuditLog - хендлер для просмотра журнала аудита изменяющих операций.
+// Доступ к хендлеру, как и к статистике, разрешен только из доверенной подсети, иначе возвращается статус 403.
+// Записи можно отфильтровать параметрами запроса actor, action, target, since, until (RFC 3339) и ограничить их количество параметром limit.
+// Если ни один из приемников аудита не поддерживает чтение, возвращается статус 501 (StatusNotImplemented).
+func (s *Server) GetAuditLog(res http.ResponseWriter, req *http.Request) {
+	if !s.acl.AllowRequest(req) {
+		writeError(res, req, service.ErrAccessDenied)
+		return
+	}
+
+	query := req.URL.Query()
+	filter := audit.Filter{
+		Actor:  query.Get("actor"),
+		Action: audit.Action(query.Get("action")),
+		Target: query.Get("target"),
+		Limit:  100,
+	}
+	if limit := query.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err == nil && value <= 0 {
+			err = errors.New("limit must be positive")
+		}
+		if err != nil {
+			writeError(res, req, service.InvalidParam("limit", err))
+			return
+		}
+		filter.Limit = value
+	}
+	for param, dst := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeError(res, req, service.InvalidParam(param, err))
+			return
+		}
+		*dst = t
+	}
+
+	records, err := s.sService.QueryAudit(req.Context(), filter)
+	if err != nil {
+		writeError(res, req, err)
+		return
+	}
+	if records == nil {
+		records = []audit.Record{}
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(records); err != nil {
+		logger.FromContext(req.Context()).Debug("error encoding responce", zap.Error(err))
 	}
-	return false
+}
+
+// TrustedOnly - middleware, пропускающий к хендлеру только запросы из доверенных подсетей, см. access.Policy.Middleware.
+func (s *Server) TrustedOnly(h http.Handler) http.Handler {
+	return s.acl.Middleware(h)
+}
+
+// auditContext - метод подготовки контекста запроса для аудита, в контекст сохраняется ip клиента.
+// Заголовки X-Real-IP и X-Forwarded-For учитываются только от доверенных прокси.
+func (s *Server) auditContext(req *http.Request) context.Context {
+	return audit.WithIP(req.Context(), s.ipResolver.FromRequest(req))
 }
 
 // createJWTToken - функция создания JWT token.
@@ -53,7 +53,7 @@ func TestShortenerURLHandler(t *testing.T) {
 			name: "Test negative request from Post hadler #3",
 			want: want{
 				code:        http.StatusBadRequest,
-				contentType: "text/plain; charset=utf-8",
+				contentType: "application/problem+json",
 				shortURL:    "http://localhost:8080/",
 			},
 			request: "/",
@@ -64,7 +64,7 @@ func TestShortenerURLHandler(t *testing.T) {
 			name: "Test negative request from Post hadler #4",
 			want: want{
 				code:        http.StatusBadRequest,
-				contentType: "text/plain; charset=utf-8",
+				contentType: "application/problem+json",
 				shortURL:    "http://localhost:8080/",
 			},
 			request: "/",
@@ -85,7 +85,7 @@ func TestShortenerURLHandler(t *testing.T) {
 				EnableHTTPS:     false,
 			}
 			sService := service.NewService(&storage.MemStorage{URLMap: make(map[string]string)}, &cfg)
-			URLServer = *New(&cfg, sService)
+			URLServer = *New(sService)
 
 			body := strings.NewReader(tt.body)
 			request := httptest.NewRequest(tt.method, tt.request, body)
@@ -115,7 +115,7 @@ func BenchmarkShortenerURLHandler(b *testing.B) {
 			EnableHTTPS:     false,
 		}
 		sService := service.NewService(&storage.MemStorage{URLMap: make(map[string]string)}, &cfg)
-		URLServer = *New(&cfg, sService)
+		URLServer = *New(sService)
 
 		body := strings.NewReader("https://www.youtube.com/")
 		request := httptest.NewRequest(http.MethodPost, "/", body)
@@ -0,0 +1,204 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/Dorrrke/shortener-url/internal/logger"
+	"github.com/Dorrrke/shortener-url/internal/models"
+	"github.com/Dorrrke/shortener-url/internal/service"
+	"github.com/Dorrrke/shortener-url/internal/transfer"
+)
+
+// maxImportErrors - максимальное количество ошибок строк в отчете о загрузке.
+const maxImportErrors = 100
+
+// ExportUserURLs - хендлер выгрузки всех url пользователя, включая удаленные, в CSV или JSON Lines (параметр format, по умолчанию csv).
+// Url передаются клиенту по мере чтения из хранилища. Без cookie пользователя возвращается статус 401.
+func (s *Server) ExportUserURLs(res http.ResponseWriter, req *http.Request) {
+	userID, ok := requireUser(res, req)
+	if !ok {
+		return
+	}
+	s.exportURLs(res, req, userID)
+}
+
+// ImportUserURLs - хендлер загрузки url пользователя из CSV или JSON Lines в формате выгрузки.
+// Каждая строка проверяется и сохраняется отдельно, ошибки строк не прерывают загрузку и перечисляются в отчете.
+// Владельцем загруженных url становится пользователь из cookie, колонка user_id игнорируется.
+func (s *Server) ImportUserURLs(res http.ResponseWriter, req *http.Request) {
+	userID, ok := requireUser(res, req)
+	if !ok {
+		return
+	}
+	s.importURLs(res, req, userID)
+}
+
+// ExportAllURLs - хендлер выгрузки всех url сервиса вместе с владельцами, доступен только из доверенной подсети.
+func (s *Server) ExportAllURLs(res http.ResponseWriter, req *http.Request) {
+	if !s.acl.AllowRequest(req) {
+		writeError(res, req, service.ErrAccessDenied)
+		return
+	}
+	s.exportURLs(res, req, "")
+}
+
+// ImportAllURLs - хендлер загрузки url с владельцами из полной выгрузки, доступен только из доверенной подсети.
+// Строки без user_id не загружаются и попадают в отчет как ошибки.
+func (s *Server) ImportAllURLs(res http.ResponseWriter, req *http.Request) {
+	if !s.acl.AllowRequest(req) {
+		writeError(res, req, service.ErrAccessDenied)
+		return
+	}
+	s.importURLs(res, req, "")
+}
+
+// requireUser - функция получения id пользователя из cookie, без корректной cookie отвечает статусом 401.
+func requireUser(res http.ResponseWriter, req *http.Request) (string, bool) {
+	reqCookie, err := req.Cookie("auth")
+	if err != nil {
+		writeError(res, req, service.ErrUnauthorized)
+		return "", false
+	}
+	userID := GetUID(reqCookie.Value)
+	if userID == "" {
+		writeError(res, req, service.ErrUnauthorized)
+		return "", false
+	}
+	return userID, true
+}
+
+// transferFormat - функция получения формата из параметра format, а без него - из заголовка Content-Type.
+func transferFormat(req *http.Request) (string, error) {
+	format := req.URL.Query().Get("format")
+	if format == "" {
+		if mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type")); err == nil &&
+			(mediaType == "application/x-ndjson" || mediaType == "application/jsonl") {
+			format = transfer.FormatJSONL
+		}
+	}
+	return transfer.ParseFormat(format)
+}
+
+// exportURLs - метод выгрузки url пользователя userID, при пустом userID - всех url вместе с владельцами.
+// Статус 200 отправляется с первой строкой, поэтому ошибка хранилища до нее возвращается обычным ответом об ошибке,
+// а после нее выгрузка обрывается.
+func (s *Server) exportURLs(res http.ResponseWriter, req *http.Request, userID string) {
+	format, err := transferFormat(req)
+	if err != nil {
+		writeError(res, req, service.InvalidParam("format", err))
+		return
+	}
+	var w transfer.Writer
+	start := func() error {
+		if w != nil {
+			return nil
+		}
+		res.Header().Set("Content-Type", transfer.ContentType(format))
+		res.Header().Set("Content-Disposition", `attachment; filename="urls.`+format+`"`)
+		res.WriteHeader(http.StatusOK)
+		w, err = transfer.NewWriter(res, format, userID == "")
+		return err
+	}
+	err = s.sService.ExportURLs(req.Context(), userID, func(url models.ExportURL) error {
+		if err := start(); err != nil {
+			return err
+		}
+		return w.Write(url)
+	})
+	if err != nil {
+		if w == nil {
+			writeError(res, req, err)
+			return
+		}
+		logger.FromContext(req.Context()).Error("Export interrupted", zap.Error(err))
+		return
+	}
+	if err := start(); err != nil {
+		logger.FromContext(req.Context()).Debug("error writing export", zap.Error(err))
+		return
+	}
+	if err := w.Flush(); err != nil {
+		logger.FromContext(req.Context()).Debug("error writing export", zap.Error(err))
+	}
+}
+
+// importURLs - метод загрузки url для пользователя userID, при пустом userID владелец берется из строки.
+// Ошибки хранилища и отмена запроса прерывают загрузку, остальные ошибки относятся к строке.
+func (s *Server) importURLs(res http.ResponseWriter, req *http.Request, userID string) {
+	format, err := transferFormat(req)
+	if err != nil {
+		writeError(res, req, service.InvalidParam("format", err))
+		return
+	}
+	reader, err := transfer.NewReader(req.Body, format)
+	if err != nil {
+		writeError(res, req, service.InvalidRequest(err))
+		return
+	}
+	ctx := s.auditContext(req)
+	report := models.ImportReport{Errors: []models.ImportError{}}
+	addError := func(rowErr models.ImportError) {
+		report.Failed++
+		if len(report.Errors) < maxImportErrors {
+			report.Errors = append(report.Errors, rowErr)
+		} else {
+			report.ErrorsTruncated = true
+		}
+	}
+	for {
+		url, line, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var rowErr *transfer.RowError
+		if errors.As(err, &rowErr) {
+			report.Total++
+			addError(models.ImportError{Line: rowErr.Line, Field: rowErr.Field, Code: service.CodeInvalidRequest, Reason: rowErr.Reason})
+			continue
+		}
+		if err != nil {
+			writeError(res, req, service.InvalidRequest(err))
+			return
+		}
+		report.Total++
+		owner := userID
+		if owner == "" {
+			owner = url.UserID
+		}
+		if owner == "" {
+			addError(models.ImportError{Line: line, Field: transfer.ColumnUserID, Code: service.CodeInvalidRequest, Reason: "user id is required"})
+			continue
+		}
+		status, err := s.sService.ImportURL(ctx, url, req.Host, owner)
+		if err != nil {
+			serviceErr := service.Classify(err)
+			switch serviceErr.Kind {
+			case service.KindInternal, service.KindCanceled, service.KindTimeout:
+				writeError(res, req, err)
+				return
+			}
+			addError(models.ImportError{Line: line, Field: serviceErr.Field, Code: serviceErr.Code, Reason: serviceErr.Error()})
+			continue
+		}
+		if status == service.ImportExists {
+			report.Existing++
+		} else {
+			report.Imported++
+		}
+	}
+	logger.FromContext(req.Context()).Info("Import finished",
+		zap.Int("total", report.Total), zap.Int("imported", report.Imported), zap.Int("failed", report.Failed))
+
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(report); err != nil {
+		logger.FromContext(req.Context()).Debug("error encoding responce", zap.Error(err))
+	}
+}
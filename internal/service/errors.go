@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Dorrrke/shortener-url/internal/audit"
+	"github.com/Dorrrke/shortener-url/internal/idempotency"
+	"github.com/Dorrrke/shortener-url/internal/ratelimit"
+	"github.com/Dorrrke/shortener-url/internal/storage"
+	"github.com/Dorrrke/shortener-url/internal/urlcheck"
+	"github.com/Dorrrke/shortener-url/internal/urlnorm"
+)
+
+// Kind - вид ошибки сервиса. По виду транспорт выбирает статус ответа: http статус или код grpc.
+type Kind string
+
+// Виды ошибок сервиса.
+const (
+	KindInternal       Kind = "internal"
+	KindValidation     Kind = "validation"
+	KindNotFound       Kind = "not_found"
+	KindConflict       Kind = "conflict"
+	KindUnauthorized   Kind = "unauthorized"
+	KindForbidden      Kind = "forbidden"
+	KindQuota          Kind = "quota"
+	KindGone           Kind = "gone"
+	KindUnavailable    Kind = "unavailable"
+	KindNotImplemented Kind = "not_implemented"
+	KindCanceled       Kind = "canceled"
+	KindTimeout        Kind = "timeout"
+)
+
+// Машиночитаемые коды ошибок, возвращаемые клиентам.
+const (
+	CodeInternal            = "internal_error"
+	CodeInvalidRequest      = "invalid_request"
+	CodeInvalidURL          = "invalid_url"
+	CodeURLNotFound         = "url_not_found"
+	CodeURLDeleted          = "url_deleted"
+	CodeURLExists           = "url_already_exists"
+	CodeURLBlocked          = "url_blocked"
+	CodeURLCheckUnavailable = "url_check_unavailable"
+	CodeUnauthorized        = "unauthorized"
+	CodeAccessDenied        = "access_denied"
+	CodeQuotaExceeded       = "quota_exceeded"
+	CodeBatchTooLarge       = "batch_too_large"
+	CodeNoContent           = "no_content"
+	CodeAuditNotSupported   = "audit_query_not_supported"
+	CodeCanceled            = "canceled"
+	CodeTimeout             = "timeout"
+	CodeIdempotencyReused   = "idempotency_key_reused"
+	CodeIdempotencyPending  = "idempotency_key_in_progress"
+	CodePasswordRequired    = "password_required"
+	CodeWrongPassword       = "wrong_password"
+	CodePasswordAttempts    = "too_many_password_attempts"
+	CodePasswordNotApplied  = "password_not_applied"
+	CodeRateLimited         = "rate_limited"
+	CodeInvalidEncoding     = "invalid_content_encoding"
+	CodeUnsupportedEncoding = "unsupported_content_encoding"
+	CodeBodyTooLarge        = "request_body_too_large"
+)
+
+// Error - типизированная ошибка сервиса с видом, машиночитаемым кодом и описанием для клиента.
+type Error struct {
+	// Kind - вид ошибки.
+	Kind Kind
+	// Code - машиночитаемый код ошибки.
+	Code string
+	// Message - описание ошибки, которое можно показать клиенту.
+	Message string
+	// Field - поле запроса, к которому относится ошибка валидации.
+	Field string
+	// Err - исходная ошибка.
+	Err error
+	// RetryAfter - время, через которое запрос можно повторить, 0 - не задано.
+	RetryAfter time.Duration
+	// ExistingShortURL - уже сохраненный сокращенный url, с которым конфликтует запрос.
+	ExistingShortURL string
+}
+
+// Error - текст ошибки.
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap - исходная ошибка.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Ошибки сервиса.
+var (
+	// ErrQuotaExceeded - у пользователя уже максимальное количество неудаленных ссылок.
+	ErrQuotaExceeded = &Error{Kind: KindQuota, Code: CodeQuotaExceeded, Message: "links quota exceeded"}
+	// ErrBatchTooLarge - в пакетном запросе больше ссылок, чем разрешено.
+	ErrBatchTooLarge = &Error{Kind: KindQuota, Code: CodeBatchTooLarge, Message: "batch size exceeds limit"}
+	// ErrUnauthorized - в запросе нет корректного токена пользователя.
+	ErrUnauthorized = &Error{Kind: KindUnauthorized, Code: CodeUnauthorized, Message: "user is not authorized"}
+	// ErrAccessDenied - запрос пришел не из доверенной подсети.
+	ErrAccessDenied = &Error{Kind: KindForbidden, Code: CodeAccessDenied, Message: "access is denied"}
+	// ErrURLNotFound - сокращенный url не найден.
+	ErrURLNotFound = &Error{Kind: KindNotFound, Code: CodeURLNotFound, Message: "short url not found"}
+	// ErrURLDeleted - сокращенный url удален пользователем.
+	ErrURLDeleted = &Error{Kind: KindGone, Code: CodeURLDeleted, Message: "short url was deleted"}
+	// ErrNoContent - у пользователя нет сохраненных url.
+	ErrNoContent = &Error{Kind: KindNotFound, Code: CodeNoContent, Message: "user has no saved urls"}
+	// ErrPasswordRequired - сокращенный url защищен паролем, а пароль не передан.
+	ErrPasswordRequired = &Error{Kind: KindUnauthorized, Code: CodePasswordRequired, Message: "short url is protected by password"}
+	// ErrWrongPassword - передан неверный пароль защищенного url.
+	ErrWrongPassword = &Error{Kind: KindUnauthorized, Code: CodeWrongPassword, Message: "wrong password"}
+)
+
+// TooManyPasswordAttempts - функция создания ошибки превышения попыток ввода пароля, повторить можно через retryAfter.
+func TooManyPasswordAttempts(retryAfter time.Duration) *Error {
+	return &Error{Kind: KindQuota, Code: CodePasswordAttempts, Message: "too many password attempts", RetryAfter: retryAfter}
+}
+
+// PasswordNotApplied - функция создания ошибки сокращения с паролем уже сокращенного url:
+// пароль к существующей ссылке existing не применен.
+func PasswordNotApplied(existing string) *Error {
+	return &Error{Kind: KindConflict, Code: CodePasswordNotApplied, Message: "url is already shortened, password was not applied", ExistingShortURL: existing}
+}
+
+// TooManyRequests - функция создания ошибки превышения ограничения частоты запросов, повторить можно через retryAfter.
+func TooManyRequests(retryAfter time.Duration) *Error {
+	return &Error{Kind: KindQuota, Code: CodeRateLimited, Message: "too many requests", RetryAfter: retryAfter}
+}
+
+// InvalidEncoding - функция создания ошибки распаковки тела запроса, сжатого кодировкой из Content-Encoding.
+func InvalidEncoding(err error) *Error {
+	return &Error{Kind: KindValidation, Code: CodeInvalidEncoding, Message: "cannot decode request body", Err: err}
+}
+
+// UnsupportedEncoding - функция создания ошибки неизвестной кодировки тела запроса.
+func UnsupportedEncoding(encoding string) *Error {
+	return &Error{Kind: KindValidation, Code: CodeUnsupportedEncoding, Message: "unsupported content encoding " + encoding}
+}
+
+// BodyTooLarge - функция создания ошибки превышения размера тела запроса.
+func BodyTooLarge(err error) *Error {
+	return &Error{Kind: KindValidation, Code: CodeBodyTooLarge, Message: "request body too large", Err: err}
+}
+
+// InvalidRequest - функция создания ошибки валидации запроса, например при некорректном json.
+func InvalidRequest(err error) *Error {
+	return &Error{Kind: KindValidation, Code: CodeInvalidRequest, Message: "invalid request body", Err: err}
+}
+
+// InvalidParam - функция создания ошибки валидации параметра запроса field.
+func InvalidParam(field string, err error) *Error {
+	return &Error{Kind: KindValidation, Code: CodeInvalidRequest, Message: "invalid request parameter", Field: field, Err: err}
+}
+
+// InvalidURL - функция создания ошибки валидации url в поле field.
+func InvalidURL(field string, err error) *Error {
+	return &Error{Kind: KindValidation, Code: CodeInvalidURL, Message: "invalid url", Field: field, Err: err}
+}
+
+// Classify - функция приведения ошибки к типизированной ошибке сервиса.
+// Ошибки хранилища, проверки и нормализации url, аудита, ограничения частоты запросов и контекста получают свой вид и код,
+// остальные ошибки считаются внутренними.
+func Classify(err error) *Error {
+	var serviceErr *Error
+	if errors.As(err, &serviceErr) {
+		return serviceErr
+	}
+	var limitErr *ratelimit.Error
+	if errors.As(err, &limitErr) {
+		e := TooManyRequests(limitErr.RetryAfter)
+		e.Err = err
+		return e
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return &Error{Kind: KindCanceled, Code: CodeCanceled, Message: "request canceled", Err: err}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &Error{Kind: KindTimeout, Code: CodeTimeout, Message: "operation timed out", Err: err}
+	case errors.Is(err, urlnorm.ErrInvalidURL):
+		return InvalidURL("url", err)
+	case errors.Is(err, urlcheck.ErrBlocked):
+		return &Error{Kind: KindForbidden, Code: CodeURLBlocked, Message: "url is blocked", Err: err}
+	case errors.Is(err, urlcheck.ErrUnavailable):
+		return &Error{Kind: KindUnavailable, Code: CodeURLCheckUnavailable, Message: "url check unavailable", Err: err}
+	case errors.Is(err, storage.ErrConflict):
+		return &Error{Kind: KindConflict, Code: CodeURLExists, Message: "url is already shortened", Err: err}
+	case errors.Is(err, storage.ErrNotFound):
+		return &Error{Kind: KindNotFound, Code: CodeURLNotFound, Message: ErrURLNotFound.Message, Err: err}
+	case errors.Is(err, storage.ErrGone):
+		return &Error{Kind: KindGone, Code: CodeURLDeleted, Message: ErrURLDeleted.Message, Err: err}
+	case errors.Is(err, idempotency.ErrInvalidKey):
+		return InvalidParam(idempotency.Header, err)
+	case errors.Is(err, idempotency.ErrKeyReused):
+		return &Error{Kind: KindValidation, Code: CodeIdempotencyReused, Message: "idempotency key is already used with a different request", Err: err}
+	case errors.Is(err, idempotency.ErrInProgress):
+		return &Error{Kind: KindConflict, Code: CodeIdempotencyPending, Message: "request with this idempotency key is in progress", Err: err}
+	case errors.Is(err, audit.ErrQueryNotSupported):
+		return &Error{Kind: KindNotImplemented, Code: CodeAuditNotSupported, Message: "audit query is not supported", Err: err}
+	}
+	return &Error{Kind: KindInternal, Code: CodeInternal, Message: "internal error", Err: err}
+}
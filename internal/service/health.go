@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// ErrTablesNotReady - таблицы в базе данных еще не созданы.
+var ErrTablesNotReady = errors.New("database tables are not created")
+
+// CheckStorage - метод проверки доступности хранилища.
+// Хранилище в памяти доступно всегда, для базы данных выполняется ping.
+func (ss *ShortenerService) CheckStorage(ctx context.Context) error {
+	if ss.Config().DatabaseDsn == "" {
+		return nil
+	}
+	return ss.storage.CheckDBConnect(ctx)
+}
+
+// CheckMigrations - метод проверки, что таблицы в базе данных созданы при запуске сервиса.
+func (ss *ShortenerService) CheckMigrations(ctx context.Context) error {
+	if ss.Config().DatabaseDsn == "" || ss.tablesReady.Load() {
+		return nil
+	}
+	return ErrTablesNotReady
+}
+
+// CheckFileStorage - метод проверки, что в файл хранилища можно дописывать url.
+func (ss *ShortenerService) CheckFileStorage(ctx context.Context) error {
+	if ss.Config().FileStoragePath == "" {
+		return nil
+	}
+	file, err := os.OpenFile(ss.Config().FileStoragePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// CheckDeletePipeline - метод проверки очереди удаления: последняя пометка url удаленными должна завершиться без ошибки.
+func (ss *ShortenerService) CheckDeletePipeline(ctx context.Context) error {
+	if err := ss.deleteErr.Load(); err != nil {
+		return errors.Wrap(*err, "set delete status")
+	}
+	return nil
+}
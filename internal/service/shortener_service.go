@@ -1,19 +1,26 @@
 package service
 
 import (
-	"bufio"
 	"context"
-	"encoding/json"
 	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 
+	"github.com/Dorrrke/shortener-url/internal/audit"
 	"github.com/Dorrrke/shortener-url/internal/config"
+	"github.com/Dorrrke/shortener-url/internal/idempotency"
 	"github.com/Dorrrke/shortener-url/internal/logger"
 	"github.com/Dorrrke/shortener-url/internal/models"
+	"github.com/Dorrrke/shortener-url/internal/ratelimit"
 	"github.com/Dorrrke/shortener-url/internal/storage"
+	"github.com/Dorrrke/shortener-url/internal/tracing"
+	"github.com/Dorrrke/shortener-url/internal/urlcheck"
+	"github.com/Dorrrke/shortener-url/internal/urlnorm"
 )
 
 // type Service interface {
@@ -28,38 +35,249 @@ import (
 // 	RestorStorage() error
 // }
 
+// Имена операций сервиса, используемые как ключи config.AppConfig.OperationTimeouts.
+const (
+	OpGetOriginalURL     = "GetOriginalURL"
+	OpGetShortByOriginal = "GetShortByOriginal"
+	OpCheckDBConnection  = "CheckDBConnection"
+	OpGetAllURLsByID     = "GetAllURLsByID"
+	OpGetServiceStat     = "GetServiceStat"
+	OpGetUserQuota       = "GetUserQuota"
+	OpSaveURL            = "SaveURL"
+	OpSaveURLBatch       = "SaveURLBatch"
+	OpDeleteURL          = "DeleteURL"
+	OpRestoreURL         = "RestoreURL"
+)
+
 type ShortenerService struct {
-	Config        *config.AppConfig
+	// cfg - текущая конфигурация, заменяется целиком при перезагрузке.
+	cfg           *config.Store
 	storage       storage.Storage
-	deleteQuereCh chan string
+	deleteQuereCh chan deleteTask
+	auditor       *audit.Auditor
+	// quotaLocks - сериализуют проверку квоты и сохранение для каждого пользователя, чтобы параллельные запросы не превысили лимит.
+	quotaLocks *userLocks
+	normalizer *urlnorm.Normalizer
+	// checker - проверка url, может заменяться при перезагрузке конфигурации.
+	checker *atomic.Pointer[checkerBox]
+	// deletePending - количество url, полученных из канала, но еще не помеченных удаленными.
+	deletePending *atomic.Int64
+	// deleteErr - последняя ошибка пометки url удаленными, nil после успешной пометки.
+	deleteErr *atomic.Pointer[error]
+	// tablesReady - таблицы в базе данных созданы.
+	tablesReady *atomic.Bool
+	deletes     *deleteState
+	// idempotency - обработка ключей идемпотентности запросов сокращения.
+	idempotency *idempotency.Keeper
+	// passwordAttempts - ограничение попыток ввода пароля защищенных url, корзина заводится на каждый url.
+	passwordAttempts *ratelimit.Limiter
+}
+
+// passwordRoute - маршрут ограничителя попыток ввода пароля.
+const passwordRoute = "password"
+
+// maxPasswordLength - максимальная длина пароля в байтах: bcrypt не учитывает байты после 72-го.
+const maxPasswordLength = 72
+
+// deleteRetryInterval - пауза перед повторной пометкой пачки url удаленными после ошибки.
+const deleteRetryInterval = time.Second
+
+// deleteTask - url в очереди удаления вместе с пользователем, запросившим удаление, и его ip для аудита.
+type deleteTask struct {
+	shortURL string
+	userID   string
+	ip       string
+}
+
+// deleteState - состояние очереди удаления, необходимое для ее остановки.
+type deleteState struct {
+	mu sync.Mutex
+	// closed - очередь закрыта, новые url не принимаются.
+	closed bool
+	// senders - горутины DeleteURL, еще передающие url в очередь.
+	senders sync.WaitGroup
+	// done - закрывается после завершения deleteUrls.
+	done chan struct{}
+	// abort - закрывается, если Close не дождался очереди, deleteUrls при этом прекращает повторы.
+	abort     chan struct{}
+	abortOnce sync.Once
 }
 
 func NewService(stor storage.Storage, cfg *config.AppConfig) *ShortenerService {
-	deleteCh := make(chan string, 5)
+	deleteCh := make(chan deleteTask, 5)
+	cfgStore := config.NewStore(cfg)
+	attempts := cfg.PasswordAttempts
+	if attempts.RPS <= 0 || attempts.Burst <= 0 {
+		attempts = config.DefaultPasswordAttempts
+	}
 	service := ShortenerService{
-		Config:        cfg,
+		cfg:           cfgStore,
+		checker:       &atomic.Pointer[checkerBox]{},
 		storage:       stor,
 		deleteQuereCh: deleteCh,
+		quotaLocks:    newUserLocks(),
+		deletePending: &atomic.Int64{},
+		deleteErr:     &atomic.Pointer[error]{},
+		tablesReady:   &atomic.Bool{},
+		deletes:       &deleteState{done: make(chan struct{}), abort: make(chan struct{})},
+		normalizer: urlnorm.New(urlnorm.Options{
+			MaxLength:       cfg.MaxURLLength,
+			KeepFragment:    cfg.KeepURLFragment,
+			KeepDefaultPort: cfg.KeepDefaultPort,
+			RejectPrivate:   cfg.RejectPrivateURLs,
+		}),
+		idempotency: idempotency.New(stor, func() time.Duration {
+			if ttl := time.Duration(cfgStore.Get().IdempotencyTTL); ttl > 0 {
+				return ttl
+			}
+			return config.DefaultIdempotencyTTL
+		}),
+		passwordAttempts: ratelimit.New(map[string]config.RateLimit{passwordRoute: attempts}),
 	}
 	go service.deleteUrls()
 
 	return &service
 }
 
-func (ss *ShortenerService) GetOriginalURL(short string) (string, bool, error) {
-	logger.Log.Info("Get from db")
-	ctx := context.Background()
+// SetAuditor - метод подключения подсистемы аудита к сервису.
+// Должен вызываться до передачи сервиса в http и grpc серверы.
+func (ss *ShortenerService) SetAuditor(auditor *audit.Auditor) {
+	ss.auditor = auditor
+}
+
+// checkerBox - обертка URLChecker для атомарной замены.
+type checkerBox struct {
+	checker urlcheck.URLChecker
+}
+
+// SetURLChecker - метод подключения проверки url на блоклисты и репутацию.
+// Проверку можно заменить во время работы, например при перезагрузке конфигурации.
+func (ss *ShortenerService) SetURLChecker(checker urlcheck.URLChecker) {
+	ss.checker.Store(&checkerBox{checker: checker})
+}
+
+// CheckURL - метод проверки оригинального url подключенным URLChecker.
+// Если url запрещен, возвращается ошибка, оборачивающая urlcheck.ErrBlocked.
+func (ss *ShortenerService) CheckURL(ctx context.Context, original string) error {
+	box := ss.checker.Load()
+	if box == nil || box.checker == nil {
+		return nil
+	}
+	return box.checker.Check(ctx, original)
+}
+
+// Config - метод получения текущего снимка конфигурации. Снимок нельзя изменять.
+func (ss *ShortenerService) Config() *config.AppConfig {
+	return ss.cfg.Get()
+}
+
+// ConfigStore - метод получения хранилища конфигурации для ее перезагрузки.
+func (ss *ShortenerService) ConfigStore() *config.Store {
+	return ss.cfg
+}
+
+// Idempotency - метод получения обработки ключей идемпотентности.
+func (ss *ShortenerService) Idempotency() *idempotency.Keeper {
+	return ss.idempotency
+}
+
+// QueryAudit - метод получения записей аудита.
+func (ss *ShortenerService) QueryAudit(ctx context.Context, f audit.Filter) ([]audit.Record, error) {
+	return ss.auditor.Query(ctx, f)
+}
+
+// GetOriginalURL - метод получения оригинального url по сокращенному url.
+// Если url не найден, возвращается storage.ErrNotFound, если удален - storage.ErrGone.
+// Для url, защищенного паролем, password проверяется: без пароля возвращается ErrPasswordRequired,
+// при неверном пароле - ErrWrongPassword. Для url без пароля password не используется.
+// Попытки ввода пароля учитываются по ip клиента, сохраненному в ctx через audit.WithIP.
+func (ss *ShortenerService) GetOriginalURL(ctx context.Context, short string, password string) (string, error) {
+	ctx, span := tracing.Start(ctx, "ShortenerService.GetOriginalURL")
+	defer span.End()
+	ctx, cancel := ss.withTimeout(ctx, OpGetOriginalURL)
+	defer cancel()
+	logger.FromContext(ctx).Debug("Get from db")
 	originalURL, deleted, err := ss.storage.GetOriginalURLByShort(ctx, short)
 	if err != nil {
-		return "", false, err
+		return "", err
+	}
+	if deleted {
+		return "", storage.ErrGone
+	}
+	if originalURL == "" {
+		return "", storage.ErrNotFound
+	}
+	if err := ss.checkPassword(ctx, short, password); err != nil {
+		return "", err
+	}
+	return originalURL, nil
+}
+
+// checkPassword - метод проверки пароля url, для url без пароля проверка не выполняется.
+// Попытки ввода пароля ограничены для пары url и ip клиента из контекста (audit.WithIP):
+// перебор пароля с одного адреса блокируется, а чужие неверные попытки не блокируют доступ
+// владельцу и другим посетителям ссылки.
+func (ss *ShortenerService) checkPassword(ctx context.Context, short string, password string) error {
+	passwordHash, err := ss.storage.GetURLPassword(ctx, short)
+	if err != nil {
+		return err
+	}
+	if passwordHash == "" {
+		return nil
+	}
+	if password == "" {
+		return ErrPasswordRequired
+	}
+	if ok, retryAfter := ss.passwordAttempts.Allow(passwordRoute, short+"|"+audit.IPFromContext(ctx)); !ok {
+		logger.FromContext(ctx).Info("Too many password attempts", zap.String("short", short))
+		return TooManyPasswordAttempts(retryAfter)
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password))
+	// bcrypt отбрасывает байты после 72-го, поэтому более длинный пароль не может совпасть с сохраненным.
+	if err == nil && len(password) > maxPasswordLength {
+		err = bcrypt.ErrMismatchedHashAndPassword
+	}
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			logger.FromContext(ctx).Info("Wrong url password", zap.String("short", short))
+			return ErrWrongPassword
+		}
+		return errors.Wrap(err, "compare password")
+	}
+	return nil
+}
+
+// hashPassword - функция проверки длины пароля и получения его bcrypt хеша, для пустого пароля возвращается пустой хеш.
+func hashPassword(password string) (string, error) {
+	if password == "" {
+		return "", nil
+	}
+	if len(password) > maxPasswordLength {
+		return "", InvalidParam("password", errors.Errorf("password is longer than %d bytes", maxPasswordLength))
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", errors.Wrap(err, "hash password")
 	}
+	return string(hash), nil
+}
 
-	return originalURL, deleted, nil
+// NormalizeURL - метод валидации и нормализации оригинального url.
+// Ошибки валидации оборачивают urlnorm.ErrInvalidURL.
+func (ss *ShortenerService) NormalizeURL(original string) (string, error) {
+	return ss.normalizer.Normalize(original)
 }
 
-func (ss *ShortenerService) GetShortByOriginal(original string) (string, error) {
-	logger.Log.Info("Get from db")
-	ctx := context.Background()
+// GetShortByOriginal - метод получения сокращенного url по оригинальному.
+func (ss *ShortenerService) GetShortByOriginal(ctx context.Context, original string) (string, error) {
+	ctx, span := tracing.Start(ctx, "ShortenerService.GetShortByOriginal")
+	defer span.End()
+	ctx, cancel := ss.withTimeout(ctx, OpGetShortByOriginal)
+	defer cancel()
+	logger.FromContext(ctx).Debug("Get from db")
+	if normalized, err := ss.NormalizeURL(original); err == nil {
+		original = normalized
+	}
 	originalURL, err := ss.storage.GetShortByOriginalURL(ctx, original)
 	if err != nil {
 		return "", err
@@ -67,17 +285,25 @@ func (ss *ShortenerService) GetShortByOriginal(original string) (string, error)
 	return originalURL, nil
 }
 
-func (ss *ShortenerService) CheckDBConnection() error {
-	ctx := context.Background()
+// CheckDBConnection - метод проверки подключения к базе данных.
+func (ss *ShortenerService) CheckDBConnection(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "ShortenerService.CheckDBConnection")
+	defer span.End()
+	ctx, cancel := ss.withTimeout(ctx, OpCheckDBConnection)
+	defer cancel()
 	if err := ss.storage.CheckDBConnect(ctx); err != nil {
-		logger.Log.Error("Error check db connection", zap.Error(err))
+		logger.FromContext(ctx).Error("Error check db connection", zap.Error(err))
 		return err
 	}
 	return nil
 }
 
-func (ss *ShortenerService) GetAllURLsByID(userID string) ([]models.URLModel, error) {
-	ctx := context.Background()
+// GetAllURLsByID - метод получения всех url пользователя.
+func (ss *ShortenerService) GetAllURLsByID(ctx context.Context, userID string) ([]models.URLModel, error) {
+	ctx, span := tracing.Start(ctx, "ShortenerService.GetAllURLsByID")
+	defer span.End()
+	ctx, cancel := ss.withTimeout(ctx, OpGetAllURLsByID)
+	defer cancel()
 	userURL, err := ss.storage.GetAllUrls(ctx, userID)
 	if err != nil {
 		return nil, err
@@ -85,141 +311,473 @@ func (ss *ShortenerService) GetAllURLsByID(userID string) ([]models.URLModel, er
 	return userURL, nil
 }
 
-func (ss *ShortenerService) GetServiceStat() (models.StatModel, error) {
-	logger.Log.Info("Get from db")
-	ctx := context.Background()
+// GetServiceStat - метод получения статистики сервиса.
+func (ss *ShortenerService) GetServiceStat(ctx context.Context) (models.StatModel, error) {
+	ctx, span := tracing.Start(ctx, "ShortenerService.GetServiceStat")
+	defer span.End()
+	ctx, cancel := ss.withTimeout(ctx, OpGetServiceStat)
+	defer cancel()
+	logger.FromContext(ctx).Debug("Get from db")
 	URLs, users, err := ss.storage.GetStats(ctx)
 	if err != nil {
 		return models.StatModel{}, err
 	}
-	return models.StatModel{
+	stat := models.StatModel{
 		URLsCount:  URLs,
 		UsercCount: users,
+	}
+	if cached, ok := ss.storage.(*storage.CachedStorage); ok {
+		cacheStats := cached.Stats()
+		stat.CacheHits = cacheStats.Hits
+		stat.CacheMisses = cacheStats.Misses
+	}
+	return stat, nil
+}
+
+// GetUserQuota - метод получения текущего использования квот пользователя.
+func (ss *ShortenerService) GetUserQuota(ctx context.Context, userID string) (models.QuotaModel, error) {
+	ctx, span := tracing.Start(ctx, "ShortenerService.GetUserQuota")
+	defer span.End()
+	ctx, cancel := ss.withTimeout(ctx, OpGetUserQuota)
+	defer cancel()
+	used, err := ss.storage.CountUserURLs(ctx, userID)
+	if err != nil {
+		return models.QuotaModel{}, err
+	}
+	return models.QuotaModel{
+		LinksUsed:  used,
+		LinksLimit: ss.Config().MaxLinksPerUser,
+		BatchLimit: ss.Config().MaxBatchSize,
 	}, nil
 }
 
-func (ss *ShortenerService) SaveURL(original string, short string, userID string) error {
-	logger.Log.Info("Save into db")
-	ctx := context.Background()
-	if err := ss.storage.InsertURL(ctx, original, short, userID); err != nil {
-		return err
+// checkLinksQuota - метод проверки, что пользователь может сохранить еще count ссылок.
+// Вызывается под блокировкой пользователя из quotaLocks.
+func (ss *ShortenerService) checkLinksQuota(ctx context.Context, userID string, count int) error {
+	if ss.Config().MaxLinksPerUser <= 0 {
+		return nil
 	}
-	if ss.Config.FileStoragePath != "" {
-		logger.Log.Info("Save into file")
-		if err := writeURL(ss.Config.FileStoragePath, models.RestorURL{ShortURL: short, OriginalURL: original}); err != nil {
-			return err
+	used, err := ss.storage.CountUserURLs(ctx, userID)
+	if err != nil {
+		return errors.Wrap(err, "count user urls")
+	}
+	if used+count > ss.Config().MaxLinksPerUser {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// SaveURL - метод сохранения url, оригинальный url сохраняется в нормализованном виде.
+// Возвращает сохраненный сокращенный url. Если оригинальный url уже был сокращен, возвращается
+// ранее сохраненный сокращенный url вместе с ошибкой, оборачивающей storage.ErrConflict.
+func (ss *ShortenerService) SaveURL(ctx context.Context, original string, short string, userID string) (string, error) {
+	ctx, span := tracing.Start(ctx, "ShortenerService.SaveURL")
+	defer span.End()
+	return ss.saveURL(ctx, original, short, userID, "")
+}
+
+// SaveProtectedURL - метод сохранения url, как SaveURL, с паролем, без которого по url нельзя перейти.
+// В хранилище сохраняется только bcrypt хеш пароля, пустой пароль сохраняет url без защиты.
+// Если оригинальный url уже был сокращен, возвращается существующий сокращенный url вместе с ошибкой
+// PasswordNotApplied: пароль к существующей ссылке не применяется, она может быть открытой или защищенной чужим паролем.
+func (ss *ShortenerService) SaveProtectedURL(ctx context.Context, original string, short string, userID string, password string) (string, error) {
+	ctx, span := tracing.Start(ctx, "ShortenerService.SaveProtectedURL")
+	defer span.End()
+	passwordHash, err := hashPassword(password)
+	if err != nil {
+		return "", err
+	}
+	return ss.saveURL(ctx, original, short, userID, passwordHash)
+}
+
+// saveURL - метод сохранения url с хешем пароля, пустой хеш - url без пароля.
+func (ss *ShortenerService) saveURL(ctx context.Context, original string, short string, userID string, passwordHash string) (string, error) {
+	ctx, cancel := ss.withTimeout(ctx, OpSaveURL)
+	defer cancel()
+	logger.FromContext(ctx).Debug("Save into db")
+	original, err := ss.NormalizeURL(original)
+	if err != nil {
+		return "", err
+	}
+	if err := ss.CheckURL(ctx, original); err != nil {
+		return "", err
+	}
+	if ss.Config().MaxLinksPerUser > 0 {
+		defer ss.quotaLocks.lock(userID)()
+		// Уже сокращенный url не создает новую ссылку, поэтому квота не проверяется,
+		// а сохранение ниже завершится конфликтом с существующей ссылкой.
+		_, lookupErr := ss.storage.GetShortByOriginalURL(ctx, original)
+		if lookupErr != nil && !errors.Is(lookupErr, storage.ErrNotFound) {
+			return "", errors.Wrap(lookupErr, "get existing short url")
 		}
-		return nil
+		if lookupErr != nil {
+			if err := ss.checkLinksQuota(ctx, userID, 1); err != nil {
+				return "", err
+			}
+		}
+	}
+	if passwordHash == "" {
+		err = ss.storage.InsertURL(ctx, original, short, userID)
+	} else {
+		err = ss.storage.InsertProtectedURL(ctx, original, short, userID, passwordHash)
+	}
+	if err != nil {
+		if !errors.Is(err, storage.ErrConflict) {
+			return "", err
+		}
+		existing, lookupErr := ss.storage.GetShortByOriginalURL(ctx, original)
+		if lookupErr != nil {
+			return "", errors.Wrap(lookupErr, "get existing short url")
+		}
+		if passwordHash != "" {
+			return existing, PasswordNotApplied(existing)
+		}
+		return existing, err
+	}
+	ss.auditor.Record(ctx, audit.ActionCreate, userID, short)
+	if ss.Config().FileStoragePath != "" {
+		logger.FromContext(ctx).Debug("Save into file")
+		now := time.Now()
+		record := models.RestorURL{ShortURL: short, OriginalURL: original, UserID: userID, CreatedAt: &now, PasswordHash: passwordHash}
+		if err := writeURL(ctx, ss.Config().FileStoragePath, record); err != nil {
+			return "", err
+		}
+	}
+	return short, nil
+}
+
+// CheckBatchSize - метод проверки, что пакет из size url не превышает максимальный размер пакета.
+// Хендлеры вызывают его до разбора url пакета.
+func (ss *ShortenerService) CheckBatchSize(size int) error {
+	if ss.Config().MaxBatchSize > 0 && size > ss.Config().MaxBatchSize {
+		return ErrBatchTooLarge
 	}
 	return nil
 }
 
-func (ss *ShortenerService) SaveURLBatch(batch []models.BantchURL) error {
-	ctx := context.Background()
-	if err := ss.storage.InsertBanchURL(ctx, batch); err != nil {
+// SaveURLBatch - метод сохранения нескольких url, оригинальные url сохраняются в нормализованном виде.
+// Размер пакета проверяется до нормализации и проверки url, чтобы слишком большой пакет отклонялся сразу.
+func (ss *ShortenerService) SaveURLBatch(ctx context.Context, batch []models.BantchURL) error {
+	ctx, span := tracing.Start(ctx, "ShortenerService.SaveURLBatch")
+	defer span.End()
+	ctx, cancel := ss.withTimeout(ctx, OpSaveURLBatch)
+	defer cancel()
+	if err := ss.CheckBatchSize(len(batch)); err != nil {
 		return err
 	}
-	if ss.Config.FileStoragePath != "" {
-		logger.Log.Info("Save batch into file")
+	for i := range batch {
+		normalized, err := ss.NormalizeURL(batch[i].OriginalURL)
+		if err != nil {
+			return err
+		}
+		if err := ss.CheckURL(ctx, normalized); err != nil {
+			return err
+		}
+		batch[i].OriginalURL = normalized
+	}
+	if ss.Config().MaxLinksPerUser > 0 {
+		perUser := make(map[string]int)
+		userIDs := make([]string, 0, len(batch))
 		for _, v := range batch {
-			if err := writeURL(ss.Config.FileStoragePath, models.RestorURL{ShortURL: v.ShortURL, OriginalURL: v.OriginalURL}); err != nil {
+			perUser[v.UserID]++
+			userIDs = append(userIDs, v.UserID)
+		}
+		defer ss.quotaLocks.lock(userIDs...)()
+		for userID, count := range perUser {
+			if err := ss.checkLinksQuota(ctx, userID, count); err != nil {
 				return err
 			}
 		}
-		return nil
+	}
+	if err := ss.storage.InsertBanchURL(ctx, batch); err != nil {
+		return err
+	}
+	for _, v := range batch {
+		ss.auditor.Record(ctx, audit.ActionCreate, v.UserID, v.ShortURL)
+	}
+	if ss.Config().FileStoragePath != "" {
+		logger.FromContext(ctx).Debug("Save batch into file")
+		now := time.Now()
+		records := make([]models.RestorURL, 0, len(batch))
+		for _, v := range batch {
+			records = append(records, models.RestorURL{ShortURL: v.ShortURL, OriginalURL: v.OriginalURL, UserID: v.UserID, CreatedAt: &now})
+		}
+		return writeURL(ctx, ss.Config().FileStoragePath, records...)
 	}
 	return nil
 }
 
-func (ss *ShortenerService) DeleteURL(moodel []string, host string) {
-	for _, data := range moodel {
-		var deleteURL string
-		if ss.Config.BaseURL == "" {
-			deleteURL = "http://" + host + "/" + data
+// DeleteURL - метод постановки url в очередь на удаление.
+// Метод не блокируется: url передаются в очередь в отдельной горутине, которую дожидается Close,
+// поэтому ctx отвязывается от отмены запроса. После Close url не принимаются.
+// Удаленными помечаются только неудаленные url пользователя userID, запись аудита о удалении
+// добавляется после пометки url в хранилище.
+func (ss *ShortenerService) DeleteURL(ctx context.Context, moodel []string, host string, userID string) {
+	ctx = context.WithoutCancel(ctx)
+	ss.deletes.mu.Lock()
+	if ss.deletes.closed {
+		ss.deletes.mu.Unlock()
+		logger.FromContext(ctx).Warn("Delete queue is closed", zap.Strings("urls", moodel))
+		return
+	}
+	ss.deletes.senders.Add(1)
+	ss.deletes.mu.Unlock()
+
+	go func() {
+		defer ss.deletes.senders.Done()
+		ctx, span := tracing.Start(ctx, "ShortenerService.DeleteURL")
+		defer span.End()
+		for _, data := range moodel {
+			var deleteURL string
+			if ss.Config().BaseURL == "" {
+				deleteURL = "http://" + host + "/" + data
+			} else {
+				deleteURL = "http://" + ss.Config().BaseURL + "/" + data
+			}
+			ss.deleteQuereCh <- deleteTask{shortURL: deleteURL, userID: userID, ip: audit.IPFromContext(ctx)}
+		}
+	}()
+}
+
+// RestoreURL - метод снятия отметки удаления с url пользователя по их id.
+// В отличие от DeleteURL выполняется синхронно и возвращает восстановленные сокращенные url:
+// url других пользователей и неудаленные url пропускаются. Восстановленные url снова учитываются в квоте,
+// поэтому при включенной квоте все переданные url должны в нее помещаться.
+func (ss *ShortenerService) RestoreURL(ctx context.Context, ids []string, host string, userID string) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "ShortenerService.RestoreURL")
+	defer span.End()
+	ctx, cancel := ss.withTimeout(ctx, OpRestoreURL)
+	defer cancel()
+	shorts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if ss.Config().BaseURL == "" {
+			shorts = append(shorts, "http://"+host+"/"+id)
 		} else {
-			deleteURL = "http://" + ss.Config.BaseURL + "/" + data
+			shorts = append(shorts, "http://"+ss.Config().BaseURL+"/"+id)
 		}
-		ss.deleteQuereCh <- deleteURL
 	}
+	if ss.Config().MaxLinksPerUser > 0 {
+		defer ss.quotaLocks.lock(userID)()
+		if err := ss.checkLinksQuota(ctx, userID, len(shorts)); err != nil {
+			return nil, err
+		}
+	}
+	restored, err := ss.storage.RestoreURLs(ctx, shorts, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, short := range restored {
+		ss.auditor.Record(ctx, audit.ActionRestore, userID, short)
+	}
+	if ss.Config().FileStoragePath != "" && len(restored) > 0 {
+		records := make([]models.RestorURL, 0, len(restored))
+		for _, short := range restored {
+			records = append(records, models.RestorURL{ShortURL: short, Restored: true})
+		}
+		if err := writeURL(ctx, ss.Config().FileStoragePath, records...); err != nil {
+			return nil, err
+		}
+	}
+	return restored, nil
 }
 
-func writeURL(fileName string, lastURL models.RestorURL) error {
-	file, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
-	if err != nil {
-		return err
+// SyncFileStorage - метод сброса файла хранилища на диск (fsync), вызывается при остановке сервиса.
+func (ss *ShortenerService) SyncFileStorage() error {
+	if ss.Config().FileStoragePath == "" {
+		return nil
 	}
-	writer := bufio.NewWriter(file)
-	data, err := json.Marshal(&lastURL)
+	file, err := os.OpenFile(ss.Config().FileStoragePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
 	if err != nil {
-		return errors.Wrap(err, "encode last url")
+		return errors.Wrap(err, "open file storage")
 	}
-	if _, err := writer.Write(data); err != nil {
-		return errors.Wrap(err, "write if file last url")
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return errors.Wrap(err, "sync file storage")
 	}
-	if err := writer.WriteByte('\n'); err != nil {
-		return errors.Wrap(err, "write in file '\n'")
+	return file.Close()
+}
+
+// writeURL - функция дописывания записей в файл хранилища.
+func writeURL(ctx context.Context, fileName string, records ...models.RestorURL) error {
+	_, span := tracing.Start(ctx, "file.WriteURL")
+	defer span.End()
+	return storage.AppendFile(fileName, records...)
+}
+
+// withTimeout - метод ограничения контекста операции таймаутом из конфигурации.
+// Если таймаут не задан, возвращается исходный контекст, дедлайн запроса при этом сохраняется.
+func (ss *ShortenerService) withTimeout(ctx context.Context, op string) (context.Context, context.CancelFunc) {
+	timeout := ss.Config().OperationTimeout(op)
+	if timeout <= 0 {
+		return ctx, func() {}
 	}
-	writer.Flush()
-	file.Close()
-	return nil
+	return context.WithTimeout(ctx, timeout)
 }
 
-func (ss *ShortenerService) deleteUrls() {
+// DeleteQueueDepth - метод получения количества url, ожидающих пометки удаленными.
+func (ss *ShortenerService) DeleteQueueDepth() int {
+	return len(ss.deleteQuereCh) + int(ss.deletePending.Load())
+}
 
-	var deleteQueue []string
+// deleteUrls - метод пометки url из очереди удаленными.
+// Все url, накопившиеся в очереди, помечаются одной пачкой; при ошибке пачка повторяется через deleteRetryInterval.
+// Метод завершается после закрытия очереди и пометки последней пачки или после отмены через Close.
+func (ss *ShortenerService) deleteUrls() {
+	defer close(ss.deletes.done)
 	ctx := context.Background()
+	for row := range ss.deleteQuereCh {
+		deleteQueue := ss.drainDeleteQueue([]deleteTask{row})
+		for {
+			ss.deletePending.Store(int64(len(deleteQueue)))
+			logger.Log.Debug("Set delete status in db", zap.Int("delete quere", len(deleteQueue)))
+			err := ss.setDeleteStatus(ctx, deleteQueue)
+			if err == nil {
+				ss.deleteErr.Store(nil)
+				break
+			}
+			logger.Log.Error("Dlete status", zap.Error(err))
+			ss.deleteErr.Store(&err)
+			select {
+			case <-ss.deletes.abort:
+				logger.Log.Error("Delete queue aborted", zap.Int("lost urls", len(deleteQueue)))
+				return
+			case <-time.After(deleteRetryInterval):
+			}
+			deleteQueue = ss.drainDeleteQueue(deleteQueue)
+		}
+		ss.deletePending.Store(0)
+	}
+}
+
+// drainDeleteQueue - метод добавления в пачку всех url, уже находящихся в очереди, без ожидания новых.
+func (ss *ShortenerService) drainDeleteQueue(deleteQueue []deleteTask) []deleteTask {
 	for {
 		select {
-		case row := <-ss.deleteQuereCh:
-			logger.Log.Info("Add url in delete quere", zap.String("url", row))
+		case row, ok := <-ss.deleteQuereCh:
+			if !ok {
+				return deleteQueue
+			}
 			deleteQueue = append(deleteQueue, row)
 		default:
-			if deleteQueue != nil {
-				logger.Log.Info("Set delete status in db", zap.Any("delete quere", deleteQueue))
-				if err := ss.storage.SetDeleteURLStatus(ctx, deleteQueue); err != nil {
-					logger.Log.Error("Dlete status", zap.Error(err))
-					continue
-				}
-				deleteQueue = nil
+			return deleteQueue
+		}
+	}
+}
+
+// Close - метод остановки очереди удаления: новые url не принимаются, url от уже принятых запросов
+// помечаются удаленными. Если ctx истекает раньше, очередь прерывается и возвращается ошибка ctx.
+func (ss *ShortenerService) Close(ctx context.Context) error {
+	ss.deletes.mu.Lock()
+	if !ss.deletes.closed {
+		ss.deletes.closed = true
+		ss.deletes.mu.Unlock()
+		sent := make(chan struct{})
+		go func() {
+			ss.deletes.senders.Wait()
+			close(ss.deleteQuereCh)
+			close(sent)
+		}()
+		select {
+		case <-sent:
+		case <-ctx.Done():
+			ss.deletes.abortOnce.Do(func() { close(ss.deletes.abort) })
+			return errors.Wrap(ctx.Err(), "wait delete senders")
+		}
+	} else {
+		ss.deletes.mu.Unlock()
+	}
+	select {
+	case <-ss.deletes.done:
+		return nil
+	case <-ctx.Done():
+		ss.deletes.abortOnce.Do(func() { close(ss.deletes.abort) })
+		return errors.Wrapf(ctx.Err(), "flush delete queue, pending %d", ss.DeleteQueueDepth())
+	}
+}
+
+// setDeleteStatus - метод пометки пачки url удаленными в отдельном спане.
+// Url группируются по пользователю, в хранилище помечаются только его неудаленные url,
+// и только для них записывается аудит. При повторе пачки после ошибки уже помеченные url не учитываются повторно.
+func (ss *ShortenerService) setDeleteStatus(ctx context.Context, deleteQueue []deleteTask) error {
+	ctx, span := tracing.Start(ctx, "ShortenerService.setDeleteStatus")
+	defer span.End()
+	ctx, cancel := ss.withTimeout(ctx, OpDeleteURL)
+	defer cancel()
+	var users []string
+	byUser := make(map[string][]deleteTask)
+	for _, task := range deleteQueue {
+		if _, ok := byUser[task.userID]; !ok {
+			users = append(users, task.userID)
+		}
+		byUser[task.userID] = append(byUser[task.userID], task)
+	}
+	for _, userID := range users {
+		tasks := byUser[userID]
+		shorts := make([]string, 0, len(tasks))
+		ips := make(map[string]string, len(tasks))
+		for _, task := range tasks {
+			shorts = append(shorts, task.shortURL)
+			if _, ok := ips[task.shortURL]; !ok {
+				ips[task.shortURL] = task.ip
 			}
 		}
+		deleted, err := ss.storage.SetDeleteURLStatus(ctx, shorts, userID)
+		if err != nil {
+			return err
+		}
+		for _, short := range deleted {
+			ss.auditor.Record(audit.WithIP(ctx, ips[short]), audit.ActionDelete, userID, short)
+		}
+		if len(deleted) == 0 || ss.Config().FileStoragePath == "" {
+			continue
+		}
+		records := make([]models.RestorURL, 0, len(deleted))
+		for _, short := range deleted {
+			records = append(records, models.RestorURL{ShortURL: short, Deleted: true})
+		}
+		if err := writeURL(ctx, ss.Config().FileStoragePath, records...); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 // RestorStorage - функция для восстановления харнилища после перезапуска сервиса.
-func (ss *ShortenerService) RestorStorage() error {
-	if err := ss.storage.CheckDBConnect(context.Background()); err == nil {
-		if err := ss.createTable(); err != nil {
-			logger.Log.Info("Error when create table: " + err.Error())
+func (ss *ShortenerService) RestorStorage(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "ShortenerService.RestorStorage")
+	defer span.End()
+	if err := ss.storage.CheckDBConnect(ctx); err == nil {
+		if err := ss.createTable(ctx); err != nil {
+			logger.FromContext(ctx).Info("Error when create table: " + err.Error())
 			return errors.Wrap(err, "Error when create table: ")
 		}
+		ss.tablesReady.Store(true)
 	}
-	if ss.Config.FileStoragePath != "" {
-		file, err := os.OpenFile(ss.Config.FileStoragePath, os.O_RDONLY|os.O_CREATE, 0666)
+	if ss.Config().FileStoragePath != "" {
+		urls, err := storage.ReadFile(ss.Config().FileStoragePath)
 		if err != nil {
-			return err
+			return errors.Wrap(err, "read file storage")
 		}
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			data := models.RestorURL{}
-			err := json.Unmarshal(scanner.Bytes(), &data)
+		for len(urls) > 0 {
+			chunk := urls[:min(len(urls), restoreChunkSize)]
+			urls = urls[len(chunk):]
+			loadCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			_, err := ss.storage.LoadURLs(loadCtx, chunk)
+			cancel()
 			if err != nil {
-				return err
+				return errors.Wrap(err, "load file storage")
 			}
-			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-			defer cancel()
-			ss.storage.InsertURL(ctx, data.ShortURL, data.OriginalURL, "")
 		}
-		file.Close()
-		return nil
 	}
 	return nil
 }
 
+// restoreChunkSize - количество url из файла хранилища, сохраняемых за один вызов LoadURLs.
+const restoreChunkSize = 500
+
 // CreateTable - функция создания таблиц в базе данных.
 // Функция запускается при успещном подключении к базе данных.
-func (ss *ShortenerService) createTable() error {
-	ctx := context.Background()
+func (ss *ShortenerService) createTable(ctx context.Context) error {
 	if err := ss.storage.CreateTable(ctx); err != nil {
 		return err
 	}
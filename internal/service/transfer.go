@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Dorrrke/shortener-url/internal/audit"
+	"github.com/Dorrrke/shortener-url/internal/models"
+	"github.com/Dorrrke/shortener-url/internal/storage"
+	"github.com/Dorrrke/shortener-url/internal/tracing"
+)
+
+// ImportStatus - результат загрузки одной ссылки.
+type ImportStatus string
+
+// Результаты загрузки ссылки.
+const (
+	// ImportCreated - ссылка сохранена.
+	ImportCreated ImportStatus = "imported"
+	// ImportExists - оригинальный url уже был сокращен, ссылка пропущена.
+	ImportExists ImportStatus = "exists"
+)
+
+// shortIDPattern - допустимый идентификатор сокращенного url в загружаемом файле.
+var shortIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// ErrShortURLTaken - сокращенный url из файла уже указывает на другой оригинальный url.
+var ErrShortURLTaken = &Error{Kind: KindConflict, Code: CodeURLExists, Message: "short url is already used for another url", Field: "short_url"}
+
+// ExportURLs - метод выгрузки url пользователя userID, включая удаленные, при пустом userID - всех url хранилища.
+// Url передаются в fn по мере чтения из хранилища, поэтому таймаут операции к выгрузке не применяется:
+// ее длительность ограничивает контекст запроса.
+func (ss *ShortenerService) ExportURLs(ctx context.Context, userID string, fn func(models.ExportURL) error) error {
+	ctx, span := tracing.Start(ctx, "ShortenerService.ExportURLs")
+	defer span.End()
+	return ss.storage.ExportURLs(ctx, userID, fn)
+}
+
+// ImportURL - метод загрузки одной ссылки из выгрузки для пользователя userID.
+// Идентификатор сокращенного url (последний сегмент short_url) сохраняется, а адрес сервиса заменяется текущим,
+// без short_url идентификатор создается заново. Ссылка проходит те же проверки и квоту, что и при сокращении.
+// Если оригинальный url уже сокращен, возвращается ImportExists, если идентификатор занят другим url - ErrShortURLTaken.
+// Ссылка с отметкой удаления сохраняется и сразу помечается удаленной.
+// Хеш пароля из полной выгрузки сохраняется вместе со ссылкой, защищенная ссылка без хеша (из выгрузки пользователя)
+// не загружается, чтобы она не стала открытой.
+func (ss *ShortenerService) ImportURL(ctx context.Context, url models.ExportURL, host string, userID string) (ImportStatus, error) {
+	ctx, span := tracing.Start(ctx, "ShortenerService.ImportURL")
+	defer span.End()
+	if url.OriginalURL == "" {
+		return "", InvalidURL("original_url", errors.New("original url is empty"))
+	}
+	if url.PasswordHash != "" {
+		if _, err := bcrypt.Cost([]byte(url.PasswordHash)); err != nil {
+			return "", InvalidParam("password_hash", err)
+		}
+	} else if url.Protected {
+		return "", InvalidParam("password_hash", errors.New("protected url can be imported only from full export with password hash"))
+	}
+	id := strings.Split(uuid.New().String(), "-")[0]
+	if url.ShortURL != "" {
+		id = url.ShortURL[strings.LastIndex(url.ShortURL, "/")+1:]
+		if !shortIDPattern.MatchString(id) {
+			return "", InvalidParam("short_url", errors.Errorf("invalid short url id %q", id))
+		}
+	}
+	short := "http://" + host + "/" + id
+	if ss.Config().BaseURL != "" {
+		short = "http://" + ss.Config().BaseURL + "/" + id
+	}
+	original, err := ss.NormalizeURL(url.OriginalURL)
+	if err != nil {
+		return "", InvalidURL("original_url", err)
+	}
+	if url.ShortURL != "" {
+		lookupCtx, cancel := ss.withTimeout(ctx, OpGetOriginalURL)
+		existing, _, err := ss.storage.GetOriginalURLByShort(lookupCtx, short)
+		cancel()
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return "", err
+		}
+		if existing == original {
+			return ImportExists, nil
+		}
+		if existing != "" {
+			return "", ErrShortURLTaken
+		}
+	}
+	if _, err := ss.saveURL(ctx, original, short, userID, url.PasswordHash); err != nil {
+		if errors.Is(err, storage.ErrConflict) || Classify(err).Code == CodePasswordNotApplied {
+			return ImportExists, nil
+		}
+		return "", err
+	}
+	if url.Deleted {
+		task := deleteTask{shortURL: short, userID: userID, ip: audit.IPFromContext(ctx)}
+		if err := ss.setDeleteStatus(ctx, []deleteTask{task}); err != nil {
+			return "", errors.Wrap(err, "mark imported url deleted")
+		}
+	}
+	return ImportCreated, nil
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"slices"
+	"sync"
+)
+
+// userLocks - блокировки по id пользователя: запросы одного пользователя выполняются по очереди,
+// запросы разных пользователей друг друга не ждут. Блокировка удаляется, когда ее никто не держит и не ждет.
+type userLocks struct {
+	mu    sync.Mutex
+	locks map[string]*userLock
+}
+
+// userLock - блокировка одного пользователя и количество запросов, держащих или ожидающих ее.
+type userLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// newUserLocks - функция создания userLocks.
+func newUserLocks() *userLocks {
+	return &userLocks{locks: make(map[string]*userLock)}
+}
+
+// lock - метод захвата блокировок пользователей userIDs и получения функции их освобождения.
+// Блокировки захватываются в порядке сортировки id, чтобы пакетные запросы нескольких пользователей не взаимоблокировались.
+func (l *userLocks) lock(userIDs ...string) func() {
+	ids := slices.Clone(userIDs)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+	held := make([]*userLock, 0, len(ids))
+	for _, id := range ids {
+		l.mu.Lock()
+		ul, ok := l.locks[id]
+		if !ok {
+			ul = &userLock{}
+			l.locks[id] = ul
+		}
+		ul.refs++
+		l.mu.Unlock()
+		ul.mu.Lock()
+		held = append(held, ul)
+	}
+	return func() {
+		for i, ul := range held {
+			ul.mu.Unlock()
+			l.mu.Lock()
+			ul.refs--
+			if ul.refs == 0 {
+				delete(l.locks, ids[i])
+			}
+			l.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserLocks(t *testing.T) {
+	l := newUserLocks()
+	unlock := l.lock("user-1")
+
+	otherUser := make(chan struct{})
+	go func() {
+		l.lock("user-2", "user-2")()
+		close(otherUser)
+	}()
+	select {
+	case <-otherUser:
+	case <-time.After(time.Second):
+		t.Fatal("lock of other user must not wait")
+	}
+
+	sameUser := make(chan struct{})
+	go func() {
+		l.lock("user-2", "user-1")()
+		close(sameUser)
+	}()
+	select {
+	case <-sameUser:
+		t.Fatal("lock of the same user must wait")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	select {
+	case <-sameUser:
+	case <-time.After(time.Second):
+		t.Fatal("lock must be acquired after unlock")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	assert.Empty(t, l.locks, "released locks are removed")
+}
@@ -3,40 +3,118 @@ package storage
 
 import (
 	"context"
+	"encoding/json"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pkg/errors"
-	"go.uber.org/zap"
 
-	"github.com/Dorrrke/shortener-url/internal/logger"
 	"github.com/Dorrrke/shortener-url/internal/models"
 )
 
-// ErrMemStorageError ошибка при попыттке записать уже существующий url в MemStorage.
-var ErrMemStorageError = errors.New("url is alredy shorted")
-
 // Storage - итерфейс хранилища с необходимыми методами.
+// Реализации возвращают ErrConflict при сохранении уже сокращенного url
+// и ErrNotFound, если url не найден.
 type Storage interface {
 	InsertURL(ctx context.Context, originalURL string, shortURL string, userID string) error
+	// InsertProtectedURL - сохраняет url, как InsertURL, вместе с хешем пароля, без которого по url нельзя перейти.
+	InsertProtectedURL(ctx context.Context, originalURL string, shortURL string, userID string, passwordHash string) error
+	// GetURLPassword - возвращает хеш пароля сокращенного url, пустая строка - url без пароля.
+	GetURLPassword(ctx context.Context, shortURL string) (string, error)
 	GetAllUrls(ctx context.Context, userID string) ([]models.URLModel, error)
 	GetOriginalURLByShort(ctx context.Context, shotURL string) (string, bool, error)
 	GetShortByOriginalURL(ctx context.Context, original string) (string, error)
 	CheckDBConnect(ctx context.Context) error
 	CreateTable(ctx context.Context) error
 	InsertBanchURL(ctx context.Context, value []models.BantchURL) error
-	SetDeleteURLStatus(ctx context.Context, value []string) error
+	// SetDeleteURLStatus - помечает удаленными url пользователя userID и возвращает помеченные url.
+	// Url других пользователей и уже удаленные url пропускаются.
+	SetDeleteURLStatus(ctx context.Context, value []string, userID string) ([]string, error)
+	// RestoreURLs - снимает отметку удаления с url пользователя userID и возвращает восстановленные url.
+	// Url других пользователей и неудаленные url пропускаются.
+	RestoreURLs(ctx context.Context, value []string, userID string) ([]string, error)
+	// ExportURLs - передает в fn url пользователя userID, включая удаленные, а при пустом userID - все url хранилища.
+	// Url передаются по мере чтения, ошибка fn прерывает выгрузку и возвращается из метода.
+	ExportURLs(ctx context.Context, userID string, fn func(models.ExportURL) error) error
+	// LoadURLs - сохраняет url из выгрузки как есть: с владельцем, отметкой удаления и временем создания.
+	// Url, сокращенный url которых уже есть в хранилище, и url, оригинальный url которых уже сокращен, пропускаются,
+	// поэтому загрузку можно повторять. Возвращает количество сохраненных url.
+	LoadURLs(ctx context.Context, urls []models.ExportURL) (int, error)
 	GetStats(ctx context.Context) (int, int, error)
+	CountUserURLs(ctx context.Context, userID string) (int, error)
+	CountActiveURLs(ctx context.Context) (int, error)
 	Clear(ctx context.Context) error
+	// ReserveIdempotencyKey - сохраняет запись о начале запроса с ключом идемпотентности.
+	// Если для области и ключа уже есть неистекшая запись, возвращает ее вместе с ErrConflict.
+	ReserveIdempotencyKey(ctx context.Context, rec models.IdempotencyRecord) (models.IdempotencyRecord, error)
+	// CompleteIdempotencyKey - сохраняет ответ на запрос с ключом идемпотентности.
+	CompleteIdempotencyKey(ctx context.Context, rec models.IdempotencyRecord) error
+	// ReleaseIdempotencyKey - удаляет незавершенную запись, чтобы запрос можно было повторить с тем же ключом.
+	ReleaseIdempotencyKey(ctx context.Context, scope string, key string) error
 }
 
 // MemStorage - реализация интерфейса Storage без базы данных, при помощи map - MemStorage
 type MemStorage struct {
 	URLMap map[string]string
+
+	mu sync.RWMutex
+	// owners - владельцы сокращенных url: short -> id пользователя.
+	owners map[string]string
+	// deleted - сокращенные url, помеченные как удаленные.
+	deleted map[string]bool
+	// created - время сохранения сокращенных url.
+	created map[string]time.Time
+	// passwords - хеши паролей защищенных url.
+	passwords map[string]string
+	// idempotency - записи ключей идемпотентности по области и ключу.
+	idempotency map[idempotencyID]models.IdempotencyRecord
+}
+
+// idempotencyID - область и ключ идемпотентности.
+type idempotencyID struct {
+	scope string
+	key   string
+}
+
+// setOwner - метод сохранения владельца url, вызывается под блокировкой.
+func (s *MemStorage) setOwner(shortURL string, userID string) {
+	if s.owners == nil {
+		s.owners = make(map[string]string)
+	}
+	s.owners[shortURL] = userID
+}
+
+// setCreated - метод сохранения времени создания url, вызывается под блокировкой.
+func (s *MemStorage) setCreated(shortURL string, createdAt time.Time) {
+	if s.created == nil {
+		s.created = make(map[string]time.Time)
+	}
+	s.created[shortURL] = createdAt
+}
+
+// setPassword - метод сохранения хеша пароля url, вызывается под блокировкой.
+func (s *MemStorage) setPassword(shortURL string, passwordHash string) {
+	if passwordHash == "" {
+		return
+	}
+	if s.passwords == nil {
+		s.passwords = make(map[string]string)
+	}
+	s.passwords[shortURL] = passwordHash
 }
 
 // InsertURL - метод сохранения url в map.
 func (s *MemStorage) InsertURL(ctx context.Context, originalURL string, shortURL string, userID string) error {
+	return s.InsertProtectedURL(ctx, originalURL, shortURL, userID, "")
+}
+
+// InsertProtectedURL - метод сохранения url с хешем пароля в map.
+func (s *MemStorage) InsertProtectedURL(ctx context.Context, originalURL string, shortURL string, userID string, passwordHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if s.URLMap == nil {
 		return errors.New("Map is not init")
 	}
@@ -47,22 +125,40 @@ func (s *MemStorage) InsertURL(ctx context.Context, originalURL string, shortURL
 		}
 	}
 	if conflict {
-		return ErrMemStorageError
+		return ErrConflict
 	}
 	s.URLMap[shortURL] = originalURL
+	s.setOwner(shortURL, userID)
+	s.setCreated(shortURL, time.Now())
+	s.setPassword(shortURL, passwordHash)
 	return nil
 }
 
+// GetURLPassword - метод получения хеша пароля url из map.
+func (s *MemStorage) GetURLPassword(ctx context.Context, shortURL string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.URLMap[shortURL]; !ok {
+		return "", ErrNotFound
+	}
+	return s.passwords[shortURL], nil
+}
+
 // GetOriginalURLByShort - метод получения оригинального url из map по сокращенному url.
 func (s *MemStorage) GetOriginalURLByShort(ctx context.Context, shotURL string) (string, bool, error) {
-	if len(s.URLMap) == 0 {
-		return "", false, errors.New("Mem Storage is empty")
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	original, ok := s.URLMap[shotURL]
+	if !ok {
+		return "", false, ErrNotFound
 	}
-	return s.URLMap[shotURL], false, nil
+	return original, s.deleted[shotURL], nil
 }
 
 // GetShortByOriginalURL - метод получения сокращенного url из map по оригинальному url.
 func (s *MemStorage) GetShortByOriginalURL(ctx context.Context, original string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	var key string
 	for k, v := range s.URLMap {
 		if v == original {
@@ -70,7 +166,7 @@ func (s *MemStorage) GetShortByOriginalURL(ctx context.Context, original string)
 		}
 	}
 	if key == "" {
-		return "", errors.New("Short url not find")
+		return "", ErrNotFound
 	}
 	return key, nil
 }
@@ -87,10 +183,62 @@ func (s *MemStorage) CreateTable(ctx context.Context) error {
 	return errors.New("DataBase is not init")
 }
 
-// SetDeleteURLStatus - метод установки статуса Delete в базе данных.
-// Так как это MemStorage возвращает ошибку, что бд не подключена.
-func (s *MemStorage) SetDeleteURLStatus(ctx context.Context, value []string) error {
-	return errors.New("DataBase is not init")
+// SetDeleteURLStatus - метод установки статуса Delete для сохраненных в map url пользователя.
+// Удаленные url не учитываются в квоте пользователя.
+func (s *MemStorage) SetDeleteURLStatus(ctx context.Context, value []string, userID string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.deleted == nil {
+		s.deleted = make(map[string]bool)
+	}
+	var deleted []string
+	for _, v := range value {
+		if _, ok := s.URLMap[v]; ok && !s.deleted[v] && s.owners[v] == userID {
+			s.deleted[v] = true
+			deleted = append(deleted, v)
+		}
+	}
+	return deleted, nil
+}
+
+// RestoreURLs - метод снятия отметки удаления с url пользователя в map.
+func (s *MemStorage) RestoreURLs(ctx context.Context, value []string, userID string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var restored []string
+	for _, v := range value {
+		if s.deleted[v] && s.owners[v] == userID {
+			delete(s.deleted, v)
+			restored = append(restored, v)
+		}
+	}
+	return restored, nil
+}
+
+// CountUserURLs - метод подсчета неудаленных url пользователя.
+func (s *MemStorage) CountUserURLs(ctx context.Context, userID string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	count := 0
+	for short, owner := range s.owners {
+		if owner == userID && !s.deleted[short] {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// CountActiveURLs - метод подсчета всех неудаленных url.
+func (s *MemStorage) CountActiveURLs(ctx context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	count := 0
+	for short := range s.URLMap {
+		if !s.deleted[short] {
+			count++
+		}
+	}
+	return count, nil
 }
 
 // GetAllUrls - метод получения количества пользователей сервиса и количество всех сокращенных URL.
@@ -99,29 +247,165 @@ func (s *MemStorage) GetStats(ctx context.Context) (int, int, error) {
 	return -1, -1, errors.New("DataBase is not init")
 }
 
-// GetAllUrls - метод получения всех сокращенных url пользвателя из бд.
-// Так как это MemStorage возвращает ошибку, что бд не подключена.
+// GetAllUrls - метод получения всех сокращенных url пользвателя из map по сохраненным владельцам.
+// Как и в бд, в список попадают и url, помеченные удаленными.
 func (s *MemStorage) GetAllUrls(ctx context.Context, userID string) ([]models.URLModel, error) {
-	return nil, errors.New("DataBase is not init")
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var urls []models.URLModel
+	for short, owner := range s.owners {
+		if owner == userID {
+			urls = append(urls, models.URLModel{ShortID: short, OriginalID: s.URLMap[short]})
+		}
+	}
+	sort.Slice(urls, func(i, j int) bool { return urls[i].ShortID < urls[j].ShortID })
+	return urls, nil
+}
+
+// ExportURLs - метод выгрузки url из map в порядке сокращенных url.
+// Url копируются под блокировкой, fn вызывается без нее, чтобы медленный получатель не блокировал хранилище.
+func (s *MemStorage) ExportURLs(ctx context.Context, userID string, fn func(models.ExportURL) error) error {
+	s.mu.RLock()
+	urls := make([]models.ExportURL, 0, len(s.URLMap))
+	for short, original := range s.URLMap {
+		owner := s.owners[short]
+		if userID != "" && owner != userID {
+			continue
+		}
+		urls = append(urls, models.ExportURL{
+			ShortURL:     short,
+			OriginalURL:  original,
+			UserID:       owner,
+			Deleted:      s.deleted[short],
+			CreatedAt:    s.created[short],
+			PasswordHash: s.passwords[short],
+		})
+	}
+	s.mu.RUnlock()
+	sort.Slice(urls, func(i, j int) bool { return urls[i].ShortURL < urls[j].ShortURL })
+	for _, url := range urls {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(url); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // InsertBanchURL - метод сохраниения нескольких url в map.
 func (s *MemStorage) InsertBanchURL(ctx context.Context, value []models.BantchURL) error {
-	if len(s.URLMap) == 0 {
-		return errors.New("Mem Storage is empty")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.URLMap == nil {
+		return errors.New("Map is not init")
 	}
 	for _, v := range value {
 		s.URLMap[v.ShortURL] = v.OriginalURL
+		s.setOwner(v.ShortURL, v.UserID)
+		s.setCreated(v.ShortURL, time.Now())
 	}
 	return nil
 }
 
+// LoadURLs - метод сохранения url из выгрузки в map.
+func (s *MemStorage) LoadURLs(ctx context.Context, urls []models.ExportURL) (int, error) {
+	loaded, err := s.loadURLs(urls)
+	return len(loaded), err
+}
+
+// loadURLs - метод сохранения url из выгрузки, возвращает сохраненные url.
+func (s *MemStorage) loadURLs(urls []models.ExportURL) ([]models.ExportURL, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.URLMap == nil {
+		return nil, errors.New("Map is not init")
+	}
+	originals := make(map[string]bool, len(s.URLMap))
+	for _, original := range s.URLMap {
+		originals[original] = true
+	}
+	var loaded []models.ExportURL
+	for _, url := range urls {
+		if _, ok := s.URLMap[url.ShortURL]; ok || originals[url.OriginalURL] {
+			continue
+		}
+		if url.CreatedAt.IsZero() {
+			url.CreatedAt = time.Now()
+		}
+		s.URLMap[url.ShortURL] = url.OriginalURL
+		originals[url.OriginalURL] = true
+		s.setOwner(url.ShortURL, url.UserID)
+		s.setCreated(url.ShortURL, url.CreatedAt)
+		s.setPassword(url.ShortURL, url.PasswordHash)
+		if url.Deleted {
+			if s.deleted == nil {
+				s.deleted = make(map[string]bool)
+			}
+			s.deleted[url.ShortURL] = true
+		}
+		loaded = append(loaded, url)
+	}
+	return loaded, nil
+}
+
 // Clear - метод очистки всех таблиц в бд.
 // Так как это MemStorage возвращает ошибку, что бд не подключена.
 func (s *MemStorage) Clear(ctx context.Context) error {
 	return errors.New("DataBase is not init")
 }
 
+// ReserveIdempotencyKey - метод сохранения записи о начале запроса с ключом идемпотентности в map.
+// Истекшие записи удаляются при каждом вызове.
+func (s *MemStorage) ReserveIdempotencyKey(ctx context.Context, rec models.IdempotencyRecord) (models.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.idempotency == nil {
+		s.idempotency = make(map[idempotencyID]models.IdempotencyRecord)
+	}
+	now := time.Now()
+	for id, v := range s.idempotency {
+		if !v.ExpiresAt.After(now) {
+			delete(s.idempotency, id)
+		}
+	}
+	id := idempotencyID{scope: rec.Scope, key: rec.Key}
+	if existing, ok := s.idempotency[id]; ok {
+		return existing, ErrConflict
+	}
+	s.idempotency[id] = rec
+	return rec, nil
+}
+
+// CompleteIdempotencyKey - метод сохранения ответа на запрос с ключом идемпотентности в map.
+func (s *MemStorage) CompleteIdempotencyKey(ctx context.Context, rec models.IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := idempotencyID{scope: rec.Scope, key: rec.Key}
+	existing, ok := s.idempotency[id]
+	if !ok {
+		return ErrNotFound
+	}
+	existing.Status = rec.Status
+	existing.ContentType = rec.ContentType
+	existing.Body = rec.Body
+	existing.Headers = rec.Headers
+	s.idempotency[id] = existing
+	return nil
+}
+
+// ReleaseIdempotencyKey - метод удаления незавершенной записи ключа идемпотентности из map.
+func (s *MemStorage) ReleaseIdempotencyKey(ctx context.Context, scope string, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := idempotencyID{scope: scope, key: key}
+	if existing, ok := s.idempotency[id]; ok && existing.Status == 0 {
+		delete(s.idempotency, id)
+	}
+	return nil
+}
+
 // DBStorage - реализация интерфейса Storage с базой данных - DBStorage.
 // Использутся база данных PostgreSQL, драйвер pgx.
 type DBStorage struct {
@@ -131,16 +415,30 @@ type DBStorage struct {
 
 // InsertURL - метод сохранинеия данных в бд.
 func (s *DBStorage) InsertURL(ctx context.Context, originalURL string, shortURL string, userID string) error {
-	_, err := s.DB.Exec(ctx, "INSERT INTO short_urls (original, short, uid) values ($1, $2, $3)", originalURL, shortURL, userID)
+	return s.InsertProtectedURL(ctx, originalURL, shortURL, userID, "")
+}
+
+// InsertProtectedURL - метод сохранения url с хешем пароля в бд.
+func (s *DBStorage) InsertProtectedURL(ctx context.Context, originalURL string, shortURL string, userID string, passwordHash string) error {
+	_, err := s.DB.Exec(ctx, "INSERT INTO short_urls (original, short, uid, password_hash) values ($1, $2, $3, $4)", originalURL, shortURL, userID, passwordHash)
 	if err != nil {
-		return errors.Wrap(err, "Error while inserting row in db")
+		return pgError(err, "Error while inserting row in db")
 	}
 	return nil
 }
 
+// GetURLPassword - метод получения хеша пароля сокращенного url из бд.
+func (s *DBStorage) GetURLPassword(ctx context.Context, shortURL string) (string, error) {
+	row := s.DB.QueryRow(ctx, "SELECT password_hash FROM short_urls WHERE short = $1", shortURL)
+	var passwordHash string
+	if err := row.Scan(&passwordHash); err != nil {
+		return "", pgError(err, "Error while reading url password")
+	}
+	return passwordHash, nil
+}
+
 // GetOriginalURLByShort - метод получения оригинального url по сокращенному из базы данных.
 func (s *DBStorage) GetOriginalURLByShort(ctx context.Context, shotURL string) (string, bool, error) {
-	logger.Log.Info("Serach shortURL: ", zap.String("1", shotURL))
 	rows := s.DB.QueryRow(ctx, "SELECT original, deleted FROM short_urls where short = $1", shotURL)
 	// if err != nil {
 	// 	return "", errors.Wrap(err, "Error when getting row from db")
@@ -149,7 +447,7 @@ func (s *DBStorage) GetOriginalURLByShort(ctx context.Context, shotURL string) (
 	var deleted bool
 
 	if err := rows.Scan(&original, &deleted); err != nil {
-		return "", false, errors.Wrap(err, "Error parsing db info")
+		return "", false, pgError(err, "Error parsing db info")
 	}
 
 	return original, deleted, nil
@@ -164,7 +462,7 @@ func (s *DBStorage) GetShortByOriginalURL(ctx context.Context, original string)
 	var result string
 
 	if err := rows.Scan(&result); err != nil {
-		return "", errors.Wrap(err, "Error parsing db info")
+		return "", pgError(err, "Error parsing db info")
 	}
 
 	return strings.TrimSpace(result), nil
@@ -222,6 +520,26 @@ func (s *DBStorage) GetStats(ctx context.Context) (int, int, error) {
 
 }
 
+// CountUserURLs - метод подсчета неудаленных url пользователя в бд.
+func (s *DBStorage) CountUserURLs(ctx context.Context, userID string) (int, error) {
+	row := s.DB.QueryRow(ctx, "SELECT COUNT(*) FROM short_urls WHERE uid = $1 AND deleted = false", userID)
+	var count int
+	if err := row.Scan(&count); err != nil {
+		return -1, errors.Wrap(err, "Error while counting user urls")
+	}
+	return count, nil
+}
+
+// CountActiveURLs - метод подсчета всех неудаленных url в бд.
+func (s *DBStorage) CountActiveURLs(ctx context.Context) (int, error) {
+	row := s.DB.QueryRow(ctx, "SELECT COUNT(*) FROM short_urls WHERE deleted = false")
+	var count int
+	if err := row.Scan(&count); err != nil {
+		return -1, errors.Wrap(err, "Error while counting urls")
+	}
+	return count, nil
+}
+
 // CreateTable - метод создания таблицы в базе данных, если ее не существует.
 func (s *DBStorage) CreateTable(ctx context.Context) error {
 	createTableStr := `CREATE TABLE IF NOT EXISTS short_urls
@@ -232,8 +550,28 @@ func (s *DBStorage) CreateTable(ctx context.Context) error {
 		uid character(255) NOT NULL,
 		deleted boolean NOT NULL DEFAULT false
 	);
-	
-	create UNIQUE INDEX IF NOT EXISTS original_id ON short_urls (original)`
+
+	ALTER TABLE short_urls ADD COLUMN IF NOT EXISTS created_at timestamptz NOT NULL DEFAULT now();
+
+	ALTER TABLE short_urls ADD COLUMN IF NOT EXISTS password_hash text NOT NULL DEFAULT '';
+
+	create UNIQUE INDEX IF NOT EXISTS original_id ON short_urls (original);
+
+	CREATE TABLE IF NOT EXISTS idempotency_keys
+	(
+		scope text NOT NULL,
+		key text NOT NULL,
+		request_hash text NOT NULL,
+		status integer NOT NULL DEFAULT 0,
+		content_type text NOT NULL DEFAULT '',
+		body bytea,
+		expires_at timestamptz NOT NULL,
+		PRIMARY KEY (scope, key)
+	);
+
+	ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS headers jsonb;
+
+	create INDEX IF NOT EXISTS idempotency_keys_expires_at ON idempotency_keys (expires_at)`
 	_, err := s.DB.Exec(ctx, createTableStr)
 	if err != nil {
 		return errors.Wrap(err, "Error whitle creating table")
@@ -257,32 +595,100 @@ func (s *DBStorage) InsertBanchURL(ctx context.Context, value []models.BantchURL
 
 	for _, v := range value {
 		if _, err := tx.Exec(ctx, "insert bantch", v.OriginalURL, v.ShortURL, v.UserID); err != nil {
-			return err
+			return pgError(err, "Error while inserting batch in db")
 		}
 	}
 	return tx.Commit(ctx)
 }
 
-// SetDeleteURLStatus - метод установки статуса Deleted в базе данных.
-// В реализации используются транзакции.
-func (s *DBStorage) SetDeleteURLStatus(ctx context.Context, value []string) error {
+// SetDeleteURLStatus - метод установки статуса Deleted для url пользователя в базе данных.
+func (s *DBStorage) SetDeleteURLStatus(ctx context.Context, value []string, userID string) ([]string, error) {
+	rows, err := s.DB.Query(ctx, "UPDATE short_urls SET deleted=true WHERE short = ANY($1) AND uid = $2 AND deleted = false RETURNING trim(short)", value, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while deleting urls")
+	}
+	defer rows.Close()
+	var deleted []string
+	for rows.Next() {
+		var short string
+		if err := rows.Scan(&short); err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, short)
+	}
+	return deleted, rows.Err()
+}
+
+// RestoreURLs - метод снятия статуса Deleted с url пользователя в базе данных.
+func (s *DBStorage) RestoreURLs(ctx context.Context, value []string, userID string) ([]string, error) {
+	rows, err := s.DB.Query(ctx, "UPDATE short_urls SET deleted=false WHERE short = ANY($1) AND uid = $2 AND deleted = true RETURNING trim(short)", value, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while restoring urls")
+	}
+	defer rows.Close()
+	var restored []string
+	for rows.Next() {
+		var short string
+		if err := rows.Scan(&short); err != nil {
+			return nil, err
+		}
+		restored = append(restored, short)
+	}
+	return restored, rows.Err()
+}
+
+// ExportURLs - метод выгрузки url из базы данных построчно, без загрузки всей выборки в память.
+func (s *DBStorage) ExportURLs(ctx context.Context, userID string, fn func(models.ExportURL) error) error {
+	rows, err := s.DB.Query(ctx, "SELECT trim(short), trim(original), trim(uid), deleted, created_at, password_hash FROM short_urls WHERE $1 = '' OR uid = $1 ORDER BY url_id", userID)
+	if err != nil {
+		return errors.Wrap(err, "Error while exporting urls")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var url models.ExportURL
+		if err := rows.Scan(&url.ShortURL, &url.OriginalURL, &url.UserID, &url.Deleted, &url.CreatedAt, &url.PasswordHash); err != nil {
+			return err
+		}
+		if err := fn(url); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// LoadURLs - метод сохранения url из выгрузки в базу данных одной транзакцией.
+// Url с уже сохраненным сокращенным или оригинальным url пропускаются, без времени создания сохраняется текущее время.
+func (s *DBStorage) LoadURLs(ctx context.Context, urls []models.ExportURL) (int, error) {
 	tx, err := s.DB.Begin(ctx)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	defer tx.Rollback(ctx)
 
-	if _, err := tx.Prepare(ctx, "delete", "UPDATE short_urls SET deleted=true WHERE short=$1"); err != nil {
-		return err
+	if _, err := tx.Prepare(ctx, "load", `INSERT INTO short_urls (original, short, uid, deleted, created_at, password_hash)
+	SELECT $1, $2, $3, $4, COALESCE($5, now()), $6
+	WHERE NOT EXISTS (SELECT 1 FROM short_urls WHERE short = $2)
+	ON CONFLICT DO NOTHING`); err != nil {
+		return 0, err
 	}
 
-	for _, v := range value {
-		if _, err := tx.Exec(ctx, "delete", v); err != nil {
-			return err
+	loaded := 0
+	for _, v := range urls {
+		var createdAt *time.Time
+		if !v.CreatedAt.IsZero() {
+			createdAt = &v.CreatedAt
+		}
+		tag, err := tx.Exec(ctx, "load", v.OriginalURL, v.ShortURL, v.UserID, v.Deleted, createdAt, v.PasswordHash)
+		if err != nil {
+			return 0, pgError(err, "Error while loading urls in db")
 		}
+		loaded += int(tag.RowsAffected())
 	}
-	return tx.Commit(ctx)
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return loaded, nil
 }
 
 // Clear - метод очистки таблицы в базе данных.
@@ -300,3 +706,62 @@ func (s *DBStorage) Clear(ctx context.Context) error {
 
 	return tx.Commit(ctx)
 }
+
+// ReserveIdempotencyKey - метод сохранения записи о начале запроса с ключом идемпотентности в бд.
+// Истекшая запись с тем же ключом заменяется, остальные истекшие записи удаляются.
+func (s *DBStorage) ReserveIdempotencyKey(ctx context.Context, rec models.IdempotencyRecord) (models.IdempotencyRecord, error) {
+	now := time.Now()
+	if _, err := s.DB.Exec(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= $1", now); err != nil {
+		return models.IdempotencyRecord{}, errors.Wrap(err, "Error while deleting expired idempotency keys")
+	}
+	tag, err := s.DB.Exec(ctx, `INSERT INTO idempotency_keys (scope, key, request_hash, expires_at) VALUES ($1, $2, $3, $4)
+	ON CONFLICT (scope, key) DO UPDATE SET request_hash = EXCLUDED.request_hash, status = 0, content_type = '', body = NULL, headers = NULL, expires_at = EXCLUDED.expires_at
+	WHERE idempotency_keys.expires_at <= $5`, rec.Scope, rec.Key, rec.RequestHash, rec.ExpiresAt, now)
+	if err != nil {
+		return models.IdempotencyRecord{}, errors.Wrap(err, "Error while reserving idempotency key")
+	}
+	if tag.RowsAffected() > 0 {
+		return rec, nil
+	}
+
+	existing := models.IdempotencyRecord{Scope: rec.Scope, Key: rec.Key}
+	row := s.DB.QueryRow(ctx, "SELECT request_hash, status, content_type, body, headers, expires_at FROM idempotency_keys WHERE scope = $1 AND key = $2", rec.Scope, rec.Key)
+	var headers []byte
+	if err := row.Scan(&existing.RequestHash, &existing.Status, &existing.ContentType, &existing.Body, &headers, &existing.ExpiresAt); err != nil {
+		return models.IdempotencyRecord{}, pgError(err, "Error while reading idempotency key")
+	}
+	if len(headers) > 0 {
+		if err := json.Unmarshal(headers, &existing.Headers); err != nil {
+			return models.IdempotencyRecord{}, errors.Wrap(err, "Error while decoding idempotent response headers")
+		}
+	}
+	return existing, ErrConflict
+}
+
+// CompleteIdempotencyKey - метод сохранения ответа на запрос с ключом идемпотентности в бд.
+func (s *DBStorage) CompleteIdempotencyKey(ctx context.Context, rec models.IdempotencyRecord) error {
+	var headers []byte
+	if len(rec.Headers) > 0 {
+		var err error
+		if headers, err = json.Marshal(rec.Headers); err != nil {
+			return errors.Wrap(err, "Error while encoding idempotent response headers")
+		}
+	}
+	tag, err := s.DB.Exec(ctx, "UPDATE idempotency_keys SET status = $3, content_type = $4, body = $5, headers = $6 WHERE scope = $1 AND key = $2",
+		rec.Scope, rec.Key, rec.Status, rec.ContentType, rec.Body, headers)
+	if err != nil {
+		return errors.Wrap(err, "Error while saving idempotent response")
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey - метод удаления незавершенной записи ключа идемпотентности из бд.
+func (s *DBStorage) ReleaseIdempotencyKey(ctx context.Context, scope string, key string) error {
+	if _, err := s.DB.Exec(ctx, "DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND status = 0", scope, key); err != nil {
+		return errors.Wrap(err, "Error while releasing idempotency key")
+	}
+	return nil
+}
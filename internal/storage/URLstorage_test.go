@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dorrrke/shortener-url/internal/models"
+)
+
+func TestMemStorageGetAllUrls(t *testing.T) {
+	ctx := context.Background()
+	stor := &MemStorage{URLMap: make(map[string]string)}
+	require.NoError(t, stor.InsertURL(ctx, "https://go.dev/", "http://localhost/b", "user"))
+	require.NoError(t, stor.InsertURL(ctx, "https://github.com/", "http://localhost/c", "other"))
+	require.NoError(t, stor.InsertBanchURL(ctx, []models.BantchURL{
+		{OriginalURL: "https://example.com/", ShortURL: "http://localhost/a", UserID: "user"},
+	}))
+	deleted, err := stor.SetDeleteURLStatus(ctx, []string{"http://localhost/a", "http://localhost/c"}, "user")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http://localhost/a"}, deleted, "url of other user is skipped")
+	deleted, err = stor.SetDeleteURLStatus(ctx, []string{"http://localhost/a"}, "user")
+	require.NoError(t, err)
+	assert.Empty(t, deleted, "deleted url is skipped")
+
+	urls, err := stor.GetAllUrls(ctx, "user")
+	require.NoError(t, err)
+	assert.Equal(t, []models.URLModel{
+		{ShortID: "http://localhost/a", OriginalID: "https://example.com/"},
+		{ShortID: "http://localhost/b", OriginalID: "https://go.dev/"},
+	}, urls)
+
+	urls, err = stor.GetAllUrls(ctx, "nobody")
+	require.NoError(t, err)
+	assert.Empty(t, urls)
+}
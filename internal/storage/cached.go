@@ -0,0 +1,232 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/Dorrrke/shortener-url/internal/cache"
+	"github.com/Dorrrke/shortener-url/internal/logger"
+	"github.com/Dorrrke/shortener-url/internal/models"
+)
+
+// Значения времени жизни записей кэша по умолчанию.
+const (
+	// DefaultCacheTTL - время жизни найденного url.
+	DefaultCacheTTL = 5 * time.Minute
+	// DefaultCacheNegativeTTL - время жизни отметки об отсутствии url.
+	DefaultCacheNegativeTTL = 30 * time.Second
+)
+
+// Префиксы значений в кэше: активный url, удаленный url и отсутствующий url.
+const (
+	cachedActive   = "+"
+	cachedDeleted  = "x"
+	cachedNotFound = "-"
+)
+
+// passwordKeyPrefix - префикс ключей кэша с хешами паролей сокращенных url.
+const passwordKeyPrefix = "password:"
+
+// CacheStats - счетчики обращений к кэшу.
+type CacheStats struct {
+	Hits   int64
+	Misses int64
+}
+
+// CachedStorage - декоратор Storage, кэширующий поиск оригинального url по сокращенному.
+// Кэшируются и отсутствующие url, чтобы перебор коротких ссылок не нагружал базу данных.
+// Ошибки кэша логируются, и запрос выполняется напрямую в хранилище.
+type CachedStorage struct {
+	Storage
+	cache       cache.Cache
+	ttl         time.Duration
+	negativeTTL time.Duration
+	hits        atomic.Int64
+	misses      atomic.Int64
+}
+
+// NewCachedStorage - функция создания кэширующего хранилища.
+// Нулевые ttl и negativeTTL заменяются на DefaultCacheTTL и DefaultCacheNegativeTTL.
+func NewCachedStorage(stor Storage, c cache.Cache, ttl time.Duration, negativeTTL time.Duration) *CachedStorage {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	if negativeTTL <= 0 {
+		negativeTTL = DefaultCacheNegativeTTL
+	}
+	return &CachedStorage{
+		Storage:     stor,
+		cache:       c,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+	}
+}
+
+// Stats - метод получения счетчиков попаданий и промахов кэша.
+func (s *CachedStorage) Stats() CacheStats {
+	return CacheStats{Hits: s.hits.Load(), Misses: s.misses.Load()}
+}
+
+// GetOriginalURLByShort - метод получения оригинального url из кэша, а при промахе из хранилища.
+// Для отсутствующего url возвращается ErrNotFound.
+func (s *CachedStorage) GetOriginalURLByShort(ctx context.Context, shotURL string) (string, bool, error) {
+	value, ok, err := s.cache.Get(ctx, shotURL)
+	if err != nil {
+		logger.Log.Error("Error read from cache", zap.Error(err))
+	}
+	if ok {
+		s.hits.Add(1)
+		switch {
+		case strings.HasPrefix(value, cachedActive):
+			return strings.TrimPrefix(value, cachedActive), false, nil
+		case strings.HasPrefix(value, cachedDeleted):
+			return strings.TrimPrefix(value, cachedDeleted), true, nil
+		default:
+			return "", false, ErrNotFound
+		}
+	}
+	s.misses.Add(1)
+
+	original, deleted, err := s.Storage.GetOriginalURLByShort(ctx, shotURL)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		s.set(ctx, shotURL, cachedNotFound, s.negativeTTL)
+	case err != nil:
+		return "", false, err
+	case deleted:
+		s.set(ctx, shotURL, cachedDeleted+original, s.ttl)
+	default:
+		s.set(ctx, shotURL, cachedActive+original, s.ttl)
+	}
+	return original, deleted, err
+}
+
+// InsertURL - метод сохранения url со сбросом отметки об его отсутствии.
+func (s *CachedStorage) InsertURL(ctx context.Context, originalURL string, shortURL string, userID string) error {
+	if err := s.Storage.InsertURL(ctx, originalURL, shortURL, userID); err != nil {
+		return err
+	}
+	s.invalidate(ctx, shortURL)
+	return nil
+}
+
+// InsertProtectedURL - метод сохранения url с хешем пароля со сбросом отметки об его отсутствии.
+func (s *CachedStorage) InsertProtectedURL(ctx context.Context, originalURL string, shortURL string, userID string, passwordHash string) error {
+	if err := s.Storage.InsertProtectedURL(ctx, originalURL, shortURL, userID, passwordHash); err != nil {
+		return err
+	}
+	s.invalidate(ctx, shortURL)
+	return nil
+}
+
+// GetURLPassword - метод получения хеша пароля url из кэша, а при промахе из хранилища.
+// Кэшируется и отсутствие пароля, чтобы переход по обычной ссылке не требовал второго запроса к хранилищу.
+func (s *CachedStorage) GetURLPassword(ctx context.Context, shortURL string) (string, error) {
+	key := passwordKeyPrefix + shortURL
+	value, ok, err := s.cache.Get(ctx, key)
+	if err != nil {
+		logger.Log.Error("Error read from cache", zap.Error(err))
+	}
+	if ok {
+		s.hits.Add(1)
+		if strings.HasPrefix(value, cachedActive) {
+			return strings.TrimPrefix(value, cachedActive), nil
+		}
+		return "", ErrNotFound
+	}
+	s.misses.Add(1)
+
+	passwordHash, err := s.Storage.GetURLPassword(ctx, shortURL)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		s.set(ctx, key, cachedNotFound, s.negativeTTL)
+	case err != nil:
+		return "", err
+	default:
+		s.set(ctx, key, cachedActive+passwordHash, s.ttl)
+	}
+	return passwordHash, err
+}
+
+// InsertBanchURL - метод сохранения нескольких url со сбросом отметок об их отсутствии.
+func (s *CachedStorage) InsertBanchURL(ctx context.Context, value []models.BantchURL) error {
+	if err := s.Storage.InsertBanchURL(ctx, value); err != nil {
+		return err
+	}
+	shorts := make([]string, 0, len(value))
+	for _, v := range value {
+		shorts = append(shorts, v.ShortURL)
+	}
+	s.invalidate(ctx, shorts...)
+	return nil
+}
+
+// SetDeleteURLStatus - метод пометки url удаленными с удалением их из кэша.
+// Из кэша удаляются все переданные url: ключи кэша совпадают с ними независимо от того,
+// в каком виде хранилище возвращает помеченные url.
+func (s *CachedStorage) SetDeleteURLStatus(ctx context.Context, value []string, userID string) ([]string, error) {
+	deleted, err := s.Storage.SetDeleteURLStatus(ctx, value, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, value...)
+	return deleted, nil
+}
+
+// RestoreURLs - метод снятия отметки удаления с удалением из кэша всех переданных url, как в SetDeleteURLStatus.
+func (s *CachedStorage) RestoreURLs(ctx context.Context, value []string, userID string) ([]string, error) {
+	restored, err := s.Storage.RestoreURLs(ctx, value, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, value...)
+	return restored, nil
+}
+
+// LoadURLs - метод сохранения url из выгрузки со сбросом отметок об их отсутствии.
+func (s *CachedStorage) LoadURLs(ctx context.Context, urls []models.ExportURL) (int, error) {
+	loaded, err := s.Storage.LoadURLs(ctx, urls)
+	if err != nil {
+		return 0, err
+	}
+	shorts := make([]string, 0, len(urls))
+	for _, v := range urls {
+		shorts = append(shorts, v.ShortURL)
+	}
+	s.invalidate(ctx, shorts...)
+	return loaded, nil
+}
+
+// Clear - метод очистки хранилища и кэша.
+func (s *CachedStorage) Clear(ctx context.Context) error {
+	if err := s.Storage.Clear(ctx); err != nil {
+		return err
+	}
+	if err := s.cache.Purge(ctx); err != nil {
+		logger.Log.Error("Error purge cache", zap.Error(err))
+	}
+	return nil
+}
+
+// set - метод сохранения значения в кэш с логированием ошибки.
+func (s *CachedStorage) set(ctx context.Context, key string, value string, ttl time.Duration) {
+	if err := s.cache.Set(ctx, key, value, ttl); err != nil {
+		logger.Log.Error("Error write to cache", zap.Error(err))
+	}
+}
+
+// invalidate - метод удаления из кэша записей сокращенных url и их паролей с логированием ошибки.
+func (s *CachedStorage) invalidate(ctx context.Context, shorts ...string) {
+	keys := make([]string, 0, 2*len(shorts))
+	for _, short := range shorts {
+		keys = append(keys, short, passwordKeyPrefix+short)
+	}
+	if err := s.cache.Delete(ctx, keys...); err != nil {
+		logger.Log.Error("Error delete from cache", zap.Error(err))
+	}
+}
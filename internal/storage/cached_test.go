@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dorrrke/shortener-url/internal/cache"
+	"github.com/Dorrrke/shortener-url/internal/models"
+)
+
+// countingStorage - MemStorage, считающий обращения за оригинальным url.
+type countingStorage struct {
+	*MemStorage
+	lookups int
+}
+
+func (s *countingStorage) GetOriginalURLByShort(ctx context.Context, shotURL string) (string, bool, error) {
+	s.lookups++
+	return s.MemStorage.GetOriginalURLByShort(ctx, shotURL)
+}
+
+// paddingStorage - MemStorage, дополняющий возвращаемые сокращенные url пробелами, как колонка character(255).
+type paddingStorage struct {
+	*MemStorage
+}
+
+func (s *paddingStorage) SetDeleteURLStatus(ctx context.Context, value []string, userID string) ([]string, error) {
+	deleted, err := s.MemStorage.SetDeleteURLStatus(ctx, value, userID)
+	return pad(deleted), err
+}
+
+func (s *paddingStorage) RestoreURLs(ctx context.Context, value []string, userID string) ([]string, error) {
+	restored, err := s.MemStorage.RestoreURLs(ctx, value, userID)
+	return pad(restored), err
+}
+
+func pad(values []string) []string {
+	padded := make([]string, 0, len(values))
+	for _, v := range values {
+		padded = append(padded, fmt.Sprintf("%-255s", v))
+	}
+	return padded
+}
+
+func TestCachedStorageInvalidatesPaddedBackend(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewLRU(10)
+	stor := NewCachedStorage(&paddingStorage{MemStorage: &MemStorage{URLMap: make(map[string]string)}}, c, time.Minute, time.Minute)
+	require.NoError(t, stor.InsertURL(ctx, "https://go.dev/", "http://localhost/a", "user"))
+	_, _, err := stor.GetOriginalURLByShort(ctx, "http://localhost/a")
+	require.NoError(t, err)
+	_, ok, err := c.Get(ctx, "http://localhost/a")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	_, err = stor.SetDeleteURLStatus(ctx, []string{"http://localhost/a"}, "user")
+	require.NoError(t, err)
+	_, ok, err = c.Get(ctx, "http://localhost/a")
+	require.NoError(t, err)
+	assert.False(t, ok, "deleted url must be removed from cache")
+
+	_, deleted, err := stor.GetOriginalURLByShort(ctx, "http://localhost/a")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	_, err = stor.RestoreURLs(ctx, []string{"http://localhost/a"}, "user")
+	require.NoError(t, err)
+	_, ok, err = c.Get(ctx, "http://localhost/a")
+	require.NoError(t, err)
+	assert.False(t, ok, "restored url must be removed from cache")
+
+	_, deleted, err = stor.GetOriginalURLByShort(ctx, "http://localhost/a")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+}
+
+func testCachedStorage(t *testing.T, c cache.Cache) {
+	ctx := context.Background()
+	base := &countingStorage{MemStorage: &MemStorage{URLMap: make(map[string]string)}}
+	stor := NewCachedStorage(base, c, time.Minute, time.Minute)
+
+	require.NoError(t, stor.InsertURL(ctx, "https://go.dev/", "http://localhost/a", "user"))
+
+	for i := 0; i < 3; i++ {
+		original, deleted, err := stor.GetOriginalURLByShort(ctx, "http://localhost/a")
+		require.NoError(t, err)
+		assert.Equal(t, "https://go.dev/", original)
+		assert.False(t, deleted)
+	}
+	assert.Equal(t, 1, base.lookups)
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 1}, stor.Stats())
+
+	for i := 0; i < 2; i++ {
+		original, _, err := stor.GetOriginalURLByShort(ctx, "http://localhost/b")
+		require.ErrorIs(t, err, ErrNotFound)
+		assert.Empty(t, original)
+	}
+	assert.Equal(t, 2, base.lookups, "negative lookup must be cached")
+
+	require.NoError(t, stor.InsertBanchURL(ctx, []models.BantchURL{{OriginalURL: "https://github.com/", ShortURL: "http://localhost/b", UserID: "user"}}))
+	original, _, err := stor.GetOriginalURLByShort(ctx, "http://localhost/b")
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.com/", original, "insert must invalidate negative entry")
+
+	_, err = stor.SetDeleteURLStatus(ctx, []string{"http://localhost/a"}, "user")
+	require.NoError(t, err)
+	original, deleted, err := stor.GetOriginalURLByShort(ctx, "http://localhost/a")
+	require.NoError(t, err)
+	assert.Equal(t, "https://go.dev/", original)
+	assert.True(t, deleted, "delete must invalidate cached entry")
+	assert.Equal(t, CacheStats{Hits: 3, Misses: 4}, stor.Stats())
+
+	require.NoError(t, stor.InsertProtectedURL(ctx, "https://docs.internal/", "http://localhost/p", "user", "hash"))
+	for i := 0; i < 2; i++ {
+		passwordHash, err := stor.GetURLPassword(ctx, "http://localhost/p")
+		require.NoError(t, err)
+		assert.Equal(t, "hash", passwordHash)
+	}
+	_, err = stor.GetURLPassword(ctx, "http://localhost/x")
+	require.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, CacheStats{Hits: 4, Misses: 6}, stor.Stats())
+}
+
+func TestCachedStorageLRU(t *testing.T) {
+	testCachedStorage(t, cache.NewLRU(100))
+}
+
+func TestCachedStorageRedis(t *testing.T) {
+	mr := miniredis.RunT(t)
+	c := cache.NewRedis(mr.Addr(), "shortener:")
+	defer c.Close()
+	testCachedStorage(t, c)
+}
+
+func TestCachedStorageCacheDown(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	c := cache.NewRedis(mr.Addr(), "")
+	defer c.Close()
+	stor := NewCachedStorage(&MemStorage{URLMap: map[string]string{"http://localhost/a": "https://go.dev/"}}, c, 0, 0)
+	mr.Close()
+
+	original, _, err := stor.GetOriginalURLByShort(ctx, "http://localhost/a")
+	require.NoError(t, err)
+	assert.Equal(t, "https://go.dev/", original)
+}
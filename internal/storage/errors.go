@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Ошибки хранилища, не зависящие от реализации. Каждая реализация Storage приводит к ним свои ошибки,
+// поэтому сервис и транспорты проверяют только их через errors.Is.
+var (
+	// ErrConflict - оригинальный url уже сохранен.
+	ErrConflict = errors.New("url is already shortened")
+	// ErrNotFound - url не найден.
+	ErrNotFound = errors.New("url not found")
+	// ErrGone - сокращенный url удален пользователем.
+	ErrGone = errors.New("url was deleted")
+)
+
+// ErrMemStorageError - ошибка при попытке записать уже существующий url.
+//
+// Deprecated: используйте ErrConflict.
+var ErrMemStorageError = ErrConflict
+
+// pgError - функция приведения ошибки postgres к ошибкам хранилища:
+// нарушение ограничения целостности - ErrConflict, отсутствие строки - ErrNotFound.
+// Исходная ошибка сохраняется в тексте, остальные ошибки возвращаются с сообщением msg.
+func pgError(err error, msg string) error {
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pgErr) && pgerrcode.IsIntegrityConstraintViolation(pgErr.Code):
+		return fmt.Errorf("%s: %w: %v", msg, ErrConflict, err)
+	case errors.Is(err, pgx.ErrNoRows):
+		return fmt.Errorf("%s: %w", msg, ErrNotFound)
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
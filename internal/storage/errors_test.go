@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemStorageErrors(t *testing.T) {
+	ctx := context.Background()
+	stor := &MemStorage{URLMap: make(map[string]string)}
+
+	_, _, err := stor.GetOriginalURLByShort(ctx, "http://localhost/a")
+	assert.ErrorIs(t, err, ErrNotFound, "empty storage")
+
+	require.NoError(t, stor.InsertURL(ctx, "https://go.dev/", "http://localhost/a", "user"))
+	assert.ErrorIs(t, stor.InsertURL(ctx, "https://go.dev/", "http://localhost/b", "user"), ErrConflict)
+
+	_, _, err = stor.GetOriginalURLByShort(ctx, "http://localhost/b")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = stor.GetShortByOriginalURL(ctx, "https://github.com/")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestPgError(t *testing.T) {
+	unique := &pgconn.PgError{Code: pgerrcode.UniqueViolation}
+	err := pgError(unique, "insert")
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Contains(t, err.Error(), "insert")
+
+	assert.ErrorIs(t, pgError(pgx.ErrNoRows, "select"), ErrNotFound)
+
+	other := errors.New("connection refused")
+	err = pgError(other, "select")
+	assert.ErrorIs(t, err, other)
+	assert.NotErrorIs(t, err, ErrConflict)
+	assert.NotErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,199 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Dorrrke/shortener-url/internal/models"
+)
+
+// AppendFile - функция дописывания записей в файл хранилища path.
+// Файл хранилища - журнал в формате JSON Lines, записи описаны в models.RestorURL.
+func AppendFile(path string, records ...models.RestorURL) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(file)
+	for _, record := range records {
+		data, err := json.Marshal(&record)
+		if err != nil {
+			file.Close()
+			return errors.Wrap(err, "encode file record")
+		}
+		if _, err := writer.Write(append(data, '\n')); err != nil {
+			file.Close()
+			return errors.Wrap(err, "write file record")
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return errors.Wrap(err, "flush file records")
+	}
+	return file.Close()
+}
+
+// ReadFile - функция чтения файла хранилища path, возвращает url в порядке сохранения с учетом удалений и восстановлений.
+// Записи удаления и восстановления неизвестных url пропускаются, несуществующий файл считается пустым.
+func ReadFile(path string) ([]models.ExportURL, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var urls []models.ExportURL
+	index := make(map[string]int)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record models.RestorURL
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, errors.Wrapf(err, "file storage line %d", line)
+		}
+		i, ok := index[record.ShortURL]
+		if record.OriginalURL == "" {
+			if ok && (record.Deleted || record.Restored) {
+				urls[i].Deleted = record.Deleted
+			}
+			continue
+		}
+		if ok {
+			continue
+		}
+		url := models.ExportURL{
+			ShortURL:     record.ShortURL,
+			OriginalURL:  record.OriginalURL,
+			UserID:       record.UserID,
+			Deleted:      record.Deleted,
+			PasswordHash: record.PasswordHash,
+		}
+		if record.CreatedAt != nil {
+			url.CreatedAt = *record.CreatedAt
+		}
+		index[record.ShortURL] = len(urls)
+		urls = append(urls, url)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return urls, nil
+}
+
+// FileStorage - реализация интерфейса Storage в памяти с сохранением изменений в файл хранилища.
+// Используется для переноса данных между хранилищами: сервис ведет файл хранилища сам.
+type FileStorage struct {
+	*MemStorage
+	path string
+	// mu - сериализует запись в файл.
+	mu sync.Mutex
+}
+
+// OpenFileStorage - функция создания FileStorage с url из файла path.
+func OpenFileStorage(ctx context.Context, path string) (*FileStorage, error) {
+	urls, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	mem := &MemStorage{URLMap: make(map[string]string)}
+	if _, err := mem.loadURLs(urls); err != nil {
+		return nil, err
+	}
+	return &FileStorage{MemStorage: mem, path: path}, nil
+}
+
+// appendFile - метод дописывания записей в файл под блокировкой.
+func (s *FileStorage) appendFile(records ...models.RestorURL) error {
+	if len(records) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return AppendFile(s.path, records...)
+}
+
+// InsertURL - метод сохранения url в map и файл.
+func (s *FileStorage) InsertURL(ctx context.Context, originalURL string, shortURL string, userID string) error {
+	return s.InsertProtectedURL(ctx, originalURL, shortURL, userID, "")
+}
+
+// InsertProtectedURL - метод сохранения url с хешем пароля в map и файл.
+func (s *FileStorage) InsertProtectedURL(ctx context.Context, originalURL string, shortURL string, userID string, passwordHash string) error {
+	if err := s.MemStorage.InsertProtectedURL(ctx, originalURL, shortURL, userID, passwordHash); err != nil {
+		return err
+	}
+	now := time.Now()
+	return s.appendFile(models.RestorURL{ShortURL: shortURL, OriginalURL: originalURL, UserID: userID, CreatedAt: &now, PasswordHash: passwordHash})
+}
+
+// InsertBanchURL - метод сохранения нескольких url в map и файл.
+func (s *FileStorage) InsertBanchURL(ctx context.Context, value []models.BantchURL) error {
+	if err := s.MemStorage.InsertBanchURL(ctx, value); err != nil {
+		return err
+	}
+	now := time.Now()
+	records := make([]models.RestorURL, 0, len(value))
+	for _, v := range value {
+		records = append(records, models.RestorURL{ShortURL: v.ShortURL, OriginalURL: v.OriginalURL, UserID: v.UserID, CreatedAt: &now})
+	}
+	return s.appendFile(records...)
+}
+
+// SetDeleteURLStatus - метод пометки url пользователя удаленными в map и файле.
+func (s *FileStorage) SetDeleteURLStatus(ctx context.Context, value []string, userID string) ([]string, error) {
+	deleted, err := s.MemStorage.SetDeleteURLStatus(ctx, value, userID)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]models.RestorURL, 0, len(deleted))
+	for _, v := range deleted {
+		records = append(records, models.RestorURL{ShortURL: v, Deleted: true})
+	}
+	return deleted, s.appendFile(records...)
+}
+
+// RestoreURLs - метод снятия отметки удаления с url пользователя в map и файле.
+func (s *FileStorage) RestoreURLs(ctx context.Context, value []string, userID string) ([]string, error) {
+	restored, err := s.MemStorage.RestoreURLs(ctx, value, userID)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]models.RestorURL, 0, len(restored))
+	for _, v := range restored {
+		records = append(records, models.RestorURL{ShortURL: v, Restored: true})
+	}
+	return restored, s.appendFile(records...)
+}
+
+// LoadURLs - метод сохранения url из выгрузки в map и файл.
+func (s *FileStorage) LoadURLs(ctx context.Context, urls []models.ExportURL) (int, error) {
+	loaded, err := s.MemStorage.loadURLs(urls)
+	if err != nil {
+		return 0, err
+	}
+	records := make([]models.RestorURL, 0, len(loaded))
+	for _, v := range loaded {
+		createdAt := v.CreatedAt
+		records = append(records, models.RestorURL{
+			ShortURL:     v.ShortURL,
+			OriginalURL:  v.OriginalURL,
+			UserID:       v.UserID,
+			Deleted:      v.Deleted,
+			CreatedAt:    &createdAt,
+			PasswordHash: v.PasswordHash,
+		})
+	}
+	return len(loaded), s.appendFile(records...)
+}
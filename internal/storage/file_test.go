@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dorrrke/shortener-url/internal/models"
+)
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "short-url-db.json")
+	urls, err := ReadFile(path)
+	require.NoError(t, err, "missing file is empty")
+	assert.Empty(t, urls)
+
+	// Записи старого формата без владельца и времени, записи удаления и восстановления.
+	data := `{"short_url":"http://localhost/a","original_url":"https://a.ru/"}
+{"short_url":"http://localhost/b","original_url":"https://b.ru/","user_id":"u1","created_at":"2024-03-01T12:00:00Z"}
+
+{"short_url":"http://localhost/a","deleted":true}
+{"short_url":"http://localhost/b","deleted":true}
+{"short_url":"http://localhost/b","restored":true}
+{"short_url":"http://localhost/unknown","deleted":true}
+{"short_url":"http://localhost/a","original_url":"https://other.ru/"}
+`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+	urls, err = ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ExportURL{
+		{ShortURL: "http://localhost/a", OriginalURL: "https://a.ru/", Deleted: true},
+		{ShortURL: "http://localhost/b", OriginalURL: "https://b.ru/", UserID: "u1", CreatedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)},
+	}, urls)
+
+	require.NoError(t, os.WriteFile(path, []byte("not json\n"), 0o600))
+	_, err = ReadFile(path)
+	assert.Error(t, err)
+}
+
+func TestFileStorage(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "short-url-db.json")
+	stor, err := OpenFileStorage(ctx, path)
+	require.NoError(t, err)
+
+	require.NoError(t, stor.InsertURL(ctx, "https://a.ru/", "http://localhost/a", "u1"))
+	require.NoError(t, stor.InsertProtectedURL(ctx, "https://e.ru/", "http://localhost/e", "u1", "hash"))
+	require.NoError(t, stor.InsertBanchURL(ctx, []models.BantchURL{{OriginalURL: "https://b.ru/", ShortURL: "http://localhost/b", UserID: "u2"}}))
+	_, err = stor.SetDeleteURLStatus(ctx, []string{"http://localhost/a"}, "u1")
+	require.NoError(t, err)
+	_, err = stor.SetDeleteURLStatus(ctx, []string{"http://localhost/b"}, "u2")
+	require.NoError(t, err)
+	restored, err := stor.RestoreURLs(ctx, []string{"http://localhost/b"}, "u2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http://localhost/b"}, restored)
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	loaded, err := stor.LoadURLs(ctx, []models.ExportURL{
+		{ShortURL: "http://localhost/c", OriginalURL: "https://c.ru/", UserID: "u3", Deleted: true, CreatedAt: created},
+		{ShortURL: "http://localhost/a", OriginalURL: "https://a.ru/"},
+		{ShortURL: "http://localhost/d", OriginalURL: "https://b.ru/"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, loaded, "existing short and original urls are skipped")
+
+	reopened, err := OpenFileStorage(ctx, path)
+	require.NoError(t, err)
+	passwordHash, err := reopened.GetURLPassword(ctx, "http://localhost/e")
+	require.NoError(t, err)
+	assert.Equal(t, "hash", passwordHash)
+	passwordHash, err = reopened.GetURLPassword(ctx, "http://localhost/a")
+	require.NoError(t, err)
+	assert.Empty(t, passwordHash)
+	_, err = reopened.GetURLPassword(ctx, "http://localhost/x")
+	assert.ErrorIs(t, err, ErrNotFound)
+	var urls []models.ExportURL
+	require.NoError(t, reopened.ExportURLs(ctx, "", func(url models.ExportURL) error {
+		assert.False(t, url.CreatedAt.IsZero())
+		url.CreatedAt = time.Time{}
+		urls = append(urls, url)
+		return nil
+	}))
+	assert.Equal(t, []models.ExportURL{
+		{ShortURL: "http://localhost/a", OriginalURL: "https://a.ru/", UserID: "u1", Deleted: true},
+		{ShortURL: "http://localhost/b", OriginalURL: "https://b.ru/", UserID: "u2"},
+		{ShortURL: "http://localhost/c", OriginalURL: "https://c.ru/", UserID: "u3", Deleted: true},
+		{ShortURL: "http://localhost/e", OriginalURL: "https://e.ru/", UserID: "u1", PasswordHash: "hash"},
+	}, urls)
+}
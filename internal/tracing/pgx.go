@@ -0,0 +1,37 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// QueryTracer - реализация pgx.QueryTracer, создающая клиентский спан на каждый запрос к postgres.
+// Подключается через pgxpool.Config.ConnConfig.Tracer.
+type QueryTracer struct{}
+
+// TraceQueryStart - метод начала спана запроса, имя спана - первое слово sql, например "db SELECT".
+func (QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	operation := strings.ToUpper(strings.SplitN(strings.TrimSpace(data.SQL), " ", 2)[0])
+	ctx, _ = Start(ctx, "db "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBStatement(data.SQL),
+			semconv.DBOperation(operation),
+			attribute.Int("db.args", len(data.Args)),
+		),
+	)
+	return ctx
+}
+
+// TraceQueryEnd - метод завершения спана запроса.
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	End(span, data.Err)
+}
@@ -0,0 +1,85 @@
+// Пакет tracing содержит настройку OpenTelemetry трассировки сервиса:
+// экспорт спанов в OTLP или stdout, middleware для http сервера, трассировку запросов к postgres.
+// Спаны grpc создаются обработчиком статистики otelgrpc, спаны сервиса и хранилища - функцией Start.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName - имя сервиса в ресурсах трассировки.
+const ServiceName = "shortener"
+
+// instrumentationName - имя трассировщика спанов сервиса.
+const instrumentationName = "github.com/Dorrrke/shortener-url"
+
+// Поддерживаемые экспортеры спанов.
+const (
+	// ExporterNone - трассировка выключена.
+	ExporterNone = ""
+	// ExporterStdout - вывод спанов в stdout для локальной отладки.
+	ExporterStdout = "stdout"
+	// ExporterOTLP - отправка спанов коллектору по OTLP/gRPC.
+	ExporterOTLP = "otlp"
+)
+
+// Init - функция настройки глобального провайдера трассировки.
+// endpoint используется только экспортером otlp, пустое значение означает адрес из переменных OTEL_EXPORTER_OTLP_*.
+// Возвращаемая функция отправляет оставшиеся спаны и останавливает провайдер.
+func Init(ctx context.Context, exporter string, endpoint string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var spanExporter sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		opts := []otlptracegrpc.Option{}
+		if endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(endpoint), otlptracegrpc.WithInsecure())
+		}
+		spanExporter, err = otlptracegrpc.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Start - функция создания дочернего спана с именем name.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// End - функция завершения спана с записью ошибки, если она есть.
+// Удобна для defer в функциях с именованной ошибкой.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,280 @@
+// Пакет transfer содержит форматы выгрузки и загрузки сокращенных url: CSV с заголовком и JSON Lines.
+// Строка выгрузки - models.ExportURL: сокращенный и оригинальный url, отметка удаления, время создания,
+// в полной выгрузке - владелец и хеш пароля, в выгрузке пользователя - отметка защищенной паролем ссылки.
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Dorrrke/shortener-url/internal/models"
+)
+
+// Поддерживаемые форматы.
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// Колонки CSV и поля JSON Lines.
+const (
+	ColumnShortURL     = "short_url"
+	ColumnOriginalURL  = "original_url"
+	ColumnDeleted      = "deleted"
+	ColumnCreatedAt    = "created_at"
+	ColumnUserID       = "user_id"
+	ColumnPasswordHash = "password_hash"
+	ColumnProtected    = "protected"
+)
+
+// ErrUnknownFormat - ошибка при неизвестном формате выгрузки.
+var ErrUnknownFormat = errors.New("unknown format, use csv or jsonl")
+
+// ParseFormat - функция проверки формата, пустой формат означает csv.
+func ParseFormat(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "", FormatCSV:
+		return FormatCSV, nil
+	case FormatJSONL:
+		return FormatJSONL, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// ContentType - функция получения типа содержимого для формата.
+func ContentType(format string) string {
+	if format == FormatJSONL {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Writer - запись url в выбранном формате.
+type Writer interface {
+	// Write - метод записи одного url.
+	Write(url models.ExportURL) error
+	// Flush - метод сброса буфера, вызывается после записи всех url.
+	Flush() error
+}
+
+// NewWriter - функция создания Writer формата format. Полная выгрузка (full) содержит владельца и хеш пароля url,
+// в остальных выгрузках их нет, а защищенные паролем url отмечаются колонкой protected.
+// Заголовок CSV записывается сразу, поэтому пустая выгрузка тоже содержит заголовок.
+func NewWriter(w io.Writer, format string, full bool) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		cw := &csvWriter{w: csv.NewWriter(w), full: full}
+		header := []string{ColumnShortURL, ColumnOriginalURL, ColumnDeleted, ColumnCreatedAt}
+		if full {
+			header = append(header, ColumnUserID, ColumnPasswordHash)
+		} else {
+			header = append(header, ColumnProtected)
+		}
+		if err := cw.w.Write(header); err != nil {
+			return nil, err
+		}
+		return cw, nil
+	case FormatJSONL:
+		bw := bufio.NewWriter(w)
+		return &jsonlWriter{w: bw, enc: json.NewEncoder(bw), full: full}, nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+// csvWriter - запись url в CSV.
+type csvWriter struct {
+	w    *csv.Writer
+	full bool
+}
+
+// Write - метод записи url строкой CSV.
+func (cw *csvWriter) Write(url models.ExportURL) error {
+	var createdAt string
+	if !url.CreatedAt.IsZero() {
+		createdAt = url.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	record := []string{url.ShortURL, url.OriginalURL, strconv.FormatBool(url.Deleted), createdAt}
+	if cw.full {
+		record = append(record, url.UserID, url.PasswordHash)
+	} else {
+		record = append(record, strconv.FormatBool(url.Protected || url.PasswordHash != ""))
+	}
+	return cw.w.Write(record)
+}
+
+// Flush - метод сброса буфера CSV.
+func (cw *csvWriter) Flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// jsonlWriter - запись url в JSON Lines.
+type jsonlWriter struct {
+	w    *bufio.Writer
+	enc  *json.Encoder
+	full bool
+}
+
+// Write - метод записи url объектом JSON на отдельной строке.
+func (jw *jsonlWriter) Write(url models.ExportURL) error {
+	if jw.full {
+		url.Protected = false
+	} else {
+		url.UserID = ""
+		url.Protected = url.Protected || url.PasswordHash != ""
+		url.PasswordHash = ""
+	}
+	return jw.enc.Encode(url)
+}
+
+// Flush - метод сброса буфера.
+func (jw *jsonlWriter) Flush() error {
+	return jw.w.Flush()
+}
+
+// RowError - ошибка в строке загружаемого файла. Чтение можно продолжить со следующей строки.
+type RowError struct {
+	// Line - номер строки файла, начиная с 1.
+	Line int
+	// Field - колонка или поле с ошибкой, пустое для ошибок разбора строки.
+	Field string
+	// Reason - описание ошибки.
+	Reason string
+}
+
+// Error - текст ошибки.
+func (e *RowError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Reason)
+	}
+	return fmt.Sprintf("line %d: %s: %s", e.Line, e.Field, e.Reason)
+}
+
+// Reader - чтение url в выбранном формате.
+type Reader interface {
+	// Read - метод чтения следующего url и номера его строки. В конце файла возвращает io.EOF,
+	// для строки с ошибкой - *RowError, после которой чтение можно продолжить.
+	Read() (models.ExportURL, int, error)
+}
+
+// NewReader - функция создания Reader формата format.
+// CSV должен начинаться с заголовка, колонка original_url обязательна, остальные колонки необязательны, порядок любой.
+func NewReader(r io.Reader, format string) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		cr.TrimLeadingSpace = true
+		header, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("empty file")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read csv header: %w", err)
+		}
+		reader := &csvReader{r: cr, columns: make(map[string]int)}
+		for i, name := range header {
+			reader.columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+		}
+		if _, ok := reader.columns[ColumnOriginalURL]; !ok {
+			return nil, fmt.Errorf("csv header must contain column %s", ColumnOriginalURL)
+		}
+		return reader, nil
+	case FormatJSONL:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+		return &jsonlReader{s: scanner}, nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+// maxLineSize - максимальная длина строки JSON Lines.
+const maxLineSize = 1 << 20
+
+// csvReader - чтение url из CSV.
+type csvReader struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+// Read - метод чтения строки CSV.
+func (cr *csvReader) Read() (models.ExportURL, int, error) {
+	record, err := cr.r.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return models.ExportURL{}, parseErr.StartLine, &RowError{Line: parseErr.StartLine, Reason: parseErr.Err.Error()}
+		}
+		return models.ExportURL{}, 0, err
+	}
+	line, _ := cr.r.FieldPos(0)
+	column := func(name string) string {
+		if i, ok := cr.columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	url := models.ExportURL{
+		ShortURL:     column(ColumnShortURL),
+		OriginalURL:  column(ColumnOriginalURL),
+		UserID:       column(ColumnUserID),
+		PasswordHash: column(ColumnPasswordHash),
+	}
+	if deleted := column(ColumnDeleted); deleted != "" {
+		url.Deleted, err = strconv.ParseBool(deleted)
+		if err != nil {
+			return url, line, &RowError{Line: line, Field: ColumnDeleted, Reason: "must be true or false"}
+		}
+	}
+	if protected := column(ColumnProtected); protected != "" {
+		url.Protected, err = strconv.ParseBool(protected)
+		if err != nil {
+			return url, line, &RowError{Line: line, Field: ColumnProtected, Reason: "must be true or false"}
+		}
+	}
+	if createdAt := column(ColumnCreatedAt); createdAt != "" {
+		url.CreatedAt, err = time.Parse(time.RFC3339, createdAt)
+		if err != nil {
+			return url, line, &RowError{Line: line, Field: ColumnCreatedAt, Reason: "must be RFC 3339 time"}
+		}
+	}
+	return url, line, nil
+}
+
+// jsonlReader - чтение url из JSON Lines, пустые строки пропускаются.
+type jsonlReader struct {
+	s    *bufio.Scanner
+	line int
+}
+
+// Read - метод чтения строки JSON Lines.
+func (jr *jsonlReader) Read() (models.ExportURL, int, error) {
+	for jr.s.Scan() {
+		jr.line++
+		data := bytes.TrimSpace(jr.s.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		var url models.ExportURL
+		if err := json.Unmarshal(data, &url); err != nil {
+			return url, jr.line, &RowError{Line: jr.line, Reason: "malformed json: " + err.Error()}
+		}
+		url.ShortURL = strings.TrimSpace(url.ShortURL)
+		url.OriginalURL = strings.TrimSpace(url.OriginalURL)
+		url.UserID = strings.TrimSpace(url.UserID)
+		url.PasswordHash = strings.TrimSpace(url.PasswordHash)
+		return url, jr.line, nil
+	}
+	if err := jr.s.Err(); err != nil {
+		return models.ExportURL{}, jr.line + 1, err
+	}
+	return models.ExportURL{}, 0, io.EOF
+}
@@ -0,0 +1,118 @@
+package transfer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dorrrke/shortener-url/internal/models"
+)
+
+// readAll - функция чтения всех строк, ошибки строк собираются отдельно.
+func readAll(t *testing.T, r Reader) ([]models.ExportURL, []*RowError) {
+	t.Helper()
+	var (
+		urls    []models.ExportURL
+		rowErrs []*RowError
+	)
+	for {
+		url, _, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return urls, rowErrs
+		}
+		var rowErr *RowError
+		if errors.As(err, &rowErr) {
+			rowErrs = append(rowErrs, rowErr)
+			continue
+		}
+		require.NoError(t, err)
+		urls = append(urls, url)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	urls := []models.ExportURL{
+		{ShortURL: "http://localhost/a", OriginalURL: "https://go.dev/?q=a,b", UserID: "u1", CreatedAt: created},
+		{ShortURL: "http://localhost/b", OriginalURL: "https://example.com/", UserID: "u2", Deleted: true},
+		{ShortURL: "http://localhost/c", OriginalURL: "https://docs.internal/", UserID: "u2", PasswordHash: "$2a$10$hash"},
+	}
+	for _, format := range []string{FormatCSV, FormatJSONL} {
+		for _, full := range []bool{false, true} {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, format, full)
+			require.NoError(t, err)
+			for _, url := range urls {
+				require.NoError(t, w.Write(url))
+			}
+			require.NoError(t, w.Flush())
+
+			r, err := NewReader(&buf, format)
+			require.NoError(t, err)
+			got, rowErrs := readAll(t, r)
+			assert.Empty(t, rowErrs)
+			want := append([]models.ExportURL(nil), urls...)
+			if !full {
+				for i := range want {
+					want[i].UserID = ""
+					want[i].Protected = want[i].PasswordHash != ""
+					want[i].PasswordHash = ""
+				}
+			}
+			assert.Equal(t, want, got, "format %s, full %v", format, full)
+		}
+	}
+}
+
+func TestReader(t *testing.T) {
+	_, err := NewReader(strings.NewReader(""), FormatCSV)
+	assert.Error(t, err)
+	_, err = NewReader(strings.NewReader("short_url,url\n"), FormatCSV)
+	assert.Error(t, err)
+	_, err = NewReader(strings.NewReader(""), "xml")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+
+	r, err := NewReader(strings.NewReader("\ufeffOriginal_URL, deleted\nhttps://a.ru/\n\nhttps://b.ru/,yes\nhttps://c.ru/,true\n\"https://d.ru/,false\n"), FormatCSV)
+	require.NoError(t, err)
+	url, line, err := r.Read()
+	require.NoError(t, err)
+	assert.Equal(t, 2, line)
+	assert.Equal(t, models.ExportURL{OriginalURL: "https://a.ru/"}, url)
+	_, line, err = r.Read()
+	var rowErr *RowError
+	require.ErrorAs(t, err, &rowErr)
+	assert.Equal(t, 4, line)
+	assert.Equal(t, ColumnDeleted, rowErr.Field)
+	url, line, err = r.Read()
+	require.NoError(t, err)
+	assert.Equal(t, models.ExportURL{OriginalURL: "https://c.ru/", Deleted: true}, url)
+	assert.Equal(t, 5, line)
+	_, line, err = r.Read()
+	require.ErrorAs(t, err, &rowErr)
+	assert.Equal(t, 6, line)
+	_, _, err = r.Read()
+	assert.ErrorIs(t, err, io.EOF)
+
+	r, err = NewReader(strings.NewReader(`{"original_url":"https://a.ru/","deleted":true}`+"\n\nnot json\n"), FormatJSONL)
+	require.NoError(t, err)
+	urls, rowErrs := readAll(t, r)
+	assert.Equal(t, []models.ExportURL{{OriginalURL: "https://a.ru/", Deleted: true}}, urls)
+	require.Len(t, rowErrs, 1)
+	assert.Equal(t, 3, rowErrs[0].Line)
+}
+
+func TestParseFormat(t *testing.T) {
+	for format, want := range map[string]string{"": FormatCSV, "CSV": FormatCSV, "jsonl": FormatJSONL} {
+		got, err := ParseFormat(format)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := ParseFormat("json")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
@@ -0,0 +1,125 @@
+package urlcheck
+
+import (
+	"bufio"
+	"context"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Dorrrke/shortener-url/internal/logger"
+)
+
+// DefaultReloadInterval - период проверки изменения файла блоклиста по умолчанию.
+const DefaultReloadInterval = 30 * time.Second
+
+// DomainBlocklist - проверка хоста url по списку доменов из файла.
+// Домен блокируется вместе со всеми поддоменами. Файл содержит по одному домену на строку,
+// пустые строки и строки, начинающиеся с #, пропускаются.
+type DomainBlocklist struct {
+	path    string
+	mu      sync.RWMutex
+	domains map[string]struct{}
+	modTime time.Time
+}
+
+// NewDomainBlocklist - функция создания блоклиста и первичной загрузки файла path.
+func NewDomainBlocklist(path string) (*DomainBlocklist, error) {
+	b := &DomainBlocklist{path: path}
+	if err := b.Reload(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Reload - метод перечитывания файла блоклиста.
+// При ошибке чтения остается действовать предыдущий список.
+func (b *DomainBlocklist) Reload() error {
+	info, err := os.Stat(b.path)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(b.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	domains := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains[strings.TrimSuffix(line, ".")] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.domains = domains
+	b.modTime = info.ModTime()
+	b.mu.Unlock()
+	logger.Log.Info("Blocklist loaded", zap.String("path", b.path), zap.Int("domains", len(domains)))
+	return nil
+}
+
+// Watch - метод периодической проверки времени изменения файла и его перечитывания.
+// Работает до отмены ctx, interval <= 0 заменяется на DefaultReloadInterval.
+func (b *DomainBlocklist) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(b.path)
+			if err != nil {
+				logger.Log.Error("Cannot stat blocklist", zap.Error(err))
+				continue
+			}
+			b.mu.RLock()
+			changed := !info.ModTime().Equal(b.modTime)
+			b.mu.RUnlock()
+			if !changed {
+				continue
+			}
+			if err := b.Reload(); err != nil {
+				logger.Log.Error("Cannot reload blocklist", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Check - метод проверки хоста url и всех его родительских доменов по блоклисту.
+func (b *DomainBlocklist) Check(_ context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for domain := host; domain != ""; {
+		if _, ok := b.domains[domain]; ok {
+			return blocked("domain " + domain + " is in blocklist")
+		}
+		i := strings.IndexByte(domain, '.')
+		if i < 0 {
+			break
+		}
+		domain = domain[i+1:]
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package urlcheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Dorrrke/shortener-url/internal/logger"
+)
+
+// DefaultReputationTimeout - время ожидания ответа сервиса репутации по умолчанию.
+const DefaultReputationTimeout = 2 * time.Second
+
+// reputationRequest - тело запроса к сервису репутации.
+type reputationRequest struct {
+	URL string `json:"url"`
+}
+
+// reputationResponse - тело ответа сервиса репутации.
+type reputationResponse struct {
+	Blocked bool   `json:"blocked"`
+	Reason  string `json:"reason"`
+}
+
+// HTTPChecker - проверка url внешним сервисом репутации.
+// Сервис получает POST запрос с телом {"url": "..."} и отвечает {"blocked": bool, "reason": "..."}.
+type HTTPChecker struct {
+	// Endpoint - адрес сервиса репутации.
+	Endpoint string
+	// Client - http клиент, при nil используется клиент с DefaultReputationTimeout.
+	Client *http.Client
+	// FailClosed - считать url запрещенным, если сервис недоступен.
+	// По умолчанию ошибка сервиса логируется и url разрешается.
+	FailClosed bool
+}
+
+// Check - метод запроса вердикта сервиса репутации.
+func (c *HTTPChecker) Check(ctx context.Context, rawURL string) error {
+	resp, err := c.query(ctx, rawURL)
+	if err != nil {
+		if c.FailClosed {
+			return fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+		logger.Log.Error("Reputation check failed, url allowed", zap.Error(err))
+		return nil
+	}
+	if resp.Blocked {
+		reason := resp.Reason
+		if reason == "" {
+			reason = "reputation service verdict"
+		}
+		return blocked(reason)
+	}
+	return nil
+}
+
+// query - метод выполнения запроса к сервису репутации.
+func (c *HTTPChecker) query(ctx context.Context, rawURL string) (reputationResponse, error) {
+	var result reputationResponse
+	body, err := json.Marshal(reputationRequest{URL: rawURL})
+	if err != nil {
+		return result, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return result, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := c.Client
+	if client == nil {
+		client = &http.Client{Timeout: DefaultReputationTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return result, fmt.Errorf("reputation service returned status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return result, err
+	}
+	return result, nil
+}
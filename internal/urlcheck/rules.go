@@ -0,0 +1,61 @@
+package urlcheck
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// RegexRules - проверка url целиком по набору регулярных выражений.
+type RegexRules struct {
+	rules []*regexp.Regexp
+}
+
+// NewRegexRules - функция компиляции правил, url блокируется при совпадении с любым из них.
+func NewRegexRules(patterns []string) (*RegexRules, error) {
+	r := &RegexRules{}
+	for _, pattern := range patterns {
+		rule, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		r.rules = append(r.rules, rule)
+	}
+	return r, nil
+}
+
+// LoadRegexRules - функция чтения правил из файла, по одному выражению на строку.
+// Пустые строки и строки, начинающиеся с #, пропускаются.
+func LoadRegexRules(path string) (*RegexRules, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewRegexRules(patterns)
+}
+
+// Check - метод проверки url по правилам.
+func (r *RegexRules) Check(_ context.Context, rawURL string) error {
+	for _, rule := range r.rules {
+		if rule.MatchString(rawURL) {
+			return blocked("matches rule " + rule.String())
+		}
+	}
+	return nil
+}
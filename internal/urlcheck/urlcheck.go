@@ -0,0 +1,41 @@
+// Пакет urlcheck содержит проверки оригинальных url на фишинг и другие нежелательные адреса.
+// Сервис сокращения консультируется с URLChecker перед сохранением ссылки и перед переходом по ней,
+// поэтому ссылки, попавшие в блоклист после создания, перестают перенаправлять пользователя.
+package urlcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrBlocked - url запрещен одной из проверок, все ошибки блокировки оборачивают ее.
+var ErrBlocked = errors.New("url is blocked")
+
+// ErrUnavailable - проверку не удалось выполнить, например сервис репутации недоступен.
+var ErrUnavailable = errors.New("url check unavailable")
+
+// URLChecker - проверка url.
+// Check возвращает nil, если url разрешен, ошибку, оборачивающую ErrBlocked, если url запрещен,
+// и ошибку, оборачивающую ErrUnavailable, если проверку выполнить не удалось.
+type URLChecker interface {
+	Check(ctx context.Context, rawURL string) error
+}
+
+// blocked - функция создания ошибки блокировки с описанием причины.
+func blocked(reason string) error {
+	return fmt.Errorf("%w: %s", ErrBlocked, reason)
+}
+
+// Chain - последовательность проверок, url разрешен, только если его разрешили все проверки.
+type Chain []URLChecker
+
+// Check - метод последовательного выполнения проверок до первой ошибки.
+func (c Chain) Check(ctx context.Context, rawURL string) error {
+	for _, checker := range c {
+		if err := checker.Check(ctx, rawURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
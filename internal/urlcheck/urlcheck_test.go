@@ -0,0 +1,91 @@
+package urlcheck
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDomainBlocklist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# phishing\nEvil.com\n\nbad.example.org.\n"), 0666))
+	b, err := NewDomainBlocklist(path)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	assert.ErrorIs(t, b.Check(ctx, "https://evil.com/login"), ErrBlocked)
+	assert.ErrorIs(t, b.Check(ctx, "https://login.evil.com/"), ErrBlocked)
+	assert.ErrorIs(t, b.Check(ctx, "http://bad.example.org:8080/"), ErrBlocked)
+	assert.NoError(t, b.Check(ctx, "https://notevil.com/"))
+	assert.NoError(t, b.Check(ctx, "https://example.org/"))
+
+	require.NoError(t, os.WriteFile(path, []byte("example.org\n"), 0666))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go b.Watch(ctx, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return b.Check(ctx, "https://evil.com/") == nil && b.Check(ctx, "https://example.org/") != nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRegexRules(t *testing.T) {
+	_, err := NewRegexRules([]string{"("})
+	assert.Error(t, err)
+
+	r, err := NewRegexRules([]string{`^https?://[^/]+/.*paypal.*login`, `\.zip$`})
+	require.NoError(t, err)
+	ctx := context.Background()
+	assert.ErrorIs(t, r.Check(ctx, "https://example.com/paypal-secure/login"), ErrBlocked)
+	assert.ErrorIs(t, r.Check(ctx, "https://example.com/file.zip"), ErrBlocked)
+	assert.NoError(t, r.Check(ctx, "https://example.com/"))
+}
+
+func TestHTTPChecker(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req reputationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch req.URL {
+		case "https://phish.ru/":
+			json.NewEncoder(w).Encode(reputationResponse{Blocked: true, Reason: "phishing"})
+		case "https://down.ru/":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			json.NewEncoder(w).Encode(reputationResponse{})
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	checker := &HTTPChecker{Endpoint: srv.URL}
+	err := checker.Check(ctx, "https://phish.ru/")
+	assert.ErrorIs(t, err, ErrBlocked)
+	assert.Contains(t, err.Error(), "phishing")
+	assert.NoError(t, checker.Check(ctx, "https://go.dev/"))
+	assert.NoError(t, checker.Check(ctx, "https://down.ru/"))
+
+	checker.FailClosed = true
+	err = checker.Check(ctx, "https://down.ru/")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.NotErrorIs(t, err, ErrBlocked)
+}
+
+func TestChain(t *testing.T) {
+	rules, err := NewRegexRules([]string{`evil`})
+	require.NoError(t, err)
+	chain := Chain{&RegexRules{}, rules}
+	assert.ErrorIs(t, chain.Check(context.Background(), "https://evil.com/"), ErrBlocked)
+	assert.NoError(t, chain.Check(context.Background(), "https://go.dev/"))
+	assert.NoError(t, Chain(nil).Check(context.Background(), "https://go.dev/"))
+}
@@ -0,0 +1,134 @@
+// Пакет urlnorm содержит валидацию и нормализацию оригинальных url перед сокращением.
+// Нормализация приводит разные записи одного адреса к единому виду, например
+// http://Example.com:80 и http://example.com/ становятся http://example.com/,
+// что позволяет сервису находить дубликаты.
+package urlnorm
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/idna"
+)
+
+// DefaultMaxLength - максимальная длина url по умолчанию.
+const DefaultMaxLength = 2048
+
+// ErrInvalidURL - базовая ошибка валидации, все ошибки пакета оборачивают ее.
+var ErrInvalidURL = errors.New("invalid url")
+
+// ErrPrivateTarget - url указывает на локальный или приватный адрес.
+var ErrPrivateTarget = fmt.Errorf("%w: private or loopback target", ErrInvalidURL)
+
+// Options - параметры нормализации.
+type Options struct {
+	// MaxLength - максимальная длина url до нормализации, 0 - DefaultMaxLength.
+	MaxLength int
+	// KeepFragment - не отбрасывать фрагмент (#...) url.
+	KeepFragment bool
+	// KeepDefaultPort - не отбрасывать порт по умолчанию (80 для http и 443 для https).
+	KeepDefaultPort bool
+	// RejectPrivate - отклонять url, указывающие на localhost, loopback, приватные и link-local адреса.
+	RejectPrivate bool
+}
+
+// Normalizer - валидирует и нормализует url.
+type Normalizer struct {
+	opts Options
+}
+
+// New - функция создания Normalizer.
+func New(opts Options) *Normalizer {
+	if opts.MaxLength <= 0 {
+		opts.MaxLength = DefaultMaxLength
+	}
+	return &Normalizer{opts: opts}
+}
+
+// invalid - функция создания ошибки валидации с описанием причины.
+func invalid(reason string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidURL, reason)
+}
+
+// Normalize - метод проверки и нормализации url.
+// Допускаются только абсолютные http и https url с хостом и без пробельных символов.
+// Схема и хост приводятся к нижнему регистру, интернациональные домены переводятся в punycode,
+// пустой путь заменяется на "/", порт по умолчанию и фрагмент отбрасываются согласно Options.
+func (n *Normalizer) Normalize(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", invalid("empty url")
+	}
+	if len(raw) > n.opts.MaxLength {
+		return "", invalid(fmt.Sprintf("url is longer than %d", n.opts.MaxLength))
+	}
+	if strings.IndexFunc(raw, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) >= 0 {
+		return "", invalid("url contains whitespace or control characters")
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", invalid(err.Error())
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", invalid("scheme must be http or https")
+	}
+	if u.Opaque != "" {
+		return "", invalid("url is not hierarchical")
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return "", invalid("host is empty")
+	}
+	port := u.Port()
+	ip := net.ParseIP(host)
+	if ip == nil {
+		host, err = idna.Lookup.ToASCII(host)
+		if err != nil {
+			return "", invalid("bad host: " + err.Error())
+		}
+	}
+	if n.opts.RejectPrivate && isPrivateHost(host, ip) {
+		return "", ErrPrivateTarget
+	}
+
+	if !n.opts.KeepDefaultPort && (u.Scheme == "http" && port == "80" || u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if ip != nil && ip.To4() == nil {
+		host = "[" + ip.String() + "]"
+	}
+	if port != "" {
+		host = host + ":" + port
+	}
+	u.Host = host
+
+	if u.Path == "" {
+		u.Path = "/"
+		u.RawPath = ""
+	}
+	if !n.opts.KeepFragment {
+		u.Fragment = ""
+		u.RawFragment = ""
+	}
+	return u.String(), nil
+}
+
+// isPrivateHost - функция проверки, что хост указывает на локальную машину или внутреннюю сеть.
+// Проверяются только ip адреса и имена localhost, dns имена не резолвятся.
+func isPrivateHost(host string, ip net.IP) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	if ip == nil {
+		return false
+	}
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() || ip.IsInterfaceLocalMulticast()
+}
@@ -0,0 +1,52 @@
+package urlnorm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		raw     string
+		want    string
+		wantErr error
+	}{
+		{name: "Test normalize #1 Lowercase host and empty path", raw: "http://Example.com", want: "http://example.com/"},
+		{name: "Test normalize #2 Same as #1", raw: "http://example.com/", want: "http://example.com/"},
+		{name: "Test normalize #3 Default port", raw: "HTTPS://example.com:443/a?b=1", want: "https://example.com/a?b=1"},
+		{name: "Test normalize #4 Keep default port", opts: Options{KeepDefaultPort: true}, raw: "http://example.com:80/", want: "http://example.com:80/"},
+		{name: "Test normalize #5 Custom port", raw: "http://example.com:8080", want: "http://example.com:8080/"},
+		{name: "Test normalize #6 Fragment", raw: "https://example.com/page#top", want: "https://example.com/page"},
+		{name: "Test normalize #7 Keep fragment", opts: Options{KeepFragment: true}, raw: "https://example.com/page#top", want: "https://example.com/page#top"},
+		{name: "Test normalize #8 IDNA", raw: "http://Пример.рф/путь", want: "http://xn--e1afmkfd.xn--p1ai/%D0%BF%D1%83%D1%82%D1%8C"},
+		{name: "Test normalize #9 Trailing dot", raw: "http://example.com./", want: "http://example.com/"},
+		{name: "Test normalize #10 IPv6", raw: "http://[2001:DB8::1]:80/", want: "http://[2001:db8::1]/"},
+		{name: "Test normalize #11 Surrounding whitespace", raw: " https://example.com/\n", want: "https://example.com/"},
+		{name: "Test normalize #12 Localhost allowed by default", raw: "http://localhost/", want: "http://localhost/"},
+		{name: "Test invalid #1 Scheme only", raw: "http://", wantErr: ErrInvalidURL},
+		{name: "Test invalid #2 Inner space", raw: "http://exa mple.com/", wantErr: ErrInvalidURL},
+		{name: "Test invalid #3 No scheme", raw: "www.youtube.com", wantErr: ErrInvalidURL},
+		{name: "Test invalid #4 Other scheme", raw: "ftp://example.com/", wantErr: ErrInvalidURL},
+		{name: "Test invalid #5 Relative", raw: "/", wantErr: ErrInvalidURL},
+		{name: "Test invalid #6 Too long", opts: Options{MaxLength: 30}, raw: "https://example.com/" + strings.Repeat("a", 20), wantErr: ErrInvalidURL},
+		{name: "Test invalid #7 Localhost", opts: Options{RejectPrivate: true}, raw: "http://localhost/", wantErr: ErrPrivateTarget},
+		{name: "Test invalid #8 Loopback", opts: Options{RejectPrivate: true}, raw: "http://127.0.0.1:8080/", wantErr: ErrPrivateTarget},
+		{name: "Test invalid #9 Private network", opts: Options{RejectPrivate: true}, raw: "http://192.168.0.10/", wantErr: ErrPrivateTarget},
+		{name: "Test invalid #10 IPv6 loopback", opts: Options{RejectPrivate: true}, raw: "http://[::1]/", wantErr: ErrPrivateTarget},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.opts).Normalize(tt.raw)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package utils
 
 import (
-	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
@@ -46,10 +45,3 @@ func GetUID(tokenString string) string {
 
 	return claim.UserID
 }
-
-func ValidationURL(URL string) bool {
-	if strings.HasPrefix(URL, "http://") || strings.HasPrefix(URL, "https://") {
-		return true
-	}
-	return false
-}
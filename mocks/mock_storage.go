@@ -63,6 +63,50 @@ func (mr *MockStorageMockRecorder) Clear(arg0 interface{}) *gomock.Call {
         return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clear", reflect.TypeOf((*MockStorage)(nil).Clear), arg0)
 }
 
+// CompleteIdempotencyKey mocks base method.
+func (m *MockStorage) CompleteIdempotencyKey(arg0 context.Context, arg1 models.IdempotencyRecord) error {
+        m.ctrl.T.Helper()
+        ret := m.ctrl.Call(m, "CompleteIdempotencyKey", arg0, arg1)
+        ret0, _ := ret[0].(error)
+        return ret0
+}
+
+// CompleteIdempotencyKey indicates an expected call of CompleteIdempotencyKey.
+func (mr *MockStorageMockRecorder) CompleteIdempotencyKey(arg0, arg1 interface{}) *gomock.Call {
+        mr.mock.ctrl.T.Helper()
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteIdempotencyKey", reflect.TypeOf((*MockStorage)(nil).CompleteIdempotencyKey), arg0, arg1)
+}
+
+// CountActiveURLs mocks base method.
+func (m *MockStorage) CountActiveURLs(arg0 context.Context) (int, error) {
+        m.ctrl.T.Helper()
+        ret := m.ctrl.Call(m, "CountActiveURLs", arg0)
+        ret0, _ := ret[0].(int)
+        ret1, _ := ret[1].(error)
+        return ret0, ret1
+}
+
+// CountActiveURLs indicates an expected call of CountActiveURLs.
+func (mr *MockStorageMockRecorder) CountActiveURLs(arg0 interface{}) *gomock.Call {
+        mr.mock.ctrl.T.Helper()
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountActiveURLs", reflect.TypeOf((*MockStorage)(nil).CountActiveURLs), arg0)
+}
+
+// CountUserURLs mocks base method.
+func (m *MockStorage) CountUserURLs(arg0 context.Context, arg1 string) (int, error) {
+        m.ctrl.T.Helper()
+        ret := m.ctrl.Call(m, "CountUserURLs", arg0, arg1)
+        ret0, _ := ret[0].(int)
+        ret1, _ := ret[1].(error)
+        return ret0, ret1
+}
+
+// CountUserURLs indicates an expected call of CountUserURLs.
+func (mr *MockStorageMockRecorder) CountUserURLs(arg0, arg1 interface{}) *gomock.Call {
+        mr.mock.ctrl.T.Helper()
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUserURLs", reflect.TypeOf((*MockStorage)(nil).CountUserURLs), arg0, arg1)
+}
+
 // CreateTable mocks base method.
 func (m *MockStorage) CreateTable(arg0 context.Context) error {
         m.ctrl.T.Helper()
@@ -77,6 +121,20 @@ func (mr *MockStorageMockRecorder) CreateTable(arg0 interface{}) *gomock.Call {
         return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTable", reflect.TypeOf((*MockStorage)(nil).CreateTable), arg0)
 }
 
+// ExportURLs mocks base method.
+func (m *MockStorage) ExportURLs(arg0 context.Context, arg1 string, arg2 func(models.ExportURL) error) error {
+        m.ctrl.T.Helper()
+        ret := m.ctrl.Call(m, "ExportURLs", arg0, arg1, arg2)
+        ret0, _ := ret[0].(error)
+        return ret0
+}
+
+// ExportURLs indicates an expected call of ExportURLs.
+func (mr *MockStorageMockRecorder) ExportURLs(arg0, arg1, arg2 interface{}) *gomock.Call {
+        mr.mock.ctrl.T.Helper()
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportURLs", reflect.TypeOf((*MockStorage)(nil).ExportURLs), arg0, arg1, arg2)
+}
+
 // GetAllUrls mocks base method.
 func (m *MockStorage) GetAllUrls(arg0 context.Context, arg1 string) ([]models.URLModel, error) {
         m.ctrl.T.Helper()
@@ -139,6 +197,21 @@ func (mr *MockStorageMockRecorder) GetStats(arg0 interface{}) *gomock.Call {
         return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStats", reflect.TypeOf((*MockStorage)(nil).GetStats), arg0)
 }
 
+// GetURLPassword mocks base method.
+func (m *MockStorage) GetURLPassword(arg0 context.Context, arg1 string) (string, error) {
+        m.ctrl.T.Helper()
+        ret := m.ctrl.Call(m, "GetURLPassword", arg0, arg1)
+        ret0, _ := ret[0].(string)
+        ret1, _ := ret[1].(error)
+        return ret0, ret1
+}
+
+// GetURLPassword indicates an expected call of GetURLPassword.
+func (mr *MockStorageMockRecorder) GetURLPassword(arg0, arg1 interface{}) *gomock.Call {
+        mr.mock.ctrl.T.Helper()
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURLPassword", reflect.TypeOf((*MockStorage)(nil).GetURLPassword), arg0, arg1)
+}
+
 // InsertBanchURL mocks base method.
 func (m *MockStorage) InsertBanchURL(arg0 context.Context, arg1 []models.BantchURL) error {
         m.ctrl.T.Helper()
@@ -153,6 +226,20 @@ func (mr *MockStorageMockRecorder) InsertBanchURL(arg0, arg1 interface{}) *gomoc
         return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertBanchURL", reflect.TypeOf((*MockStorage)(nil).InsertBanchURL), arg0, arg1)
 }
 
+// InsertProtectedURL mocks base method.
+func (m *MockStorage) InsertProtectedURL(arg0 context.Context, arg1, arg2, arg3, arg4 string) error {
+        m.ctrl.T.Helper()
+        ret := m.ctrl.Call(m, "InsertProtectedURL", arg0, arg1, arg2, arg3, arg4)
+        ret0, _ := ret[0].(error)
+        return ret0
+}
+
+// InsertProtectedURL indicates an expected call of InsertProtectedURL.
+func (mr *MockStorageMockRecorder) InsertProtectedURL(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+        mr.mock.ctrl.T.Helper()
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertProtectedURL", reflect.TypeOf((*MockStorage)(nil).InsertProtectedURL), arg0, arg1, arg2, arg3, arg4)
+}
+
 // InsertURL mocks base method.
 func (m *MockStorage) InsertURL(arg0 context.Context, arg1, arg2, arg3 string) error {
         m.ctrl.T.Helper()
@@ -167,16 +254,76 @@ func (mr *MockStorageMockRecorder) InsertURL(arg0, arg1, arg2, arg3 interface{})
         return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertURL", reflect.TypeOf((*MockStorage)(nil).InsertURL), arg0, arg1, arg2, arg3)
 }
 
-// SetDeleteURLStatus mocks base method.
-func (m *MockStorage) SetDeleteURLStatus(arg0 context.Context, arg1 []string) error {
+// LoadURLs mocks base method.
+func (m *MockStorage) LoadURLs(arg0 context.Context, arg1 []models.ExportURL) (int, error) {
+        m.ctrl.T.Helper()
+        ret := m.ctrl.Call(m, "LoadURLs", arg0, arg1)
+        ret0, _ := ret[0].(int)
+        ret1, _ := ret[1].(error)
+        return ret0, ret1
+}
+
+// LoadURLs indicates an expected call of LoadURLs.
+func (mr *MockStorageMockRecorder) LoadURLs(arg0, arg1 interface{}) *gomock.Call {
+        mr.mock.ctrl.T.Helper()
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadURLs", reflect.TypeOf((*MockStorage)(nil).LoadURLs), arg0, arg1)
+}
+
+// ReleaseIdempotencyKey mocks base method.
+func (m *MockStorage) ReleaseIdempotencyKey(arg0 context.Context, arg1 string, arg2 string) error {
         m.ctrl.T.Helper()
-        ret := m.ctrl.Call(m, "SetDeleteURLStatus", arg0, arg1)
+        ret := m.ctrl.Call(m, "ReleaseIdempotencyKey", arg0, arg1, arg2)
         ret0, _ := ret[0].(error)
         return ret0
 }
 
+// ReleaseIdempotencyKey indicates an expected call of ReleaseIdempotencyKey.
+func (mr *MockStorageMockRecorder) ReleaseIdempotencyKey(arg0, arg1, arg2 interface{}) *gomock.Call {
+        mr.mock.ctrl.T.Helper()
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseIdempotencyKey", reflect.TypeOf((*MockStorage)(nil).ReleaseIdempotencyKey), arg0, arg1, arg2)
+}
+
+// ReserveIdempotencyKey mocks base method.
+func (m *MockStorage) ReserveIdempotencyKey(arg0 context.Context, arg1 models.IdempotencyRecord) (models.IdempotencyRecord, error) {
+        m.ctrl.T.Helper()
+        ret := m.ctrl.Call(m, "ReserveIdempotencyKey", arg0, arg1)
+        ret0, _ := ret[0].(models.IdempotencyRecord)
+        ret1, _ := ret[1].(error)
+        return ret0, ret1
+}
+
+// ReserveIdempotencyKey indicates an expected call of ReserveIdempotencyKey.
+func (mr *MockStorageMockRecorder) ReserveIdempotencyKey(arg0, arg1 interface{}) *gomock.Call {
+        mr.mock.ctrl.T.Helper()
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveIdempotencyKey", reflect.TypeOf((*MockStorage)(nil).ReserveIdempotencyKey), arg0, arg1)
+}
+
+// RestoreURLs mocks base method.
+func (m *MockStorage) RestoreURLs(arg0 context.Context, arg1 []string, arg2 string) ([]string, error) {
+        m.ctrl.T.Helper()
+        ret := m.ctrl.Call(m, "RestoreURLs", arg0, arg1, arg2)
+        ret0, _ := ret[0].([]string)
+        ret1, _ := ret[1].(error)
+        return ret0, ret1
+}
+
+// RestoreURLs indicates an expected call of RestoreURLs.
+func (mr *MockStorageMockRecorder) RestoreURLs(arg0, arg1, arg2 interface{}) *gomock.Call {
+        mr.mock.ctrl.T.Helper()
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreURLs", reflect.TypeOf((*MockStorage)(nil).RestoreURLs), arg0, arg1, arg2)
+}
+
+// SetDeleteURLStatus mocks base method.
+func (m *MockStorage) SetDeleteURLStatus(arg0 context.Context, arg1 []string, arg2 string) ([]string, error) {
+        m.ctrl.T.Helper()
+        ret := m.ctrl.Call(m, "SetDeleteURLStatus", arg0, arg1, arg2)
+        ret0, _ := ret[0].([]string)
+        ret1, _ := ret[1].(error)
+        return ret0, ret1
+}
+
 // SetDeleteURLStatus indicates an expected call of SetDeleteURLStatus.
-func (mr *MockStorageMockRecorder) SetDeleteURLStatus(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) SetDeleteURLStatus(arg0, arg1, arg2 interface{}) *gomock.Call {
         mr.mock.ctrl.T.Helper()
-        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDeleteURLStatus", reflect.TypeOf((*MockStorage)(nil).SetDeleteURLStatus), arg0, arg1)
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDeleteURLStatus", reflect.TypeOf((*MockStorage)(nil).SetDeleteURLStatus), arg0, arg1, arg2)
 }
\ No newline at end of file
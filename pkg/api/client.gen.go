@@ -0,0 +1,1196 @@
+// Code generated by internal/openapi/gen from internal/openapi/openapi.json. DO NOT EDIT.
+
+// Пакет api содержит типизированный REST клиент сервиса Shortener URL (версия API 1.0.0),
+// сгенерированный по спецификации OpenAPI. Спецификация также доступна у сервиса по адресу /api/openapi.json.
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AuditRecord - запись журнала аудита.
+type AuditRecord struct {
+	// Time - время операции.
+	Time time.Time `json:"ts"`
+	// Actor - id пользователя, выполнившего операцию.
+	Actor string `json:"actor"`
+	// Action - вид операции, одно из значений: create, delete, restore.
+	Action string `json:"action"`
+	// Target - сокращенный url, над которым выполнена операция.
+	Target string `json:"target"`
+	// IP - ip адрес клиента.
+	IP string `json:"ip"`
+}
+
+// BatchItem - url для сокращения в пакете.
+type BatchItem struct {
+	// CorrelationID - id строки пакета, возвращается в ответе.
+	CorrelationID string `json:"correlation_id"`
+	// OriginalURL - url для сокращения.
+	OriginalURL string `json:"original_url"`
+}
+
+// BatchResult - сокращенный url из пакета.
+type BatchResult struct {
+	// CorrelationID - id строки пакета из запроса.
+	CorrelationID string `json:"correlation_id"`
+	// ShortURL - сокращенный url.
+	ShortURL string `json:"short_url"`
+}
+
+// ImportError - ошибка строки загружаемого файла.
+type ImportError struct {
+	// Line - номер строки файла.
+	Line int `json:"line"`
+	// Field - колонка или поле с ошибкой.
+	Field string `json:"field,omitempty"`
+	// Code - машиночитаемый код ошибки.
+	Code string `json:"code"`
+	// Reason - описание ошибки.
+	Reason string `json:"reason"`
+}
+
+// ImportReport - итоги загрузки url.
+type ImportReport struct {
+	// Total - количество строк с данными.
+	Total int `json:"total"`
+	// Imported - количество сохраненных url.
+	Imported int `json:"imported"`
+	// Existing - количество пропущенных url, которые уже были сокращены.
+	Existing int `json:"existing"`
+	// Failed - количество строк с ошибками.
+	Failed int `json:"failed"`
+	// Errors - ошибки строк, не больше 100.
+	Errors []ImportError `json:"errors"`
+	// ErrorsTruncated - в отчет попали не все ошибки строк.
+	ErrorsTruncated bool `json:"errors_truncated,omitempty"`
+}
+
+// InvalidParam - поле запроса, не прошедшее проверку.
+type InvalidParam struct {
+	// Name - имя поля.
+	Name string `json:"name"`
+	// Reason - причина ошибки.
+	Reason string `json:"reason"`
+}
+
+// Problem - описание ошибки по RFC 7807.
+type Problem struct {
+	// Type - ссылка на описание вида ошибки.
+	Type string `json:"type"`
+	// Title - краткое описание вида ошибки.
+	Title string `json:"title"`
+	// Status - http статус ответа.
+	Status int `json:"status"`
+	// Detail - описание конкретной ошибки.
+	Detail string `json:"detail,omitempty"`
+	// Instance - путь запроса, на который получена ошибка.
+	Instance string `json:"instance,omitempty"`
+	// Code - машиночитаемый код ошибки.
+	Code string `json:"code"`
+	// RequestID - id запроса для поиска в логах.
+	RequestID string `json:"request_id,omitempty"`
+	// InvalidParams - поля запроса, не прошедшие проверку.
+	InvalidParams []InvalidParam `json:"invalid_params,omitempty"`
+	// ExistingShortURL - уже сохраненный сокращенный url, с которым конфликтует запрос.
+	ExistingShortURL string `json:"existing_short_url,omitempty"`
+}
+
+// Quota - использование квот пользователя, 0 в лимите означает отсутствие ограничения.
+type Quota struct {
+	// LinksUsed - количество неудаленных ссылок пользователя.
+	LinksUsed int `json:"links_used"`
+	// LinksLimit - максимальное количество ссылок пользователя.
+	LinksLimit int `json:"links_limit"`
+	// BatchLimit - максимальный размер пакета.
+	BatchLimit int `json:"batch_limit"`
+}
+
+// ShortenRequest - запрос сокращения url.
+type ShortenRequest struct {
+	// URL - url для сокращения.
+	URL string `json:"url"`
+	// Password - пароль, без которого по сокращенной ссылке нельзя перейти.
+	Password string `json:"password,omitempty"`
+}
+
+// ShortenResponse - ответ с сокращенным url.
+type ShortenResponse struct {
+	// Result - сокращенный url.
+	Result string `json:"result"`
+}
+
+// Stats - статистика сервиса.
+type Stats struct {
+	// URLs - количество сокращенных url.
+	URLs int `json:"urls"`
+	// Users - количество пользователей.
+	Users int `json:"users"`
+	// CacheHits - попадания в кэш переходов, заполняется при включенном кэше.
+	CacheHits int64 `json:"cache_hits,omitempty"`
+	// CacheMisses - промахи кэша переходов, заполняется при включенном кэше.
+	CacheMisses int64 `json:"cache_misses,omitempty"`
+}
+
+// UserURL - url, сокращенный пользователем.
+type UserURL struct {
+	// ShortURL - сокращенный url.
+	ShortURL string `json:"short_url"`
+	// OriginalURL - оригинальный url.
+	OriginalURL string `json:"original_url"`
+}
+
+// HTTPRequestDoer - http клиент, которым отправляются запросы, например *http.Client.
+type HTTPRequestDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// RequestEditorFn - функция изменения запроса перед отправкой, например для установки cookie или заголовков.
+type RequestEditorFn func(ctx context.Context, req *http.Request) error
+
+// Client - REST клиент сервиса.
+type Client struct {
+	// Server - базовый адрес сервиса, например http://localhost:8080.
+	Server string
+	// HTTPClient - http клиент для отправки запросов.
+	HTTPClient HTTPRequestDoer
+	// RequestEditors - функции, применяемые ко всем запросам клиента.
+	RequestEditors []RequestEditorFn
+}
+
+// ClientOption - опция создания клиента.
+type ClientOption func(*Client) error
+
+// NewClient - функция создания клиента сервиса по адресу server.
+// По умолчанию используется http клиент, который не следует перенаправлениям,
+// чтобы ответ на переход по сокращенной ссылке содержал оригинальный url в заголовке Location.
+func NewClient(server string, opts ...ClientOption) (*Client, error) {
+	client := Client{Server: strings.TrimSuffix(server, "/")}
+	for _, opt := range opts {
+		if err := opt(&client); err != nil {
+			return nil, err
+		}
+	}
+	if client.HTTPClient == nil {
+		client.HTTPClient = &http.Client{
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+	return &client, nil
+}
+
+// WithHTTPClient - опция установки http клиента, например с cookie jar для сохранения cookie auth.
+func WithHTTPClient(doer HTTPRequestDoer) ClientOption {
+	return func(c *Client) error {
+		c.HTTPClient = doer
+		return nil
+	}
+}
+
+// WithRequestEditorFn - опция добавления функции изменения всех запросов клиента.
+func WithRequestEditorFn(fn RequestEditorFn) ClientOption {
+	return func(c *Client) error {
+		c.RequestEditors = append(c.RequestEditors, fn)
+		return nil
+	}
+}
+
+// ShortenerURLParams - параметры запроса ShortenerURL.
+type ShortenerURLParams struct {
+	// IdempotencyKey - ключ идемпотентности: повтор запроса с тем же ключом возвращает сохраненный ответ.
+	IdempotencyKey *string
+	// XLinkPassword - пароль, без которого по сокращенной ссылке нельзя перейти.
+	XLinkPassword *string
+}
+
+// ShortenerURLResponse - ответ на запрос ShortenerURL.
+type ShortenerURLResponse struct {
+	// HTTPResponse - http ответ, тело уже прочитано в Body.
+	HTTPResponse *http.Response
+	// Body - тело ответа.
+	Body []byte
+	// Problem - описание ошибки, если сервис вернул ответ application/problem+json.
+	Problem *Problem
+}
+
+// StatusCode - метод получения http статуса ответа.
+func (r *ShortenerURLResponse) StatusCode() int {
+	if r.HTTPResponse == nil {
+		return 0
+	}
+	return r.HTTPResponse.StatusCode
+}
+
+// ShortenerURL - сокращение url, переданного в теле запроса текстом.
+// Операция ShortenerURLHandler: POST /.
+func (c *Client) ShortenerURL(ctx context.Context, params *ShortenerURLParams, body string, reqEditors ...RequestEditorFn) (*ShortenerURLResponse, error) {
+	payload := []byte(body)
+	req, err := c.newRequest(ctx, http.MethodPost, "/", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "text/plain")
+	if params != nil {
+		if params.IdempotencyKey != nil {
+			req.Header.Set("Idempotency-Key", *params.IdempotencyKey)
+		}
+		if params.XLinkPassword != nil {
+			req.Header.Set("X-Link-Password", *params.XLinkPassword)
+		}
+	}
+	res, payloadRes, err := c.do(ctx, req, reqEditors)
+	if err != nil {
+		return nil, err
+	}
+	response := &ShortenerURLResponse{HTTPResponse: res, Body: payloadRes}
+	mediaType := responseMediaType(res)
+	switch {
+	case mediaType == "application/problem+json":
+		var dest Problem
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.Problem = &dest
+	}
+	return response, nil
+}
+
+// GetAuditLogParams - параметры запроса GetAuditLog.
+type GetAuditLogParams struct {
+	// Actor - id пользователя, выполнившего операцию.
+	Actor *string
+	// Action - вид операции.
+	Action *string
+	// Target - сокращенный url, над которым выполнена операция.
+	Target *string
+	// Since - начало периода (RFC 3339).
+	Since *time.Time
+	// Until - конец периода (RFC 3339).
+	Until *time.Time
+	// Limit - максимальное количество записей, по умолчанию 100.
+	Limit *int
+}
+
+// GetAuditLogResponse - ответ на запрос GetAuditLog.
+type GetAuditLogResponse struct {
+	// HTTPResponse - http ответ, тело уже прочитано в Body.
+	HTTPResponse *http.Response
+	// Body - тело ответа.
+	Body []byte
+	// JSON200 - разобранное тело ответа со статусом 200.
+	JSON200 *[]AuditRecord
+	// Problem - описание ошибки, если сервис вернул ответ application/problem+json.
+	Problem *Problem
+}
+
+// StatusCode - метод получения http статуса ответа.
+func (r *GetAuditLogResponse) StatusCode() int {
+	if r.HTTPResponse == nil {
+		return 0
+	}
+	return r.HTTPResponse.StatusCode
+}
+
+// GetAuditLog - журнал аудита изменяющих операций.
+// Операция GetAuditLog: GET /api/internal/audit.
+func (c *Client) GetAuditLog(ctx context.Context, params *GetAuditLogParams, reqEditors ...RequestEditorFn) (*GetAuditLogResponse, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/internal/audit", nil)
+	if err != nil {
+		return nil, err
+	}
+	if params != nil {
+		query := req.URL.Query()
+		if params.Actor != nil {
+			query.Set("actor", *params.Actor)
+		}
+		if params.Action != nil {
+			query.Set("action", *params.Action)
+		}
+		if params.Target != nil {
+			query.Set("target", *params.Target)
+		}
+		if params.Since != nil {
+			query.Set("since", params.Since.Format(time.RFC3339Nano))
+		}
+		if params.Until != nil {
+			query.Set("until", params.Until.Format(time.RFC3339Nano))
+		}
+		if params.Limit != nil {
+			query.Set("limit", strconv.Itoa(*params.Limit))
+		}
+		req.URL.RawQuery = query.Encode()
+	}
+	res, payloadRes, err := c.do(ctx, req, reqEditors)
+	if err != nil {
+		return nil, err
+	}
+	response := &GetAuditLogResponse{HTTPResponse: res, Body: payloadRes}
+	mediaType := responseMediaType(res)
+	switch {
+	case res.StatusCode == 200 && mediaType == "application/json":
+		var dest []AuditRecord
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+	case mediaType == "application/problem+json":
+		var dest Problem
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.Problem = &dest
+	}
+	return response, nil
+}
+
+// GetServiceStatsResponse - ответ на запрос GetServiceStats.
+type GetServiceStatsResponse struct {
+	// HTTPResponse - http ответ, тело уже прочитано в Body.
+	HTTPResponse *http.Response
+	// Body - тело ответа.
+	Body []byte
+	// JSON200 - разобранное тело ответа со статусом 200.
+	JSON200 *Stats
+	// Problem - описание ошибки, если сервис вернул ответ application/problem+json.
+	Problem *Problem
+}
+
+// StatusCode - метод получения http статуса ответа.
+func (r *GetServiceStatsResponse) StatusCode() int {
+	if r.HTTPResponse == nil {
+		return 0
+	}
+	return r.HTTPResponse.StatusCode
+}
+
+// GetServiceStats - статистика сервиса.
+// Операция GetServiceStats: GET /api/internal/stats.
+func (c *Client) GetServiceStats(ctx context.Context, reqEditors ...RequestEditorFn) (*GetServiceStatsResponse, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/internal/stats", nil)
+	if err != nil {
+		return nil, err
+	}
+	res, payloadRes, err := c.do(ctx, req, reqEditors)
+	if err != nil {
+		return nil, err
+	}
+	response := &GetServiceStatsResponse{HTTPResponse: res, Body: payloadRes}
+	mediaType := responseMediaType(res)
+	switch {
+	case res.StatusCode == 200 && mediaType == "application/json":
+		var dest Stats
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+	case mediaType == "application/problem+json":
+		var dest Problem
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.Problem = &dest
+	}
+	return response, nil
+}
+
+// ExportAllURLsParams - параметры запроса ExportAllURLs.
+type ExportAllURLsParams struct {
+	// Format - формат выгрузки и загрузки, по умолчанию csv.
+	Format *string
+}
+
+// ExportAllURLsResponse - ответ на запрос ExportAllURLs.
+type ExportAllURLsResponse struct {
+	// HTTPResponse - http ответ, тело уже прочитано в Body.
+	HTTPResponse *http.Response
+	// Body - тело ответа.
+	Body []byte
+	// Problem - описание ошибки, если сервис вернул ответ application/problem+json.
+	Problem *Problem
+}
+
+// StatusCode - метод получения http статуса ответа.
+func (r *ExportAllURLsResponse) StatusCode() int {
+	if r.HTTPResponse == nil {
+		return 0
+	}
+	return r.HTTPResponse.StatusCode
+}
+
+// ExportAllURLs - выгрузка всех url сервиса.
+// Операция ExportAllURLs: GET /api/internal/urls/export.
+func (c *Client) ExportAllURLs(ctx context.Context, params *ExportAllURLsParams, reqEditors ...RequestEditorFn) (*ExportAllURLsResponse, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/internal/urls/export", nil)
+	if err != nil {
+		return nil, err
+	}
+	if params != nil {
+		query := req.URL.Query()
+		if params.Format != nil {
+			query.Set("format", *params.Format)
+		}
+		req.URL.RawQuery = query.Encode()
+	}
+	res, payloadRes, err := c.do(ctx, req, reqEditors)
+	if err != nil {
+		return nil, err
+	}
+	response := &ExportAllURLsResponse{HTTPResponse: res, Body: payloadRes}
+	mediaType := responseMediaType(res)
+	switch {
+	case mediaType == "application/problem+json":
+		var dest Problem
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.Problem = &dest
+	}
+	return response, nil
+}
+
+// ImportAllURLsParams - параметры запроса ImportAllURLs.
+type ImportAllURLsParams struct {
+	// Format - формат выгрузки и загрузки, по умолчанию csv.
+	Format *string
+}
+
+// ImportAllURLsResponse - ответ на запрос ImportAllURLs.
+type ImportAllURLsResponse struct {
+	// HTTPResponse - http ответ, тело уже прочитано в Body.
+	HTTPResponse *http.Response
+	// Body - тело ответа.
+	Body []byte
+	// JSON200 - разобранное тело ответа со статусом 200.
+	JSON200 *ImportReport
+	// Problem - описание ошибки, если сервис вернул ответ application/problem+json.
+	Problem *Problem
+}
+
+// StatusCode - метод получения http статуса ответа.
+func (r *ImportAllURLsResponse) StatusCode() int {
+	if r.HTTPResponse == nil {
+		return 0
+	}
+	return r.HTTPResponse.StatusCode
+}
+
+// ImportAllURLs - загрузка url из полной выгрузки.
+// Операция ImportAllURLs: POST /api/internal/urls/import.
+func (c *Client) ImportAllURLs(ctx context.Context, params *ImportAllURLsParams, body string, reqEditors ...RequestEditorFn) (*ImportAllURLsResponse, error) {
+	payload := []byte(body)
+	req, err := c.newRequest(ctx, http.MethodPost, "/api/internal/urls/import", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if params != nil {
+		query := req.URL.Query()
+		if params.Format != nil {
+			query.Set("format", *params.Format)
+		}
+		req.URL.RawQuery = query.Encode()
+	}
+	res, payloadRes, err := c.do(ctx, req, reqEditors)
+	if err != nil {
+		return nil, err
+	}
+	response := &ImportAllURLsResponse{HTTPResponse: res, Body: payloadRes}
+	mediaType := responseMediaType(res)
+	switch {
+	case res.StatusCode == 200 && mediaType == "application/json":
+		var dest ImportReport
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+	case mediaType == "application/problem+json":
+		var dest Problem
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.Problem = &dest
+	}
+	return response, nil
+}
+
+// GetOpenAPISpecResponse - ответ на запрос GetOpenAPISpec.
+type GetOpenAPISpecResponse struct {
+	// HTTPResponse - http ответ, тело уже прочитано в Body.
+	HTTPResponse *http.Response
+	// Body - тело ответа.
+	Body []byte
+	// JSON200 - разобранное тело ответа со статусом 200.
+	JSON200 *map[string]any
+}
+
+// StatusCode - метод получения http статуса ответа.
+func (r *GetOpenAPISpecResponse) StatusCode() int {
+	if r.HTTPResponse == nil {
+		return 0
+	}
+	return r.HTTPResponse.StatusCode
+}
+
+// GetOpenAPISpec - спецификация OpenAPI сервиса.
+// Операция GetOpenAPISpec: GET /api/openapi.json.
+func (c *Client) GetOpenAPISpec(ctx context.Context, reqEditors ...RequestEditorFn) (*GetOpenAPISpecResponse, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/openapi.json", nil)
+	if err != nil {
+		return nil, err
+	}
+	res, payloadRes, err := c.do(ctx, req, reqEditors)
+	if err != nil {
+		return nil, err
+	}
+	response := &GetOpenAPISpecResponse{HTTPResponse: res, Body: payloadRes}
+	mediaType := responseMediaType(res)
+	switch {
+	case res.StatusCode == 200 && mediaType == "application/json":
+		var dest map[string]any
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+	}
+	return response, nil
+}
+
+// ShortenerJSONURLParams - параметры запроса ShortenerJSONURL.
+type ShortenerJSONURLParams struct {
+	// IdempotencyKey - ключ идемпотентности: повтор запроса с тем же ключом возвращает сохраненный ответ.
+	IdempotencyKey *string
+}
+
+// ShortenerJSONURLResponse - ответ на запрос ShortenerJSONURL.
+type ShortenerJSONURLResponse struct {
+	// HTTPResponse - http ответ, тело уже прочитано в Body.
+	HTTPResponse *http.Response
+	// Body - тело ответа.
+	Body []byte
+	// JSON201 - разобранное тело ответа со статусом 201.
+	JSON201 *ShortenResponse
+	// JSON409 - разобранное тело ответа со статусом 409.
+	JSON409 *ShortenResponse
+	// Problem - описание ошибки, если сервис вернул ответ application/problem+json.
+	Problem *Problem
+}
+
+// StatusCode - метод получения http статуса ответа.
+func (r *ShortenerJSONURLResponse) StatusCode() int {
+	if r.HTTPResponse == nil {
+		return 0
+	}
+	return r.HTTPResponse.StatusCode
+}
+
+// ShortenerJSONURL - сокращение url, переданного в теле запроса в формате json.
+// Операция ShortenerJSONURLHandler: POST /api/shorten.
+func (c *Client) ShortenerJSONURL(ctx context.Context, params *ShortenerJSONURLParams, body ShortenRequest, reqEditors ...RequestEditorFn) (*ShortenerJSONURLResponse, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newRequest(ctx, http.MethodPost, "/api/shorten", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if params != nil {
+		if params.IdempotencyKey != nil {
+			req.Header.Set("Idempotency-Key", *params.IdempotencyKey)
+		}
+	}
+	res, payloadRes, err := c.do(ctx, req, reqEditors)
+	if err != nil {
+		return nil, err
+	}
+	response := &ShortenerJSONURLResponse{HTTPResponse: res, Body: payloadRes}
+	mediaType := responseMediaType(res)
+	switch {
+	case res.StatusCode == 201 && mediaType == "application/json":
+		var dest ShortenResponse
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON201 = &dest
+	case res.StatusCode == 409 && mediaType == "application/json":
+		var dest ShortenResponse
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON409 = &dest
+	case mediaType == "application/problem+json":
+		var dest Problem
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.Problem = &dest
+	}
+	return response, nil
+}
+
+// InsertBatchParams - параметры запроса InsertBatch.
+type InsertBatchParams struct {
+	// IdempotencyKey - ключ идемпотентности: повтор запроса с тем же ключом возвращает сохраненный ответ.
+	IdempotencyKey *string
+}
+
+// InsertBatchResponse - ответ на запрос InsertBatch.
+type InsertBatchResponse struct {
+	// HTTPResponse - http ответ, тело уже прочитано в Body.
+	HTTPResponse *http.Response
+	// Body - тело ответа.
+	Body []byte
+	// JSON201 - разобранное тело ответа со статусом 201.
+	JSON201 *[]BatchResult
+	// Problem - описание ошибки, если сервис вернул ответ application/problem+json.
+	Problem *Problem
+}
+
+// StatusCode - метод получения http статуса ответа.
+func (r *InsertBatchResponse) StatusCode() int {
+	if r.HTTPResponse == nil {
+		return 0
+	}
+	return r.HTTPResponse.StatusCode
+}
+
+// InsertBatch - сокращение нескольких url одним запросом.
+// Операция InsertBatchHandler: POST /api/shorten/batch.
+func (c *Client) InsertBatch(ctx context.Context, params *InsertBatchParams, body []BatchItem, reqEditors ...RequestEditorFn) (*InsertBatchResponse, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newRequest(ctx, http.MethodPost, "/api/shorten/batch", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if params != nil {
+		if params.IdempotencyKey != nil {
+			req.Header.Set("Idempotency-Key", *params.IdempotencyKey)
+		}
+	}
+	res, payloadRes, err := c.do(ctx, req, reqEditors)
+	if err != nil {
+		return nil, err
+	}
+	response := &InsertBatchResponse{HTTPResponse: res, Body: payloadRes}
+	mediaType := responseMediaType(res)
+	switch {
+	case res.StatusCode == 201 && mediaType == "application/json":
+		var dest []BatchResult
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON201 = &dest
+	case mediaType == "application/problem+json":
+		var dest Problem
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.Problem = &dest
+	}
+	return response, nil
+}
+
+// GetUserQuotaResponse - ответ на запрос GetUserQuota.
+type GetUserQuotaResponse struct {
+	// HTTPResponse - http ответ, тело уже прочитано в Body.
+	HTTPResponse *http.Response
+	// Body - тело ответа.
+	Body []byte
+	// JSON200 - разобранное тело ответа со статусом 200.
+	JSON200 *Quota
+	// Problem - описание ошибки, если сервис вернул ответ application/problem+json.
+	Problem *Problem
+}
+
+// StatusCode - метод получения http статуса ответа.
+func (r *GetUserQuotaResponse) StatusCode() int {
+	if r.HTTPResponse == nil {
+		return 0
+	}
+	return r.HTTPResponse.StatusCode
+}
+
+// GetUserQuota - текущее использование квот пользователя.
+// Операция GetUserQuota: GET /api/user/quota.
+func (c *Client) GetUserQuota(ctx context.Context, reqEditors ...RequestEditorFn) (*GetUserQuotaResponse, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/user/quota", nil)
+	if err != nil {
+		return nil, err
+	}
+	res, payloadRes, err := c.do(ctx, req, reqEditors)
+	if err != nil {
+		return nil, err
+	}
+	response := &GetUserQuotaResponse{HTTPResponse: res, Body: payloadRes}
+	mediaType := responseMediaType(res)
+	switch {
+	case res.StatusCode == 200 && mediaType == "application/json":
+		var dest Quota
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+	case mediaType == "application/problem+json":
+		var dest Problem
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.Problem = &dest
+	}
+	return response, nil
+}
+
+// GetAllUrlsResponse - ответ на запрос GetAllUrls.
+type GetAllUrlsResponse struct {
+	// HTTPResponse - http ответ, тело уже прочитано в Body.
+	HTTPResponse *http.Response
+	// Body - тело ответа.
+	Body []byte
+	// JSON200 - разобранное тело ответа со статусом 200.
+	JSON200 *[]UserURL
+	// Problem - описание ошибки, если сервис вернул ответ application/problem+json.
+	Problem *Problem
+}
+
+// StatusCode - метод получения http статуса ответа.
+func (r *GetAllUrlsResponse) StatusCode() int {
+	if r.HTTPResponse == nil {
+		return 0
+	}
+	return r.HTTPResponse.StatusCode
+}
+
+// GetAllUrls - список url, сокращенных пользователем.
+// Операция GetAllUrls: GET /api/user/urls.
+func (c *Client) GetAllUrls(ctx context.Context, reqEditors ...RequestEditorFn) (*GetAllUrlsResponse, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/user/urls", nil)
+	if err != nil {
+		return nil, err
+	}
+	res, payloadRes, err := c.do(ctx, req, reqEditors)
+	if err != nil {
+		return nil, err
+	}
+	response := &GetAllUrlsResponse{HTTPResponse: res, Body: payloadRes}
+	mediaType := responseMediaType(res)
+	switch {
+	case res.StatusCode == 200 && mediaType == "application/json":
+		var dest []UserURL
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+	case mediaType == "application/problem+json":
+		var dest Problem
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.Problem = &dest
+	}
+	return response, nil
+}
+
+// DeleteURLResponse - ответ на запрос DeleteURL.
+type DeleteURLResponse struct {
+	// HTTPResponse - http ответ, тело уже прочитано в Body.
+	HTTPResponse *http.Response
+	// Body - тело ответа.
+	Body []byte
+	// Problem - описание ошибки, если сервис вернул ответ application/problem+json.
+	Problem *Problem
+}
+
+// StatusCode - метод получения http статуса ответа.
+func (r *DeleteURLResponse) StatusCode() int {
+	if r.HTTPResponse == nil {
+		return 0
+	}
+	return r.HTTPResponse.StatusCode
+}
+
+// DeleteURL - удаление сокращенных пользователем url.
+// Операция DeleteURLHandler: DELETE /api/user/urls.
+func (c *Client) DeleteURL(ctx context.Context, body []string, reqEditors ...RequestEditorFn) (*DeleteURLResponse, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newRequest(ctx, http.MethodDelete, "/api/user/urls", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, payloadRes, err := c.do(ctx, req, reqEditors)
+	if err != nil {
+		return nil, err
+	}
+	response := &DeleteURLResponse{HTTPResponse: res, Body: payloadRes}
+	mediaType := responseMediaType(res)
+	switch {
+	case mediaType == "application/problem+json":
+		var dest Problem
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.Problem = &dest
+	}
+	return response, nil
+}
+
+// ExportUserURLsParams - параметры запроса ExportUserURLs.
+type ExportUserURLsParams struct {
+	// Format - формат выгрузки и загрузки, по умолчанию csv.
+	Format *string
+}
+
+// ExportUserURLsResponse - ответ на запрос ExportUserURLs.
+type ExportUserURLsResponse struct {
+	// HTTPResponse - http ответ, тело уже прочитано в Body.
+	HTTPResponse *http.Response
+	// Body - тело ответа.
+	Body []byte
+	// Problem - описание ошибки, если сервис вернул ответ application/problem+json.
+	Problem *Problem
+}
+
+// StatusCode - метод получения http статуса ответа.
+func (r *ExportUserURLsResponse) StatusCode() int {
+	if r.HTTPResponse == nil {
+		return 0
+	}
+	return r.HTTPResponse.StatusCode
+}
+
+// ExportUserURLs - выгрузка url пользователя.
+// Операция ExportUserURLs: GET /api/user/urls/export.
+func (c *Client) ExportUserURLs(ctx context.Context, params *ExportUserURLsParams, reqEditors ...RequestEditorFn) (*ExportUserURLsResponse, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/user/urls/export", nil)
+	if err != nil {
+		return nil, err
+	}
+	if params != nil {
+		query := req.URL.Query()
+		if params.Format != nil {
+			query.Set("format", *params.Format)
+		}
+		req.URL.RawQuery = query.Encode()
+	}
+	res, payloadRes, err := c.do(ctx, req, reqEditors)
+	if err != nil {
+		return nil, err
+	}
+	response := &ExportUserURLsResponse{HTTPResponse: res, Body: payloadRes}
+	mediaType := responseMediaType(res)
+	switch {
+	case mediaType == "application/problem+json":
+		var dest Problem
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.Problem = &dest
+	}
+	return response, nil
+}
+
+// ImportUserURLsParams - параметры запроса ImportUserURLs.
+type ImportUserURLsParams struct {
+	// Format - формат выгрузки и загрузки, по умолчанию csv.
+	Format *string
+}
+
+// ImportUserURLsResponse - ответ на запрос ImportUserURLs.
+type ImportUserURLsResponse struct {
+	// HTTPResponse - http ответ, тело уже прочитано в Body.
+	HTTPResponse *http.Response
+	// Body - тело ответа.
+	Body []byte
+	// JSON200 - разобранное тело ответа со статусом 200.
+	JSON200 *ImportReport
+	// Problem - описание ошибки, если сервис вернул ответ application/problem+json.
+	Problem *Problem
+}
+
+// StatusCode - метод получения http статуса ответа.
+func (r *ImportUserURLsResponse) StatusCode() int {
+	if r.HTTPResponse == nil {
+		return 0
+	}
+	return r.HTTPResponse.StatusCode
+}
+
+// ImportUserURLs - загрузка url пользователя из выгрузки.
+// Операция ImportUserURLs: POST /api/user/urls/import.
+func (c *Client) ImportUserURLs(ctx context.Context, params *ImportUserURLsParams, body string, reqEditors ...RequestEditorFn) (*ImportUserURLsResponse, error) {
+	payload := []byte(body)
+	req, err := c.newRequest(ctx, http.MethodPost, "/api/user/urls/import", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if params != nil {
+		query := req.URL.Query()
+		if params.Format != nil {
+			query.Set("format", *params.Format)
+		}
+		req.URL.RawQuery = query.Encode()
+	}
+	res, payloadRes, err := c.do(ctx, req, reqEditors)
+	if err != nil {
+		return nil, err
+	}
+	response := &ImportUserURLsResponse{HTTPResponse: res, Body: payloadRes}
+	mediaType := responseMediaType(res)
+	switch {
+	case res.StatusCode == 200 && mediaType == "application/json":
+		var dest ImportReport
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+	case mediaType == "application/problem+json":
+		var dest Problem
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.Problem = &dest
+	}
+	return response, nil
+}
+
+// RestoreURLResponse - ответ на запрос RestoreURL.
+type RestoreURLResponse struct {
+	// HTTPResponse - http ответ, тело уже прочитано в Body.
+	HTTPResponse *http.Response
+	// Body - тело ответа.
+	Body []byte
+	// JSON200 - разобранное тело ответа со статусом 200.
+	JSON200 *[]string
+	// Problem - описание ошибки, если сервис вернул ответ application/problem+json.
+	Problem *Problem
+}
+
+// StatusCode - метод получения http статуса ответа.
+func (r *RestoreURLResponse) StatusCode() int {
+	if r.HTTPResponse == nil {
+		return 0
+	}
+	return r.HTTPResponse.StatusCode
+}
+
+// RestoreURL - восстановление удаленных пользователем url.
+// Операция RestoreURLHandler: POST /api/user/urls/restore.
+func (c *Client) RestoreURL(ctx context.Context, body []string, reqEditors ...RequestEditorFn) (*RestoreURLResponse, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newRequest(ctx, http.MethodPost, "/api/user/urls/restore", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, payloadRes, err := c.do(ctx, req, reqEditors)
+	if err != nil {
+		return nil, err
+	}
+	response := &RestoreURLResponse{HTTPResponse: res, Body: payloadRes}
+	mediaType := responseMediaType(res)
+	switch {
+	case res.StatusCode == 200 && mediaType == "application/json":
+		var dest []string
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+	case mediaType == "application/problem+json":
+		var dest Problem
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.Problem = &dest
+	}
+	return response, nil
+}
+
+// CheckDBConnectionResponse - ответ на запрос CheckDBConnection.
+type CheckDBConnectionResponse struct {
+	// HTTPResponse - http ответ, тело уже прочитано в Body.
+	HTTPResponse *http.Response
+	// Body - тело ответа.
+	Body []byte
+	// Problem - описание ошибки, если сервис вернул ответ application/problem+json.
+	Problem *Problem
+}
+
+// StatusCode - метод получения http статуса ответа.
+func (r *CheckDBConnectionResponse) StatusCode() int {
+	if r.HTTPResponse == nil {
+		return 0
+	}
+	return r.HTTPResponse.StatusCode
+}
+
+// CheckDBConnection - проверка подключения к хранилищу.
+// Операция CheckDBConnectionHandler: GET /ping.
+func (c *Client) CheckDBConnection(ctx context.Context, reqEditors ...RequestEditorFn) (*CheckDBConnectionResponse, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/ping", nil)
+	if err != nil {
+		return nil, err
+	}
+	res, payloadRes, err := c.do(ctx, req, reqEditors)
+	if err != nil {
+		return nil, err
+	}
+	response := &CheckDBConnectionResponse{HTTPResponse: res, Body: payloadRes}
+	mediaType := responseMediaType(res)
+	switch {
+	case mediaType == "application/problem+json":
+		var dest Problem
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.Problem = &dest
+	}
+	return response, nil
+}
+
+// GetOriginalURLResponse - ответ на запрос GetOriginalURL.
+type GetOriginalURLResponse struct {
+	// HTTPResponse - http ответ, тело уже прочитано в Body.
+	HTTPResponse *http.Response
+	// Body - тело ответа.
+	Body []byte
+	// Problem - описание ошибки, если сервис вернул ответ application/problem+json.
+	Problem *Problem
+}
+
+// StatusCode - метод получения http статуса ответа.
+func (r *GetOriginalURLResponse) StatusCode() int {
+	if r.HTTPResponse == nil {
+		return 0
+	}
+	return r.HTTPResponse.StatusCode
+}
+
+// GetOriginalURL - переход на оригинальный url по сокращенной ссылке.
+// Операция GetOriginalURLHandler: GET /{id}.
+func (c *Client) GetOriginalURL(ctx context.Context, id string, reqEditors ...RequestEditorFn) (*GetOriginalURLResponse, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, payloadRes, err := c.do(ctx, req, reqEditors)
+	if err != nil {
+		return nil, err
+	}
+	response := &GetOriginalURLResponse{HTTPResponse: res, Body: payloadRes}
+	mediaType := responseMediaType(res)
+	switch {
+	case mediaType == "application/problem+json":
+		var dest Problem
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.Problem = &dest
+	}
+	return response, nil
+}
+
+// UnlockURLResponse - ответ на запрос UnlockURL.
+type UnlockURLResponse struct {
+	// HTTPResponse - http ответ, тело уже прочитано в Body.
+	HTTPResponse *http.Response
+	// Body - тело ответа.
+	Body []byte
+	// Problem - описание ошибки, если сервис вернул ответ application/problem+json.
+	Problem *Problem
+}
+
+// StatusCode - метод получения http статуса ответа.
+func (r *UnlockURLResponse) StatusCode() int {
+	if r.HTTPResponse == nil {
+		return 0
+	}
+	return r.HTTPResponse.StatusCode
+}
+
+// UnlockURL - переход по сокращенной ссылке, защищенной паролем.
+// Операция UnlockURLHandler: POST /{id}.
+func (c *Client) UnlockURL(ctx context.Context, id string, body string, reqEditors ...RequestEditorFn) (*UnlockURLResponse, error) {
+	payload := []byte(body)
+	req, err := c.newRequest(ctx, http.MethodPost, "/"+url.PathEscape(id), bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res, payloadRes, err := c.do(ctx, req, reqEditors)
+	if err != nil {
+		return nil, err
+	}
+	response := &UnlockURLResponse{HTTPResponse: res, Body: payloadRes}
+	mediaType := responseMediaType(res)
+	switch {
+	case mediaType == "application/problem+json":
+		var dest Problem
+		if err := json.Unmarshal(payloadRes, &dest); err != nil {
+			return nil, err
+		}
+		response.Problem = &dest
+	}
+	return response, nil
+}
+
+// newRequest - метод создания запроса к пути path сервиса.
+func (c *Client) newRequest(ctx context.Context, method string, path string, body io.Reader) (*http.Request, error) {
+	target, err := url.Parse(c.Server + path)
+	if err != nil {
+		return nil, err
+	}
+	return http.NewRequestWithContext(ctx, method, target.String(), body)
+}
+
+// do - метод применения функций изменения запроса, отправки запроса и чтения тела ответа.
+func (c *Client) do(ctx context.Context, req *http.Request, reqEditors []RequestEditorFn) (*http.Response, []byte, error) {
+	for _, editors := range [][]RequestEditorFn{c.RequestEditors, reqEditors} {
+		for _, edit := range editors {
+			if err := edit(ctx, req); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, body, nil
+}
+
+// responseMediaType - функция получения типа тела ответа без параметров.
+func responseMediaType(res *http.Response) string {
+	mediaType, _, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
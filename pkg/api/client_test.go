@@ -0,0 +1,137 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dorrrke/shortener-url/internal/config"
+	"github.com/Dorrrke/shortener-url/internal/server"
+	"github.com/Dorrrke/shortener-url/internal/service"
+	"github.com/Dorrrke/shortener-url/internal/storage"
+	"github.com/Dorrrke/shortener-url/pkg/api"
+)
+
+// newTestServer - функция запуска сервиса с хранилищем в памяти и маршрутами как в cmd/shortener.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	cfg := config.AppConfig{}
+	sService := service.NewService(&storage.MemStorage{URLMap: make(map[string]string)}, &cfg)
+	serv := server.New(sService)
+
+	r := chi.NewRouter()
+	r.Post("/", serv.Validated(serv.Idempotent(serv.ShortenerURLHandler)))
+	r.Get("/{id}", serv.Validated(serv.GetOriginalURLHandler))
+	r.Route("/api", func(r chi.Router) {
+		r.Get("/user/urls", serv.Validated(serv.GetAllUrls))
+		r.Get("/openapi.json", serv.GetOpenAPISpec)
+		r.Post("/shorten", serv.Validated(serv.Idempotent(serv.ShortenerJSONURLHandler)))
+		r.Post("/shorten/batch", serv.Validated(serv.Idempotent(serv.InsertBatchHandler)))
+	})
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestClient(t *testing.T, srv *httptest.Server) *api.Client {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	client, err := api.NewClient(srv.URL, api.WithHTTPClient(&http.Client{
+		Jar: jar,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}))
+	require.NoError(t, err)
+	return client
+}
+
+func TestClient(t *testing.T) {
+	srv := newTestServer(t)
+	client := newTestClient(t, srv)
+	ctx := context.Background()
+
+	created, err := client.ShortenerJSONURL(ctx, nil, api.ShortenRequest{URL: "https://go.dev/"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, created.StatusCode(), string(created.Body))
+	require.NotNil(t, created.JSON201)
+	short := created.JSON201.Result
+
+	conflict, err := client.ShortenerJSONURL(ctx, nil, api.ShortenRequest{URL: "https://go.dev/"})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, conflict.StatusCode())
+	require.NotNil(t, conflict.JSON409)
+	assert.Equal(t, short, conflict.JSON409.Result)
+
+	key := "batch-1"
+	batch, err := client.InsertBatch(ctx, &api.InsertBatchParams{IdempotencyKey: &key}, []api.BatchItem{
+		{CorrelationID: "a", OriginalURL: "https://github.com/"},
+		{CorrelationID: "b", OriginalURL: "https://example.com/"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, batch.StatusCode(), string(batch.Body))
+	require.NotNil(t, batch.JSON201)
+	assert.Len(t, *batch.JSON201, 2)
+
+	id := short[strings.LastIndex(short, "/")+1:]
+	redirect, err := client.GetOriginalURL(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTemporaryRedirect, redirect.StatusCode())
+	assert.Equal(t, "https://go.dev/", redirect.HTTPResponse.Header.Get("Location"))
+
+	spec, err := client.GetOpenAPISpec(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, spec.JSON200)
+	assert.Equal(t, "3.0.3", (*spec.JSON200)["openapi"])
+}
+
+func TestClientProblem(t *testing.T) {
+	srv := newTestServer(t)
+	client := newTestClient(t, srv)
+	ctx := context.Background()
+
+	invalid, err := client.InsertBatch(ctx, nil, []api.BatchItem{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, invalid.StatusCode())
+	require.NotNil(t, invalid.Problem)
+	assert.Equal(t, "invalid_request", invalid.Problem.Code)
+	assert.Nil(t, invalid.JSON201)
+
+	missing, err := client.GetOriginalURL(ctx, "missing")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, missing.StatusCode())
+	require.NotNil(t, missing.Problem)
+	assert.Equal(t, "url_not_found", missing.Problem.Code)
+
+	unauthorized, err := client.GetAllUrls(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, unauthorized.StatusCode())
+	require.NotNil(t, unauthorized.Problem)
+}
+
+func TestClientRequestEditor(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		got = req.Header.Get("X-Request-ID")
+		res.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL+"/", api.WithRequestEditorFn(func(ctx context.Context, req *http.Request) error {
+		req.Header.Set("X-Request-ID", "client-test")
+		return nil
+	}))
+	require.NoError(t, err)
+	res, err := client.CheckDBConnection(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+	assert.Equal(t, "client-test", got)
+}
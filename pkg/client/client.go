@@ -0,0 +1,136 @@
+// Пакет client - Go SDK сервиса сокращения url с REST и gRPC реализациями одного интерфейса Client.
+// Клиент хранит токен пользователя между запросами, повторяет запросы при временных ошибках с экспоненциальной паузой,
+// разбивает большие списки url на пачки и возвращает типизированные ошибки, например ErrConflict и ErrGone.
+//
+//	c, err := client.NewREST("http://localhost:8080", client.Options{TokenStore: client.NewFileTokenStore("token")})
+//	short, err := c.Shorten(ctx, "https://go.dev/")
+//	if errors.Is(err, client.ErrConflict) {
+//		// url уже сокращен, short - существующий сокращенный url
+//	}
+package client
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Dorrrke/shortener-url/pkg/api"
+)
+
+// DefaultBatchSize - размер пачки url по умолчанию при сокращении списка.
+const DefaultBatchSize = 100
+
+// Значения по умолчанию для повторов запросов.
+const (
+	DefaultMaxRetries = 3
+	DefaultMinBackoff = 100 * time.Millisecond
+	DefaultMaxBackoff = 2 * time.Second
+)
+
+// BatchItem - url для сокращения в пачке.
+type BatchItem = api.BatchItem
+
+// BatchResult - сокращенный url из пачки.
+type BatchResult = api.BatchResult
+
+// URL - url, сокращенный пользователем.
+type URL = api.UserURL
+
+// Stats - статистика сервиса.
+type Stats = api.Stats
+
+// Client - клиент сервиса сокращения url. Реализуется RESTClient и GRPCClient.
+type Client interface {
+	// Shorten - метод сокращения url. Если url уже сокращен, возвращается существующий сокращенный url вместе с ошибкой ErrConflict.
+	Shorten(ctx context.Context, originalURL string) (string, error)
+	// ShortenBatch - метод сокращения списка url. Список отправляется пачками по Options.BatchSize,
+	// при ошибке возвращаются результаты уже сохраненных пачек и ошибка.
+	ShortenBatch(ctx context.Context, items []BatchItem) ([]BatchResult, error)
+	// Expand - метод получения оригинального url по сокращенному url или его id.
+	// Для удаленной ссылки возвращается ErrGone, для заблокированной - ErrBlocked.
+	Expand(ctx context.Context, short string) (string, error)
+	// UserURLs - метод получения url, сокращенных пользователем. Если url нет, возвращается пустой список.
+	UserURLs(ctx context.Context) ([]URL, error)
+	// Delete - метод удаления url пользователя по id или сокращенным url. Удаление выполняется сервисом асинхронно.
+	Delete(ctx context.Context, shorts []string) error
+	// Restore - метод восстановления удаленных url пользователя по id или сокращенным url, возвращает восстановленные сокращенные url.
+	// gRPC API восстановление не поддерживает, GRPCClient возвращает ErrUnsupported.
+	Restore(ctx context.Context, shorts []string) ([]string, error)
+	// Stats - метод получения статистики сервиса, доступен только из доверенной подсети.
+	Stats(ctx context.Context) (Stats, error)
+	// Ping - метод проверки доступности хранилища сервиса.
+	Ping(ctx context.Context) error
+	// Token - метод получения токена пользователя, пустой до первого запроса, выдающего токен.
+	Token() string
+	// Close - метод освобождения ресурсов клиента.
+	Close() error
+}
+
+// Options - настройки клиента. Нулевые значения заменяются значениями по умолчанию.
+type Options struct {
+	// Token - токен пользователя. Если не задан, берется из TokenStore или выдается сервисом при первом сокращении.
+	Token string
+	// TokenStore - хранилище токена между запусками, по умолчанию токен хранится только в памяти.
+	TokenStore TokenStore
+	// MaxRetries - количество повторов запроса при временных ошибках, отрицательное значение отключает повторы.
+	MaxRetries int
+	// MinBackoff и MaxBackoff - пауза перед первым повтором и максимальная пауза, пауза удваивается с каждым повтором.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// BatchSize - размер пачки url для ShortenBatch, должен не превышать max_batch_size сервиса.
+	BatchSize int
+}
+
+// withDefaults - метод получения настроек с подставленными значениями по умолчанию.
+func (o Options) withDefaults() Options {
+	if o.TokenStore == nil {
+		o.TokenStore = &MemoryTokenStore{}
+	}
+	if o.MaxRetries == 0 {
+		o.MaxRetries = DefaultMaxRetries
+	}
+	if o.MaxRetries < 0 {
+		o.MaxRetries = 0
+	}
+	if o.MinBackoff <= 0 {
+		o.MinBackoff = DefaultMinBackoff
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = DefaultMaxBackoff
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultBatchSize
+	}
+	return o
+}
+
+// shortID - функция получения id из сокращенного url, id передается как есть.
+func shortID(short string) string {
+	short = strings.TrimSuffix(short, "/")
+	if i := strings.LastIndex(short, "/"); i >= 0 {
+		return short[i+1:]
+	}
+	return short
+}
+
+// shortIDs - функция получения id из списка сокращенных url.
+func shortIDs(shorts []string) []string {
+	ids := make([]string, 0, len(shorts))
+	for _, short := range shorts {
+		ids = append(ids, shortID(short))
+	}
+	return ids
+}
+
+// chunks - функция разбиения списка на пачки не больше size элементов.
+func chunks(items []BatchItem, size int) [][]BatchItem {
+	var result [][]BatchItem
+	for len(items) > size {
+		result = append(result, items[:size])
+		items = items[size:]
+	}
+	if len(items) > 0 {
+		result = append(result, items)
+	}
+	return result
+}
@@ -0,0 +1,258 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/Dorrrke/shortener-url/internal/config"
+	grpcserver "github.com/Dorrrke/shortener-url/internal/grpc"
+	"github.com/Dorrrke/shortener-url/internal/server"
+	"github.com/Dorrrke/shortener-url/internal/service"
+	"github.com/Dorrrke/shortener-url/internal/storage"
+	"github.com/Dorrrke/shortener-url/pkg/client"
+)
+
+// newService - функция создания сервиса с хранилищем в памяти.
+func newService(t *testing.T) *service.ShortenerService {
+	t.Helper()
+	cfg := config.AppConfig{ServerAddress: "localhost:8080"}
+	sService := service.NewService(&storage.MemStorage{URLMap: make(map[string]string)}, &cfg)
+	t.Cleanup(func() {
+		sService.Close(context.Background())
+	})
+	return sService
+}
+
+// newRESTServer - функция запуска REST API сервиса с маршрутами как в cmd/shortener.
+// wrap позволяет подменить ответы сервиса, например для проверки повторов.
+func newRESTServer(t *testing.T, sService *service.ShortenerService, wrap func(http.Handler) http.Handler) *httptest.Server {
+	t.Helper()
+	serv := server.New(sService)
+
+	r := chi.NewRouter()
+	r.Post("/", serv.Validated(serv.Idempotent(serv.ShortenerURLHandler)))
+	r.Get("/ping", serv.CheckDBConnectionHandler)
+	r.Get("/{id}", serv.Validated(serv.GetOriginalURLHandler))
+	r.Route("/api", func(r chi.Router) {
+		r.Get("/user/urls", serv.Validated(serv.GetAllUrls))
+		r.Delete("/user/urls", serv.Validated(serv.DeleteURLHandler))
+		r.Post("/user/urls/restore", serv.Validated(serv.RestoreURLHandler))
+		r.Get("/internal/stats", serv.GetServiceStats)
+		r.Post("/shorten", serv.Validated(serv.Idempotent(serv.ShortenerJSONURLHandler)))
+		r.Post("/shorten/batch", serv.Validated(serv.Idempotent(serv.InsertBatchHandler)))
+	})
+	var h http.Handler = r
+	if wrap != nil {
+		h = wrap(r)
+	}
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newGRPCConn - функция запуска gRPC API сервиса в памяти процесса и подключения к нему.
+func newGRPCConn(t *testing.T, sService *service.ShortenerService) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	grpcserver.RegisterGrpcService(srv, sService)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// transports - фабрики клиентов обоих транспортов поверх одного сервиса.
+func transports(t *testing.T) map[string]func(opts client.Options) client.Client {
+	t.Helper()
+	return map[string]func(opts client.Options) client.Client{
+		"rest": func(opts client.Options) client.Client {
+			srv := newRESTServer(t, newService(t), nil)
+			c, err := client.NewREST(srv.URL, nil, opts)
+			require.NoError(t, err)
+			return c
+		},
+		"grpc": func(opts client.Options) client.Client {
+			c, err := client.NewGRPCFromConn(newGRPCConn(t, newService(t)), opts)
+			require.NoError(t, err)
+			return c
+		},
+	}
+}
+
+func TestClient(t *testing.T) {
+	for name, newClient := range transports(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			c := newClient(client.Options{BatchSize: 2, MinBackoff: time.Millisecond})
+			defer c.Close()
+
+			urls, err := c.UserURLs(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, urls)
+
+			short, err := c.Shorten(ctx, "https://go.dev/")
+			require.NoError(t, err)
+			require.NotEmpty(t, short)
+			assert.NotEmpty(t, c.Token())
+
+			again, err := c.Shorten(ctx, "https://go.dev/")
+			require.ErrorIs(t, err, client.ErrConflict)
+			assert.Equal(t, short, again)
+
+			_, err = c.Shorten(ctx, "not a url")
+			assert.ErrorIs(t, err, client.ErrInvalidRequest)
+
+			var items []client.BatchItem
+			for i := 0; i < 5; i++ {
+				items = append(items, client.BatchItem{CorrelationID: fmt.Sprint(i), OriginalURL: fmt.Sprintf("https://example.com/%d", i)})
+			}
+			results, err := c.ShortenBatch(ctx, items)
+			require.NoError(t, err)
+			require.Len(t, results, 5)
+			for i, res := range results {
+				assert.Equal(t, fmt.Sprint(i), res.CorrelationID)
+				original, err := c.Expand(ctx, res.ShortURL)
+				require.NoError(t, err)
+				assert.Equal(t, items[i].OriginalURL, original)
+			}
+
+			urls, err = c.UserURLs(ctx)
+			require.NoError(t, err)
+			assert.Len(t, urls, 6)
+
+			original, err := c.Expand(ctx, short)
+			require.NoError(t, err)
+			assert.Equal(t, "https://go.dev/", original)
+
+			_, err = c.Expand(ctx, "unknown")
+			assert.ErrorIs(t, err, client.ErrNotFound)
+
+			require.NoError(t, c.Delete(ctx, []string{short}))
+			assert.Eventually(t, func() bool {
+				_, err := c.Expand(ctx, short)
+				return errors.Is(err, client.ErrGone)
+			}, time.Second, 10*time.Millisecond)
+
+			restored, err := c.Restore(ctx, []string{short})
+			if name == "grpc" {
+				assert.ErrorIs(t, err, client.ErrUnsupported)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, []string{short}, restored)
+				original, err = c.Expand(ctx, short)
+				require.NoError(t, err)
+				assert.Equal(t, "https://go.dev/", original)
+			}
+
+			_, err = c.Stats(ctx)
+			assert.ErrorIs(t, err, client.ErrForbidden)
+		})
+	}
+}
+
+func TestClientTokenStore(t *testing.T) {
+	for name, newClient := range transports(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			path := filepath.Join(t.TempDir(), "token")
+			c := newClient(client.Options{TokenStore: client.NewFileTokenStore(path)})
+			defer c.Close()
+
+			_, err := c.Shorten(ctx, "https://go.dev/")
+			require.NoError(t, err)
+
+			token, err := client.NewFileTokenStore(path).Load()
+			require.NoError(t, err)
+			assert.Equal(t, c.Token(), token)
+		})
+	}
+}
+
+func TestClientTokenReuse(t *testing.T) {
+	ctx := context.Background()
+	store := &client.MemoryTokenStore{}
+	srv := newRESTServer(t, newService(t), nil)
+
+	first, err := client.NewREST(srv.URL, nil, client.Options{TokenStore: store})
+	require.NoError(t, err)
+	short, err := first.Shorten(ctx, "https://go.dev/")
+	require.NoError(t, err)
+
+	second, err := client.NewREST(srv.URL, nil, client.Options{TokenStore: store})
+	require.NoError(t, err)
+	assert.Equal(t, first.Token(), second.Token())
+	urls, err := second.UserURLs(ctx)
+	require.NoError(t, err)
+	require.Len(t, urls, 1)
+	assert.Equal(t, short, urls[0].ShortURL)
+}
+
+func TestClientRetry(t *testing.T) {
+	ctx := context.Background()
+	var calls atomic.Int32
+	unavailable := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			if calls.Add(1) <= 2 {
+				res.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			next.ServeHTTP(res, req)
+		})
+	}
+
+	t.Run("recovered", func(t *testing.T) {
+		calls.Store(0)
+		srv := newRESTServer(t, newService(t), unavailable)
+		c, err := client.NewREST(srv.URL, nil, client.Options{MinBackoff: time.Millisecond})
+		require.NoError(t, err)
+		short, err := c.Shorten(ctx, "https://go.dev/")
+		require.NoError(t, err)
+		assert.NotEmpty(t, short)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		calls.Store(0)
+		srv := newRESTServer(t, newService(t), unavailable)
+		c, err := client.NewREST(srv.URL, nil, client.Options{MaxRetries: -1})
+		require.NoError(t, err)
+		_, err = c.Shorten(ctx, "https://go.dev/")
+		var serviceErr *client.Error
+		require.ErrorAs(t, err, &serviceErr)
+		assert.Equal(t, http.StatusServiceUnavailable, serviceErr.HTTPStatus)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("not retried", func(t *testing.T) {
+		calls.Store(10)
+		srv := newRESTServer(t, newService(t), unavailable)
+		c, err := client.NewREST(srv.URL, nil, client.Options{MinBackoff: time.Millisecond})
+		require.NoError(t, err)
+		_, err = c.Expand(ctx, "unknown")
+		assert.ErrorIs(t, err, client.ErrNotFound)
+		assert.Equal(t, int32(11), calls.Load())
+	})
+}
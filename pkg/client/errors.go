@@ -0,0 +1,167 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/Dorrrke/shortener-url/pkg/api"
+)
+
+// Ошибки сервиса, с которыми можно сравнить ошибку клиента через errors.Is.
+var (
+	// ErrConflict - url уже сокращен.
+	ErrConflict = errors.New("url already shortened")
+	// ErrGone - сокращенный url удален.
+	ErrGone = errors.New("short url was deleted")
+	// ErrNotFound - сокращенный url не найден.
+	ErrNotFound = errors.New("short url not found")
+	// ErrBlocked - url запрещен блоклистом или проверкой репутации.
+	ErrBlocked = errors.New("url is blocked")
+	// ErrUnauthorized - токен пользователя отсутствует или недействителен.
+	ErrUnauthorized = errors.New("user is not authorized")
+	// ErrForbidden - доступ запрещен, например к статистике не из доверенной подсети.
+	ErrForbidden = errors.New("access is denied")
+	// ErrQuotaExceeded - превышена квота пользователя или размер пачки.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrInvalidRequest - запрос не прошел проверку сервиса.
+	ErrInvalidRequest = errors.New("invalid request")
+	// ErrUnsupported - операция не поддерживается API выбранного транспорта.
+	ErrUnsupported = errors.New("operation is not supported by transport")
+)
+
+// codeErrors - ошибки клиента для машиночитаемых кодов ошибок сервиса.
+var codeErrors = map[string]error{
+	"url_already_exists":     ErrConflict,
+	"url_deleted":            ErrGone,
+	"url_not_found":          ErrNotFound,
+	"url_blocked":            ErrBlocked,
+	"unauthorized":           ErrUnauthorized,
+	"access_denied":          ErrForbidden,
+	"quota_exceeded":         ErrQuotaExceeded,
+	"batch_too_large":        ErrQuotaExceeded,
+	"invalid_request":        ErrInvalidRequest,
+	"invalid_url":            ErrInvalidRequest,
+	"idempotency_key_reused": ErrInvalidRequest,
+}
+
+// codeURLExists, codeURLBlocked и codeInProgress - коды ошибок сервиса, которые клиент обрабатывает отдельно.
+const (
+	codeURLExists  = "url_already_exists"
+	codeURLBlocked = "url_blocked"
+	codeInProgress = "idempotency_key_in_progress"
+)
+
+// Error - ошибка, полученная от сервиса.
+type Error struct {
+	// Code - машиночитаемый код ошибки сервиса, например url_not_found. Пустой, если сервис не вернул код.
+	Code string
+	// Message - описание ошибки.
+	Message string
+	// Field - поле запроса, не прошедшее проверку.
+	Field string
+	// HTTPStatus - http статус ответа REST клиента.
+	HTTPStatus int
+	// GRPCCode - код ответа gRPC клиента.
+	GRPCCode codes.Code
+	// RetryAfter - пауза перед повтором, которую попросил сервис.
+	RetryAfter time.Duration
+}
+
+// Error - текст ошибки.
+func (e *Error) Error() string {
+	text := e.Message
+	if text == "" {
+		text = "request failed"
+	}
+	if e.Code != "" {
+		text = e.Code + ": " + text
+	}
+	if e.Field != "" {
+		text += " (" + e.Field + ")"
+	}
+	if e.HTTPStatus != 0 {
+		return fmt.Sprintf("shortener: %s [http %d]", text, e.HTTPStatus)
+	}
+	return fmt.Sprintf("shortener: %s [grpc %s]", text, e.GRPCCode)
+}
+
+// Is - метод сравнения ошибки с ошибками пакета по коду ошибки сервиса.
+func (e *Error) Is(target error) bool {
+	return codeErrors[e.Code] == target && target != nil
+}
+
+// Temporary - метод проверки, что запрос можно повторить: сервис перегружен, недоступен,
+// ограничил частоту запросов или еще выполняет запрос с тем же ключом идемпотентности.
+func (e *Error) Temporary() bool {
+	if e.Code == codeInProgress {
+		return true
+	}
+	if e.HTTPStatus != 0 {
+		switch e.HTTPStatus {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	switch e.GRPCCode {
+	case codes.Unavailable:
+		return true
+	case codes.ResourceExhausted:
+		_, known := codeErrors[e.Code]
+		return !known
+	}
+	return false
+}
+
+// problemError - функция создания ошибки по ответу REST сервиса.
+func problemError(res *http.Response, problem *api.Problem) *Error {
+	e := &Error{HTTPStatus: res.StatusCode, Message: http.StatusText(res.StatusCode)}
+	if problem != nil {
+		e.Code = problem.Code
+		if problem.Detail != "" {
+			e.Message = problem.Detail
+		}
+		if len(problem.InvalidParams) > 0 {
+			e.Field = problem.InvalidParams[0].Name
+			e.Message += ": " + problem.InvalidParams[0].Reason
+		}
+	}
+	if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil {
+		e.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return e
+}
+
+// statusError - функция создания ошибки по статусу gRPC, код ошибки сервиса берется из google.rpc.ErrorInfo.
+// Ошибки, не являющиеся статусом gRPC, например отмена контекста, возвращаются как есть.
+func statusError(err error, header metadata.MD) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	e := &Error{GRPCCode: st.Code(), Message: st.Message()}
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			e.Code = d.GetReason()
+		case *errdetails.BadRequest:
+			if violations := d.GetFieldViolations(); len(violations) > 0 {
+				e.Field = violations[0].GetField()
+			}
+		}
+	}
+	if values := header.Get("retry-after"); len(values) > 0 {
+		if seconds, err := strconv.Atoi(values[0]); err == nil {
+			e.RetryAfter = time.Duration(seconds) * time.Second
+		}
+	}
+	return e
+}
@@ -0,0 +1,256 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	shortenergrpcv1 "github.com/Dorrrke/shortener-url/internal/grpc/gen/shortenergrpc.v1"
+)
+
+// Ключи метаданных gRPC сервиса.
+const (
+	// authMetadataKey - токен пользователя, передается в запросе и возвращается в заголовке ответа.
+	authMetadataKey = "auth"
+	// idempotencyMetadataKey - ключ идемпотентности запроса.
+	idempotencyMetadataKey = "idempotency-key"
+	// urlExistsMetadataKey - заголовок ответа ShortenerURL, которым сервис помечает уже сокращенный url.
+	urlExistsMetadataKey = "url-already-exists"
+	// codeNoContent - код ошибки, которым GetAllURLs сообщает об отсутствии url пользователя.
+	codeNoContent = "no_content"
+)
+
+// GRPCClient - реализация Client поверх gRPC API. Токен передается в метаданных auth,
+// новый токен из заголовка ответа сохраняется в TokenStore.
+type GRPCClient struct {
+	conn    *grpc.ClientConn
+	owned   bool
+	api     shortenergrpcv1.ShortenerClient
+	token   *tokenHolder
+	retry   retrier
+	batches int
+}
+
+// Client реализуется GRPCClient.
+var _ Client = (*GRPCClient)(nil)
+
+// NewGRPC - функция создания gRPC клиента сервиса по адресу target, например localhost:3200.
+// Без dialOpts соединение устанавливается без TLS. Соединение закрывается методом Close.
+func NewGRPC(target string, opts Options, dialOpts ...grpc.DialOption) (*GRPCClient, error) {
+	if len(dialOpts) == 0 {
+		dialOpts = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+	conn, err := grpc.Dial(target, dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+	c, err := NewGRPCFromConn(conn, opts)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	c.owned = true
+	return c, nil
+}
+
+// NewGRPCFromConn - функция создания gRPC клиента поверх готового соединения conn.
+// Close такого клиента соединение не закрывает.
+func NewGRPCFromConn(conn *grpc.ClientConn, opts Options) (*GRPCClient, error) {
+	opts = opts.withDefaults()
+	token, err := newTokenHolder(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &GRPCClient{
+		conn:    conn,
+		api:     shortenergrpcv1.NewShortenerClient(conn),
+		token:   token,
+		retry:   newRetrier(opts),
+		batches: opts.BatchSize,
+	}, nil
+}
+
+// invoke - метод вызова метода сервиса с токеном и ключом идемпотентности key в метаданных.
+// Токен из заголовка ответа сохраняется, заголовок возвращается вызывающему.
+func (c *GRPCClient) invoke(ctx context.Context, key string, call func(ctx context.Context, opts ...grpc.CallOption) error) (metadata.MD, error) {
+	if token := c.token.get(); token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, authMetadataKey, token)
+	}
+	if key != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, idempotencyMetadataKey, key)
+	}
+	var header metadata.MD
+	err := call(ctx, grpc.Header(&header))
+	if values := header.Get(authMetadataKey); len(values) > 0 {
+		if err := c.token.set(values[0]); err != nil {
+			return header, &permanentError{err: err}
+		}
+	}
+	if err != nil {
+		return header, statusError(err, header)
+	}
+	return header, nil
+}
+
+// Shorten - метод сокращения url через ShortenerURL. Повторы безопасны: все попытки отправляются с одним ключом идемпотентности.
+func (c *GRPCClient) Shorten(ctx context.Context, originalURL string) (string, error) {
+	key := uuid.New().String()
+	var short string
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		var res *shortenergrpcv1.ShortenerURLResponce
+		header, err := c.invoke(ctx, key, func(ctx context.Context, opts ...grpc.CallOption) (err error) {
+			res, err = c.api.ShortenerURL(ctx, &shortenergrpcv1.ShortenerURLRequest{OriginalUrl: originalURL}, opts...)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		short = res.GetShortUrl()
+		if len(header.Get(urlExistsMetadataKey)) > 0 {
+			return &Error{Code: codeURLExists, Message: "url already shortened", GRPCCode: codes.AlreadyExists}
+		}
+		return nil
+	})
+	return short, err
+}
+
+// ShortenBatch - метод сокращения списка url через InsertBatch пачками по Options.BatchSize.
+func (c *GRPCClient) ShortenBatch(ctx context.Context, items []BatchItem) ([]BatchResult, error) {
+	results := make([]BatchResult, 0, len(items))
+	for _, chunk := range chunks(items, c.batches) {
+		body, err := json.Marshal(chunk)
+		if err != nil {
+			return results, err
+		}
+		key := uuid.New().String()
+		err = c.retry.do(ctx, func(ctx context.Context) error {
+			var res *shortenergrpcv1.InsertBatchResponce
+			_, err := c.invoke(ctx, key, func(ctx context.Context, opts ...grpc.CallOption) (err error) {
+				res, err = c.api.InsertBatch(ctx, &shortenergrpcv1.InsertBatchRequest{UrlsJson: string(body)}, opts...)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			var saved []BatchResult
+			if err := json.Unmarshal([]byte(res.GetShortUrlsJson()), &saved); err != nil {
+				return &permanentError{err: fmt.Errorf("decode batch response: %w", err)}
+			}
+			results = append(results, saved...)
+			return nil
+		})
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// Expand - метод получения оригинального url через GetOriginalURL.
+func (c *GRPCClient) Expand(ctx context.Context, short string) (string, error) {
+	var original string
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		_, err := c.invoke(ctx, "", func(ctx context.Context, opts ...grpc.CallOption) error {
+			res, err := c.api.GetOriginalURL(ctx, &shortenergrpcv1.GetOriginalURLRequest{ShortUrl: shortID(short)}, opts...)
+			original = res.GetOriginalUrl()
+			return err
+		})
+		return err
+	})
+	return original, err
+}
+
+// UserURLs - метод получения url пользователя через GetAllURLs.
+func (c *GRPCClient) UserURLs(ctx context.Context) ([]URL, error) {
+	var urls []URL
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		var res *shortenergrpcv1.GetAllURLsResponce
+		_, err := c.invoke(ctx, "", func(ctx context.Context, opts ...grpc.CallOption) (err error) {
+			res, err = c.api.GetAllURLs(ctx, &shortenergrpcv1.GetAllURLsRequest{}, opts...)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal([]byte(res.GetAllUrlsJson()), &urls); err != nil {
+			return &permanentError{err: fmt.Errorf("decode urls: %w", err)}
+		}
+		return nil
+	})
+	var serviceErr *Error
+	if errors.As(err, &serviceErr) && serviceErr.Code == codeNoContent {
+		return []URL{}, nil
+	}
+	return urls, err
+}
+
+// Delete - метод удаления url пользователя через DeleteURL.
+func (c *GRPCClient) Delete(ctx context.Context, shorts []string) error {
+	body, err := json.Marshal(shortIDs(shorts))
+	if err != nil {
+		return err
+	}
+	return c.retry.do(ctx, func(ctx context.Context) error {
+		_, err := c.invoke(ctx, "", func(ctx context.Context, opts ...grpc.CallOption) error {
+			_, err := c.api.DeleteURL(ctx, &shortenergrpcv1.DeleteURLRequest{Urls: string(body)}, opts...)
+			return err
+		})
+		return err
+	})
+}
+
+// Restore - метод восстановления удаленных url, в gRPC API метода восстановления нет.
+func (c *GRPCClient) Restore(ctx context.Context, shorts []string) ([]string, error) {
+	return nil, fmt.Errorf("restore: %w", ErrUnsupported)
+}
+
+// Stats - метод получения статистики через ServiceStat.
+func (c *GRPCClient) Stats(ctx context.Context) (Stats, error) {
+	var stats Stats
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		var res *shortenergrpcv1.ServiceStatResponce
+		_, err := c.invoke(ctx, "", func(ctx context.Context, opts ...grpc.CallOption) (err error) {
+			res, err = c.api.ServiceStat(ctx, &shortenergrpcv1.ServiceStatRequest{}, opts...)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal([]byte(res.GetStat()), &stats); err != nil {
+			return &permanentError{err: fmt.Errorf("decode stats: %w", err)}
+		}
+		return nil
+	})
+	return stats, err
+}
+
+// Ping - метод проверки хранилища через CheckDBConnection.
+func (c *GRPCClient) Ping(ctx context.Context) error {
+	return c.retry.do(ctx, func(ctx context.Context) error {
+		_, err := c.invoke(ctx, "", func(ctx context.Context, opts ...grpc.CallOption) error {
+			_, err := c.api.CheckDBConnection(ctx, &shortenergrpcv1.CheckDBConnectionRequest{}, opts...)
+			return err
+		})
+		return err
+	})
+}
+
+// Token - метод получения текущего токена пользователя.
+func (c *GRPCClient) Token() string {
+	return c.token.get()
+}
+
+// Close - метод закрытия соединения, созданного NewGRPC.
+func (c *GRPCClient) Close() error {
+	if !c.owned {
+		return nil
+	}
+	return c.conn.Close()
+}
@@ -0,0 +1,242 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/Dorrrke/shortener-url/pkg/api"
+)
+
+// authCookie - имя cookie с токеном пользователя.
+const authCookie = "auth"
+
+// RESTClient - реализация Client поверх REST API, построенная на сгенерированном клиенте pkg/api.
+// Токен передается в cookie auth, новый токен из Set-Cookie сохраняется в TokenStore.
+type RESTClient struct {
+	api     *api.Client
+	token   *tokenHolder
+	retry   retrier
+	batches int
+}
+
+// Client реализуется RESTClient.
+var _ Client = (*RESTClient)(nil)
+
+// NewREST - функция создания REST клиента сервиса по адресу baseURL, например http://localhost:8080.
+// httpClient может быть nil, тогда используется http клиент по умолчанию. Перенаправления не выполняются в любом случае:
+// Expand получает оригинальный url из заголовка Location.
+func NewREST(baseURL string, httpClient *http.Client, opts Options) (*RESTClient, error) {
+	opts = opts.withDefaults()
+	token, err := newTokenHolder(opts)
+	if err != nil {
+		return nil, err
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	noRedirect := *httpClient
+	noRedirect.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	c := &RESTClient{token: token, retry: newRetrier(opts), batches: opts.BatchSize}
+	c.api, err = api.NewClient(baseURL, api.WithHTTPClient(&tokenDoer{next: &noRedirect, token: token}))
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// tokenDoer - http клиент, добавляющий в запрос cookie с токеном и сохраняющий токен из ответа.
+type tokenDoer struct {
+	next  api.HTTPRequestDoer
+	token *tokenHolder
+}
+
+// Do - метод отправки запроса.
+func (d *tokenDoer) Do(req *http.Request) (*http.Response, error) {
+	if token := d.token.get(); token != "" {
+		req.AddCookie(&http.Cookie{Name: authCookie, Value: token})
+	}
+	res, err := d.next.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, cookie := range res.Cookies() {
+		if cookie.Name == authCookie {
+			if err := d.token.set(cookie.Value); err != nil {
+				res.Body.Close()
+				return nil, &permanentError{err: err}
+			}
+		}
+	}
+	return res, nil
+}
+
+// Shorten - метод сокращения url через POST /api/shorten. Повторы безопасны: все попытки отправляются с одним ключом идемпотентности.
+func (c *RESTClient) Shorten(ctx context.Context, originalURL string) (string, error) {
+	key := uuid.New().String()
+	var short string
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		res, err := c.api.ShortenerJSONURL(ctx, &api.ShortenerJSONURLParams{IdempotencyKey: &key}, api.ShortenRequest{URL: originalURL})
+		if err != nil {
+			return err
+		}
+		switch {
+		case res.JSON201 != nil:
+			short = res.JSON201.Result
+			return nil
+		case res.JSON409 != nil:
+			short = res.JSON409.Result
+			return &Error{Code: codeURLExists, Message: "url already shortened", HTTPStatus: res.StatusCode()}
+		}
+		return problemError(res.HTTPResponse, res.Problem)
+	})
+	return short, err
+}
+
+// ShortenBatch - метод сокращения списка url через POST /api/shorten/batch пачками по Options.BatchSize.
+func (c *RESTClient) ShortenBatch(ctx context.Context, items []BatchItem) ([]BatchResult, error) {
+	results := make([]BatchResult, 0, len(items))
+	for _, chunk := range chunks(items, c.batches) {
+		key := uuid.New().String()
+		err := c.retry.do(ctx, func(ctx context.Context) error {
+			res, err := c.api.InsertBatch(ctx, &api.InsertBatchParams{IdempotencyKey: &key}, chunk)
+			if err != nil {
+				return err
+			}
+			if res.JSON201 == nil {
+				return problemError(res.HTTPResponse, res.Problem)
+			}
+			results = append(results, *res.JSON201...)
+			return nil
+		})
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// Expand - метод получения оригинального url через GET /{id} без перехода по перенаправлению.
+func (c *RESTClient) Expand(ctx context.Context, short string) (string, error) {
+	var original string
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		res, err := c.api.GetOriginalURL(ctx, shortID(short))
+		if err != nil {
+			return err
+		}
+		switch res.StatusCode() {
+		case http.StatusTemporaryRedirect:
+			original = res.HTTPResponse.Header.Get("Location")
+			return nil
+		case http.StatusForbidden:
+			if res.Problem == nil {
+				// Вместо перенаправления сервис отдал страницу-предупреждение о заблокированном url.
+				return &Error{Code: codeURLBlocked, Message: "url is blocked", HTTPStatus: res.StatusCode()}
+			}
+		}
+		return problemError(res.HTTPResponse, res.Problem)
+	})
+	return original, err
+}
+
+// UserURLs - метод получения url пользователя через GET /api/user/urls.
+// Без токена сервис отвечает 401, поэтому у клиента без токена url нет и запрос не отправляется.
+func (c *RESTClient) UserURLs(ctx context.Context) ([]URL, error) {
+	if c.token.get() == "" {
+		return []URL{}, nil
+	}
+	var urls []URL
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		res, err := c.api.GetAllUrls(ctx)
+		if err != nil {
+			return err
+		}
+		switch {
+		case res.JSON200 != nil:
+			urls = *res.JSON200
+			return nil
+		case res.StatusCode() == http.StatusNoContent:
+			urls = []URL{}
+			return nil
+		}
+		return problemError(res.HTTPResponse, res.Problem)
+	})
+	return urls, err
+}
+
+// Delete - метод удаления url пользователя через DELETE /api/user/urls.
+func (c *RESTClient) Delete(ctx context.Context, shorts []string) error {
+	ids := shortIDs(shorts)
+	return c.retry.do(ctx, func(ctx context.Context) error {
+		res, err := c.api.DeleteURL(ctx, ids)
+		if err != nil {
+			return err
+		}
+		if res.StatusCode() != http.StatusAccepted {
+			return problemError(res.HTTPResponse, res.Problem)
+		}
+		return nil
+	})
+}
+
+// Restore - метод восстановления удаленных url пользователя через POST /api/user/urls/restore.
+func (c *RESTClient) Restore(ctx context.Context, shorts []string) ([]string, error) {
+	ids := shortIDs(shorts)
+	var restored []string
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		res, err := c.api.RestoreURL(ctx, ids)
+		if err != nil {
+			return err
+		}
+		if res.JSON200 == nil {
+			return problemError(res.HTTPResponse, res.Problem)
+		}
+		restored = *res.JSON200
+		return nil
+	})
+	return restored, err
+}
+
+// Stats - метод получения статистики через GET /api/internal/stats.
+func (c *RESTClient) Stats(ctx context.Context) (Stats, error) {
+	var stats Stats
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		res, err := c.api.GetServiceStats(ctx)
+		if err != nil {
+			return err
+		}
+		if res.JSON200 == nil {
+			return problemError(res.HTTPResponse, res.Problem)
+		}
+		stats = *res.JSON200
+		return nil
+	})
+	return stats, err
+}
+
+// Ping - метод проверки хранилища через GET /ping.
+func (c *RESTClient) Ping(ctx context.Context) error {
+	return c.retry.do(ctx, func(ctx context.Context) error {
+		res, err := c.api.CheckDBConnection(ctx)
+		if err != nil {
+			return err
+		}
+		if res.StatusCode() != http.StatusOK {
+			return problemError(res.HTTPResponse, res.Problem)
+		}
+		return nil
+	})
+}
+
+// Token - метод получения текущего токена пользователя.
+func (c *RESTClient) Token() string {
+	return c.token.get()
+}
+
+// Close - метод освобождения ресурсов клиента, у REST клиента ресурсов нет.
+func (c *RESTClient) Close() error {
+	return nil
+}
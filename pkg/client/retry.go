@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// retrier - повтор запросов при временных ошибках с экспоненциальной паузой.
+type retrier struct {
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// newRetrier - функция создания retrier по настройкам клиента.
+func newRetrier(opts Options) retrier {
+	return retrier{maxRetries: opts.MaxRetries, minBackoff: opts.MinBackoff, maxBackoff: opts.MaxBackoff}
+}
+
+// do - метод выполнения call с повторами. Повторяются только временные ошибки сервиса и ошибки сети,
+// пауза удваивается с каждой попыткой до maxBackoff и случайно уменьшается до половины, чтобы клиенты не повторяли запросы одновременно.
+// Если сервис передал Retry-After, пауза не меньше него. Отмена ctx прерывает ожидание.
+func (r retrier) do(ctx context.Context, call func(ctx context.Context) error) error {
+	backoff := r.minBackoff
+	for attempt := 0; ; attempt++ {
+		err := call(ctx)
+		if err == nil || attempt >= r.maxRetries || !retryable(ctx, err) {
+			return err
+		}
+		wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		var serviceErr *Error
+		if errors.As(err, &serviceErr) && serviceErr.RetryAfter > wait {
+			wait = serviceErr.RetryAfter
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+		if backoff > r.maxBackoff {
+			backoff = r.maxBackoff
+		}
+	}
+}
+
+// retryable - функция проверки, что после ошибки err запрос можно повторить.
+// Ошибки без ответа сервиса (сеть, обрыв соединения) повторяются, пока не отменен ctx.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var serviceErr *Error
+	if errors.As(err, &serviceErr) {
+		return serviceErr.Temporary()
+	}
+	var permanent *permanentError
+	return !errors.As(err, &permanent)
+}
+
+// permanentError - ошибка клиента, при которой повтор запроса бессмысленен, например ошибка разбора ответа.
+type permanentError struct {
+	err error
+}
+
+// Error - текст ошибки.
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap - метод получения исходной ошибки.
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// TokenStore - хранилище токена пользователя. Load возвращает пустую строку, если токен еще не сохранен.
+type TokenStore interface {
+	Load() (string, error)
+	Save(token string) error
+}
+
+// MemoryTokenStore - хранилище токена в памяти процесса.
+type MemoryTokenStore struct {
+	mu    sync.Mutex
+	token string
+}
+
+// Load - метод получения сохраненного токена.
+func (s *MemoryTokenStore) Load() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token, nil
+}
+
+// Save - метод сохранения токена.
+func (s *MemoryTokenStore) Save(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+	return nil
+}
+
+// FileTokenStore - хранилище токена в файле, токен доступен только владельцу файла.
+type FileTokenStore struct {
+	path string
+}
+
+// NewFileTokenStore - функция создания хранилища токена в файле path.
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path}
+}
+
+// Load - метод чтения токена из файла, отсутствующий файл означает, что токена нет.
+func (s *FileTokenStore) Load() (string, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Save - метод записи токена в файл. Токен записывается во временный файл и переименовывается,
+// чтобы при сбое не остался обрезанный токен.
+func (s *FileTokenStore) Save(token string) error {
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return err
+		}
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(token+"\n"), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// tokenHolder - текущий токен клиента с сохранением изменений в TokenStore.
+type tokenHolder struct {
+	mu    sync.RWMutex
+	token string
+	store TokenStore
+}
+
+// newTokenHolder - функция создания tokenHolder: токен берется из настроек или из хранилища.
+func newTokenHolder(opts Options) (*tokenHolder, error) {
+	h := &tokenHolder{token: opts.Token, store: opts.TokenStore}
+	if h.token == "" {
+		token, err := h.store.Load()
+		if err != nil {
+			return nil, err
+		}
+		h.token = token
+	}
+	return h, nil
+}
+
+// get - метод получения текущего токена.
+func (h *tokenHolder) get() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.token
+}
+
+// set - метод замены токена, выданного сервисом. Хранилище обновляется только при изменении токена.
+func (h *tokenHolder) set(token string) error {
+	if token == "" {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if token == h.token {
+		return nil
+	}
+	h.token = token
+	return h.store.Save(token)
+}